@@ -1,8 +1,12 @@
 package lxd
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 // The EventListener struct is used to interact with a LXD event stream
@@ -44,6 +48,24 @@ func (e *EventListener) AddHandler(types []string, function func(interface{})) (
 	return &target, nil
 }
 
+// AddLifecycleHandler adds a function to be called whenever a lifecycle event is received
+func (e *EventListener) AddLifecycleHandler(function func(api.EventLifecycle)) (*EventTarget, error) {
+	if function == nil {
+		return nil, fmt.Errorf("A valid function must be provided")
+	}
+
+	return e.AddHandler([]string{"lifecycle"}, func(message interface{}) {
+		event := api.EventLifecycle{}
+
+		err := eventDecodeMetadata(message, &event)
+		if err != nil {
+			return
+		}
+
+		function(event)
+	})
+}
+
 // RemoveHandler removes a function to be called whenever an event is received
 func (e *EventListener) RemoveHandler(target *EventTarget) error {
 	if target == nil {
@@ -67,6 +89,20 @@ func (e *EventListener) RemoveHandler(target *EventTarget) error {
 	return fmt.Errorf("Couldn't find this function and event types combination")
 }
 
+// dispatch sends an event to all the matching handlers
+func (e *EventListener) dispatch(message map[string]interface{}, messageType string) {
+	e.targetsLock.Lock()
+	defer e.targetsLock.Unlock()
+
+	for _, target := range e.targets {
+		if target.types != nil && !shared.StringInSlice(messageType, target.types) {
+			continue
+		}
+
+		go target.function(message)
+	}
+}
+
 // Disconnect must be used once done listening for events
 func (e *EventListener) Disconnect() {
 	if e.disconnected {
@@ -98,3 +134,19 @@ func (e *EventListener) Wait() error {
 	<-e.chActive
 	return e.err
 }
+
+// eventDecodeMetadata unpacks the metadata of a raw event into the provided struct
+func eventDecodeMetadata(message interface{}, target interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	event := api.Event{}
+	err = json.Unmarshal(data, &event)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(event.Metadata, target)
+}
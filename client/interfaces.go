@@ -2,6 +2,7 @@ package lxd
 
 import (
 	"io"
+	"time"
 
 	"github.com/gorilla/websocket"
 
@@ -43,6 +44,8 @@ type ContainerServer interface {
 	GetServer() (server *api.Server, ETag string, err error)
 	UpdateServer(server api.ServerPut, ETag string) (err error)
 	HasExtension(extension string) bool
+	GetServerResources() (resources *api.Resources, err error)
+	UseProject(name string) (client ContainerServer)
 
 	// Certificate functions
 	GetCertificateFingerprints() (fingerprints []string, err error)
@@ -52,6 +55,12 @@ type ContainerServer interface {
 	UpdateCertificate(fingerprint string, certificate api.CertificatePut, ETag string) (err error)
 	DeleteCertificate(fingerprint string) (err error)
 
+	// Certificate add token functions ("certificate_token" API extension)
+	GetCertificateTokens() (tokens []api.CertificateToken, err error)
+	GetCertificateToken(name string) (token *api.CertificateToken, err error)
+	CreateCertificateToken(token api.CertificateTokensPost) (created *api.CertificateToken, err error)
+	DeleteCertificateToken(name string) (err error)
+
 	// Container functions
 	GetContainerNames() (names []string, err error)
 	GetContainers() (containers []api.Container, err error)
@@ -65,6 +74,13 @@ type ContainerServer interface {
 	DeleteContainer(name string) (op *Operation, err error)
 
 	ExecContainer(containerName string, exec api.ContainerExecPost, args *ContainerExecArgs) (*Operation, error)
+	GetContainerExecSessions(containerName string) (sessions []api.ContainerExecSession, err error)
+	GetContainerExecSession(containerName string, id string) (session *api.ContainerExecSession, err error)
+	AttachContainerExecSession(containerName string, id string, args *ContainerExecArgs) (*Operation, error)
+
+	ConsoleContainer(containerName string, console api.ContainerConsolePost, args *ContainerConsoleArgs) (*Operation, error)
+	GetContainerConsoleLog(containerName string) (content io.ReadCloser, err error)
+	DeleteContainerConsoleLog(containerName string) (err error)
 
 	GetContainerFile(containerName string, path string) (content io.ReadCloser, resp *ContainerFileResponse, err error)
 	CreateContainerFile(containerName string, path string, args ContainerFileArgs) (err error)
@@ -79,15 +95,29 @@ type ContainerServer interface {
 	MigrateContainerSnapshot(containerName string, name string, container api.ContainerSnapshotPost) (op *Operation, err error)
 	DeleteContainerSnapshot(containerName string, name string) (op *Operation, err error)
 
+	GetContainerBackupNames(containerName string) (names []string, err error)
+	GetContainerBackups(containerName string) (backups []api.ContainerBackup, err error)
+	GetContainerBackup(containerName string, name string) (backup *api.ContainerBackup, ETag string, err error)
+	CreateContainerBackup(containerName string, backup api.ContainerBackupsPost) (op *Operation, err error)
+	RenameContainerBackup(containerName string, name string, backup api.ContainerBackupPost) (op *Operation, err error)
+	DeleteContainerBackup(containerName string, name string) (op *Operation, err error)
+	GetContainerBackupFile(containerName string, name string) (content io.ReadCloser, err error)
+	CreateContainerFromBackup(args ContainerBackupArgs) (op *Operation, err error)
+
 	GetContainerState(name string) (state *api.ContainerState, ETag string, err error)
 	UpdateContainerState(name string, state api.ContainerStatePut, ETag string) (op *Operation, err error)
 
 	GetContainerLogfiles(name string) (logfiles []string, err error)
 	GetContainerLogfile(name string, filename string) (content io.ReadCloser, err error)
+	GetContainerLogfileWebsocket(name string, filename string) (conn *websocket.Conn, err error)
 	DeleteContainerLogfile(name string, filename string) (err error)
 
+	// Audit log functions ("audit" API extension)
+	GetAuditEntries(args AuditArgs) (entries []api.AuditEntry, err error)
+
 	// Event handling functions
 	GetEvents() (listener *EventListener, err error)
+	GetEventsWithArgs(args EventsArgs) (listener *EventListener, err error)
 
 	// Image functions
 	CreateImage(image api.ImagesPost, args *ImageCreateArgs) (op *Operation, err error)
@@ -110,6 +140,12 @@ type ContainerServer interface {
 	RenameNetwork(name string, network api.NetworkPost) (err error)
 	DeleteNetwork(name string) (err error)
 
+	// Network lease functions ("network_leases" API extension)
+	GetNetworkLeases(name string) (leases []api.NetworkLease, err error)
+
+	// Network state functions ("network_state" API extension)
+	GetNetworkState(name string) (state *api.NetworkState, err error)
+
 	// Operation functions
 	GetOperation(uuid string) (op *api.Operation, ETag string, err error)
 	DeleteOperation(uuid string) (err error)
@@ -124,6 +160,15 @@ type ContainerServer interface {
 	RenameProfile(name string, profile api.ProfilePost) (err error)
 	DeleteProfile(name string) (err error)
 
+	// Project functions ("projects" API extension)
+	GetProjectNames() (names []string, err error)
+	GetProjects() (projects []api.Project, err error)
+	GetProject(name string) (project *api.Project, ETag string, err error)
+	CreateProject(project api.ProjectsPost) (err error)
+	UpdateProject(name string, project api.ProjectPut, ETag string) (err error)
+	RenameProject(name string, project api.ProjectPost) (err error)
+	DeleteProject(name string) (err error)
+
 	// Storage pool functions ("storage" API extension)
 	GetStoragePoolNames() (names []string, err error)
 	GetStoragePools() (pools []api.StoragePool, err error)
@@ -131,6 +176,7 @@ type ContainerServer interface {
 	CreateStoragePool(pool api.StoragePoolsPost) (err error)
 	UpdateStoragePool(name string, pool api.StoragePoolPut, ETag string) (err error)
 	DeleteStoragePool(name string) (err error)
+	GetStoragePoolResources(name string) (resources *api.ResourcesStoragePool, err error)
 
 	// Storage volume functions ("storage" API extension)
 	GetStoragePoolVolumeNames(pool string) (names []string, err error)
@@ -140,6 +186,19 @@ type ContainerServer interface {
 	UpdateStoragePoolVolume(pool string, volType string, name string, volume api.StorageVolumePut, ETag string) (err error)
 	DeleteStoragePoolVolume(pool string, volType string, name string) (err error)
 
+	// Storage volume copy and move functions ("storage_api_local_volume_handling" API extension)
+	CopyStoragePoolVolume(pool string, source ContainerServer, sourcePool string, volume api.StorageVolume, args *StoragePoolVolumeCopyArgs) (op *RemoteOperation, err error)
+	MoveStoragePoolVolume(pool string, source ContainerServer, sourcePool string, volume api.StorageVolume, args *StoragePoolVolumeMoveArgs) (op *RemoteOperation, err error)
+	MigrateStoragePoolVolume(pool string, name string, volume api.StorageVolumePost) (op *Operation, err error)
+
+	// Storage volume snapshot functions ("storage_api_volume_snapshots" API extension)
+	GetStoragePoolVolumeSnapshotNames(pool string, volType string, volName string) (names []string, err error)
+	GetStoragePoolVolumeSnapshots(pool string, volType string, volName string) (snapshots []api.StorageVolumeSnapshot, err error)
+	GetStoragePoolVolumeSnapshot(pool string, volType string, volName string, name string) (snapshot *api.StorageVolumeSnapshot, err error)
+	CreateStoragePoolVolumeSnapshot(pool string, volType string, volName string, snapshot api.StorageVolumeSnapshotsPost) (op *Operation, err error)
+	RenameStoragePoolVolumeSnapshot(pool string, volType string, volName string, name string, snapshot api.StorageVolumeSnapshotPost) (op *Operation, err error)
+	DeleteStoragePoolVolumeSnapshot(pool string, volType string, volName string, name string) (op *Operation, err error)
+
 	// Internal functions (for internal use)
 	RawQuery(method string, path string, data interface{}, queryETag string) (resp *api.Response, ETag string, err error)
 	RawWebsocket(path string) (conn *websocket.Conn, err error)
@@ -167,6 +226,40 @@ type ProgressData struct {
 	TotalBytes int64
 }
 
+// The EventsArgs struct is used to filter the events sent by the server
+type EventsArgs struct {
+	// Event types to listen for (defaults to all)
+	Types []string
+
+	// Only receive operation and lifecycle events affecting those URLs
+	Resources []string
+
+	// Minimum log level of logging events
+	Level string
+
+	// Replay the events which followed this event ID (0 to disable)
+	Since int64
+}
+
+// The AuditArgs struct is used to filter the entries of the audit log
+type AuditArgs struct {
+	// Only return the requests made by the client with this certificate
+	// fingerprint (or a prefix of it), or "unix socket"
+	Requestor string
+
+	// Only return the requests with this HTTP method
+	Method string
+
+	// Only return the requests whose URL contains this string
+	URL string
+
+	// Only return the requests made since then (zero to disable)
+	Since time.Time
+
+	// Only return this many of the latest requests (0 to disable)
+	Limit int
+}
+
 // The ImageCreateArgs struct is used for direct image upload
 type ImageCreateArgs struct {
 	// Reader for the meta file
@@ -243,6 +336,23 @@ type ContainerCopyArgs struct {
 
 	// The transfer mode, can be "pull" (default), "push" or "relay"
 	Mode string
+
+	// If set, an existing copy of the container is only sent what it's missing ("container_incremental_copy" API extension)
+	Refresh bool
+}
+
+// The StoragePoolVolumeCopyArgs struct is used to pass additional options
+// during storage volume copy
+type StoragePoolVolumeCopyArgs struct {
+	// If set, the storage volume will be renamed on copy
+	Name string
+}
+
+// The StoragePoolVolumeMoveArgs struct is used to pass additional options
+// during storage volume move
+type StoragePoolVolumeMoveArgs struct {
+	// If set, the storage volume will be renamed on move
+	Name string
 }
 
 // The ContainerSnapshotCopyArgs struct is used to pass additional options during container copy
@@ -254,6 +364,15 @@ type ContainerSnapshotCopyArgs struct {
 	Mode string
 }
 
+// The ContainerBackupArgs struct is used when restoring a container from a backup
+type ContainerBackupArgs struct {
+	// The backup tarball
+	BackupFile io.Reader
+
+	// Storage pool to restore the container onto (optional)
+	PoolName string
+}
+
 // The ContainerExecArgs struct is used to pass additional options during container exec
 type ContainerExecArgs struct {
 	// Standard input
@@ -272,6 +391,21 @@ type ContainerExecArgs struct {
 	DataDone chan bool
 }
 
+// The ContainerConsoleArgs struct is used to pass additional options during container console sessions
+type ContainerConsoleArgs struct {
+	// Input from the terminal
+	Stdin io.ReadCloser
+
+	// Output to the terminal
+	Stdout io.WriteCloser
+
+	// Control message handler (window resize)
+	Control func(conn *websocket.Conn)
+
+	// Channel that will be closed when all data operations are done
+	DataDone chan bool
+}
+
 // The ContainerFileArgs struct is used to pass the various options for a container file upload
 type ContainerFileArgs struct {
 	// File content
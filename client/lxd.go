@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 
@@ -27,6 +28,8 @@ type ProtocolLXD struct {
 	httpHost        string
 	httpProtocol    string
 	httpUserAgent   string
+
+	project string
 }
 
 // GetConnectionInfo returns the basic connection information used to interact with the server
@@ -159,9 +162,31 @@ func (r *ProtocolLXD) rawQuery(method string, url string, data interface{}, ETag
 	return r.parseResponse(resp)
 }
 
+// setQueryAttributes adds the project the client was set to use to the
+// query string of the given URL.
+func (r *ProtocolLXD) setQueryAttributes(uri string) (string, error) {
+	if r.project == "" {
+		return uri, nil
+	}
+
+	fields, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	values := fields.Query()
+	values.Set("project", r.project)
+	fields.RawQuery = values.Encode()
+
+	return fields.String(), nil
+}
+
 func (r *ProtocolLXD) query(method string, path string, data interface{}, ETag string) (*api.Response, string, error) {
 	// Generate the URL
-	url := fmt.Sprintf("%s/1.0%s", r.httpHost, path)
+	url, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0%s", r.httpHost, path))
+	if err != nil {
+		return nil, "", err
+	}
 
 	return r.rawQuery(method, url, data, ETag)
 }
@@ -264,5 +289,10 @@ func (r *ProtocolLXD) websocket(path string) (*websocket.Conn, error) {
 		url = fmt.Sprintf("ws://%s/1.0%s", strings.TrimPrefix(r.httpHost, "http://"), path)
 	}
 
+	url, err := r.setQueryAttributes(url)
+	if err != nil {
+		return nil, err
+	}
+
 	return r.rawWebsocket(url)
 }
@@ -0,0 +1,56 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Audit log handling functions
+
+// GetAuditEntries returns the requests recorded in the audit log, oldest first
+func (r *ProtocolLXD) GetAuditEntries(args AuditArgs) ([]api.AuditEntry, error) {
+	if !r.HasExtension("audit") {
+		return nil, fmt.Errorf("The server is missing the required \"audit\" API extension")
+	}
+
+	// Prepare the query
+	values := url.Values{}
+	if args.Requestor != "" {
+		values.Set("requestor", args.Requestor)
+	}
+
+	if args.Method != "" {
+		values.Set("method", args.Method)
+	}
+
+	if args.URL != "" {
+		values.Set("url", args.URL)
+	}
+
+	if !args.Since.IsZero() {
+		values.Set("since", args.Since.Format(time.RFC3339))
+	}
+
+	if args.Limit > 0 {
+		values.Set("limit", strconv.Itoa(args.Limit))
+	}
+
+	path := "/audit"
+	if len(values) > 0 {
+		path = fmt.Sprintf("%s?%s", path, values.Encode())
+	}
+
+	entries := []api.AuditEntry{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", path, nil, "", &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
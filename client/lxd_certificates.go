@@ -57,6 +57,15 @@ func (r *ProtocolLXD) GetCertificate(fingerprint string) (*api.Certificate, stri
 
 // CreateCertificate adds a new certificate to the LXD trust store
 func (r *ProtocolLXD) CreateCertificate(certificate api.CertificatesPost) error {
+	restricted := certificate.ReadOnly || len(certificate.Containers) > 0 || len(certificate.Profiles) > 0 || len(certificate.Projects) > 0
+	if restricted && !r.HasExtension("certificate_permissions") {
+		return fmt.Errorf("The server is missing the required \"certificate_permissions\" API extension")
+	}
+
+	if certificate.Token != "" && !r.HasExtension("certificate_token") {
+		return fmt.Errorf("The server is missing the required \"certificate_token\" API extension")
+	}
+
 	// Send the request
 	_, _, err := r.query("POST", "/certificates", certificate, "")
 	if err != nil {
@@ -91,3 +100,69 @@ func (r *ProtocolLXD) DeleteCertificate(fingerprint string) error {
 
 	return nil
 }
+
+// GetCertificateTokens returns the pending certificate add tokens
+func (r *ProtocolLXD) GetCertificateTokens() ([]api.CertificateToken, error) {
+	if !r.HasExtension("certificate_token") {
+		return nil, fmt.Errorf("The server is missing the required \"certificate_token\" API extension")
+	}
+
+	tokens := []api.CertificateToken{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/certificates/tokens?recursion=1", nil, "", &tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetCertificateToken returns the pending certificate add token with the provided name
+func (r *ProtocolLXD) GetCertificateToken(name string) (*api.CertificateToken, error) {
+	if !r.HasExtension("certificate_token") {
+		return nil, fmt.Errorf("The server is missing the required \"certificate_token\" API extension")
+	}
+
+	token := api.CertificateToken{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/certificates/tokens/%s", name), nil, "", &token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// CreateCertificateToken creates a single-use token letting a client add its own certificate
+func (r *ProtocolLXD) CreateCertificateToken(token api.CertificateTokensPost) (*api.CertificateToken, error) {
+	if !r.HasExtension("certificate_token") {
+		return nil, fmt.Errorf("The server is missing the required \"certificate_token\" API extension")
+	}
+
+	created := api.CertificateToken{}
+
+	// Send the request
+	_, err := r.queryStruct("POST", "/certificates/tokens", token, "", &created)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// DeleteCertificateToken revokes a pending certificate add token
+func (r *ProtocolLXD) DeleteCertificateToken(name string) error {
+	if !r.HasExtension("certificate_token") {
+		return fmt.Errorf("The server is missing the required \"certificate_token\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/certificates/tokens/%s", name), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -231,6 +231,16 @@ func (r *ProtocolLXD) CopyContainer(source ContainerServer, container api.Contai
 			return nil, fmt.Errorf("The source server is missing the required \"container_push_target\" API extension")
 		}
 
+		if args.Refresh {
+			if !r.HasExtension("container_incremental_copy") {
+				return nil, fmt.Errorf("The target server is missing the required \"container_incremental_copy\" API extension")
+			}
+
+			if !source.HasExtension("container_incremental_copy") {
+				return nil, fmt.Errorf("The source server is missing the required \"container_incremental_copy\" API extension")
+			}
+		}
+
 		// Allow overriding the target name
 		if args.Name != "" {
 			req.Name = args.Name
@@ -238,6 +248,7 @@ func (r *ProtocolLXD) CopyContainer(source ContainerServer, container api.Contai
 
 		req.Source.Live = args.Live
 		req.Source.ContainerOnly = args.ContainerOnly
+		req.Source.Refresh = args.Refresh
 	}
 
 	if req.Source.Live {
@@ -577,6 +588,18 @@ func (r *ProtocolLXD) ExecContainer(containerName string, exec api.ContainerExec
 		}
 	}
 
+	if exec.User != 0 || exec.Group != 0 || exec.Cwd != "" {
+		if !r.HasExtension("container_exec_user_group_cwd") {
+			return nil, fmt.Errorf("The server is missing the required \"container_exec_user_group_cwd\" API extension")
+		}
+	}
+
+	if exec.Persistent {
+		if !r.HasExtension("container_exec_persistent") {
+			return nil, fmt.Errorf("The server is missing the required \"container_exec_persistent\" API extension")
+		}
+	}
+
 	// Send the request
 	op, _, err := r.queryOperation("POST", fmt.Sprintf("/containers/%s/exec", containerName), exec, "")
 	if err != nil {
@@ -585,118 +608,267 @@ func (r *ProtocolLXD) ExecContainer(containerName string, exec api.ContainerExec
 
 	// Process additional arguments
 	if args != nil {
-		// Parse the fds
-		fds := map[string]string{}
-
-		value, ok := op.Metadata["fds"]
-		if ok {
-			values := value.(map[string]interface{})
-			for k, v := range values {
-				fds[k] = v.(string)
-			}
+		err = r.execContainerConnect(op, exec.Interactive, args)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		// Call the control handler with a connection to the control socket
-		if args.Control != nil && fds["control"] != "" {
-			conn, err := r.GetOperationWebsocket(op.ID, fds["control"])
-			if err != nil {
-				return nil, err
-			}
+	return op, nil
+}
+
+// GetContainerExecSessions returns the persistent exec sessions of the container
+func (r *ProtocolLXD) GetContainerExecSessions(containerName string) ([]api.ContainerExecSession, error) {
+	if !r.HasExtension("container_exec_persistent") {
+		return nil, fmt.Errorf("The server is missing the required \"container_exec_persistent\" API extension")
+	}
+
+	sessions := []api.ContainerExecSession{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/exec?recursion=1", containerName), nil, "", &sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// GetContainerExecSession returns a persistent exec session of the container
+func (r *ProtocolLXD) GetContainerExecSession(containerName string, id string) (*api.ContainerExecSession, error) {
+	if !r.HasExtension("container_exec_persistent") {
+		return nil, fmt.Errorf("The server is missing the required \"container_exec_persistent\" API extension")
+	}
+
+	session := api.ContainerExecSession{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/exec/%s", containerName, id), nil, "", &session)
+	if err != nil {
+		return nil, err
+	}
 
-			go args.Control(conn)
+	return &session, nil
+}
+
+// AttachContainerExecSession attaches to a persistent exec session, taking it over from its current client
+func (r *ProtocolLXD) AttachContainerExecSession(containerName string, id string, args *ContainerExecArgs) (*Operation, error) {
+	if !r.HasExtension("container_exec_persistent") {
+		return nil, fmt.Errorf("The server is missing the required \"container_exec_persistent\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/containers/%s/exec/%s", containerName, id), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Process additional arguments
+	if args != nil {
+		err = r.execContainerConnect(op, true, args)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		if exec.Interactive {
-			// Handle interactive sections
-			if args.Stdin != nil && args.Stdout != nil {
-				// Connect to the websocket
-				conn, err := r.GetOperationWebsocket(op.ID, fds["0"])
-				if err != nil {
-					return nil, err
-				}
+	return op, nil
+}
 
-				// And attach stdin and stdout to it
-				go func() {
-					shared.WebsocketSendStream(conn, args.Stdin, -1)
-					<-shared.WebsocketRecvStream(args.Stdout, conn)
-					conn.Close()
+// execContainerConnect connects the exec arguments to the websockets of the operation
+func (r *ProtocolLXD) execContainerConnect(op *Operation, interactive bool, args *ContainerExecArgs) error {
+	// Parse the fds
+	fds := map[string]string{}
+
+	value, ok := op.Metadata["fds"]
+	if ok {
+		values := value.(map[string]interface{})
+		for k, v := range values {
+			fds[k] = v.(string)
+		}
+	}
+
+	// Call the control handler with a connection to the control socket
+	if args.Control != nil && fds["control"] != "" {
+		conn, err := r.GetOperationWebsocket(op.ID, fds["control"])
+		if err != nil {
+			return err
+		}
+
+		go args.Control(conn)
+	}
+
+	if interactive {
+		// Handle interactive sections
+		if args.Stdin != nil && args.Stdout != nil {
+			// Connect to the websocket
+			conn, err := r.GetOperationWebsocket(op.ID, fds["0"])
+			if err != nil {
+				return err
+			}
+
+			// And attach stdin and stdout to it
+			go func() {
+				shared.WebsocketSendStream(conn, args.Stdin, -1)
+				<-shared.WebsocketRecvStream(args.Stdout, conn)
+				conn.Close()
 
-					if args.DataDone != nil {
-						close(args.DataDone)
-					}
-				}()
-			} else {
 				if args.DataDone != nil {
 					close(args.DataDone)
 				}
-			}
+			}()
 		} else {
-			// Handle non-interactive sessions
-			dones := map[int]chan bool{}
-			conns := []*websocket.Conn{}
+			if args.DataDone != nil {
+				close(args.DataDone)
+			}
+		}
+	} else {
+		// Handle non-interactive sessions
+		dones := map[int]chan bool{}
+		conns := []*websocket.Conn{}
 
-			// Handle stdin
-			if fds["0"] != "" {
-				conn, err := r.GetOperationWebsocket(op.ID, fds["0"])
-				if err != nil {
-					return nil, err
-				}
+		// Handle stdin
+		if fds["0"] != "" {
+			conn, err := r.GetOperationWebsocket(op.ID, fds["0"])
+			if err != nil {
+				return err
+			}
+
+			conns = append(conns, conn)
+			dones[0] = shared.WebsocketSendStream(conn, args.Stdin, -1)
+		}
 
-				conns = append(conns, conn)
-				dones[0] = shared.WebsocketSendStream(conn, args.Stdin, -1)
+		// Handle stdout
+		if fds["1"] != "" {
+			conn, err := r.GetOperationWebsocket(op.ID, fds["1"])
+			if err != nil {
+				return err
 			}
 
-			// Handle stdout
-			if fds["1"] != "" {
-				conn, err := r.GetOperationWebsocket(op.ID, fds["1"])
-				if err != nil {
-					return nil, err
-				}
+			conns = append(conns, conn)
+			dones[1] = shared.WebsocketRecvStream(args.Stdout, conn)
+		}
 
-				conns = append(conns, conn)
-				dones[1] = shared.WebsocketRecvStream(args.Stdout, conn)
+		// Handle stderr
+		if fds["2"] != "" {
+			conn, err := r.GetOperationWebsocket(op.ID, fds["2"])
+			if err != nil {
+				return err
 			}
 
-			// Handle stderr
-			if fds["2"] != "" {
-				conn, err := r.GetOperationWebsocket(op.ID, fds["2"])
-				if err != nil {
-					return nil, err
+			conns = append(conns, conn)
+			dones[2] = shared.WebsocketRecvStream(args.Stderr, conn)
+		}
+
+		// Wait for everything to be done
+		go func() {
+			for i, chDone := range dones {
+				// Skip stdin, dealing with it separately below
+				if i == 0 {
+					continue
 				}
 
-				conns = append(conns, conn)
-				dones[2] = shared.WebsocketRecvStream(args.Stderr, conn)
+				<-chDone
 			}
 
-			// Wait for everything to be done
-			go func() {
-				for i, chDone := range dones {
-					// Skip stdin, dealing with it separately below
-					if i == 0 {
-						continue
-					}
+			if fds["0"] != "" {
+				args.Stdin.Close()
+			}
 
-					<-chDone
-				}
+			for _, conn := range conns {
+				conn.Close()
+			}
 
-				if fds["0"] != "" {
-					args.Stdin.Close()
-				}
+			if args.DataDone != nil {
+				close(args.DataDone)
+			}
+		}()
+	}
 
-				for _, conn := range conns {
-					conn.Close()
-				}
+	return nil
+}
 
-				if args.DataDone != nil {
-					close(args.DataDone)
-				}
-			}()
+// ConsoleContainer attaches to the console of a container
+func (r *ProtocolLXD) ConsoleContainer(containerName string, console api.ContainerConsolePost, args *ContainerConsoleArgs) (*Operation, error) {
+	if !r.HasExtension("console") {
+		return nil, fmt.Errorf("The server is missing the required \"console\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/containers/%s/console", containerName), console, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Process additional arguments
+	if args != nil {
+		err = r.execContainerConnect(op, true, &ContainerExecArgs{
+			Stdin:    args.Stdin,
+			Stdout:   args.Stdout,
+			Control:  args.Control,
+			DataDone: args.DataDone,
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	return op, nil
 }
 
+// GetContainerConsoleLog returns the console log of the container
+func (r *ProtocolLXD) GetContainerConsoleLog(containerName string) (io.ReadCloser, error) {
+	if !r.HasExtension("console") {
+		return nil, fmt.Errorf("The server is missing the required \"console\" API extension")
+	}
+
+	// Prepare the HTTP request
+	url, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0/containers/%s/console", r.httpHost, containerName))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the user agent
+	if r.httpUserAgent != "" {
+		req.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	// Send the request
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := r.parseResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Body, err
+}
+
+// DeleteContainerConsoleLog clears the console log of the container
+func (r *ProtocolLXD) DeleteContainerConsoleLog(containerName string) error {
+	if !r.HasExtension("console") {
+		return fmt.Errorf("The server is missing the required \"console\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/containers/%s/console", containerName), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // GetContainerFile retrieves the provided path from the container
 func (r *ProtocolLXD) GetContainerFile(containerName string, path string) (io.ReadCloser, *ContainerFileResponse, error) {
 	// Prepare the HTTP request
@@ -707,6 +879,11 @@ func (r *ProtocolLXD) GetContainerFile(containerName string, path string) (io.Re
 		return nil, nil, err
 	}
 
+	requestURL, err = r.setQueryAttributes(requestURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
 		return nil, nil, err
@@ -792,6 +969,12 @@ func (r *ProtocolLXD) CreateContainerFile(containerName string, path string, arg
 	if err != nil {
 		return err
 	}
+
+	requestURL, err = r.setQueryAttributes(requestURL)
+	if err != nil {
+		return err
+	}
+
 	req, err := http.NewRequest("POST", requestURL, args.Content)
 	if err != nil {
 		return err
@@ -1174,6 +1357,208 @@ func (r *ProtocolLXD) DeleteContainerSnapshot(containerName string, name string)
 	return op, nil
 }
 
+// GetContainerBackupNames returns a list of backup names for the container
+func (r *ProtocolLXD) GetContainerBackupNames(containerName string) ([]string, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	urls := []string{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/backups", containerName), nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it
+	names := []string{}
+	for _, url := range urls {
+		fields := strings.Split(url, fmt.Sprintf("/containers/%s/backups/", containerName))
+		names = append(names, fields[len(fields)-1])
+	}
+
+	return names, nil
+}
+
+// GetContainerBackups returns a list of backups for the container
+func (r *ProtocolLXD) GetContainerBackups(containerName string) ([]api.ContainerBackup, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	backups := []api.ContainerBackup{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/backups?recursion=1", containerName), nil, "", &backups)
+	if err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+// GetContainerBackup returns a Backup struct for the provided container and backup names
+func (r *ProtocolLXD) GetContainerBackup(containerName string, name string) (*api.ContainerBackup, string, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, "", fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	backup := api.ContainerBackup{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/backups/%s", containerName, name), nil, "", &backup)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &backup, etag, nil
+}
+
+// CreateContainerBackup requests that LXD creates a new backup for the container
+func (r *ProtocolLXD) CreateContainerBackup(containerName string, backup api.ContainerBackupsPost) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/containers/%s/backups", containerName), backup, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// RenameContainerBackup requests that LXD renames the backup
+func (r *ProtocolLXD) RenameContainerBackup(containerName string, name string, backup api.ContainerBackupPost) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/containers/%s/backups/%s", containerName, name), backup, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// DeleteContainerBackup requests that LXD deletes the container backup
+func (r *ProtocolLXD) DeleteContainerBackup(containerName string, name string) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("DELETE", fmt.Sprintf("/containers/%s/backups/%s", containerName, name), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// GetContainerBackupFile returns the content of the backup tarball
+//
+// Note that it's the caller's responsibility to close the returned ReadCloser
+func (r *ProtocolLXD) GetContainerBackupFile(containerName string, name string) (io.ReadCloser, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Prepare the HTTP request
+	url, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0/containers/%s/backups/%s/export", r.httpHost, containerName, name))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the user agent
+	if r.httpUserAgent != "" {
+		req.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	// Send the request
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := r.parseResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Body, err
+}
+
+// CreateContainerFromBackup is a convenience function to make it easier to
+// create a container from a backup
+func (r *ProtocolLXD) CreateContainerFromBackup(args ContainerBackupArgs) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Prepare the HTTP request
+	reqURL, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0/containers", r.httpHost))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", reqURL, args.BackupFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup the headers
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if args.PoolName != "" {
+		req.Header.Set("X-LXD-pool", args.PoolName)
+	}
+
+	// Set the user agent
+	if r.httpUserAgent != "" {
+		req.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	// Send the request
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Handle errors
+	response, _, err := r.parseResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get to the operation
+	respOperation, err := response.MetadataAsOperation()
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup an Operation wrapper
+	op := Operation{
+		Operation: *respOperation,
+		r:         r,
+		chActive:  make(chan bool),
+	}
+
+	return &op, nil
+}
+
 // GetContainerState returns a ContainerState entry for the provided container name
 func (r *ProtocolLXD) GetContainerState(name string) (*api.ContainerState, string, error) {
 	state := api.ContainerState{}
@@ -1223,7 +1608,11 @@ func (r *ProtocolLXD) GetContainerLogfiles(name string) ([]string, error) {
 // Note that it's the caller's responsibility to close the returned ReadCloser
 func (r *ProtocolLXD) GetContainerLogfile(name string, filename string) (io.ReadCloser, error) {
 	// Prepare the HTTP request
-	url := fmt.Sprintf("%s/1.0/containers/%s/logs/%s", r.httpHost, name, filename)
+	url, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0/containers/%s/logs/%s", r.httpHost, name, filename))
+	if err != nil {
+		return nil, err
+	}
+
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -1251,6 +1640,15 @@ func (r *ProtocolLXD) GetContainerLogfile(name string, filename string) (io.Read
 	return resp.Body, err
 }
 
+// GetContainerLogfileWebsocket returns a websocket streaming the requested logfile as it grows
+func (r *ProtocolLXD) GetContainerLogfileWebsocket(name string, filename string) (*websocket.Conn, error) {
+	if !r.HasExtension("container_logs_follow_rotation") {
+		return nil, fmt.Errorf("The server is missing the required \"container_logs_follow_rotation\" API extension")
+	}
+
+	return r.websocket(fmt.Sprintf("/containers/%s/logs/%s?follow=1", name, filename))
+}
+
 // DeleteContainerLogfile deletes the requested logfile
 func (r *ProtocolLXD) DeleteContainerLogfile(name string, filename string) error {
 	// Send the request
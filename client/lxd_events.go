@@ -2,8 +2,10 @@ package lxd
 
 import (
 	"encoding/json"
-
-	"github.com/lxc/lxd/shared"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // Event handling functions
@@ -71,35 +73,111 @@ func (r *ProtocolLXD) GetEvents() (*EventListener, error) {
 			}
 
 			// Attempt to unpack the message
-			message := make(map[string]interface{})
-			err = json.Unmarshal(data, &message)
-			if err != nil {
-				continue
-			}
-
-			// Extract the message type
-			_, ok := message["type"]
+			message, messageType, ok := eventParse(data)
 			if !ok {
 				continue
 			}
-			messageType := message["type"].(string)
 
 			// Send the message to all handlers
 			r.eventListenersLock.Lock()
 			for _, listener := range r.eventListeners {
-				listener.targetsLock.Lock()
-				for _, target := range listener.targets {
-					if target.types != nil && !shared.StringInSlice(messageType, target.types) {
-						continue
-					}
+				listener.dispatch(message, messageType)
+			}
+			r.eventListenersLock.Unlock()
+		}
+	}()
+
+	return &listener, nil
+}
+
+// GetEventsWithArgs connects to the LXD monitoring interface, letting the
+// server filter the events and replay those missed since a previous event
+func (r *ProtocolLXD) GetEventsWithArgs(args EventsArgs) (*EventListener, error) {
+	if !r.HasExtension("event_filter_replay") {
+		return nil, fmt.Errorf("The server is missing the required \"event_filter_replay\" API extension")
+	}
+
+	// Prepare the query
+	values := url.Values{}
+	if len(args.Types) > 0 {
+		values.Set("type", strings.Join(args.Types, ","))
+	}
 
-					go target.function(message)
+	if len(args.Resources) > 0 {
+		values.Set("resource", strings.Join(args.Resources, ","))
+	}
+
+	if args.Level != "" {
+		values.Set("level", args.Level)
+	}
+
+	if args.Since > 0 {
+		values.Set("since", strconv.FormatInt(args.Since, 10))
+	}
+
+	path := "/events"
+	if len(values) > 0 {
+		path = fmt.Sprintf("%s?%s", path, values.Encode())
+	}
+
+	// Setup a dedicated connection with LXD
+	conn, err := r.websocket(path)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := EventListener{
+		r:        r,
+		chActive: make(chan bool),
+	}
+
+	// Disconnect once the listener is done
+	go func() {
+		<-listener.chActive
+		conn.Close()
+	}()
+
+	// And spawn the listener
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				r.eventListenersLock.Lock()
+				if !listener.disconnected {
+					listener.err = err
+					listener.disconnected = true
+					close(listener.chActive)
 				}
-				listener.targetsLock.Unlock()
+				r.eventListenersLock.Unlock()
+				return
 			}
-			r.eventListenersLock.Unlock()
+
+			// Attempt to unpack the message
+			message, messageType, ok := eventParse(data)
+			if !ok {
+				continue
+			}
+
+			listener.dispatch(message, messageType)
 		}
 	}()
 
 	return &listener, nil
 }
+
+// eventParse unpacks a raw event, returning it along with its type
+func eventParse(data []byte) (map[string]interface{}, string, bool) {
+	message := make(map[string]interface{})
+	err := json.Unmarshal(data, &message)
+	if err != nil {
+		return nil, "", false
+	}
+
+	// Extract the message type
+	messageType, ok := message["type"].(string)
+	if !ok {
+		return nil, "", false
+	}
+
+	return message, messageType, true
+}
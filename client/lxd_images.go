@@ -107,6 +107,11 @@ func (r *ProtocolLXD) GetPrivateImageFile(fingerprint string, secret string, req
 		url = fmt.Sprintf("%s?secret=%s", url, secret)
 	}
 
+	url, err := r.setQueryAttributes(url)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare the download request
 	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -372,7 +377,11 @@ func (r *ProtocolLXD) CreateImage(image api.ImagesPost, args *ImageCreateArgs) (
 	}
 
 	// Prepare the HTTP request
-	reqURL := fmt.Sprintf("%s/1.0/images", r.httpHost)
+	reqURL, err := r.setQueryAttributes(fmt.Sprintf("%s/1.0/images", r.httpHost))
+	if err != nil {
+		return nil, err
+	}
+
 	req, err := http.NewRequest("POST", reqURL, body)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,1031 @@
+package lxd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// The Manifest struct represents the desired state of a set of objects on a LXD server
+type Manifest struct {
+	StoragePools   []ManifestStoragePool   `json:"storage_pools,omitempty" yaml:"storage_pools,omitempty"`
+	StorageVolumes []ManifestStorageVolume `json:"storage_volumes,omitempty" yaml:"storage_volumes,omitempty"`
+	Networks       []ManifestNetwork       `json:"networks,omitempty" yaml:"networks,omitempty"`
+	Profiles       []ManifestProfile       `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	Containers     []ManifestContainer     `json:"containers,omitempty" yaml:"containers,omitempty"`
+}
+
+// The ManifestStoragePool struct represents a storage pool in a Manifest
+//
+// As LXD fills in some of the configuration of a pool, only the keys which
+// are in the manifest are compared and updated.
+type ManifestStoragePool struct {
+	Name        string            `json:"name" yaml:"name"`
+	Driver      string            `json:"driver" yaml:"driver"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Config      map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// The ManifestStorageVolume struct represents a custom storage volume in a Manifest
+//
+// Only the configuration keys which are in the manifest are compared and updated.
+type ManifestStorageVolume struct {
+	Pool        string            `json:"pool" yaml:"pool"`
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Config      map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// The ManifestNetwork struct represents a managed network in a Manifest
+//
+// As LXD fills in some of the configuration of a network, only the keys
+// which are in the manifest are compared and updated.
+type ManifestNetwork struct {
+	Name        string            `json:"name" yaml:"name"`
+	Type        string            `json:"type,omitempty" yaml:"type,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Config      map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// The ManifestProfile struct represents a profile in a Manifest
+type ManifestProfile struct {
+	Name        string                       `json:"name" yaml:"name"`
+	Description string                       `json:"description,omitempty" yaml:"description,omitempty"`
+	Config      map[string]string            `json:"config,omitempty" yaml:"config,omitempty"`
+	Devices     map[string]map[string]string `json:"devices,omitempty" yaml:"devices,omitempty"`
+}
+
+// The ManifestContainer struct represents a container in a Manifest
+//
+// The source is only used when creating the container and is required to do
+// so, a "none" source creates an empty container. The "volatile." and
+// "image." configuration keys are managed by LXD and ignored. When the
+// profiles aren't set, the container keeps whichever profiles it has.
+type ManifestContainer struct {
+	Name        string                       `json:"name" yaml:"name"`
+	Description string                       `json:"description,omitempty" yaml:"description,omitempty"`
+	Source      *api.ContainerSource         `json:"source,omitempty" yaml:"source,omitempty"`
+	Profiles    []string                     `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	Ephemeral   bool                         `json:"ephemeral,omitempty" yaml:"ephemeral,omitempty"`
+	Config      map[string]string            `json:"config,omitempty" yaml:"config,omitempty"`
+	Devices     map[string]map[string]string `json:"devices,omitempty" yaml:"devices,omitempty"`
+}
+
+// The ManifestPlanArgs struct is used to pass additional options to PlanManifest
+type ManifestPlanArgs struct {
+	// Delete the objects which aren't in the manifest
+	Prune bool
+}
+
+// The ManifestAction struct represents a single change needed for a server to match a Manifest
+type ManifestAction struct {
+	// One of "create", "update" or "delete"
+	Action string
+
+	// One of "storage-pool", "storage-volume", "network", "profile" or "container"
+	Type string
+
+	// The name of the object ("<pool>/<volume>" for storage volumes)
+	Name string
+
+	// The changed fields of an update (e.g. "config.limits.cpu" or "devices.eth0")
+	Changes []string
+
+	run func() error
+}
+
+// String returns a one line description of the action
+func (a *ManifestAction) String() string {
+	prefix := map[string]string{"create": "+", "update": "~", "delete": "-"}[a.Action]
+
+	desc := fmt.Sprintf("%s %s %s \"%s\"", prefix, a.Action, a.Type, a.Name)
+	if len(a.Changes) > 0 {
+		desc = fmt.Sprintf("%s (%s)", desc, strings.Join(a.Changes, ", "))
+	}
+
+	return desc
+}
+
+// Run performs the action against the server the plan was made for
+func (a *ManifestAction) Run() error {
+	err := a.run()
+	if err != nil {
+		return fmt.Errorf("Failed to %s %s \"%s\": %v", a.Action, a.Type, a.Name, err)
+	}
+
+	return nil
+}
+
+// The ManifestPlan struct represents the ordered actions needed for a server to match a Manifest
+type ManifestPlan struct {
+	Actions []ManifestAction
+}
+
+// Apply runs all the actions of the plan, stopping at the first failure
+func (p *ManifestPlan) Apply() error {
+	for i := range p.Actions {
+		err := p.Actions[i].Run()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PlanManifest compares a Manifest to the current state of a server and
+// returns the actions needed to converge the server to it.
+//
+// Objects are created and updated in dependency order (storage pools,
+// networks, storage volumes, profiles and then containers) and, when
+// pruning, deleted in the reverse order. Updates use the ETag of the object
+// as it was when planning so that concurrent changes cause a failure.
+func PlanManifest(server ContainerServer, manifest Manifest, args *ManifestPlanArgs) (*ManifestPlan, error) {
+	if args == nil {
+		args = &ManifestPlanArgs{}
+	}
+
+	err := manifest.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	hasStorage := server.HasExtension("storage")
+	if !hasStorage && (len(manifest.StoragePools) > 0 || len(manifest.StorageVolumes) > 0) {
+		return nil, fmt.Errorf("The server is missing the required \"storage\" API extension")
+	}
+
+	hasNetwork := server.HasExtension("network")
+	if !hasNetwork && len(manifest.Networks) > 0 {
+		return nil, fmt.Errorf("The server is missing the required \"network\" API extension")
+	}
+
+	plan := ManifestPlan{}
+	prune := []ManifestAction{}
+
+	planners := []func(server ContainerServer, manifest Manifest, args *ManifestPlanArgs) ([]ManifestAction, []ManifestAction, error){}
+	if hasStorage {
+		planners = append(planners, planManifestStoragePools)
+	}
+
+	if hasNetwork {
+		planners = append(planners, planManifestNetworks)
+	}
+
+	if hasStorage {
+		planners = append(planners, planManifestStorageVolumes)
+	}
+
+	planners = append(planners, planManifestProfiles, planManifestContainers)
+
+	for _, planner := range planners {
+		actions, deletions, err := planner(server, manifest, args)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.Actions = append(plan.Actions, actions...)
+		prune = append(deletions, prune...)
+	}
+
+	plan.Actions = append(plan.Actions, prune...)
+
+	return &plan, nil
+}
+
+// ExportManifest returns the current state of a server as a Manifest.
+func ExportManifest(server ContainerServer) (*Manifest, error) {
+	manifest := Manifest{}
+
+	if server.HasExtension("storage") {
+		pools, err := server.GetStoragePools()
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Sort(manifestStoragePoolsByName(pools))
+		for _, pool := range pools {
+			manifest.StoragePools = append(manifest.StoragePools, ManifestStoragePool{
+				Name:        pool.Name,
+				Driver:      pool.Driver,
+				Description: pool.Description,
+				Config:      manifestFilterConfig(pool.Config, "volatile."),
+			})
+
+			volumes, err := server.GetStoragePoolVolumes(pool.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			sort.Sort(manifestStorageVolumesByName(volumes))
+			for _, volume := range volumes {
+				if volume.Type != "custom" {
+					continue
+				}
+
+				manifest.StorageVolumes = append(manifest.StorageVolumes, ManifestStorageVolume{
+					Pool:        pool.Name,
+					Name:        volume.Name,
+					Description: volume.Description,
+					Config:      manifestFilterConfig(volume.Config, "volatile."),
+				})
+			}
+		}
+	}
+
+	if server.HasExtension("network") {
+		networks, err := server.GetNetworks()
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Sort(manifestNetworksByName(networks))
+		for _, network := range networks {
+			if !network.Managed {
+				continue
+			}
+
+			manifest.Networks = append(manifest.Networks, ManifestNetwork{
+				Name:        network.Name,
+				Type:        network.Type,
+				Description: network.Description,
+				Config:      manifestFilterConfig(network.Config, "volatile."),
+			})
+		}
+	}
+
+	profiles, err := server.GetProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(manifestProfilesByName(profiles))
+	for _, profile := range profiles {
+		manifest.Profiles = append(manifest.Profiles, ManifestProfile{
+			Name:        profile.Name,
+			Description: profile.Description,
+			Config:      profile.Config,
+			Devices:     profile.Devices,
+		})
+	}
+
+	containers, err := server.GetContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(manifestContainersByName(containers))
+	for _, container := range containers {
+		manifest.Containers = append(manifest.Containers, ManifestContainer{
+			Name:        container.Name,
+			Description: container.Description,
+			Source:      manifestContainerSource(container),
+			Profiles:    container.Profiles,
+			Ephemeral:   container.Ephemeral,
+			Config:      manifestFilterConfig(container.Config, "volatile.", "image."),
+			Devices:     container.Devices,
+		})
+	}
+
+	return &manifest, nil
+}
+
+// manifestContainerSource returns the image a container was created from,
+// nil when it's unknown.
+func manifestContainerSource(container api.Container) *api.ContainerSource {
+	fingerprint := container.Config["volatile.base_image"]
+	if fingerprint == "" {
+		return nil
+	}
+
+	return &api.ContainerSource{
+		Type:        "image",
+		Fingerprint: fingerprint,
+	}
+}
+
+func (m *Manifest) validate() error {
+	seen := map[string]bool{}
+	check := func(objType string, name string) error {
+		if name == "" {
+			return fmt.Errorf("Missing name for %s in manifest", objType)
+		}
+
+		key := fmt.Sprintf("%s/%s", objType, name)
+		if seen[key] {
+			return fmt.Errorf("Duplicate %s \"%s\" in manifest", objType, name)
+		}
+		seen[key] = true
+
+		return nil
+	}
+
+	for _, pool := range m.StoragePools {
+		err := check("storage-pool", pool.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, volume := range m.StorageVolumes {
+		if volume.Pool == "" {
+			return fmt.Errorf("Missing pool for storage-volume \"%s\" in manifest", volume.Name)
+		}
+
+		err := check("storage-volume", fmt.Sprintf("%s/%s", volume.Pool, volume.Name))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, network := range m.Networks {
+		err := check("network", network.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, profile := range m.Profiles {
+		err := check("profile", profile.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, container := range m.Containers {
+		err := check("container", container.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func planManifestStoragePools(server ContainerServer, manifest Manifest, args *ManifestPlanArgs) ([]ManifestAction, []ManifestAction, error) {
+	actions := []ManifestAction{}
+	deletions := []ManifestAction{}
+
+	names, err := server.GetStoragePoolNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wanted := []string{}
+	for _, entry := range manifest.StoragePools {
+		pool := entry
+		wanted = append(wanted, pool.Name)
+
+		if !shared.StringInSlice(pool.Name, names) {
+			req := api.StoragePoolsPost{
+				Name:   pool.Name,
+				Driver: pool.Driver,
+			}
+			req.Config = pool.Config
+			req.Description = pool.Description
+
+			actions = append(actions, ManifestAction{
+				Action: "create",
+				Type:   "storage-pool",
+				Name:   pool.Name,
+				run: func() error {
+					return server.CreateStoragePool(req)
+				},
+			})
+			continue
+		}
+
+		current, etag, err := server.GetStoragePool(pool.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if pool.Driver != "" && pool.Driver != current.Driver {
+			return nil, nil, fmt.Errorf("Storage pool \"%s\" uses the \"%s\" driver, not \"%s\"", pool.Name, current.Driver, pool.Driver)
+		}
+
+		changes := manifestConfigChanges(pool.Config, current.Config, true)
+		if pool.Description != current.Description {
+			changes = append([]string{"description"}, changes...)
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		put := current.Writable()
+		put.Config = manifestMergeConfig(current.Config, pool.Config)
+		put.Description = pool.Description
+
+		actions = append(actions, ManifestAction{
+			Action:  "update",
+			Type:    "storage-pool",
+			Name:    pool.Name,
+			Changes: changes,
+			run: func() error {
+				return server.UpdateStoragePool(pool.Name, put, etag)
+			},
+		})
+	}
+
+	if args.Prune {
+		for _, entry := range names {
+			name := entry
+			if shared.StringInSlice(name, wanted) {
+				continue
+			}
+
+			deletions = append(deletions, ManifestAction{
+				Action: "delete",
+				Type:   "storage-pool",
+				Name:   name,
+				run: func() error {
+					return server.DeleteStoragePool(name)
+				},
+			})
+		}
+	}
+
+	return actions, deletions, nil
+}
+
+func planManifestStorageVolumes(server ContainerServer, manifest Manifest, args *ManifestPlanArgs) ([]ManifestAction, []ManifestAction, error) {
+	actions := []ManifestAction{}
+	deletions := []ManifestAction{}
+
+	pools, err := server.GetStoragePoolNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The custom volumes of the existing pools
+	existing := map[string][]string{}
+	for _, pool := range pools {
+		volumes, err := server.GetStoragePoolVolumes(pool)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		existing[pool] = []string{}
+		for _, volume := range volumes {
+			if volume.Type == "custom" {
+				existing[pool] = append(existing[pool], volume.Name)
+			}
+		}
+	}
+
+	wanted := []string{}
+	for _, entry := range manifest.StorageVolumes {
+		volume := entry
+		fullName := fmt.Sprintf("%s/%s", volume.Pool, volume.Name)
+		wanted = append(wanted, fullName)
+
+		if !shared.StringInSlice(volume.Name, existing[volume.Pool]) {
+			req := api.StorageVolumesPost{
+				Name: volume.Name,
+				Type: "custom",
+			}
+			req.Config = volume.Config
+			req.Description = volume.Description
+
+			actions = append(actions, ManifestAction{
+				Action: "create",
+				Type:   "storage-volume",
+				Name:   fullName,
+				run: func() error {
+					return server.CreateStoragePoolVolume(volume.Pool, req)
+				},
+			})
+			continue
+		}
+
+		current, etag, err := server.GetStoragePoolVolume(volume.Pool, "custom", volume.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		changes := manifestConfigChanges(volume.Config, current.Config, true)
+		if volume.Description != current.Description {
+			changes = append([]string{"description"}, changes...)
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		put := current.Writable()
+		put.Config = manifestMergeConfig(current.Config, volume.Config)
+		put.Description = volume.Description
+
+		actions = append(actions, ManifestAction{
+			Action:  "update",
+			Type:    "storage-volume",
+			Name:    fullName,
+			Changes: changes,
+			run: func() error {
+				return server.UpdateStoragePoolVolume(volume.Pool, "custom", volume.Name, put, etag)
+			},
+		})
+	}
+
+	if args.Prune {
+		for _, pool := range pools {
+			for _, entry := range existing[pool] {
+				poolName := pool
+				name := entry
+				fullName := fmt.Sprintf("%s/%s", poolName, name)
+				if shared.StringInSlice(fullName, wanted) {
+					continue
+				}
+
+				deletions = append(deletions, ManifestAction{
+					Action: "delete",
+					Type:   "storage-volume",
+					Name:   fullName,
+					run: func() error {
+						return server.DeleteStoragePoolVolume(poolName, "custom", name)
+					},
+				})
+			}
+		}
+	}
+
+	return actions, deletions, nil
+}
+
+func planManifestNetworks(server ContainerServer, manifest Manifest, args *ManifestPlanArgs) ([]ManifestAction, []ManifestAction, error) {
+	actions := []ManifestAction{}
+	deletions := []ManifestAction{}
+
+	networks, err := server.GetNetworks()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	managed := []string{}
+	for _, network := range networks {
+		if network.Managed {
+			managed = append(managed, network.Name)
+		}
+	}
+
+	wanted := []string{}
+	for _, entry := range manifest.Networks {
+		network := entry
+		wanted = append(wanted, network.Name)
+
+		if !shared.StringInSlice(network.Name, managed) {
+			req := api.NetworksPost{
+				Name: network.Name,
+				Type: network.Type,
+			}
+			req.Config = network.Config
+			req.Description = network.Description
+
+			actions = append(actions, ManifestAction{
+				Action: "create",
+				Type:   "network",
+				Name:   network.Name,
+				run: func() error {
+					return server.CreateNetwork(req)
+				},
+			})
+			continue
+		}
+
+		current, etag, err := server.GetNetwork(network.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		changes := manifestConfigChanges(network.Config, current.Config, true)
+		if network.Description != current.Description {
+			changes = append([]string{"description"}, changes...)
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		put := current.Writable()
+		put.Config = manifestMergeConfig(current.Config, network.Config)
+		put.Description = network.Description
+
+		actions = append(actions, ManifestAction{
+			Action:  "update",
+			Type:    "network",
+			Name:    network.Name,
+			Changes: changes,
+			run: func() error {
+				return server.UpdateNetwork(network.Name, put, etag)
+			},
+		})
+	}
+
+	if args.Prune {
+		for _, entry := range managed {
+			name := entry
+			if shared.StringInSlice(name, wanted) {
+				continue
+			}
+
+			deletions = append(deletions, ManifestAction{
+				Action: "delete",
+				Type:   "network",
+				Name:   name,
+				run: func() error {
+					return server.DeleteNetwork(name)
+				},
+			})
+		}
+	}
+
+	return actions, deletions, nil
+}
+
+func planManifestProfiles(server ContainerServer, manifest Manifest, args *ManifestPlanArgs) ([]ManifestAction, []ManifestAction, error) {
+	actions := []ManifestAction{}
+	deletions := []ManifestAction{}
+
+	names, err := server.GetProfileNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wanted := []string{}
+	for _, entry := range manifest.Profiles {
+		profile := entry
+		wanted = append(wanted, profile.Name)
+
+		put := api.ProfilePut{
+			Config:      profile.Config,
+			Description: profile.Description,
+			Devices:     profile.Devices,
+		}
+
+		if put.Config == nil {
+			put.Config = map[string]string{}
+		}
+
+		if put.Devices == nil {
+			put.Devices = map[string]map[string]string{}
+		}
+
+		if !shared.StringInSlice(profile.Name, names) {
+			req := api.ProfilesPost{
+				Name:       profile.Name,
+				ProfilePut: put,
+			}
+
+			actions = append(actions, ManifestAction{
+				Action: "create",
+				Type:   "profile",
+				Name:   profile.Name,
+				run: func() error {
+					return server.CreateProfile(req)
+				},
+			})
+			continue
+		}
+
+		current, etag, err := server.GetProfile(profile.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		changes := manifestConfigChanges(profile.Config, current.Config, false)
+		changes = append(changes, manifestDevicesChanges(profile.Devices, current.Devices)...)
+		if profile.Description != current.Description {
+			changes = append([]string{"description"}, changes...)
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		actions = append(actions, ManifestAction{
+			Action:  "update",
+			Type:    "profile",
+			Name:    profile.Name,
+			Changes: changes,
+			run: func() error {
+				return server.UpdateProfile(profile.Name, put, etag)
+			},
+		})
+	}
+
+	if args.Prune {
+		for _, entry := range names {
+			name := entry
+
+			// The default profile can't be deleted
+			if name == "default" || shared.StringInSlice(name, wanted) {
+				continue
+			}
+
+			deletions = append(deletions, ManifestAction{
+				Action: "delete",
+				Type:   "profile",
+				Name:   name,
+				run: func() error {
+					return server.DeleteProfile(name)
+				},
+			})
+		}
+	}
+
+	return actions, deletions, nil
+}
+
+func planManifestContainers(server ContainerServer, manifest Manifest, args *ManifestPlanArgs) ([]ManifestAction, []ManifestAction, error) {
+	actions := []ManifestAction{}
+	deletions := []ManifestAction{}
+
+	names, err := server.GetContainerNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wanted := []string{}
+	for _, entry := range manifest.Containers {
+		container := entry
+		wanted = append(wanted, container.Name)
+
+		config := container.Config
+		if config == nil {
+			config = map[string]string{}
+		}
+
+		devices := container.Devices
+		if devices == nil {
+			devices = map[string]map[string]string{}
+		}
+
+		if !shared.StringInSlice(container.Name, names) {
+			if container.Source == nil || container.Source.Type == "" {
+				return nil, nil, fmt.Errorf("Container \"%s\" can't be created without a source", container.Name)
+			}
+
+			req := api.ContainersPost{
+				Name:   container.Name,
+				Source: *container.Source,
+			}
+			req.Config = config
+			req.Description = container.Description
+			req.Devices = devices
+			req.Ephemeral = container.Ephemeral
+			req.Profiles = container.Profiles
+
+			actions = append(actions, ManifestAction{
+				Action: "create",
+				Type:   "container",
+				Name:   container.Name,
+				run: func() error {
+					op, err := server.CreateContainer(req)
+					if err != nil {
+						return err
+					}
+
+					return op.Wait()
+				},
+			})
+			continue
+		}
+
+		current, etag, err := server.GetContainer(container.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		changes := manifestConfigChanges(config, manifestFilterConfig(current.Config, "volatile.", "image."), false)
+		changes = append(changes, manifestDevicesChanges(devices, current.Devices)...)
+		if container.Profiles != nil && strings.Join(container.Profiles, ",") != strings.Join(current.Profiles, ",") {
+			changes = append([]string{"profiles"}, changes...)
+		}
+
+		if container.Ephemeral != current.Ephemeral {
+			changes = append([]string{"ephemeral"}, changes...)
+		}
+
+		if container.Description != current.Description {
+			changes = append([]string{"description"}, changes...)
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		// Keep the keys managed by LXD
+		put := current.Writable()
+		put.Config = map[string]string{}
+		for key, value := range current.Config {
+			if strings.HasPrefix(key, "volatile.") || strings.HasPrefix(key, "image.") {
+				put.Config[key] = value
+			}
+		}
+
+		for key, value := range config {
+			put.Config[key] = value
+		}
+
+		put.Description = container.Description
+		put.Devices = devices
+		put.Ephemeral = container.Ephemeral
+		if container.Profiles != nil {
+			put.Profiles = container.Profiles
+		}
+
+		actions = append(actions, ManifestAction{
+			Action:  "update",
+			Type:    "container",
+			Name:    container.Name,
+			Changes: changes,
+			run: func() error {
+				op, err := server.UpdateContainer(container.Name, put, etag)
+				if err != nil {
+					return err
+				}
+
+				return op.Wait()
+			},
+		})
+	}
+
+	if args.Prune {
+		for _, entry := range names {
+			name := entry
+			if shared.StringInSlice(name, wanted) {
+				continue
+			}
+
+			deletions = append(deletions, ManifestAction{
+				Action: "delete",
+				Type:   "container",
+				Name:   name,
+				run: func() error {
+					return manifestDeleteContainer(server, name)
+				},
+			})
+		}
+	}
+
+	return actions, deletions, nil
+}
+
+// manifestDeleteContainer deletes a container, stopping it first if needed.
+func manifestDeleteContainer(server ContainerServer, name string) error {
+	current, _, err := server.GetContainer(name)
+	if err != nil {
+		return err
+	}
+
+	if current.IsActive() {
+		op, err := server.UpdateContainerState(name, api.ContainerStatePut{Action: "stop", Timeout: -1, Force: true}, "")
+		if err != nil {
+			return err
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return err
+		}
+	}
+
+	op, err := server.DeleteContainer(name)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+// manifestConfigChanges returns the sorted list of the configuration keys
+// which differ. With partial set, the keys missing from the desired
+// configuration are ignored.
+func manifestConfigChanges(desired map[string]string, current map[string]string, partial bool) []string {
+	changes := []string{}
+
+	for key, value := range desired {
+		if current[key] != value {
+			changes = append(changes, fmt.Sprintf("config.%s", key))
+		}
+	}
+
+	if !partial {
+		for key, value := range current {
+			_, ok := desired[key]
+			if !ok && value != "" {
+				changes = append(changes, fmt.Sprintf("config.%s", key))
+			}
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+// manifestDevicesChanges returns the sorted list of the devices which differ.
+func manifestDevicesChanges(desired map[string]map[string]string, current map[string]map[string]string) []string {
+	changes := []string{}
+
+	for name, device := range desired {
+		if !manifestMapsEqual(device, current[name]) {
+			changes = append(changes, fmt.Sprintf("devices.%s", name))
+		}
+	}
+
+	for name := range current {
+		_, ok := desired[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("devices.%s", name))
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+// manifestMergeConfig returns a copy of current with the keys of desired set.
+func manifestMergeConfig(current map[string]string, desired map[string]string) map[string]string {
+	config := map[string]string{}
+	for key, value := range current {
+		config[key] = value
+	}
+
+	for key, value := range desired {
+		config[key] = value
+	}
+
+	return config
+}
+
+// manifestFilterConfig returns a copy of config without the keys starting
+// with one of the prefixes.
+func manifestFilterConfig(config map[string]string, prefixes ...string) map[string]string {
+	filtered := map[string]string{}
+
+	for key, value := range config {
+		skip := false
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				skip = true
+				break
+			}
+		}
+
+		if !skip {
+			filtered[key] = value
+		}
+	}
+
+	return filtered
+}
+
+func manifestMapsEqual(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || other != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sorting of the exported objects by name
+type manifestStoragePoolsByName []api.StoragePool
+
+func (a manifestStoragePoolsByName) Len() int           { return len(a) }
+func (a manifestStoragePoolsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a manifestStoragePoolsByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+type manifestStorageVolumesByName []api.StorageVolume
+
+func (a manifestStorageVolumesByName) Len() int           { return len(a) }
+func (a manifestStorageVolumesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a manifestStorageVolumesByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+type manifestNetworksByName []api.Network
+
+func (a manifestNetworksByName) Len() int           { return len(a) }
+func (a manifestNetworksByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a manifestNetworksByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+type manifestProfilesByName []api.Profile
+
+func (a manifestProfilesByName) Len() int           { return len(a) }
+func (a manifestProfilesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a manifestProfilesByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+type manifestContainersByName []api.Container
+
+func (a manifestContainersByName) Len() int           { return len(a) }
+func (a manifestContainersByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a manifestContainersByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
@@ -0,0 +1,310 @@
+package lxd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestManifestConfigChanges(t *testing.T) {
+	current := map[string]string{"limits.cpu": "1", "user.foo": "bar", "user.empty": ""}
+
+	tests := []struct {
+		desired map[string]string
+		partial bool
+		changes []string
+	}{
+		{map[string]string{"limits.cpu": "1", "user.foo": "bar"}, false, []string{}},
+		{map[string]string{"limits.cpu": "2", "user.foo": "bar"}, false, []string{"config.limits.cpu"}},
+		{map[string]string{"limits.cpu": "1"}, false, []string{"config.user.foo"}},
+		{map[string]string{"limits.cpu": "1"}, true, []string{}},
+		{map[string]string{"user.new": "x", "limits.cpu": "2"}, true, []string{"config.limits.cpu", "config.user.new"}},
+		{nil, false, []string{"config.limits.cpu", "config.user.foo"}},
+		{nil, true, []string{}},
+	}
+
+	for i, test := range tests {
+		changes := manifestConfigChanges(test.desired, current, test.partial)
+		if !reflect.DeepEqual(changes, test.changes) {
+			t.Errorf("Test %d: got %v, expected %v", i, changes, test.changes)
+		}
+	}
+}
+
+func TestManifestDevicesChanges(t *testing.T) {
+	current := map[string]map[string]string{
+		"eth0": {"type": "nic", "nictype": "bridged", "parent": "lxdbr0"},
+		"root": {"type": "disk", "path": "/", "pool": "default"},
+	}
+
+	tests := []struct {
+		desired map[string]map[string]string
+		changes []string
+	}{
+		{current, []string{}},
+		{map[string]map[string]string{
+			"eth0": {"type": "nic", "nictype": "bridged", "parent": "lxdbr1"},
+			"root": {"type": "disk", "path": "/", "pool": "default"},
+		}, []string{"devices.eth0"}},
+		{map[string]map[string]string{
+			"root": {"type": "disk", "path": "/", "pool": "default"},
+		}, []string{"devices.eth0"}},
+		{map[string]map[string]string{
+			"eth0": {"type": "nic", "nictype": "bridged", "parent": "lxdbr0"},
+			"root": {"type": "disk", "path": "/", "pool": "default", "size": "10GB"},
+			"data": {"type": "disk", "path": "/data", "source": "/srv"},
+		}, []string{"devices.data", "devices.root"}},
+		{nil, []string{"devices.eth0", "devices.root"}},
+	}
+
+	for i, test := range tests {
+		changes := manifestDevicesChanges(test.desired, current)
+		if !reflect.DeepEqual(changes, test.changes) {
+			t.Errorf("Test %d: got %v, expected %v", i, changes, test.changes)
+		}
+	}
+}
+
+func TestManifestMergeConfig(t *testing.T) {
+	tests := []struct {
+		current map[string]string
+		desired map[string]string
+		merged  map[string]string
+	}{
+		{map[string]string{"a": "1"}, nil, map[string]string{"a": "1"}},
+		{nil, map[string]string{"a": "1"}, map[string]string{"a": "1"}},
+		{map[string]string{"a": "1", "b": "2"}, map[string]string{"b": "3", "c": "4"}, map[string]string{"a": "1", "b": "3", "c": "4"}},
+		{nil, nil, map[string]string{}},
+	}
+
+	for i, test := range tests {
+		current := map[string]string{}
+		for key, value := range test.current {
+			current[key] = value
+		}
+
+		merged := manifestMergeConfig(test.current, test.desired)
+		if !reflect.DeepEqual(merged, test.merged) {
+			t.Errorf("Test %d: got %v, expected %v", i, merged, test.merged)
+		}
+
+		// The current config is left untouched
+		if test.current != nil && !reflect.DeepEqual(current, test.current) {
+			t.Errorf("Test %d: the current config was modified", i)
+		}
+	}
+}
+
+// manifestTestServer is a ContainerServer with a fixed set of profiles and
+// containers, recording the changes made to it. Anything else panics.
+type manifestTestServer struct {
+	ContainerServer
+
+	profiles   map[string]api.Profile
+	containers map[string]api.Container
+	changes    []string
+}
+
+func (s *manifestTestServer) HasExtension(extension string) bool {
+	return false
+}
+
+func (s *manifestTestServer) GetProfileNames() ([]string, error) {
+	names := []string{}
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (s *manifestTestServer) GetProfile(name string) (*api.Profile, string, error) {
+	profile, ok := s.profiles[name]
+	if !ok {
+		return nil, "", fmt.Errorf("not found")
+	}
+
+	return &profile, "etag-" + name, nil
+}
+
+func (s *manifestTestServer) CreateProfile(profile api.ProfilesPost) error {
+	s.changes = append(s.changes, "create profile "+profile.Name)
+	return nil
+}
+
+func (s *manifestTestServer) UpdateProfile(name string, profile api.ProfilePut, ETag string) error {
+	s.changes = append(s.changes, fmt.Sprintf("update profile %s (%s)", name, ETag))
+	return nil
+}
+
+func (s *manifestTestServer) DeleteProfile(name string) error {
+	s.changes = append(s.changes, "delete profile "+name)
+	return nil
+}
+
+func (s *manifestTestServer) GetContainerNames() ([]string, error) {
+	names := []string{}
+	for name := range s.containers {
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (s *manifestTestServer) GetContainer(name string) (*api.Container, string, error) {
+	container, ok := s.containers[name]
+	if !ok {
+		return nil, "", fmt.Errorf("not found")
+	}
+
+	return &container, "etag-" + name, nil
+}
+
+func TestPlanManifest(t *testing.T) {
+	server := &manifestTestServer{
+		profiles: map[string]api.Profile{
+			"default": {Name: "default", ProfilePut: api.ProfilePut{
+				Devices: map[string]map[string]string{"root": {"type": "disk", "path": "/", "pool": "default"}},
+			}},
+			"old": {Name: "old"},
+			"web": {Name: "web", ProfilePut: api.ProfilePut{Config: map[string]string{"limits.cpu": "1"}}},
+		},
+		containers: map[string]api.Container{
+			"c1": {Name: "c1", ContainerPut: api.ContainerPut{
+				Profiles: []string{"default"},
+				Config:   map[string]string{"volatile.base_image": "abcdef", "image.os": "Ubuntu", "user.foo": "bar"},
+			}},
+		},
+	}
+
+	image := &api.ContainerSource{Type: "image", Alias: "ubuntu"}
+	defaultProfile := ManifestProfile{
+		Name:    "default",
+		Devices: map[string]map[string]string{"root": {"type": "disk", "path": "/", "pool": "default"}},
+	}
+
+	tests := []struct {
+		manifest Manifest
+		prune    bool
+		actions  []string
+	}{
+		// Matching manifest, LXD managed keys are ignored
+		{Manifest{
+			Profiles:   []ManifestProfile{defaultProfile, {Name: "old"}, {Name: "web", Config: map[string]string{"limits.cpu": "1"}}},
+			Containers: []ManifestContainer{{Name: "c1", Config: map[string]string{"user.foo": "bar"}}},
+		}, true, []string{}},
+
+		// Creations and updates in dependency order
+		{Manifest{
+			Profiles: []ManifestProfile{{Name: "web", Config: map[string]string{"limits.cpu": "2"}}, {Name: "db"}},
+			Containers: []ManifestContainer{
+				{Name: "c2", Source: image, Profiles: []string{"default", "db"}},
+				{Name: "c1", Config: map[string]string{"user.foo": "baz"}, Ephemeral: true},
+			},
+		}, false, []string{
+			`~ update profile "web" (config.limits.cpu)`,
+			`+ create profile "db"`,
+			`+ create container "c2"`,
+			`~ update container "c1" (ephemeral, config.user.foo)`,
+		}},
+
+		// Pruning deletes in reverse order, never the default profile
+		{Manifest{
+			Profiles: []ManifestProfile{{Name: "web", Config: map[string]string{"limits.cpu": "1"}}},
+		}, true, []string{
+			`- delete container "c1"`,
+			`- delete profile "old"`,
+		}},
+	}
+
+	for i, test := range tests {
+		plan, err := PlanManifest(server, test.manifest, &ManifestPlanArgs{Prune: test.prune})
+		if err != nil {
+			t.Errorf("Test %d: %s", i, err)
+			continue
+		}
+
+		actions := []string{}
+		for _, action := range plan.Actions {
+			actions = append(actions, action.String())
+		}
+
+		if !reflect.DeepEqual(actions, test.actions) {
+			t.Errorf("Test %d: got %v, expected %v", i, actions, test.actions)
+		}
+	}
+}
+
+func TestPlanManifestErrors(t *testing.T) {
+	server := &manifestTestServer{
+		profiles:   map[string]api.Profile{"default": {Name: "default"}},
+		containers: map[string]api.Container{},
+	}
+
+	tests := []Manifest{
+		// Duplicates
+		{Profiles: []ManifestProfile{{Name: "p1"}, {Name: "p1"}}},
+		// Missing names
+		{Containers: []ManifestContainer{{}}},
+		// Containers can't be created without a source
+		{Containers: []ManifestContainer{{Name: "c1"}}},
+		// Storage pools and networks need the API extensions
+		{StoragePools: []ManifestStoragePool{{Name: "default", Driver: "dir"}}},
+		{Networks: []ManifestNetwork{{Name: "lxdbr0"}}},
+	}
+
+	for i, manifest := range tests {
+		_, err := PlanManifest(server, manifest, nil)
+		if err == nil {
+			t.Errorf("Test %d: expected an error", i)
+		}
+	}
+}
+
+func TestManifestPlanApply(t *testing.T) {
+	server := &manifestTestServer{
+		profiles: map[string]api.Profile{
+			"default": {Name: "default"},
+			"web":     {Name: "web"},
+		},
+		containers: map[string]api.Container{},
+	}
+
+	manifest := Manifest{
+		Profiles: []ManifestProfile{{Name: "default"}, {Name: "web", Description: "Web"}, {Name: "db"}},
+	}
+
+	plan, err := PlanManifest(server, manifest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = plan.Apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"update profile web (etag-web)", "create profile db"}
+	if !reflect.DeepEqual(server.changes, expected) {
+		t.Errorf("Got changes %v, expected %v", server.changes, expected)
+	}
+}
+
+func TestManifestContainerSource(t *testing.T) {
+	container := api.Container{}
+	container.Config = map[string]string{"volatile.base_image": "abcdef"}
+
+	source := manifestContainerSource(container)
+	if source == nil || source.Type != "image" || source.Fingerprint != "abcdef" {
+		t.Errorf("Unexpected source %v", source)
+	}
+
+	// Containers which weren't created from an image have no source
+	container.Config = map[string]string{}
+	source = manifestContainerSource(container)
+	if source != nil {
+		t.Errorf("Unexpected source %v", source)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/config"
+	"github.com/lxc/lxd/shared/gnuflag"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type applyCmd struct {
+	prune  bool
+	dryRun bool
+}
+
+func (c *applyCmd) showByDefault() bool {
+	return true
+}
+
+func (c *applyCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc apply [<remote>:] <file> [--prune] [--dry-run]
+
+Make a LXD server match a YAML manifest.
+
+The manifest lists storage pools, custom storage volumes, managed networks,
+profiles and containers, in the format printed by "lxc export-manifest".
+The changes needed are printed, then the missing objects are created and the
+existing ones updated. Use "-" as the file to read the manifest from stdin.
+Containers can only be created from a source, "type: none" creating empty
+ones.
+
+With --prune, the objects which aren't in the manifest are deleted too (except
+for the default profile). Running containers are stopped before being deleted.
+
+*Examples*
+lxc apply dev.yaml
+    Make the local server match dev.yaml.
+
+lxc apply remote: dev.yaml --prune --dry-run
+    Show what it would take for "remote" to only have what's in dev.yaml.`)
+}
+
+func (c *applyCmd) flags() {
+	gnuflag.BoolVar(&c.prune, "prune", false, i18n.G("Delete the objects which aren't in the manifest"))
+	gnuflag.BoolVar(&c.dryRun, "dry-run", false, i18n.G("Only show the changes, don't make them"))
+}
+
+func (c *applyCmd) run(conf *config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errArgs
+	}
+
+	remote := conf.DefaultRemote
+	file := args[0]
+	if len(args) == 2 {
+		var err error
+		remote, _, err = conf.ParseRemote(args[0])
+		if err != nil {
+			return err
+		}
+
+		file = args[1]
+	}
+
+	var content []byte
+	var err error
+	if file == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	manifest := lxd.Manifest{}
+	err = yaml.Unmarshal(content, &manifest)
+	if err != nil {
+		return fmt.Errorf(i18n.G("Invalid manifest: %s"), err)
+	}
+
+	d, err := conf.GetContainerServer(remote)
+	if err != nil {
+		return err
+	}
+
+	plan, err := lxd.PlanManifest(d, manifest, &lxd.ManifestPlanArgs{Prune: c.prune})
+	if err != nil {
+		return err
+	}
+
+	if len(plan.Actions) == 0 {
+		fmt.Println(i18n.G("Nothing to do, the server matches the manifest"))
+		return nil
+	}
+
+	for _, action := range plan.Actions {
+		fmt.Println(action.String())
+	}
+
+	if c.dryRun {
+		return nil
+	}
+
+	err = plan.Apply()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(i18n.G("Applied %d changes")+"\n", len(plan.Actions))
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/config"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/gnuflag"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type auditCmd struct {
+	requestor string
+	method    string
+	url       string
+	since     string
+	limit     int
+	format    string
+}
+
+func (c *auditCmd) showByDefault() bool {
+	return false
+}
+
+func (c *auditCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc audit [<remote>:] [--requestor=FINGERPRINT] [--method=METHOD] [--url=URL] [--since=DATE|DURATION] [--limit=N] [--format table|yaml]
+
+Show the requests which modified a LXD server.
+
+Requests are listed oldest first along with the client which made them,
+either by certificate fingerprint or as "unix socket" with the uid of
+the local user, and the final status of the operation they started.
+
+The table format leaves out the request bodies, the yaml format has them
+with their secrets redacted.
+
+*Examples*
+lxc audit --url=/1.0/containers/c1 --since=12h
+    Show the requests on container c1 in the last 12 hours.
+
+lxc audit --method=PUT --limit=10 --format=yaml
+    Show the last 10 PUT requests along with their bodies.`)
+}
+
+func (c *auditCmd) flags() {
+	gnuflag.StringVar(&c.requestor, "requestor", "", i18n.G("Only show requests from this certificate fingerprint or \"unix socket\""))
+	gnuflag.StringVar(&c.method, "method", "", i18n.G("Only show requests with this method"))
+	gnuflag.StringVar(&c.url, "url", "", i18n.G("Only show requests whose URL contains this string"))
+	gnuflag.StringVar(&c.since, "since", "", i18n.G("Only show requests since this date (RFC3339) or for this duration"))
+	gnuflag.IntVar(&c.limit, "limit", 0, i18n.G("Only show this many of the latest requests"))
+	gnuflag.StringVar(&c.format, "format", "table", i18n.G("Format (table|yaml)"))
+}
+
+func (c *auditCmd) run(conf *config.Config, args []string) error {
+	if len(args) > 1 {
+		return errArgs
+	}
+
+	remote := conf.DefaultRemote
+	if len(args) == 1 {
+		var err error
+		remote, _, err = conf.ParseRemote(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	filter := lxd.AuditArgs{
+		Requestor: c.requestor,
+		Method:    strings.ToUpper(c.method),
+		URL:       c.url,
+		Limit:     c.limit,
+	}
+
+	if c.since != "" {
+		duration, err := time.ParseDuration(c.since)
+		if err == nil {
+			filter.Since = time.Now().Add(-duration)
+		} else {
+			filter.Since, err = time.Parse(time.RFC3339, c.since)
+			if err != nil {
+				return fmt.Errorf(i18n.G("Invalid date or duration '%s'"), c.since)
+			}
+		}
+	}
+
+	d, err := conf.GetContainerServer(remote)
+	if err != nil {
+		return err
+	}
+
+	entries, err := d.GetAuditEntries(filter)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case "table":
+		return c.showTable(entries)
+	case "yaml":
+		data, err := yaml.Marshal(&entries)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s", data)
+		return nil
+	default:
+		return fmt.Errorf(i18n.G("Invalid format '%s'"), c.format)
+	}
+}
+
+func (c *auditCmd) showTable(entries []api.AuditEntry) error {
+	const layout = "2006/01/02 15:04:05 MST"
+
+	data := [][]string{}
+	for _, entry := range entries {
+		requestor := entry.Requestor
+		if entry.UID >= 0 {
+			requestor = fmt.Sprintf("%s (uid %d)", requestor, entry.UID)
+		} else if len(requestor) > 12 {
+			requestor = requestor[0:12]
+		}
+
+		operation := entry.Operation
+		if len(operation) > 8 {
+			operation = operation[0:8]
+		}
+
+		data = append(data, []string{entry.Date.Local().Format(layout), requestor, entry.Method, entry.URL, operation, entry.Status})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("DATE"),
+		i18n.G("REQUESTOR"),
+		i18n.G("METHOD"),
+		i18n.G("URL"),
+		i18n.G("OPERATION"),
+		i18n.G("STATUS")})
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
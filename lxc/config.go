@@ -25,6 +25,12 @@ import (
 
 type configCmd struct {
 	expanded bool
+
+	trustReadOnly   bool
+	trustContainers string
+	trustProfiles   string
+	trustProjects   string
+	trustToken      bool
 }
 
 func (c *configCmd) showByDefault() bool {
@@ -33,6 +39,11 @@ func (c *configCmd) showByDefault() bool {
 
 func (c *configCmd) flags() {
 	gnuflag.BoolVar(&c.expanded, "expanded", false, i18n.G("Show the expanded configuration"))
+	gnuflag.BoolVar(&c.trustReadOnly, "read-only", false, i18n.G("Only allow the certificate to read from the server"))
+	gnuflag.StringVar(&c.trustContainers, "containers", "", i18n.G("Comma separated list of containers the certificate is restricted to"))
+	gnuflag.StringVar(&c.trustProfiles, "profiles", "", i18n.G("Comma separated list of profiles the certificate is restricted to"))
+	gnuflag.StringVar(&c.trustProjects, "projects", "", i18n.G("Comma separated list of projects the certificate is restricted to"))
+	gnuflag.BoolVar(&c.trustToken, "token", false, i18n.G("Generate a single-use token for a client to add itself"))
 }
 
 func (c *configCmd) configEditHelp() string {
@@ -107,8 +118,17 @@ lxc config device remove [<remote>:]<container> <name>...
 lxc config trust list [<remote>:]
     List all trusted certs.
 
-lxc config trust add [<remote>:] <certfile.crt>
-    Add certfile.crt to trusted hosts.
+lxc config trust add [<remote>:] <certfile.crt> [--read-only] [--containers=<list>] [--profiles=<list>] [--projects=<list>]
+    Add certfile.crt to trusted hosts, optionally restricting what it can access.
+
+lxc config trust add [<remote>:] <name> --token
+    Generate a single-use token letting client <name> add itself with "lxc remote add".
+
+lxc config trust list-tokens [<remote>:]
+    List the tokens which haven't been used yet.
+
+lxc config trust revoke-token [<remote>:] <name>
+    Revoke the token of client <name>.
 
 lxc config trust remove [<remote>:] [hostname|fingerprint]
     Remove the cert from trusted hosts.
@@ -128,7 +148,18 @@ lxc config set core.https_address [::]:8443
     Will have LXD listen on IPv4 and IPv6 port 8443.
 
 lxc config set core.trust_password blah
-    Will set the server's trust password to blah.`)
+    Will set the server's trust password to blah.
+
+lxc config trust add ci.crt --projects=ci
+    Will only let ci.crt manage the containers, images, profiles and volumes of project "ci".`)
+}
+
+func (c *configCmd) splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+
+	return strings.Split(list, ",")
 }
 
 func (c *configCmd) doSet(conf *config.Config, args []string, unset bool) error {
@@ -373,6 +404,18 @@ func (c *configCmd) run(conf *config.Config, args []string) error {
 				return err
 			}
 
+			if c.trustToken {
+				name := args[len(args)-1]
+				token, err := d.CreateCertificateToken(api.CertificateTokensPost{Name: name})
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf(i18n.G("Client %s certificate add token:")+"\n", name)
+				fmt.Println(token.Token)
+				return nil
+			}
+
 			fname := args[len(args)-1]
 			x509Cert, err := shared.ReadCert(fname)
 			if err != nil {
@@ -384,6 +427,10 @@ func (c *configCmd) run(conf *config.Config, args []string) error {
 			cert.Certificate = base64.StdEncoding.EncodeToString(x509Cert.Raw)
 			cert.Name = name
 			cert.Type = "client"
+			cert.ReadOnly = c.trustReadOnly
+			cert.Containers = c.splitList(c.trustContainers)
+			cert.Profiles = c.splitList(c.trustProfiles)
+			cert.Projects = c.splitList(c.trustProjects)
 
 			return d.CreateCertificate(cert)
 		case "remove":
@@ -406,6 +453,67 @@ func (c *configCmd) run(conf *config.Config, args []string) error {
 			}
 
 			return d.DeleteCertificate(args[len(args)-1])
+		case "list-tokens":
+			var remote string
+			if len(args) == 3 {
+				var err error
+				remote, _, err = conf.ParseRemote(args[2])
+				if err != nil {
+					return err
+				}
+			} else {
+				remote = conf.DefaultRemote
+			}
+
+			d, err := conf.GetContainerServer(remote)
+			if err != nil {
+				return err
+			}
+
+			tokens, err := d.GetCertificateTokens()
+			if err != nil {
+				return err
+			}
+
+			data := [][]string{}
+			for _, token := range tokens {
+				const layout = "Jan 2, 2006 at 3:04pm (MST)"
+				data = append(data, []string{token.Name, token.Token, token.ExpiresAt.Local().Format(layout)})
+			}
+
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetAutoWrapText(false)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetRowLine(true)
+			table.SetHeader([]string{
+				i18n.G("NAME"),
+				i18n.G("TOKEN"),
+				i18n.G("EXPIRY DATE")})
+			sort.Sort(StringList(data))
+			table.AppendBulk(data)
+			table.Render()
+
+			return nil
+		case "revoke-token":
+			var remote string
+			if len(args) < 3 {
+				return fmt.Errorf(i18n.G("No token name specified"))
+			} else if len(args) == 4 {
+				var err error
+				remote, _, err = conf.ParseRemote(args[2])
+				if err != nil {
+					return err
+				}
+			} else {
+				remote = conf.DefaultRemote
+			}
+
+			d, err := conf.GetContainerServer(remote)
+			if err != nil {
+				return err
+			}
+
+			return d.DeleteCertificateToken(args[len(args)-1])
 		default:
 			return errArgs
 		}
@@ -14,6 +14,7 @@ type Remote struct {
 	Addr     string `yaml:"addr"`
 	Public   bool   `yaml:"public"`
 	Protocol string `yaml:"protocol,omitempty"`
+	Project  string `yaml:"project,omitempty"`
 	Static   bool   `yaml:"-"`
 }
 
@@ -63,6 +64,10 @@ func (c *Config) GetContainerServer(name string) (lxd.ContainerServer, error) {
 			return nil, err
 		}
 
+		if remote.Project != "" && remote.Project != "default" {
+			d = d.UseProject(remote.Project)
+		}
+
 		return d, nil
 	}
 
@@ -76,6 +81,10 @@ func (c *Config) GetContainerServer(name string) (lxd.ContainerServer, error) {
 		return nil, err
 	}
 
+	if remote.Project != "" && remote.Project != "default" {
+		d = d.UseProject(remote.Project)
+	}
+
 	return d, nil
 }
 
@@ -100,6 +109,10 @@ func (c *Config) GetImageServer(name string) (lxd.ImageServer, error) {
 			return nil, err
 		}
 
+		if remote.Project != "" && remote.Project != "default" {
+			return d.UseProject(remote.Project), nil
+		}
+
 		return d, nil
 	}
 
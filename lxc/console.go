@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/config"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/gnuflag"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/termios"
+)
+
+// The key ending a console session, after the ctrl+a prefix
+const consoleDetachKey = 'q'
+
+type consoleCmd struct {
+	showLog bool
+}
+
+func (c *consoleCmd) showByDefault() bool {
+	return true
+}
+
+func (c *consoleCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc console [<remote>:]<container> [--show-log]
+
+Attach to container consoles.
+
+This command allows you to interact with the boot console of a container
+as well as retrieve past log entries from it.
+
+Detach from the console with ctrl+a q.`)
+}
+
+func (c *consoleCmd) flags() {
+	gnuflag.BoolVar(&c.showLog, "show-log", false, i18n.G("Retrieve the container's console log"))
+}
+
+func (c *consoleCmd) sendTermSize(control *websocket.Conn) error {
+	width, height, err := termios.GetSize(int(syscall.Stdout))
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Window size is now: %dx%d", width, height)
+
+	w, err := control.NextWriter(websocket.TextMessage)
+	if err != nil {
+		return err
+	}
+
+	msg := api.ContainerConsoleControl{}
+	msg.Command = "window-resize"
+	msg.Args = make(map[string]string)
+	msg.Args["width"] = strconv.Itoa(width)
+	msg.Args["height"] = strconv.Itoa(height)
+
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+
+	w.Close()
+	return err
+}
+
+func (c *consoleCmd) run(conf *config.Config, args []string) error {
+	if len(args) != 1 {
+		return errArgs
+	}
+
+	remote, name, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetContainerServer(remote)
+	if err != nil {
+		return err
+	}
+
+	if c.showLog {
+		log, err := d.GetContainerConsoleLog(name)
+		if err != nil {
+			return err
+		}
+		defer log.Close()
+
+		_, err = io.Copy(os.Stdout, log)
+		return err
+	}
+
+	cfd := int(syscall.Stdin)
+
+	var width, height int
+	var oldttystate *termios.State
+	if termios.IsTerminal(cfd) {
+		width, height, err = termios.GetSize(int(syscall.Stdout))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf(i18n.G("To detach from the console, press: <ctrl>+a q") + "\n\r")
+
+		oldttystate, err = termios.MakeRaw(cfd)
+		if err != nil {
+			return err
+		}
+		defer termios.Restore(cfd, oldttystate)
+	}
+
+	req := api.ContainerConsolePost{
+		Width:  width,
+		Height: height,
+	}
+
+	consoleArgs := lxd.ContainerConsoleArgs{
+		Stdin:    &execDetachReader{ReadCloser: os.Stdin, key: consoleDetachKey},
+		Stdout:   os.Stdout,
+		Control:  c.controlSocketHandler,
+		DataDone: make(chan bool),
+	}
+
+	op, err := d.ConsoleContainer(name, req, &consoleArgs)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the operation to complete
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	// Wait for any remaining I/O to be flushed
+	<-consoleArgs.DataDone
+
+	return nil
+}
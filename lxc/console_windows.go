@@ -0,0 +1,17 @@
+// +build windows
+
+package main
+
+import (
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+func (c *consoleCmd) controlSocketHandler(control *websocket.Conn) {
+	// Windows doesn't have SIGWINCH, so only send the initial size
+	err := c.sendTermSize(control)
+	if err != nil {
+		logger.Debugf("error setting term size %s", err)
+	}
+}
@@ -17,6 +17,7 @@ type copyCmd struct {
 	ephem         bool
 	containerOnly bool
 	mode          string
+	refresh       bool
 }
 
 func (c *copyCmd) showByDefault() bool {
@@ -25,9 +26,12 @@ func (c *copyCmd) showByDefault() bool {
 
 func (c *copyCmd) usage() string {
 	return i18n.G(
-		`Usage: lxc copy [<remote>:]<source>[/<snapshot>] [[<remote>:]<destination>] [--ephemeral|e] [--profile|-p <profile>...] [--config|-c <key=value>...] [--container-only]
+		`Usage: lxc copy [<remote>:]<source>[/<snapshot>] [[<remote>:]<destination>] [--ephemeral|e] [--profile|-p <profile>...] [--config|-c <key=value>...] [--container-only] [--refresh]
 
-Copy containers within or in between LXD instances.`)
+Copy containers within or in between LXD instances.
+
+With --refresh, an existing copy of the container is updated with only the
+snapshots it's missing and the changes to the container itself.`)
 }
 
 func (c *copyCmd) flags() {
@@ -39,6 +43,7 @@ func (c *copyCmd) flags() {
 	gnuflag.BoolVar(&c.ephem, "e", false, i18n.G("Ephemeral container"))
 	gnuflag.StringVar(&c.mode, "mode", "pull", i18n.G("Transfer mode. One of pull (default), push or relay."))
 	gnuflag.BoolVar(&c.containerOnly, "container-only", false, i18n.G("Copy the container without its snapshots"))
+	gnuflag.BoolVar(&c.refresh, "refresh", false, i18n.G("Update an existing copy of the container"))
 }
 
 func (c *copyCmd) copyContainer(conf *config.Config, sourceResource string, destResource string, keepVolatile bool, ephemeral int, stateful bool, containerOnly bool, mode string) error {
@@ -85,6 +90,10 @@ func (c *copyCmd) copyContainer(conf *config.Config, sourceResource string, dest
 
 	var op *lxd.RemoteOperation
 	if shared.IsSnapshot(sourceName) {
+		if c.refresh {
+			return fmt.Errorf(i18n.G("--refresh can only be used with containers"))
+		}
+
 		// Prepare the container creation request
 		args := lxd.ContainerSnapshotCopyArgs{
 			Name: destName,
@@ -142,6 +151,7 @@ func (c *copyCmd) copyContainer(conf *config.Config, sourceResource string, dest
 			Live:          stateful,
 			ContainerOnly: containerOnly,
 			Mode:          mode,
+			Refresh:       c.refresh,
 		}
 
 		// Copy of a container into a new container
@@ -247,6 +257,10 @@ func (c *copyCmd) run(conf *config.Config, args []string) error {
 
 	// If not target name is specified, one will be chosed by the server
 	if len(args) < 2 {
+		if c.refresh {
+			return fmt.Errorf(i18n.G("--refresh requires a target container"))
+		}
+
 		return c.copyContainer(conf, args[0], "", false, ephem, false, c.containerOnly, mode)
 	}
 
@@ -7,11 +7,13 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/gorilla/websocket"
+	"github.com/olekukonko/tablewriter"
 
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxc/config"
@@ -43,6 +45,13 @@ type execCmd struct {
 	forceInteractive    bool
 	forceNonInteractive bool
 	disableStdin        bool
+	user                uint
+	group               uint
+	cwd                 string
+	persistent          bool
+	persistentTimeout   int
+	attach              string
+	sessions            bool
 }
 
 func (c *execCmd) showByDefault() bool {
@@ -51,11 +60,16 @@ func (c *execCmd) showByDefault() bool {
 
 func (c *execCmd) usage() string {
 	return i18n.G(
-		`Usage: lxc exec [<remote>:]<container> [-t] [-T] [-n] [--mode=auto|interactive|non-interactive] [--env KEY=VALUE...] [--] <command line>
+		`Usage: lxc exec [<remote>:]<container> [-t] [-T] [-n] [--mode=auto|interactive|non-interactive] [--env KEY=VALUE...] [--user=UID] [--group=GID] [--cwd=PATH] [--persistent [--persistent-timeout=SECONDS]] [--] <command line>
+       lxc exec [<remote>:]<container> --attach=<session>
+       lxc exec [<remote>:]<container> --sessions
 
 Execute commands in containers.
 
-Mode defaults to non-interactive, interactive mode is selected if both stdin AND stdout are terminals (stderr is ignored).`)
+Mode defaults to non-interactive, interactive mode is selected if both stdin AND stdout are terminals (stderr is ignored).
+
+Persistent sessions keep running when the client disconnects or detaches
+(ctrl+a d) and can be reattached to until their timeout expires.`)
 }
 
 func (c *execCmd) flags() {
@@ -64,6 +78,13 @@ func (c *execCmd) flags() {
 	gnuflag.BoolVar(&c.forceInteractive, "t", false, i18n.G("Force pseudo-terminal allocation"))
 	gnuflag.BoolVar(&c.forceNonInteractive, "T", false, i18n.G("Disable pseudo-terminal allocation"))
 	gnuflag.BoolVar(&c.disableStdin, "n", false, i18n.G("Disable stdin (reads from /dev/null)"))
+	gnuflag.UintVar(&c.user, "user", 0, i18n.G("User ID to run the command as"))
+	gnuflag.UintVar(&c.group, "group", 0, i18n.G("Group ID to run the command as"))
+	gnuflag.StringVar(&c.cwd, "cwd", "", i18n.G("Directory to run the command in (default /root)"))
+	gnuflag.BoolVar(&c.persistent, "persistent", false, i18n.G("Keep the session running when disconnected"))
+	gnuflag.IntVar(&c.persistentTimeout, "persistent-timeout", 0, i18n.G("Seconds a disconnected session is kept for (default 300)"))
+	gnuflag.StringVar(&c.attach, "attach", "", i18n.G("Attach to an existing persistent session"))
+	gnuflag.BoolVar(&c.sessions, "sessions", false, i18n.G("List the persistent sessions of the container"))
 }
 
 func (c *execCmd) sendTermSize(control *websocket.Conn) error {
@@ -118,7 +139,11 @@ func (c *execCmd) forwardSignal(control *websocket.Conn, sig syscall.Signal) err
 }
 
 func (c *execCmd) run(conf *config.Config, args []string) error {
-	if len(args) < 2 {
+	if c.sessions || c.attach != "" {
+		if len(args) != 1 {
+			return errArgs
+		}
+	} else if len(args) < 2 {
 		return errArgs
 	}
 
@@ -140,10 +165,19 @@ func (c *execCmd) run(conf *config.Config, args []string) error {
 		return err
 	}
 
+	if c.sessions {
+		return c.listSessions(d, name)
+	}
+
 	/* FIXME: Default values for HOME and USER are now handled by LXD.
 	   This code should be removed after most users upgraded.
 	*/
-	env := map[string]string{"HOME": "/root", "USER": "root"}
+	env := map[string]string{}
+	if c.user == 0 {
+		env["HOME"] = "/root"
+		env["USER"] = "root"
+	}
+
 	if myTerm, ok := c.getTERM(); ok {
 		env["TERM"] = myTerm
 	}
@@ -170,6 +204,11 @@ func (c *execCmd) run(conf *config.Config, args []string) error {
 		interactive = termios.IsTerminal(cfd) && termios.IsTerminal(int(syscall.Stdout))
 	}
 
+	persistent := c.persistent || c.attach != ""
+	if persistent && !interactive {
+		return fmt.Errorf(i18n.G("Persistent sessions require interactive mode"))
+	}
+
 	var oldttystate *termios.State
 	if interactive {
 		oldttystate, err = termios.MakeRaw(cfd)
@@ -198,6 +237,10 @@ func (c *execCmd) run(conf *config.Config, args []string) error {
 		stdin = ioutil.NopCloser(bytes.NewReader(nil))
 	}
 
+	if persistent {
+		stdin = &execDetachReader{ReadCloser: stdin, key: execDetachKey}
+	}
+
 	stdout := c.getStdout()
 
 	req := api.ContainerExecPost{
@@ -207,6 +250,12 @@ func (c *execCmd) run(conf *config.Config, args []string) error {
 		Environment: env,
 		Width:       width,
 		Height:      height,
+		User:        uint32(c.user),
+		Group:       uint32(c.group),
+		Cwd:         c.cwd,
+
+		Persistent:        c.persistent,
+		PersistentTimeout: c.persistentTimeout,
 	}
 
 	execArgs := lxd.ContainerExecArgs{
@@ -217,12 +266,41 @@ func (c *execCmd) run(conf *config.Config, args []string) error {
 		DataDone: make(chan bool),
 	}
 
-	// Run the command in the container
-	op, err := d.ExecContainer(name, req, &execArgs)
+	// Run the command in the container, or take over an existing session
+	var op *lxd.Operation
+	if c.attach != "" {
+		// Let the session know about our terminal size
+		execArgs.Control = func(control *websocket.Conn) {
+			c.sendTermSize(control)
+			c.controlSocketHandler(control)
+		}
+
+		op, err = d.AttachContainerExecSession(name, c.attach, &execArgs)
+	} else {
+		op, err = d.ExecContainer(name, req, &execArgs)
+	}
 	if err != nil {
 		return err
 	}
 
+	if persistent {
+		// The data connection ends when either the command exits or
+		// we're detached, only the former sets the return value.
+		<-execArgs.DataDone
+
+		err = op.Refresh()
+		if err != nil {
+			return err
+		}
+
+		_, ok := op.Metadata["return"]
+		if !ok {
+			termios.Restore(cfd, oldttystate)
+			fmt.Printf(i18n.G("Detached from session %s")+"\n", op.ID)
+			return nil
+		}
+	}
+
 	// Wait for the operation to complete
 	err = op.Wait()
 	if err != nil {
@@ -246,3 +324,97 @@ func (c *execCmd) run(conf *config.Config, args []string) error {
 	os.Exit(int(op.Metadata["return"].(float64)))
 	return nil
 }
+
+func (c *execCmd) listSessions(d lxd.ContainerServer, name string) error {
+	sessions, err := d.GetContainerExecSessions(name)
+	if err != nil {
+		return err
+	}
+
+	const layout = "2006/01/02 15:04 UTC"
+
+	data := [][]string{}
+	for _, session := range sessions {
+		attached := i18n.G("NO")
+		expiry := ""
+		if session.Attached {
+			attached = i18n.G("YES")
+		} else {
+			expiry = session.ExpiresAt.UTC().Format(layout)
+		}
+
+		data = append(data, []string{
+			session.ID,
+			strings.Join(session.Command, " "),
+			session.CreatedAt.UTC().Format(layout),
+			attached,
+			expiry})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("ID"),
+		i18n.G("COMMAND"),
+		i18n.G("CREATED AT"),
+		i18n.G("ATTACHED"),
+		i18n.G("EXPIRES AT")})
+	sort.Sort(StringList(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
+// The detach sequence of persistent sessions (ctrl+a d)
+const execDetachPrefix = 0x01
+const execDetachKey = 'd'
+
+// execDetachReader ends the input when the prefix followed by key is typed.
+// The prefix is held back until the next key is known, so that it still
+// reaches the container when followed by anything else.
+type execDetachReader struct {
+	io.ReadCloser
+	key     byte
+	escaped bool
+}
+
+func (r *execDetachReader) Read(p []byte) (int, error) {
+	// Leave room for a held back prefix
+	size := len(p)
+	if size > 1 {
+		size--
+	}
+
+	buf := make([]byte, size)
+	n, err := r.ReadCloser.Read(buf)
+
+	out := p[:0]
+	for _, b := range buf[:n] {
+		if r.escaped {
+			r.escaped = false
+			if b == r.key {
+				return len(out), io.EOF
+			}
+
+			out = append(out, execDetachPrefix)
+		}
+
+		if b == execDetachPrefix {
+			r.escaped = true
+			continue
+		}
+
+		out = append(out, b)
+	}
+
+	// Nothing else is coming, pass on the held back prefix
+	if err != nil && r.escaped {
+		r.escaped = false
+		out = append(out, execDetachPrefix)
+	}
+
+	return len(out), err
+}
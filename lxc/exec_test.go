@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestExecDetachReader(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"foo\n", "foo\n"},
+		{"foo\x01dbar", "foo"},
+		{"\x01a\x01\x01d", "\x01a\x01"},
+		{"foo\x01", "foo\x01"},
+	}
+
+	for _, test := range tests {
+		r := &execDetachReader{ReadCloser: ioutil.NopCloser(bytes.NewReader([]byte(test.input))), key: execDetachKey}
+
+		output, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Failed to read %q: %s", test.input, err)
+		}
+
+		if string(output) != test.output {
+			t.Errorf("Got %q for %q, expected %q", output, test.input, test.output)
+		}
+	}
+}
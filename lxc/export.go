@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lxc/lxd/lxc/config"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/gnuflag"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type exportCmd struct {
+	containerOnly    bool
+	optimizedStorage bool
+}
+
+func (c *exportCmd) showByDefault() bool {
+	return true
+}
+
+func (c *exportCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc export [<remote>:]<container> [target] [--container-only] [--optimized-storage]
+
+Export container backups as tarballs.
+
+The tarball contains the container configuration, its profiles, its
+snapshots and its root filesystem. It can be restored with "lxc import".
+
+*Examples*
+lxc export u1 backup0.tar.gz
+    Download a backup tarball of the u1 container.`)
+}
+
+func (c *exportCmd) flags() {
+	gnuflag.BoolVar(&c.containerOnly, "container-only", false, i18n.G("Whether or not to only backup the container (without snapshots)"))
+	gnuflag.BoolVar(&c.optimizedStorage, "optimized-storage", false, i18n.G("Use storage driver optimized format (can only be restored on a similar pool)"))
+}
+
+func (c *exportCmd) run(conf *config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errArgs
+	}
+
+	remote, name, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetContainerServer(remote)
+	if err != nil {
+		return err
+	}
+
+	target := fmt.Sprintf("%s.tar.gz", name)
+	if len(args) == 2 {
+		target = args[1]
+	}
+
+	// Create a short lived backup on the server, it gets removed once
+	// downloaded and expires in case we fail to do so.
+	backupName := fmt.Sprintf("lxc-export-%d", time.Now().UnixNano())
+	req := api.ContainerBackupsPost{
+		Name:             backupName,
+		ExpiryDate:       time.Now().Add(24 * time.Hour),
+		ContainerOnly:    c.containerOnly,
+		OptimizedStorage: c.optimizedStorage,
+	}
+
+	op, err := d.CreateContainerBackup(name, req)
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		op, err := d.DeleteContainerBackup(name, backupName)
+		if err == nil {
+			op.Wait()
+		}
+	}()
+
+	// Download the tarball
+	content, err := d.GetContainerBackupFile(name, backupName)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, content)
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+
+	fmt.Println(i18n.G("Backup exported successfully!"))
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/config"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type exportManifestCmd struct {
+}
+
+func (c *exportManifestCmd) showByDefault() bool {
+	return true
+}
+
+func (c *exportManifestCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc export-manifest [<remote>:]
+
+Print the storage pools, custom storage volumes, managed networks, profiles
+and containers of a LXD server as a YAML manifest for "lxc apply".
+
+The keys managed by LXD ("volatile." and "image.") are left out. Containers
+get the image they were created from as their source, which must be available
+to the server the manifest is applied to.
+
+*Examples*
+lxc export-manifest remote: > dev.yaml
+    Save the state of "remote" so it can be reproduced with "lxc apply dev.yaml".`)
+}
+
+func (c *exportManifestCmd) flags() {}
+
+func (c *exportManifestCmd) run(conf *config.Config, args []string) error {
+	if len(args) > 1 {
+		return errArgs
+	}
+
+	remote := conf.DefaultRemote
+	if len(args) == 1 {
+		var err error
+		remote, _, err = conf.ParseRemote(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	d, err := conf.GetContainerServer(remote)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := lxd.ExportManifest(d)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
 
 	"gopkg.in/yaml.v2"
@@ -15,7 +16,9 @@ import (
 )
 
 type infoCmd struct {
-	showLog bool
+	showLog   bool
+	follow    bool
+	resources bool
 }
 
 func (c *infoCmd) showByDefault() bool {
@@ -24,19 +27,22 @@ func (c *infoCmd) showByDefault() bool {
 
 func (c *infoCmd) usage() string {
 	return i18n.G(
-		`Usage: lxc info [<remote>:][<container>] [--show-log]
+		`Usage: lxc info [<remote>:][<container>] [--show-log [-f]] [--resources]
 
 Show container or server information.
 
-lxc info [<remote>:]<container> [--show-log]
-    For container information.
+lxc info [<remote>:]<container> [--show-log [-f]]
+    For container information, -f keeps printing new log lines.
 
-lxc info [<remote>:]
+lxc info [<remote>:] [--resources]
     For LXD server information.`)
 }
 
 func (c *infoCmd) flags() {
 	gnuflag.BoolVar(&c.showLog, "show-log", false, i18n.G("Show the container's last 100 log lines?"))
+	gnuflag.BoolVar(&c.resources, "resources", false, i18n.G("Show the resources available to the server"))
+	gnuflag.BoolVar(&c.follow, "f", false, i18n.G("Follow the log as it grows"))
+	gnuflag.BoolVar(&c.follow, "follow", false, i18n.G("Follow the log as it grows"))
 }
 
 func (c *infoCmd) run(conf *config.Config, args []string) error {
@@ -68,6 +74,22 @@ func (c *infoCmd) run(conf *config.Config, args []string) error {
 }
 
 func (c *infoCmd) remoteInfo(d lxd.ContainerServer) error {
+	if c.resources {
+		resources, err := d.GetServerResources()
+		if err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(&resources)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s", data)
+
+		return nil
+	}
+
 	serverStatus, _, err := d.GetServer()
 	if err != nil {
 		return err
@@ -236,7 +258,15 @@ func (c *infoCmd) containerInfo(d lxd.ContainerServer, remote config.Remote, nam
 		first_snapshot = false
 	}
 
-	if showLog {
+	if showLog && c.follow {
+		conn, err := d.GetContainerLogfileWebsocket(name, "lxc.log")
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n" + i18n.G("Log:") + "\n\n")
+		<-shared.WebsocketRecvStream(os.Stdout, conn)
+	} else if showLog {
 		log, err := d.GetContainerLogfile(name, "lxc.log")
 		if err != nil {
 			return err
@@ -195,28 +195,35 @@ type command interface {
 }
 
 var commands = map[string]command{
-	"config":  &configCmd{},
-	"copy":    &copyCmd{},
-	"delete":  &deleteCmd{},
-	"exec":    &execCmd{},
-	"file":    &fileCmd{},
-	"finger":  &fingerCmd{},
-	"help":    &helpCmd{},
-	"image":   &imageCmd{},
-	"info":    &infoCmd{},
-	"init":    &initCmd{},
-	"launch":  &launchCmd{},
-	"list":    &listCmd{},
-	"manpage": &manpageCmd{},
-	"monitor": &monitorCmd{},
-	"move":    &moveCmd{},
-	"network": &networkCmd{},
+	"apply":           &applyCmd{},
+	"audit":           &auditCmd{},
+	"config":          &configCmd{},
+	"console":         &consoleCmd{},
+	"copy":            &copyCmd{},
+	"delete":          &deleteCmd{},
+	"exec":            &execCmd{},
+	"export":          &exportCmd{},
+	"export-manifest": &exportManifestCmd{},
+	"file":            &fileCmd{},
+	"finger":          &fingerCmd{},
+	"help":            &helpCmd{},
+	"image":           &imageCmd{},
+	"import":          &importCmd{},
+	"info":            &infoCmd{},
+	"init":            &initCmd{},
+	"launch":          &launchCmd{},
+	"list":            &listCmd{},
+	"manpage":         &manpageCmd{},
+	"monitor":         &monitorCmd{},
+	"move":            &moveCmd{},
+	"network":         &networkCmd{},
 	"pause": &actionCmd{
 		action:      shared.Freeze,
 		description: i18n.G("Pause containers."),
 		name:        "pause",
 	},
 	"profile": &profileCmd{},
+	"project": &projectCmd{},
 	"publish": &publishCmd{},
 	"remote":  &remoteCmd{},
 	"restart": &actionCmd{
@@ -5,6 +5,7 @@ import (
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxc/config"
 	"github.com/lxc/lxd/shared/gnuflag"
 	"github.com/lxc/lxd/shared/i18n"
@@ -30,7 +31,10 @@ func (f *typeList) Set(value string) error {
 }
 
 type monitorCmd struct {
-	typeArgs typeList
+	typeArgs     typeList
+	resourceArgs typeList
+	level        string
+	since        int64
 }
 
 func (c *monitorCmd) showByDefault() bool {
@@ -39,7 +43,7 @@ func (c *monitorCmd) showByDefault() bool {
 
 func (c *monitorCmd) usage() string {
 	return i18n.G(
-		`Usage: lxc monitor [<remote>:] [--type=TYPE...]
+		`Usage: lxc monitor [<remote>:] [--type=TYPE...] [--resource=URL...] [--level=LEVEL] [--since=ID]
 
 Monitor a local or remote LXD server.
 
@@ -47,13 +51,24 @@ By default the monitor will listen to all message types.
 
 Message types to listen for can be specified with --type.
 
+Operation and lifecycle events can be restricted to those affecting
+a given resource with --resource, logging events to a minimum level
+with --level. Events which followed a given event ID can be replayed
+with --since.
+
 *Examples*
 lxc monitor --type=logging
-    Only show log message.`)
+    Only show log message.
+
+lxc monitor --type=operation --resource=/1.0/containers/c1
+    Only show operations affecting container c1.`)
 }
 
 func (c *monitorCmd) flags() {
 	gnuflag.Var(&c.typeArgs, "type", i18n.G("Event type to listen for"))
+	gnuflag.Var(&c.resourceArgs, "resource", i18n.G("Resource URL to filter events on"))
+	gnuflag.StringVar(&c.level, "level", "", i18n.G("Minimum level of log messages"))
+	gnuflag.Int64Var(&c.since, "since", 0, i18n.G("Replay the events since this event ID"))
 }
 
 func (c *monitorCmd) run(conf *config.Config, args []string) error {
@@ -81,7 +96,17 @@ func (c *monitorCmd) run(conf *config.Config, args []string) error {
 		return err
 	}
 
-	listener, err := d.GetEvents()
+	var listener *lxd.EventListener
+	if len(c.resourceArgs) > 0 || c.level != "" || c.since > 0 {
+		listener, err = d.GetEventsWithArgs(lxd.EventsArgs{
+			Types:     c.typeArgs,
+			Resources: c.resourceArgs,
+			Level:     c.level,
+			Since:     c.since,
+		})
+	} else {
+		listener, err = d.GetEvents()
+	}
 	if err != nil {
 		return err
 	}
@@ -58,6 +58,12 @@ lxc network list [<remote>:]
 lxc network show [<remote>:]<network>
     Show details of a network.
 
+lxc network info [<remote>:]<network>
+    Show the runtime state of a network.
+
+lxc network list-leases [<remote>:]<network>
+    List the DHCP leases and static reservations of a network.
+
 lxc network create [<remote>:]<network> [key=value...]
     Create a network.
 
@@ -135,6 +141,10 @@ func (c *networkCmd) run(conf *config.Config, args []string) error {
 		return c.doNetworkEdit(client, network)
 	case "get":
 		return c.doNetworkGet(client, network, args[2:])
+	case "info":
+		return c.doNetworkInfo(client, network)
+	case "list-leases":
+		return c.doNetworkListLeases(client, network)
 	case "set":
 		return c.doNetworkSet(client, network, args[2:])
 	case "unset":
@@ -513,6 +523,96 @@ func (c *networkCmd) doNetworkList(conf *config.Config, args []string) error {
 	return nil
 }
 
+func (c *networkCmd) doNetworkInfo(client lxd.ContainerServer, name string) error {
+	if name == "" {
+		return errArgs
+	}
+
+	state, err := client.GetNetworkState(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(i18n.G("Name: %s")+"\n", name)
+	fmt.Printf(i18n.G("MAC address: %s")+"\n", state.Hwaddr)
+	fmt.Printf(i18n.G("MTU: %d")+"\n", state.Mtu)
+	fmt.Printf(i18n.G("State: %s")+"\n", state.State)
+	fmt.Printf(i18n.G("Type: %s")+"\n", state.Type)
+
+	if len(state.Addresses) > 0 {
+		fmt.Println("")
+		fmt.Println(i18n.G("IP addresses:"))
+		for _, addr := range state.Addresses {
+			fmt.Printf("  %s\t%s/%s (%s)\n", addr.Family, addr.Address, addr.Netmask, addr.Scope)
+		}
+	}
+
+	fmt.Println("")
+	fmt.Println(i18n.G("Network usage:"))
+	fmt.Printf("  %s: %s\n", i18n.G("Bytes received"), shared.GetByteSizeString(state.Counters.BytesReceived, 2))
+	fmt.Printf("  %s: %s\n", i18n.G("Bytes sent"), shared.GetByteSizeString(state.Counters.BytesSent, 2))
+	fmt.Printf("  %s: %d\n", i18n.G("Packets received"), state.Counters.PacketsReceived)
+	fmt.Printf("  %s: %d\n", i18n.G("Packets sent"), state.Counters.PacketsSent)
+
+	if state.Bridge != nil {
+		fmt.Println("")
+		fmt.Println(i18n.G("Bridge:"))
+		fmt.Printf("  %s: %s\n", i18n.G("ID"), state.Bridge.ID)
+		fmt.Printf("  %s: %v\n", i18n.G("STP"), state.Bridge.STP)
+		fmt.Printf("  %s: %d\n", i18n.G("Forward delay"), state.Bridge.ForwardDelay)
+		fmt.Printf("  %s: %d\n", i18n.G("Default VLAN ID"), state.Bridge.VLANDefault)
+		fmt.Printf("  %s: %v\n", i18n.G("VLAN filtering"), state.Bridge.VLANFiltering)
+		fmt.Printf("  %s:\n", i18n.G("Ports"))
+		for _, port := range state.Bridge.Ports {
+			vlans := []string{}
+			for _, vlan := range port.VLANs {
+				vlans = append(vlans, fmt.Sprintf("%d", vlan.ID))
+			}
+
+			if len(vlans) == 0 {
+				fmt.Printf("    %s\n", port.Name)
+				continue
+			}
+
+			fmt.Printf("    %s (%s: %s)\n", port.Name, i18n.G("VLANs"), strings.Join(vlans, ", "))
+		}
+	}
+
+	return nil
+}
+
+func (c *networkCmd) doNetworkListLeases(client lxd.ContainerServer, name string) error {
+	if name == "" {
+		return errArgs
+	}
+
+	leases, err := client.GetNetworkLeases(name)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, lease := range leases {
+		data = append(data, []string{lease.Hostname, lease.Hwaddr, lease.Address, strings.ToUpper(lease.Type), lease.Container})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("HOSTNAME"),
+		i18n.G("MAC ADDRESS"),
+		i18n.G("IP ADDRESS"),
+		i18n.G("TYPE"),
+		i18n.G("CONTAINER")})
+	sort.Sort(byName(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
 func (c *networkCmd) doNetworkSet(client lxd.ContainerServer, name string, args []string) error {
 	// we shifted @args so so it should read "<key> [<value>]"
 	if len(args) < 1 {
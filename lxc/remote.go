@@ -44,6 +44,9 @@ Manage the list of remote LXD servers.
 lxc remote add [<remote>] <IP|FQDN|URL> [--accept-certificate] [--password=PASSWORD] [--public] [--protocol=PROTOCOL]
     Add the remote <remote> at <url>.
 
+lxc remote add <remote> <token>
+    Add the remote <remote> using a token from "lxc config trust add --token".
+
 lxc remote remove <remote>
     Remove the remote <remote>.
 
@@ -237,21 +240,11 @@ func (c *remoteCmd) addServer(conf *config.Config, server string, addr string, a
 			}
 		}
 
-		dnam := conf.ConfigPath("servercerts")
-		err := os.MkdirAll(dnam, 0750)
-		if err != nil {
-			return fmt.Errorf(i18n.G("Could not create server cert dir"))
-		}
-
-		certf := fmt.Sprintf("%s/%s.crt", dnam, server)
-		certOut, err := os.Create(certf)
+		err := c.saveServerCertificate(conf, server, certificate)
 		if err != nil {
 			return err
 		}
 
-		pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
-		certOut.Close()
-
 		// Setup a new connection, this time with the remote certificate
 		d, err = conf.GetContainerServer(server)
 		if err != nil {
@@ -317,6 +310,93 @@ func (c *remoteCmd) addServer(conf *config.Config, server string, addr string, a
 	return nil
 }
 
+func (c *remoteCmd) saveServerCertificate(conf *config.Config, server string, certificate *x509.Certificate) error {
+	dnam := conf.ConfigPath("servercerts")
+	err := os.MkdirAll(dnam, 0750)
+	if err != nil {
+		return fmt.Errorf(i18n.G("Could not create server cert dir"))
+	}
+
+	certf := fmt.Sprintf("%s/%s.crt", dnam, server)
+	certOut, err := os.Create(certf)
+	if err != nil {
+		return err
+	}
+
+	pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	certOut.Close()
+
+	return nil
+}
+
+func (c *remoteCmd) addServerToken(conf *config.Config, server string, token *api.CertificateTokenData) error {
+	// Setup the remotes list
+	if conf.Remotes == nil {
+		conf.Remotes = make(map[string]config.Remote)
+	}
+
+	err := c.generateClientCertificate(conf)
+	if err != nil {
+		return err
+	}
+
+	// Find an address the server can be reached at, trusting the
+	// certificate recorded in the token rather than prompting for it
+	var certificate *x509.Certificate
+	var addr string
+	for _, address := range token.Addresses {
+		addr = fmt.Sprintf("https://%s", address)
+		certificate, err = c.getRemoteCertificate(addr)
+		if err == nil {
+			break
+		}
+	}
+
+	if certificate == nil {
+		return fmt.Errorf(i18n.G("Unable to connect to any of the server's addresses: %s"), err)
+	}
+
+	if shared.CertFingerprint(certificate) != token.Fingerprint {
+		return fmt.Errorf(i18n.G("Certificate fingerprint mismatch between token and server %s"), addr)
+	}
+
+	conf.Remotes[server] = config.Remote{Addr: addr}
+
+	err = c.saveServerCertificate(conf, server, certificate)
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetContainerServer(server)
+	if err != nil {
+		return err
+	}
+
+	// Add client certificate to trust store
+	req := api.CertificatesPost{
+		Token: token.Secret,
+	}
+	req.Type = "client"
+
+	err = d.CreateCertificate(req)
+	if err != nil {
+		return err
+	}
+
+	// And check if trusted now
+	srv, _, err := d.GetServer()
+	if err != nil {
+		return err
+	}
+
+	if srv.Auth != "trusted" {
+		return fmt.Errorf(i18n.G("Server doesn't trust us after adding our cert"))
+	}
+
+	fmt.Println(i18n.G("Client certificate stored at server: "), server)
+	return nil
+}
+
 func (c *remoteCmd) removeCertificate(conf *config.Config, remote string) {
 	certf := conf.ServerCertPath(remote)
 	logger.Debugf("Trying to remove %s", certf)
@@ -345,7 +425,17 @@ func (c *remoteCmd) run(conf *config.Config, args []string) error {
 			return fmt.Errorf(i18n.G("remote %s exists as <%s>"), remote, rc.Addr)
 		}
 
-		err := c.addServer(conf, remote, fqdn, c.acceptCert, c.password, c.public, c.protocol)
+		var err error
+		token, tokenErr := api.CertificateTokenDecode(fqdn)
+		if tokenErr == nil {
+			if len(args) < 3 {
+				return fmt.Errorf(i18n.G("A remote name must be provided along with the token"))
+			}
+
+			err = c.addServerToken(conf, remote, token)
+		} else {
+			err = c.addServer(conf, remote, fqdn, c.acceptCert, c.password, c.public, c.protocol)
+		}
 		if err != nil {
 			delete(conf.Remotes, remote)
 			c.removeCertificate(conf, remote)
@@ -114,6 +114,12 @@ lxc storage volume delete [<remote>:]<pool> <volume>
 lxc storage volume edit [<remote>:]<pool> <volume>
     Edit storage pool, either by launching external editor or reading STDIN.
 
+lxc storage volume copy [<remote>:]<pool>/<volume> [<remote>:]<pool>[/<volume>]
+    Copy an existing volume to a new volume, possibly on a different pool or LXD host.
+
+lxc storage volume move [<remote>:]<pool>/<volume> [<remote>:]<pool>[/<volume>]
+    Move an existing volume to a different pool or LXD host, or rename it.
+
 lxc storage volume attach [<remote>:]<pool> <volume> <container> [device name] <path>
     Attach a storage volume to the specified container.
 
@@ -126,6 +132,21 @@ lxc storage volume detach [<remote>:]<pool> <volume> <container> [device name]
 lxc storage volume detach-profile [<remote:>]<pool> <volume> <profile> [device name]
     Detach a storage volume from the specified profile.
 
+lxc storage volume snapshot [<remote>:]<pool> <volume> [<snapshot>]
+    Create a snapshot of a storage volume.
+
+lxc storage volume snapshot-list [<remote>:]<pool> <volume>
+    List the snapshots of a storage volume.
+
+lxc storage volume snapshot-rename [<remote>:]<pool> <volume> <snapshot> <new name>
+    Rename a snapshot of a storage volume.
+
+lxc storage volume snapshot-delete [<remote>:]<pool> <volume> <snapshot>
+    Delete a snapshot of a storage volume.
+
+lxc storage volume restore [<remote>:]<pool> <volume> <snapshot>
+    Restore a storage volume from one of its snapshots.
+
 Unless specified through a prefix, all volume operations affect "custom" (user created) volumes.
 
 *Examples*
@@ -183,6 +204,11 @@ func (c *storageCmd) run(conf *config.Config, args []string) error {
 			pool := args[2]
 			volume := args[3]
 			return c.doStoragePoolVolumeAttachProfile(client, pool, volume, args[4:])
+		case "copy":
+			if len(args) != 4 {
+				return errArgs
+			}
+			return c.doStoragePoolVolumeCopy(conf, args[2], args[3], false)
 		case "create":
 			if len(args) < 4 {
 				return errArgs
@@ -190,6 +216,11 @@ func (c *storageCmd) run(conf *config.Config, args []string) error {
 			pool := args[2]
 			volume := args[3]
 			return c.doStoragePoolVolumeCreate(client, pool, volume, args[4:])
+		case "move":
+			if len(args) != 4 {
+				return errArgs
+			}
+			return c.doStoragePoolVolumeCopy(conf, args[2], args[3], true)
 		case "delete":
 			if len(args) != 4 {
 				return errArgs
@@ -252,6 +283,41 @@ func (c *storageCmd) run(conf *config.Config, args []string) error {
 			pool := args[2]
 			volume := args[3]
 			return c.doStoragePoolVolumeShow(client, pool, volume)
+		case "snapshot":
+			if len(args) < 4 || len(args) > 5 {
+				return errArgs
+			}
+			pool := args[2]
+			volume := args[3]
+			return c.doStoragePoolVolumeSnapshotCreate(client, pool, volume, args[4:])
+		case "snapshot-list":
+			if len(args) != 4 {
+				return errArgs
+			}
+			pool := args[2]
+			volume := args[3]
+			return c.doStoragePoolVolumeSnapshotsList(client, pool, volume)
+		case "snapshot-rename":
+			if len(args) != 6 {
+				return errArgs
+			}
+			pool := args[2]
+			volume := args[3]
+			return c.doStoragePoolVolumeSnapshotRename(client, pool, volume, args[4], args[5])
+		case "snapshot-delete":
+			if len(args) != 5 {
+				return errArgs
+			}
+			pool := args[2]
+			volume := args[3]
+			return c.doStoragePoolVolumeSnapshotDelete(client, pool, volume, args[4])
+		case "restore":
+			if len(args) != 5 {
+				return errArgs
+			}
+			pool := args[2]
+			volume := args[3]
+			return c.doStoragePoolVolumeRestore(client, pool, volume, args[4])
 		default:
 			return errArgs
 		}
@@ -784,6 +850,93 @@ func (c *storageCmd) doStoragePoolVolumeCreate(client lxd.ContainerServer, pool
 	return nil
 }
 
+func (c *storageCmd) doStoragePoolVolumeCopy(conf *config.Config, source string, target string, move bool) error {
+	// Parse the input
+	srcRemote, srcName, err := conf.ParseRemote(source)
+	if err != nil {
+		return err
+	}
+
+	dstRemote, dstName, err := conf.ParseRemote(target)
+	if err != nil {
+		return err
+	}
+
+	srcFields := strings.SplitN(srcName, "/", 2)
+	if len(srcFields) != 2 || srcFields[0] == "" || srcFields[1] == "" {
+		return fmt.Errorf(i18n.G("The source volume must be specified as <pool>/<volume>"))
+	}
+	srcPool := srcFields[0]
+	srcVolume := srcFields[1]
+
+	dstFields := strings.SplitN(dstName, "/", 2)
+	dstPool := dstFields[0]
+	dstVolume := srcVolume
+	if len(dstFields) == 2 && dstFields[1] != "" {
+		dstVolume = dstFields[1]
+	}
+
+	// Connect to the servers
+	srcServer, err := conf.GetContainerServer(srcRemote)
+	if err != nil {
+		return err
+	}
+
+	dstServer := srcServer
+	if srcRemote != dstRemote {
+		dstServer, err = conf.GetContainerServer(dstRemote)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Get the source volume
+	vol, _, err := srcServer.GetStoragePoolVolume(srcPool, "custom", srcVolume)
+	if err != nil {
+		return err
+	}
+
+	// Moves within a single LXD are handled server side
+	if move && srcRemote == dstRemote {
+		op, err := dstServer.MoveStoragePoolVolume(dstPool, srcServer, srcPool, *vol, &lxd.StoragePoolVolumeMoveArgs{Name: dstVolume})
+		if err != nil {
+			return err
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf(i18n.G("Storage volume moved successfully!") + "\n")
+		return nil
+	}
+
+	op, err := dstServer.CopyStoragePoolVolume(dstPool, srcServer, srcPool, *vol, &lxd.StoragePoolVolumeCopyArgs{Name: dstVolume})
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	if !move {
+		fmt.Printf(i18n.G("Storage volume copied successfully!") + "\n")
+		return nil
+	}
+
+	// Remove the source volume once the copy to the other LXD completed
+	err = srcServer.DeleteStoragePoolVolume(srcPool, "custom", srcVolume)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(i18n.G("Storage volume moved successfully!") + "\n")
+	return nil
+}
+
 func (c *storageCmd) doStoragePoolVolumeDelete(client lxd.ContainerServer, pool string, volume string) error {
 	// Parse the input
 	volName, volType := c.parseVolume(volume)
@@ -885,6 +1038,97 @@ func (c *storageCmd) doStoragePoolVolumeShow(client lxd.ContainerServer, pool st
 	return nil
 }
 
+func (c *storageCmd) doStoragePoolVolumeSnapshotCreate(client lxd.ContainerServer, pool string, volume string, args []string) error {
+	// Parse the input
+	volName, volType := c.parseVolume(volume)
+
+	req := api.StorageVolumeSnapshotsPost{}
+	if len(args) > 0 {
+		req.Name = args[0]
+	}
+
+	op, err := client.CreateStoragePoolVolumeSnapshot(pool, volType, volName, req)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+func (c *storageCmd) doStoragePoolVolumeSnapshotsList(client lxd.ContainerServer, pool string, volume string) error {
+	// Parse the input
+	volName, volType := c.parseVolume(volume)
+
+	snapshots, err := client.GetStoragePoolVolumeSnapshots(pool, volType, volName)
+	if err != nil {
+		return err
+	}
+
+	const layout = "2006/01/02 15:04 UTC"
+
+	data := [][]string{}
+	for _, snapshot := range snapshots {
+		created := ""
+		if shared.TimeIsSet(snapshot.CreationDate) {
+			created = snapshot.CreationDate.UTC().Format(layout)
+		}
+
+		data = append(data, []string{snapshot.Name, created})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("NAME"),
+		i18n.G("TAKEN AT")})
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
+func (c *storageCmd) doStoragePoolVolumeSnapshotRename(client lxd.ContainerServer, pool string, volume string, snapshot string, newName string) error {
+	// Parse the input
+	volName, volType := c.parseVolume(volume)
+
+	op, err := client.RenameStoragePoolVolumeSnapshot(pool, volType, volName, snapshot, api.StorageVolumeSnapshotPost{Name: newName})
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+func (c *storageCmd) doStoragePoolVolumeSnapshotDelete(client lxd.ContainerServer, pool string, volume string, snapshot string) error {
+	// Parse the input
+	volName, volType := c.parseVolume(volume)
+
+	op, err := client.DeleteStoragePoolVolumeSnapshot(pool, volType, volName, snapshot)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+func (c *storageCmd) doStoragePoolVolumeRestore(client lxd.ContainerServer, pool string, volume string, snapshot string) error {
+	// Parse the input
+	volName, volType := c.parseVolume(volume)
+
+	// Get the storage volume entry
+	vol, etag, err := client.GetStoragePoolVolume(pool, volType, volName)
+	if err != nil {
+		return err
+	}
+
+	req := vol.Writable()
+	req.Restore = snapshot
+
+	return client.UpdateStoragePoolVolume(pool, vol.Type, vol.Name, req, etag)
+}
+
 func (c *storageCmd) doStoragePoolVolumeEdit(client lxd.ContainerServer, pool string, volume string) error {
 	// Parse the input
 	volName, volType := c.parseVolume(volume)
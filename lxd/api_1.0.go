@@ -25,7 +25,12 @@ var api10 = []Command{
 	containerLogCmd,
 	containerSnapshotsCmd,
 	containerSnapshotCmd,
+	containerBackupsCmd,
+	containerBackupCmd,
+	containerBackupExportCmd,
 	containerExecCmd,
+	containerExecSessionCmd,
+	containerConsoleCmd,
 	aliasCmd,
 	aliasesCmd,
 	eventsCmd,
@@ -40,8 +45,13 @@ var api10 = []Command{
 	operationWebsocket,
 	networksCmd,
 	networkCmd,
+	networkLeasesCmd,
+	networkStateCmd,
 	api10Cmd,
+	auditCmd,
 	certificatesCmd,
+	certificateTokensCmd,
+	certificateTokenCmd,
 	certificateFingerprintCmd,
 	profilesCmd,
 	profileCmd,
@@ -49,7 +59,14 @@ var api10 = []Command{
 	storagePoolCmd,
 	storagePoolVolumesCmd,
 	storagePoolVolumesTypeCmd,
+	storagePoolVolumeSnapshotsTypeCmd,
+	storagePoolVolumeSnapshotTypeCmd,
 	storagePoolVolumeTypeCmd,
+	metricsCmd,
+	serverResourceCmd,
+	storagePoolResourcesCmd,
+	projectsCmd,
+	projectCmd,
 }
 
 func api10Get(d *Daemon, r *http.Request) Response {
@@ -114,6 +131,31 @@ func api10Get(d *Daemon, r *http.Request) Response {
 			"container_push_target",
 			"network_vlan_physical",
 			"storage_images_delete",
+			"snapshot_scheduling",
+			"container_backup",
+			"storage_api_volume_snapshots",
+			"storage_api_local_volume_handling",
+			"storage_api_remote_volume_handling",
+			"storage_driver_ceph",
+			"metrics",
+			"proxy",
+			"network_leases",
+			"network_state",
+			"resources",
+			"event_lifecycle",
+			"event_filter_replay",
+			"webhook",
+			"container_exec_user_group_cwd",
+			"container_exec_persistent",
+			"console",
+			"container_logs_follow_rotation",
+			"container_incremental_copy",
+			"migration_pre_copy",
+			"projects",
+			"certificate_permissions",
+			"certificate_token",
+			"audit",
+			"unix_unprivileged_users",
 		},
 		APIStatus:  "stable",
 		APIVersion: version.APIVersion,
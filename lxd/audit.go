@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// Request bodies larger than this aren't recorded
+const auditBodyMaxSize = 64 * 1024
+
+// Keys whose values are never recorded in the audit log, wherever they
+// appear in a request body
+var auditRedactedKeys = []string{
+	"core.trust_password",
+	"core.webhook_secret",
+	"password",
+	"secret",
+	"secrets",
+	"token",
+}
+
+func auditRedact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, sub := range v {
+			if shared.StringInSlice(key, auditRedactedKeys) {
+				v[key] = "[redacted]"
+				continue
+			}
+
+			v[key] = auditRedact(sub)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = auditRedact(v[i])
+		}
+	}
+
+	return value
+}
+
+// Returns the body of a request as recorded in the audit log, leaving the
+// request readable by its handler. Only JSON bodies are recorded, with their
+// secrets redacted.
+func auditRequestBody(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(r.Body, auditBodyMaxSize+1))
+	if err != nil {
+		return ""
+	}
+
+	if len(buf) > auditBodyMaxSize {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+		return ""
+	}
+	r.Body = shared.BytesReadCloser{Buf: bytes.NewBuffer(buf)}
+
+	var body interface{}
+	err = json.Unmarshal(buf, &body)
+	if err != nil {
+		return ""
+	}
+
+	redacted, err := json.Marshal(auditRedact(body))
+	if err != nil {
+		return ""
+	}
+
+	return string(redacted)
+}
+
+// Returns who made a request: the fingerprint of the client certificate, or
+// "unix socket" along with the uid of the peer.
+func (d *Daemon) auditRequestor(w http.ResponseWriter, r *http.Request) (string, int64) {
+	if r.TLS == nil {
+		cred := extractUnderlyingCred(w)
+		if cred == nil {
+			return "unix socket", -1
+		}
+
+		return "unix socket", cred.uid
+	}
+
+	for i := range r.TLS.PeerCertificates {
+		if d.CheckTrustState(*r.TLS.PeerCertificates[i]) {
+			return shared.CertFingerprint(r.TLS.PeerCertificates[i]), -1
+		}
+	}
+
+	if len(r.TLS.PeerCertificates) > 0 {
+		return shared.CertFingerprint(r.TLS.PeerCertificates[0]), -1
+	}
+
+	return "untrusted", -1
+}
+
+// auditRequestStart prepares the audit log entry of a request. It must be
+// called before the request body is read.
+func (d *Daemon) auditRequestStart(w http.ResponseWriter, r *http.Request) *api.AuditEntry {
+	requestor, uid := d.auditRequestor(w, r)
+
+	return &api.AuditEntry{
+		Date:      time.Now().UTC(),
+		Requestor: requestor,
+		UID:       uid,
+		Address:   r.RemoteAddr,
+		Method:    r.Method,
+		URL:       r.URL.RequestURI(),
+		Body:      auditRequestBody(r),
+	}
+}
+
+// auditRequestDone records a request in the audit log once its response was
+// rendered. Requests starting an operation get their status updated when
+// the operation is done.
+func (d *Daemon) auditRequestDone(entry *api.AuditEntry, resp Response, renderErr error) {
+	if entry == nil {
+		return
+	}
+
+	var op *operation
+	switch r := resp.(type) {
+	case *errorResponse:
+		entry.Status = fmt.Sprintf("Failure: %s", r.msg)
+	case *operationResponse:
+		entry.Operation = r.op.id
+		entry.Status = api.Running.String()
+		op = r.op
+	default:
+		entry.Status = api.Success.String()
+	}
+
+	if renderErr != nil {
+		entry.Status = fmt.Sprintf("Failure: %s", renderErr)
+		op = nil
+	}
+
+	id, err := dbAuditAdd(d.db, entry)
+	if err != nil {
+		logger.Error("Failed to record request in the audit log", log.Ctx{"url": entry.URL, "err": err})
+		return
+	}
+
+	maxEntries := daemonConfig["audit.max_entries"].GetInt64()
+	if maxEntries > 0 {
+		err = dbAuditPrune(d.db, maxEntries)
+		if err != nil {
+			logger.Error("Failed to prune the audit log", log.Ctx{"err": err})
+		}
+	}
+
+	if op == nil {
+		return
+	}
+
+	go func() {
+		op.WaitFinal(-1)
+
+		op.lock.Lock()
+		status := op.status.String()
+		if op.err != "" {
+			status = fmt.Sprintf("%s: %s", status, op.err)
+		}
+		op.lock.Unlock()
+
+		err := dbAuditUpdateStatus(d.db, id, status)
+		if err != nil {
+			logger.Error("Failed to record operation status in the audit log", log.Ctx{"operation": op.id, "err": err})
+		}
+	}()
+}
+
+func auditGet(d *Daemon, r *http.Request) Response {
+	filter := dbAuditFilter{
+		Requestor: r.FormValue("requestor"),
+		Method:    r.FormValue("method"),
+		URL:       r.FormValue("url"),
+	}
+
+	since := r.FormValue("since")
+	if since != "" {
+		var err error
+		filter.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return BadRequest(fmt.Errorf("Invalid since date: %s", err))
+		}
+	}
+
+	limit := r.FormValue("limit")
+	if limit != "" {
+		var err error
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 0 {
+			return BadRequest(fmt.Errorf("Invalid limit '%s'", limit))
+		}
+	}
+
+	entries, err := dbAuditGet(d.db, filter)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponse(true, entries)
+}
+
+var auditCmd = Command{name: "audit", get: auditGet}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func Test_auditRequestBody(t *testing.T) {
+	tests := []struct {
+		body     string
+		recorded string
+	}{
+		{`{"config": {"core.trust_password": "sekret", "core.https_address": ":8443"}}`, `{"config":{"core.https_address":":8443","core.trust_password":"[redacted]"}}`},
+		{`{"type": "client", "password": "sekret"}`, `{"password":"[redacted]","type":"client"}`},
+		{`[{"token": "abc"}, {"name": "c1"}]`, `[{"token":"[redacted]"},{"name":"c1"}]`},
+		{`not json`, ``},
+		{strings.Repeat(" ", auditBodyMaxSize) + `{}`, ``},
+	}
+
+	for i, test := range tests {
+		req, err := http.NewRequest("PUT", "/1.0", bytes.NewBufferString(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorded := auditRequestBody(req)
+		if recorded != test.recorded {
+			t.Errorf("Body %d: recorded %q instead of %q", i, recorded, test.recorded)
+		}
+
+		// The handler still gets the original body
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(body) != test.body {
+			t.Errorf("Body %d: handler got %q", i, body)
+		}
+	}
+}
@@ -0,0 +1,639 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxd/types"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/osarch"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// backupArgs contains the database fields of a container backup.
+type backupArgs struct {
+	ID               int
+	ContainerID      int
+	Name             string
+	CreationDate     time.Time
+	ExpiryDate       time.Time
+	ContainerOnly    bool
+	OptimizedStorage bool
+}
+
+// backup represents a container backup.
+type backup struct {
+	d         *Daemon
+	container container
+
+	// Properties
+	id               int
+	name             string
+	creationDate     time.Time
+	expiryDate       time.Time
+	containerOnly    bool
+	optimizedStorage bool
+}
+
+// backupIndex is the content of the index.yaml file found at the top of a
+// backup tarball.
+type backupIndex struct {
+	Name      string                   `yaml:"name"`
+	Backend   string                   `yaml:"backend"`
+	Pool      string                   `yaml:"pool"`
+	Optimized bool                     `yaml:"optimized"`
+	Container *api.Container           `yaml:"container"`
+	Snapshots []*api.ContainerSnapshot `yaml:"snapshots,omitempty"`
+	Profiles  []*api.Profile           `yaml:"profiles,omitempty"`
+}
+
+func backupValidName(name string) error {
+	if name == "" {
+		return fmt.Errorf("No backup name provided")
+	}
+
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("Backup names may not contain slashes")
+	}
+
+	if shared.StringInSlice(name, []string{".", ".."}) {
+		return fmt.Errorf("Invalid backup name '%s'", name)
+	}
+
+	return nil
+}
+
+// Load a backup from the database
+func backupLoadByName(d *Daemon, containerName string, name string) (*backup, error) {
+	args, err := dbContainerBackupGet(d.db, containerName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := containerLoadByName(d, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backup{
+		d:                d,
+		container:        c,
+		id:               args.ID,
+		name:             args.Name,
+		creationDate:     args.CreationDate,
+		expiryDate:       args.ExpiryDate,
+		containerOnly:    args.ContainerOnly,
+		optimizedStorage: args.OptimizedStorage,
+	}, nil
+}
+
+// Create a new backup of the given container
+func backupCreate(d *Daemon, args backupArgs, sourceContainer container) error {
+	// Create the database entry
+	args.ContainerID = sourceContainer.Id()
+	args.CreationDate = time.Now().UTC()
+	err := dbContainerBackupCreate(d.db, args)
+	if err != nil {
+		if err == DbErrAlreadyDefined {
+			return fmt.Errorf("The backup already exists")
+		}
+
+		return err
+	}
+
+	b, err := backupLoadByName(d, sourceContainer.Name(), args.Name)
+	if err != nil {
+		return err
+	}
+
+	err = b.generate()
+	if err != nil {
+		dbContainerBackupRemove(d.db, b.id)
+		return err
+	}
+
+	return nil
+}
+
+// Name returns the name of the backup.
+func (b *backup) Name() string {
+	return b.name
+}
+
+// Path returns the location of the backup tarball.
+func (b *backup) Path() string {
+	return shared.VarPath("backups", b.container.Name(), b.name)
+}
+
+// ContainerOnly returns whether the backup excludes the container's snapshots.
+func (b *backup) ContainerOnly() bool {
+	return b.containerOnly
+}
+
+// OptimizedStorage returns whether the backup uses the storage driver's own
+// format.
+func (b *backup) OptimizedStorage() bool {
+	return b.optimizedStorage
+}
+
+// generate dumps the container into a tarball.
+func (b *backup) generate() error {
+	tmpPath, err := ioutil.TempDir(shared.VarPath("backups"), "lxd_backup_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpPath)
+
+	backupPath := filepath.Join(tmpPath, "backup")
+	err = os.MkdirAll(filepath.Join(backupPath, "snapshots"), 0700)
+	if err != nil {
+		return err
+	}
+
+	// Let the storage driver dump the container and its snapshots
+	err = b.container.Storage().ContainerBackupCreate(backupPath, *b, b.container)
+	if err != nil {
+		return err
+	}
+
+	err = b.writeIndex(filepath.Join(backupPath, "index.yaml"))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(shared.VarPath("backups", b.container.Name()), 0700)
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand("tar", "--numeric-owner", "--xattrs", "-C", tmpPath, "-zcf", b.Path(), "backup")
+	if err != nil {
+		os.Remove(b.Path())
+		return err
+	}
+
+	return nil
+}
+
+func (b *backup) writeIndex(path string) error {
+	ci, _, err := b.container.Render()
+	if err != nil {
+		return err
+	}
+
+	poolName, err := b.container.StoragePool()
+	if err != nil {
+		return err
+	}
+
+	index := backupIndex{
+		Name:      projectUnprefix(b.container.Project(), b.container.Name()),
+		Backend:   b.container.Storage().GetStorageTypeName(),
+		Pool:      poolName,
+		Optimized: b.optimizedStorage,
+		Container: ci.(*api.Container),
+	}
+
+	if !b.containerOnly {
+		snapshots, err := b.container.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snapshots {
+			si, _, err := snap.Render()
+			if err != nil {
+				return err
+			}
+
+			index.Snapshots = append(index.Snapshots, si.(*api.ContainerSnapshot))
+		}
+	}
+
+	profilesProject, err := projectProfilesProject(b.d.db, b.container.Project())
+	if err != nil {
+		return err
+	}
+
+	for _, name := range b.container.Profiles() {
+		_, profile, err := dbProfileGet(b.d.db, profilesProject, name)
+		if err != nil {
+			return err
+		}
+
+		index.Profiles = append(index.Profiles, profile)
+	}
+
+	data, err := yaml.Marshal(&index)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Rename renames a container backup.
+func (b *backup) Rename(newName string) error {
+	oldPath := b.Path()
+	newPath := shared.VarPath("backups", b.container.Name(), newName)
+
+	err := os.Rename(oldPath, newPath)
+	if err != nil {
+		return err
+	}
+
+	err = dbContainerBackupRename(b.d.db, b.id, newName)
+	if err != nil {
+		os.Rename(newPath, oldPath)
+		return err
+	}
+
+	b.name = newName
+	return nil
+}
+
+// Delete removes a container backup.
+func (b *backup) Delete() error {
+	err := os.Remove(b.Path())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return dbContainerBackupRemove(b.d.db, b.id)
+}
+
+// Render returns the API representation of the backup.
+func (b *backup) Render() *api.ContainerBackup {
+	return &api.ContainerBackup{
+		Name:             b.name,
+		CreationDate:     b.creationDate,
+		ExpiryDate:       b.expiryDate,
+		ContainerOnly:    b.containerOnly,
+		OptimizedStorage: b.optimizedStorage,
+	}
+}
+
+// backupGetIndex reads the index.yaml file out of a backup tarball.
+func backupGetIndex(path string) (*backupIndex, error) {
+	compressionArgs, _, err := detectCompression(path)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-O"}
+	args = append(args, compressionArgs...)
+	args = append(args, path, "backup/index.yaml")
+
+	output, err := shared.RunCommand("tar", args...)
+	if err != nil {
+		outputLines := strings.Split(output, "\n")
+		return nil, fmt.Errorf("Could not extract index.yaml from backup: %v (%s)", err, outputLines[0])
+	}
+
+	index := backupIndex{}
+	err = yaml.Unmarshal([]byte(output), &index)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse index.yaml: %v", err)
+	}
+
+	if index.Name == "" || index.Container == nil {
+		return nil, fmt.Errorf("Invalid backup index")
+	}
+
+	return &index, nil
+}
+
+// backupUnpack extracts a backup tarball into the given directory.
+func backupUnpack(file string, path string) error {
+	extractArgs, _, err := detectCompression(file)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-C", path, "--numeric-owner", "--xattrs-include=*"}
+	args = append(args, extractArgs...)
+	args = append(args, file)
+
+	_, err = shared.RunCommand("tar", args...)
+	return err
+}
+
+// backupSetRootDevicePool points the local root disk device of a container
+// or snapshot to the given pool, adding such a device if needed.
+func backupSetRootDevicePool(devices types.Devices, pool string) types.Devices {
+	if devices == nil {
+		devices = types.Devices{}
+	}
+
+	rootDevName, _, _ := containerGetRootDiskDevice(devices)
+	if rootDevName != "" {
+		devices[rootDevName]["pool"] = pool
+		return devices
+	}
+
+	// Make sure that we do not overwrite a device the user is currently
+	// using under the name "root".
+	rootDevName = "root"
+	for i := 0; devices[rootDevName] != nil; i++ {
+		rootDevName = fmt.Sprintf("root%d", i)
+	}
+
+	devices[rootDevName] = map[string]string{
+		"type": "disk",
+		"path": "/",
+		"pool": pool,
+	}
+
+	return devices
+}
+
+// containerCreateFromBackup restores the container described by index from
+// the backup tarball found at path onto the given storage pool, as part of
+// the given project.
+func containerCreateFromBackup(d *Daemon, project string, index *backupIndex, path string, pool string) (container, error) {
+	tmpPath, err := ioutil.TempDir(shared.VarPath("backups"), "lxd_restore_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpPath)
+
+	err = backupUnpack(path, tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	profilesProject, err := projectProfilesProject(d.db, project)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the profiles which don't exist on this host yet
+	for _, profile := range index.Profiles {
+		_, p, _ := dbProfileGet(d.db, profilesProject, profile.Name)
+		if p != nil {
+			continue
+		}
+
+		devices := types.Devices(profile.Devices)
+		if devices == nil {
+			devices = types.Devices{}
+		}
+
+		rootDevName, _, _ := containerGetRootDiskDevice(devices)
+		if rootDevName != "" {
+			devices[rootDevName]["pool"] = pool
+		}
+
+		_, err = dbProfileCreate(d.db, profilesProject, profile.Name, profile.Description, profile.Config, devices)
+		if err != nil {
+			return nil, fmt.Errorf("Error inserting profile %s into database: %s", profile.Name, err)
+		}
+	}
+
+	arch, err := osarch.ArchitectureId(index.Container.Architecture)
+	if err != nil {
+		return nil, err
+	}
+
+	args := containerArgs{
+		Architecture: arch,
+		BaseImage:    index.Container.Config["volatile.base_image"],
+		Config:       index.Container.Config,
+		Ctype:        cTypeRegular,
+		Description:  index.Container.Description,
+		Devices:      backupSetRootDevicePool(index.Container.Devices, pool),
+		Ephemeral:    index.Container.Ephemeral,
+		Name:         projectPrefix(project, index.Name),
+		Profiles:     index.Container.Profiles,
+		Project:      project,
+		Stateful:     index.Container.Stateful,
+	}
+
+	snapshots := []containerArgs{}
+	for _, snap := range index.Snapshots {
+		arch, err := osarch.ArchitectureId(snap.Architecture)
+		if err != nil {
+			return nil, err
+		}
+
+		_, snapOnlyName, _ := containerGetParentAndSnapshotName(snap.Name)
+		snapshots = append(snapshots, containerArgs{
+			Architecture: arch,
+			BaseImage:    snap.Config["volatile.base_image"],
+			Config:       snap.Config,
+			Ctype:        cTypeSnapshot,
+			Devices:      backupSetRootDevicePool(snap.Devices, pool),
+			Ephemeral:    snap.Ephemeral,
+			ExpiryDate:   snap.ExpiryDate,
+			Name:         projectPrefix(project, index.Name) + shared.SnapshotDelimiter + snapOnlyName,
+			Profiles:     snap.Profiles,
+			Project:      project,
+			Stateful:     snap.Stateful,
+		})
+	}
+
+	c, err := containerCreateAsEmpty(d, args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Storage().ContainerBackupLoad(c, snapshots, filepath.Join(tmpPath, "backup"), index.Optimized)
+	if err != nil {
+		c.Delete()
+		return nil, err
+	}
+
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		c.Delete()
+		return nil, err
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	// The restored backup.yaml still describes the original storage pool
+	err = writeBackupFile(c)
+	if err != nil {
+		c.Delete()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// rsyncBackupCreate is the generic way of dumping a container (and its
+// snapshots) into a backup directory.
+func rsyncBackupCreate(path string, backup backup, source container) error {
+	if !backup.ContainerOnly() {
+		snapshots, err := source.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snapshots {
+			ourStart, err := snap.StorageStart()
+			if err != nil {
+				return err
+			}
+			if ourStart {
+				defer snap.StorageStop()
+			}
+
+			_, snapOnlyName, _ := containerGetParentAndSnapshotName(snap.Name())
+			target := filepath.Join(path, "snapshots", snapOnlyName)
+			output, err := rsyncLocalCopy(snap.Path(), target, "")
+			if err != nil {
+				return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+			}
+		}
+	}
+
+	ourStart, err := source.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer source.StorageStop()
+	}
+
+	output, err := rsyncLocalCopy(source.Path(), filepath.Join(path, "container"), "")
+	if err != nil {
+		return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+	}
+
+	return nil
+}
+
+// rsyncBackupLoad is the generic way of restoring a container (and its
+// snapshots) from an unpacked backup directory.
+func rsyncBackupLoad(c container, snapshots []containerArgs, path string) error {
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	isDirBackend := c.Storage().GetStorageType() == storageTypeDir
+	for _, args := range snapshots {
+		_, snapOnlyName, _ := containerGetParentAndSnapshotName(args.Name)
+		snapPath := filepath.Join(path, "snapshots", snapOnlyName)
+
+		if isDirBackend {
+			s, err := containerCreateEmptySnapshot(c.Daemon(), args)
+			if err != nil {
+				return err
+			}
+
+			output, err := rsyncLocalCopy(snapPath, s.Path(), "")
+			if err != nil {
+				return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+			}
+
+			continue
+		}
+
+		// Restore the snapshot's content into the container and
+		// snapshot it from there.
+		output, err := rsyncLocalCopy(snapPath, c.Path(), "")
+		if err != nil {
+			return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+		}
+
+		_, err = containerCreateAsSnapshot(c.Daemon(), args, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	output, err := rsyncLocalCopy(filepath.Join(path, "container"), c.Path(), "")
+	if err != nil {
+		return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+	}
+
+	return nil
+}
+
+// pruneExpiredContainerBackups deletes all container backups which have
+// reached their expiry date.
+func pruneExpiredContainerBackups(d *Daemon) {
+	expired, err := dbContainerGetExpiredBackups(d.db, time.Now())
+	if err != nil {
+		logger.Error("Unable to retrieve the list of expired container backups", log.Ctx{"err": err})
+		return
+	}
+
+	if len(expired) == 0 {
+		return
+	}
+
+	// Operation resources are named within their project, so each project
+	// gets its own operation
+	projects := map[string][]*backup{}
+	for _, entry := range expired {
+		b, err := backupLoadByName(d, entry[0], entry[1])
+		if err != nil {
+			logger.Error("Error loading expired backup", log.Ctx{"container": entry[0], "backup": entry[1], "err": err})
+			continue
+		}
+
+		project := b.container.Project()
+		projects[project] = append(projects[project], b)
+	}
+
+	logger.Infof("Pruning expired container backups")
+
+	for project, backups := range projects {
+		backups := backups
+
+		prune := func(op *operation) error {
+			for _, b := range backups {
+				err := b.Delete()
+				if err != nil {
+					logger.Error("Error deleting expired backup", log.Ctx{"container": b.container.Name(), "backup": b.Name(), "err": err})
+					continue
+				}
+
+				logger.Info("Deleted expired backup", log.Ctx{"container": b.container.Name(), "backup": b.Name()})
+			}
+
+			return nil
+		}
+
+		resources := map[string][]string{}
+		resources["containers"] = []string{}
+		for _, b := range backups {
+			name := projectUnprefix(project, b.container.Name())
+			if !shared.StringInSlice(name, resources["containers"]) {
+				resources["containers"] = append(resources["containers"], name)
+			}
+		}
+
+		op, err := operationCreate(project, nil, operationClassTask, resources, nil, prune, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start backup expiry operation", log.Ctx{"project": project, "err": err})
+			continue
+		}
+
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to prune expired container backups", log.Ctx{"project": project, "err": err})
+			continue
+		}
+
+		op.WaitFinal(-1)
+	}
+
+	logger.Infof("Done pruning expired container backups")
+}
@@ -6,8 +6,11 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -28,15 +31,7 @@ func certificatesGet(d *Daemon, r *http.Request) Response {
 			return SmartError(err)
 		}
 		for _, baseCert := range baseCerts {
-			resp := api.Certificate{}
-			resp.Fingerprint = baseCert.Fingerprint
-			resp.Certificate = baseCert.Certificate
-			if baseCert.Type == 1 {
-				resp.Type = "client"
-			} else {
-				resp.Type = "unknown"
-			}
-			certResponses = append(certResponses, resp)
+			certResponses = append(certResponses, certificateRender(baseCert))
 		}
 		return SyncResponse(true, certResponses)
 	}
@@ -52,6 +47,7 @@ func certificatesGet(d *Daemon, r *http.Request) Response {
 
 func readSavedClientCAList(d *Daemon) {
 	d.clientCerts = []x509.Certificate{}
+	d.clientPermissions = map[string]*dbCertInfo{}
 
 	dbCerts, err := dbCertsGet(d.db)
 	if err != nil {
@@ -72,10 +68,14 @@ func readSavedClientCAList(d *Daemon) {
 			continue
 		}
 		d.clientCerts = append(d.clientCerts, *cert)
+
+		if dbCert.isRestricted() {
+			d.clientPermissions[shared.CertFingerprint(cert)] = dbCert
+		}
 	}
 }
 
-func saveCert(d *Daemon, host string, cert *x509.Certificate) error {
+func saveCert(d *Daemon, host string, cert *x509.Certificate, permissions api.CertificatePut) error {
 	baseCert := new(dbCertInfo)
 	baseCert.Fingerprint = shared.CertFingerprint(cert)
 	baseCert.Type = 1
@@ -83,10 +83,63 @@ func saveCert(d *Daemon, host string, cert *x509.Certificate) error {
 	baseCert.Certificate = string(
 		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
 	)
+	baseCert.ReadOnly = permissions.ReadOnly
+	baseCert.Containers = permissions.Containers
+	baseCert.Profiles = permissions.Profiles
+	baseCert.Projects = permissions.Projects
 
 	return dbCertSave(d.db, baseCert)
 }
 
+func certificateRender(cert *dbCertInfo) api.Certificate {
+	resp := api.Certificate{}
+	resp.Fingerprint = cert.Fingerprint
+	resp.Certificate = cert.Certificate
+	resp.Name = cert.Name
+	if cert.Type == 1 {
+		resp.Type = "client"
+	} else {
+		resp.Type = "unknown"
+	}
+
+	resp.ReadOnly = cert.ReadOnly
+	resp.Containers = cert.Containers
+	resp.Profiles = cert.Profiles
+	resp.Projects = cert.Projects
+
+	return resp
+}
+
+// Checks that the objects a certificate is restricted to are valid. The
+// containers and profiles don't need to exist yet.
+func certificateValidPermissions(d *Daemon, req api.CertificatePut) error {
+	for _, name := range req.Containers {
+		err := containerValidName(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range req.Profiles {
+		if name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("Invalid profile name '%s'", name)
+		}
+	}
+
+	for _, name := range req.Projects {
+		_, _, err := dbProjectGet(d.db, name)
+		if err != nil {
+			if err == NoSuchObjectError {
+				return fmt.Errorf("Project '%s' doesn't exist", name)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
 func certificatesPost(d *Daemon, r *http.Request) Response {
 	// Parse the request
 	req := api.CertificatesPost{}
@@ -95,14 +148,30 @@ func certificatesPost(d *Daemon, r *http.Request) Response {
 	}
 
 	// Access check
-	if !d.isTrustedClient(r) && d.PasswordCheck(req.Password) != nil {
-		return Forbidden
+	var token *dbCertTokenInfo
+	if !d.isTrustedClient(r) {
+		if req.Token != "" {
+			// The token is only consumed once the request is
+			// known to be valid.
+			var err error
+			token, err = dbCertTokenFind(d.db, req.Token)
+			if err != nil {
+				return Forbidden
+			}
+		} else if d.PasswordCheck(req.Password) != nil {
+			return Forbidden
+		}
 	}
 
 	if req.Type != "client" {
 		return BadRequest(fmt.Errorf("Unknown request type %s", req.Type))
 	}
 
+	err := certificateValidPermissions(d, req.CertificatePut)
+	if err != nil {
+		return BadRequest(err)
+	}
+
 	// Extract the certificate
 	var cert *x509.Certificate
 	var name string
@@ -133,6 +202,11 @@ func certificatesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("Can't use TLS data on non-TLS link"))
 	}
 
+	// Certificates added with a token are named after it
+	if token != nil && req.Name == "" {
+		name = token.Name
+	}
+
 	fingerprint := shared.CertFingerprint(cert)
 	for _, existingCert := range d.clientCerts {
 		if fingerprint == shared.CertFingerprint(&existingCert) {
@@ -140,12 +214,19 @@ func certificatesPost(d *Daemon, r *http.Request) Response {
 		}
 	}
 
-	err := saveCert(d, name, cert)
+	if token != nil {
+		_, err = dbCertTokenUse(d.db, req.Token)
+		if err != nil {
+			return Forbidden
+		}
+	}
+
+	err = saveCert(d, name, cert, req.CertificatePut)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	d.clientCerts = append(d.clientCerts, *cert)
+	readSavedClientCAList(d)
 
 	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/certificates/%s", version.APIVersion, fingerprint))
 }
@@ -171,16 +252,7 @@ func doCertificateGet(d *Daemon, fingerprint string) (api.Certificate, error) {
 		return resp, err
 	}
 
-	resp.Fingerprint = dbCertInfo.Fingerprint
-	resp.Certificate = dbCertInfo.Certificate
-	resp.Name = dbCertInfo.Name
-	if dbCertInfo.Type == 1 {
-		resp.Type = "client"
-	} else {
-		resp.Type = "unknown"
-	}
-
-	return resp, nil
+	return certificateRender(dbCertInfo), nil
 }
 
 func certificateFingerprintPut(d *Daemon, r *http.Request) Response {
@@ -219,9 +291,19 @@ func certificateFingerprintPatch(d *Daemon, r *http.Request) Response {
 		return PreconditionFailed(err)
 	}
 
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return InternalError(err)
+	}
+
 	req := oldEntry
 	reqRaw := shared.Jmap{}
-	if err := json.NewDecoder(r.Body).Decode(&reqRaw); err != nil {
+	if err := json.Unmarshal(body, &reqRaw); err != nil {
+		return BadRequest(err)
+	}
+
+	reqPatch := api.CertificatePut{}
+	if err := json.Unmarshal(body, &reqPatch); err != nil {
 		return BadRequest(err)
 	}
 
@@ -237,6 +319,27 @@ func certificateFingerprintPatch(d *Daemon, r *http.Request) Response {
 		req.Type = value
 	}
 
+	// Get permissions
+	_, ok := reqRaw["read_only"]
+	if ok {
+		req.ReadOnly = reqPatch.ReadOnly
+	}
+
+	_, ok = reqRaw["containers"]
+	if ok {
+		req.Containers = reqPatch.Containers
+	}
+
+	_, ok = reqRaw["profiles"]
+	if ok {
+		req.Profiles = reqPatch.Profiles
+	}
+
+	_, ok = reqRaw["projects"]
+	if ok {
+		req.Projects = reqPatch.Projects
+	}
+
 	return doCertificateUpdate(d, fingerprint, req.Writable())
 }
 
@@ -245,11 +348,28 @@ func doCertificateUpdate(d *Daemon, fingerprint string, req api.CertificatePut)
 		return BadRequest(fmt.Errorf("Unknown request type %s", req.Type))
 	}
 
-	err := dbCertUpdate(d.db, fingerprint, req.Name, 1)
+	err := certificateValidPermissions(d, req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	cert := dbCertInfo{
+		Name:       req.Name,
+		Type:       1,
+		ReadOnly:   req.ReadOnly,
+		Containers: req.Containers,
+		Profiles:   req.Profiles,
+		Projects:   req.Projects,
+	}
+
+	err = dbCertUpdate(d.db, fingerprint, &cert)
 	if err != nil {
 		return SmartError(err)
 	}
 
+	// Apply the new permissions
+	readSavedClientCAList(d)
+
 	return EmptySyncResponse
 }
 
@@ -271,3 +391,142 @@ func certificateFingerprintDelete(d *Daemon, r *http.Request) Response {
 }
 
 var certificateFingerprintCmd = Command{name: "certificates/{fingerprint}", get: certificateFingerprintGet, delete: certificateFingerprintDelete, put: certificateFingerprintPut, patch: certificateFingerprintPatch}
+
+func certificateTokenRender(d *Daemon, token *dbCertTokenInfo) (*api.CertificateToken, error) {
+	addresses, err := d.ListenAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("The server isn't listening on the network")
+	}
+
+	cert, err := x509.ParseCertificate(d.tlsConfig.Certificates[0].Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	data := api.CertificateTokenData{
+		Name:        token.Name,
+		Fingerprint: shared.CertFingerprint(cert),
+		Addresses:   addresses,
+		Secret:      token.Secret,
+		ExpiresAt:   token.ExpiresAt,
+	}
+
+	return &api.CertificateToken{
+		Name:      token.Name,
+		Secret:    token.Secret,
+		ExpiresAt: token.ExpiresAt,
+		Token:     data.String(),
+	}, nil
+}
+
+func certificateTokensGet(d *Daemon, r *http.Request) Response {
+	tokens, err := dbCertTokensGet(d.db)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	recursion := d.isRecursionRequest(r)
+
+	resultString := []string{}
+	resultMap := []*api.CertificateToken{}
+	for _, token := range tokens {
+		if !recursion {
+			url := fmt.Sprintf("/%s/certificates/tokens/%s", version.APIVersion, token.Name)
+			resultString = append(resultString, url)
+		} else {
+			resp, err := certificateTokenRender(d, token)
+			if err != nil {
+				return SmartError(err)
+			}
+			resultMap = append(resultMap, resp)
+		}
+	}
+
+	if !recursion {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+func certificateTokensPost(d *Daemon, r *http.Request) Response {
+	req := api.CertificateTokensPost{}
+	if err := shared.ReadToJSON(r.Body, &req); err != nil {
+		return BadRequest(err)
+	}
+
+	if req.Name == "" || strings.Contains(req.Name, "/") {
+		return BadRequest(fmt.Errorf("Invalid token name '%s'", req.Name))
+	}
+
+	if req.ExpiresAt.IsZero() {
+		req.ExpiresAt = time.Now().Add(24 * time.Hour)
+	} else if req.ExpiresAt.Before(time.Now()) {
+		return BadRequest(fmt.Errorf("The expiry date is in the past"))
+	}
+
+	_, err := dbCertTokenGet(d.db, req.Name)
+	if err == nil {
+		return Conflict
+	}
+
+	secret, err := shared.RandomCryptoString()
+	if err != nil {
+		return InternalError(err)
+	}
+
+	token := &dbCertTokenInfo{Name: req.Name, Secret: secret, ExpiresAt: req.ExpiresAt}
+
+	// Make sure the token can be handed out before storing it
+	resp, err := certificateTokenRender(d, token)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = dbCertTokenCreate(d.db, token)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponseLocation(true, resp, fmt.Sprintf("/%s/certificates/tokens/%s", version.APIVersion, req.Name))
+}
+
+var certificateTokensCmd = Command{name: "certificates/tokens", get: certificateTokensGet, post: certificateTokensPost}
+
+func certificateTokenGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	token, err := dbCertTokenGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resp, err := certificateTokenRender(d, token)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponse(true, resp)
+}
+
+func certificateTokenDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	_, err := dbCertTokenGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = dbCertTokenDelete(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+var certificateTokenCmd = Command{name: "certificates/tokens/{name}", get: certificateTokenGet, delete: certificateTokenDelete}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Returns the permissions of the certificate a trusted request was made with,
+// or nil if the client isn't restricted.
+func (d *Daemon) requestPermissions(r *http.Request) *dbCertInfo {
+	if r.RemoteAddr == "@" || r.TLS == nil {
+		// Unix socket
+		return nil
+	}
+
+	for i := range r.TLS.PeerCertificates {
+		cert := r.TLS.PeerCertificates[i]
+		if d.CheckTrustState(*cert) {
+			return d.clientPermissions[shared.CertFingerprint(cert)]
+		}
+	}
+
+	return nil
+}
+
+// certificateCheckPermissions returns an error when a request isn't allowed
+// by the restrictions of the certificate it was made with.
+//
+// Containers and profiles are restricted by name, and containers, images,
+// profiles and custom volumes by project. Object kinds which aren't
+// covered by a restriction can only be read, as can all the host-wide
+// objects like networks and storage pools. Containers and profiles can only
+// be given configurations which don't give access to the host, and
+// operations and events are limited to the allowed containers and projects.
+func certificateCheckPermissions(d *Daemon, cert *dbCertInfo, version string, c Command, r *http.Request) error {
+	// Restricted clients could lift their own restrictions through the
+	// trust store, and have no business with the internal API or the
+	// requests of other clients.
+	kind := strings.SplitN(c.name, "/", 2)[0]
+	if version == "internal" || kind == "certificates" || kind == "audit" {
+		return fmt.Errorf("Restricted certificates can't access %s", r.URL.Path)
+	}
+
+	if cert.ReadOnly && r.Method != "GET" {
+		return fmt.Errorf("The certificate only has read access")
+	}
+
+	switch kind {
+	case "events":
+		// Filtered down to the allowed operations
+		return nil
+	case "operations":
+		return certificateCheckOperation(cert, r)
+	case "containers":
+		err := certificateCheckObject(cert, r, "container", cert.Containers)
+		if err != nil {
+			return err
+		}
+
+		return certificateCheckContainerConfig(d, c, r)
+	case "profiles":
+		err := certificateCheckObject(cert, r, "profile", cert.Profiles)
+		if err != nil {
+			return err
+		}
+
+		return certificateCheckProfileConfig(d, c, r)
+	case "images":
+		return certificateCheckObject(cert, r, "image", nil)
+	case "storage-pools":
+		if strings.Contains(c.name, "/volumes") {
+			return certificateCheckObject(cert, r, "storage volume", nil)
+		}
+	case "projects":
+		name := mux.Vars(r)["name"]
+		if name != "" && len(cert.Projects) > 0 && !shared.StringInSlice(name, cert.Projects) {
+			return fmt.Errorf("Access to project '%s' isn't allowed", name)
+		}
+	}
+
+	if r.Method != "GET" {
+		return fmt.Errorf("The certificate can't modify %s", r.URL.Path)
+	}
+
+	return nil
+}
+
+// Checks a request on an object belonging to a project against the allowed
+// projects and, for containers and profiles, the allowed names.
+func certificateCheckObject(cert *dbCertInfo, r *http.Request, kind string, names []string) error {
+	project := projectParam(r)
+	if len(cert.Projects) > 0 && !shared.StringInSlice(project, cert.Projects) {
+		return fmt.Errorf("Access to project '%s' isn't allowed", project)
+	}
+
+	if len(names) > 0 {
+		targets, err := certificateRequestTargets(r)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range targets {
+			if !shared.StringInSlice(name, names) {
+				return fmt.Errorf("Access to %s '%s' isn't allowed", kind, name)
+			}
+		}
+
+		return nil
+	}
+
+	// Without a restriction on the objects of this kind, they can only be
+	// modified within the allowed projects.
+	if len(cert.Projects) == 0 && r.Method != "GET" {
+		return fmt.Errorf("The certificate can't modify %s", r.URL.Path)
+	}
+
+	return nil
+}
+
+// Returns the names of the objects a request on a container or profile
+// affects. New objects are named in the request body, as is the source of
+// a container copy.
+func certificateRequestTargets(r *http.Request) ([]string, error) {
+	name := mux.Vars(r)["name"]
+	if name != "" {
+		return []string{name}, nil
+	}
+
+	if r.Method != "POST" {
+		// Listing the collection
+		return []string{}, nil
+	}
+
+	if !isJSONRequest(r) {
+		return nil, fmt.Errorf("Restricted certificates can only create objects from JSON requests")
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = shared.BytesReadCloser{Buf: bytes.NewBuffer(body)}
+
+	req := struct {
+		Name   string `json:"name"`
+		Source struct {
+			Type   string `json:"type"`
+			Source string `json:"source"`
+		} `json:"source"`
+	}{}
+
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []string{req.Name}
+	if req.Source.Type == "copy" {
+		parent, _, _ := containerGetParentAndSnapshotName(req.Source.Source)
+		targets = append(targets, parent)
+	}
+
+	return targets, nil
+}
+
+// Checks the config and devices of the containers created or updated by a
+// request.
+func certificateCheckContainerConfig(d *Daemon, c Command, r *http.Request) error {
+	project := projectParam(r)
+
+	if c.name == "containers" && r.Method == "POST" {
+		req := api.ContainersPost{}
+		err := restrictedRequestBody(r, &req)
+		if err != nil {
+			return err
+		}
+
+		return restrictedCheckCreate(d, project, req)
+	}
+
+	if c.name == "containers/{name}" && (r.Method == "PUT" || r.Method == "PATCH") {
+		req := api.ContainerPut{}
+		err := restrictedRequestBody(r, &req)
+		if err != nil {
+			return err
+		}
+
+		return restrictedCheckUpdate(d, project, mux.Vars(r)["name"], req)
+	}
+
+	return nil
+}
+
+// Checks the config and devices of the profiles created or updated by a
+// request.
+func certificateCheckProfileConfig(d *Daemon, c Command, r *http.Request) error {
+	if c.name == "profiles" && r.Method == "POST" {
+		req := api.ProfilesPost{}
+		err := restrictedRequestBody(r, &req)
+		if err != nil {
+			return err
+		}
+
+		return restrictedCheckConfig(req.Config, req.Devices, nil, nil)
+	}
+
+	if c.name == "profiles/{name}" && (r.Method == "PUT" || r.Method == "PATCH") {
+		req := api.ProfilePut{}
+		err := restrictedRequestBody(r, &req)
+		if err != nil {
+			return err
+		}
+
+		profilesProject, err := projectProfilesProject(d.db, projectParam(r))
+		if err != nil {
+			return err
+		}
+
+		// Missing profiles are reported by the handler
+		_, profile, err := dbProfileGet(d.db, profilesProject, mux.Vars(r)["name"])
+		if err != nil {
+			return restrictedCheckConfig(req.Config, req.Devices, nil, nil)
+		}
+
+		return restrictedCheckConfig(req.Config, req.Devices, profile.Config, profile.Devices)
+	}
+
+	return nil
+}
+
+// Operations can only be accessed if they're allowed by the certificate.
+func certificateCheckOperation(cert *dbCertInfo, r *http.Request) error {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		// Listing is filtered
+		return nil
+	}
+
+	op, err := operationGet(id)
+	if err != nil {
+		// Let the handler report it
+		return nil
+	}
+
+	op.lock.Lock()
+	project := op.project
+	resources := op.resources
+	op.lock.Unlock()
+
+	if !certificateAllowsOperation(cert, project, resources) {
+		return fmt.Errorf("Access to operation '%s' isn't allowed", id)
+	}
+
+	return nil
+}
+
+// Whether a restricted certificate may see and use an operation with the
+// given project and resources. Operations hold the secrets of exec and
+// console sessions, so they're only visible when within an allowed project
+// and, with restricted container names, when all the containers they affect
+// are allowed.
+func certificateAllowsOperation(cert *dbCertInfo, project string, resources map[string][]string) bool {
+	if len(cert.Projects) > 0 && !shared.StringInSlice(project, cert.Projects) {
+		return false
+	}
+
+	if len(cert.Containers) == 0 {
+		return true
+	}
+
+	containers := resources["containers"]
+	if len(containers) == 0 {
+		return false
+	}
+
+	for _, name := range containers {
+		parent, _, _ := containerGetParentAndSnapshotName(name)
+		if !shared.StringInSlice(parent, cert.Containers) {
+			return false
+		}
+	}
+
+	return true
+}
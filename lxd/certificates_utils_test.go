@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/lxc/lxd/shared"
+)
+
+type certificatesUtilsTestSuite struct {
+	lxdTestSuite
+}
+
+// Runs the permission check of the given command on a request, going
+// through a router so that the URL variables are set.
+func (suite *certificatesUtilsTestSuite) checkPermissions(cert *dbCertInfo, version string, c Command, method string, url string, body string) error {
+	var err error
+
+	uri := "/" + version
+	if c.name != "" {
+		uri += "/" + c.name
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		err = certificateCheckPermissions(suite.d, cert, version, c, r)
+	})
+
+	r := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	return err
+}
+
+func (suite *certificatesUtilsTestSuite) TestCertificateCheckPermissions() {
+	readOnly := &dbCertInfo{ReadOnly: true}
+	containers := &dbCertInfo{Containers: []string{"c1"}}
+	projects := &dbCertInfo{Projects: []string{"ci"}}
+
+	cases := []struct {
+		cert    *dbCertInfo
+		version string
+		command Command
+		method  string
+		url     string
+		body    string
+		allowed bool
+	}{
+		// The trust store and internal API are always off limits
+		{projects, "1.0", certificatesCmd, "GET", "/1.0/certificates", "", false},
+		{projects, "internal", Command{name: "shutdown"}, "PUT", "/internal/shutdown", "", false},
+
+		// Read-only certificates can only read
+		{readOnly, "1.0", containersCmd, "GET", "/1.0/containers", "", true},
+		{readOnly, "1.0", containerCmd, "DELETE", "/1.0/containers/c1", "", false},
+
+		// Containers are restricted by name
+		{containers, "1.0", containerCmd, "PUT", "/1.0/containers/c1", "{}", true},
+		{containers, "1.0", containerCmd, "PUT", "/1.0/containers/c2", "{}", false},
+		{containers, "1.0", containersCmd, "POST", "/1.0/containers", `{"name": "c1", "source": {"type": "image"}}`, true},
+		{containers, "1.0", containersCmd, "POST", "/1.0/containers", `{"name": "c2", "source": {"type": "image"}}`, false},
+		{containers, "1.0", containersCmd, "POST", "/1.0/containers", `{"name": "c1", "source": {"type": "copy", "source": "c2/snap0"}}`, false},
+		{containers, "1.0", profileCmd, "PUT", "/1.0/profiles/default", "{}", false},
+
+		// Project restrictions cover containers, images, profiles and volumes
+		{projects, "1.0", containersCmd, "POST", "/1.0/containers?project=ci", `{"name": "c1", "source": {"type": "image"}}`, true},
+		{projects, "1.0", containersCmd, "POST", "/1.0/containers", `{"name": "c1", "source": {"type": "image"}}`, false},
+		{projects, "1.0", imagesCmd, "POST", "/1.0/images?project=ci", "", true},
+		{projects, "1.0", profileCmd, "PUT", "/1.0/profiles/default?project=ci", "{}", true},
+		{projects, "1.0", projectCmd, "GET", "/1.0/projects/ci", "", true},
+		{projects, "1.0", projectCmd, "GET", "/1.0/projects/default", "", false},
+
+		// Containers and profiles can't be given access to the host
+		{projects, "1.0", containersCmd, "POST", "/1.0/containers?project=ci", `{"name": "c1", "source": {"type": "image"}, "config": {"security.privileged": "true"}}`, false},
+		{projects, "1.0", containersCmd, "POST", "/1.0/containers?project=ci", `{"name": "c1", "source": {"type": "image"}, "config": {"raw.lxc": "lxc.aa_profile=unconfined"}}`, false},
+		{projects, "1.0", containersCmd, "POST", "/1.0/containers?project=ci", `{"name": "c1", "source": {"type": "image"}, "devices": {"host": {"type": "disk", "source": "/", "path": "/mnt"}}}`, false},
+		{projects, "1.0", containersCmd, "POST", "/1.0/containers?project=ci", `{"name": "c1", "source": {"type": "image"}, "devices": {"proxy": {"type": "proxy", "listen": "tcp:0.0.0.0:80", "connect": "tcp:127.0.0.1:80"}}}`, false},
+		{projects, "1.0", containersCmd, "POST", "/1.0/containers?project=ci", `{"name": "c1", "source": {"type": "migration"}}`, false},
+		{containers, "1.0", containerCmd, "PATCH", "/1.0/containers/c1", `{"config": {"security.privileged": "true"}}`, false},
+		{projects, "1.0", profilesCmd, "POST", "/1.0/profiles?project=ci", `{"name": "p1", "config": {"raw.idmap": "both 0 0"}}`, false},
+		{projects, "1.0", profileCmd, "PUT", "/1.0/profiles/default?project=ci", `{"config": {"security.privileged": "true"}}`, false},
+		{projects, "1.0", profileCmd, "PUT", "/1.0/profiles/default?project=ci", `{"config": {"limits.cpu": "2"}}`, true},
+
+		// Host-wide objects can only be read
+		{projects, "1.0", networksCmd, "GET", "/1.0/networks", "", true},
+		{projects, "1.0", networksCmd, "POST", "/1.0/networks", "", false},
+		{projects, "1.0", storagePoolsCmd, "POST", "/1.0/storage-pools", "", false},
+		{projects, "1.0", api10Cmd, "PUT", "/1.0", "", false},
+	}
+
+	for i, c := range cases {
+		err := suite.checkPermissions(c.cert, c.version, c.command, c.method, c.url, c.body)
+		if c.allowed && err != nil {
+			suite.T().Errorf("Case %d: %s %s was rejected: %s", i, c.method, c.url, err)
+		} else if !c.allowed && err == nil {
+			suite.T().Errorf("Case %d: %s %s was allowed", i, c.method, c.url)
+		}
+	}
+}
+
+// Operations are only visible to restricted certificates when they're on
+// allowed containers within allowed projects.
+func (suite *certificatesUtilsTestSuite) TestCertificateCheckOperation() {
+	containers := &dbCertInfo{Containers: []string{"c1"}}
+	projects := &dbCertInfo{Projects: []string{"ci"}}
+
+	newOperation := func(project string, resources map[string][]string) string {
+		op, err := operationCreate(project, nil, operationClassToken, resources, nil, nil, nil, nil)
+		suite.Req.Nil(err)
+
+		return op.id
+	}
+
+	own := newOperation("ci", map[string][]string{"containers": {"c1"}})
+	other := newOperation("default", map[string][]string{"containers": {"c2"}})
+	image := newOperation("default", map[string][]string{"images": {"abcdef"}})
+
+	cases := []struct {
+		cert    *dbCertInfo
+		command Command
+		method  string
+		id      string
+		allowed bool
+	}{
+		{containers, operationCmd, "GET", own, true},
+		{containers, operationCmd, "DELETE", own, true},
+		{containers, operationWait, "GET", own, true},
+		{containers, operationCmd, "GET", other, false},
+		{containers, operationCmd, "DELETE", other, false},
+		{containers, operationWait, "GET", other, false},
+		{containers, operationCmd, "GET", image, false},
+		{projects, operationCmd, "GET", own, true},
+		{projects, operationCmd, "GET", other, false},
+		{projects, operationCmd, "GET", image, false},
+	}
+
+	for i, c := range cases {
+		url := "/1.0/operations/" + c.id
+		if c.command.name == operationWait.name {
+			url += "/wait"
+		}
+
+		err := suite.checkPermissions(c.cert, "1.0", c.command, c.method, url, "")
+		if c.allowed && err != nil {
+			suite.T().Errorf("Case %d: %s %s was rejected: %s", i, c.method, url, err)
+		} else if !c.allowed && err == nil {
+			suite.T().Errorf("Case %d: %s %s was allowed", i, c.method, url)
+		}
+	}
+
+	// Listing is filtered by the handler
+	suite.Req.Nil(suite.checkPermissions(containers, "1.0", operationsCmd, "GET", "/1.0/operations", ""))
+}
+
+func (suite *certificatesUtilsTestSuite) TestCertificateAllowsOperation() {
+	cert := &dbCertInfo{Containers: []string{"c1"}}
+
+	suite.Req.True(certificateAllowsOperation(cert, "default", map[string][]string{"containers": {"c1", "c1/snap0"}}))
+	suite.Req.False(certificateAllowsOperation(cert, "default", map[string][]string{"containers": {"c1", "c2"}}))
+	suite.Req.False(certificateAllowsOperation(cert, "default", map[string][]string{"images": {"abcdef"}}))
+
+	// Without a container restriction, only the project matters
+	cert = &dbCertInfo{Projects: []string{"ci"}}
+	suite.Req.True(certificateAllowsOperation(cert, "ci", map[string][]string{"images": {"abcdef"}}))
+	suite.Req.False(certificateAllowsOperation(cert, "default", map[string][]string{"images": {"abcdef"}}))
+}
+
+// Returns a request made with a newly trusted certificate having the given
+// restrictions.
+func (suite *certificatesUtilsTestSuite) restrictedRequest(cert *dbCertInfo, url string) *http.Request {
+	certBytes, _, err := shared.GenerateMemCert(true)
+	suite.Req.Nil(err)
+
+	block, _ := pem.Decode(certBytes)
+	x509Cert, err := x509.ParseCertificate(block.Bytes)
+	suite.Req.Nil(err)
+
+	suite.d.clientCerts = append(suite.d.clientCerts, *x509Cert)
+	suite.d.clientPermissions = map[string]*dbCertInfo{shared.CertFingerprint(x509Cert): cert}
+
+	r := httptest.NewRequest("GET", url, nil)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{x509Cert}}
+
+	return r
+}
+
+// The users of other objects only include the allowed containers and
+// profiles.
+func (suite *certificatesUtilsTestSuite) TestRestrictedFilterUsedBy() {
+	usedBy := []string{
+		"/1.0/containers/c1",
+		"/1.0/containers/c1/snapshots/snap0",
+		"/1.0/containers/c2",
+		"/1.0/containers/c1?project=ci",
+		"/1.0/profiles/default",
+		"/1.0/profiles/p1?project=ci",
+		"/1.0/images/abcdef",
+	}
+
+	r := suite.restrictedRequest(&dbCertInfo{Containers: []string{"c1"}, Profiles: []string{"p1"}}, "/1.0/networks/lxdbr0")
+	result, err := restrictedFilterUsedBy(suite.d, r, usedBy)
+	suite.Req.Nil(err)
+	suite.Req.Equal([]string{
+		"/1.0/containers/c1",
+		"/1.0/containers/c1/snapshots/snap0",
+		"/1.0/containers/c1?project=ci",
+		"/1.0/profiles/p1?project=ci",
+		"/1.0/images/abcdef",
+	}, result)
+
+	r = suite.restrictedRequest(&dbCertInfo{Projects: []string{"ci"}}, "/1.0/networks/lxdbr0")
+	result, err = restrictedFilterUsedBy(suite.d, r, usedBy)
+	suite.Req.Nil(err)
+	suite.Req.Equal([]string{
+		"/1.0/containers/c1?project=ci",
+		"/1.0/profiles/default",
+		"/1.0/profiles/p1?project=ci",
+		"/1.0/images/abcdef",
+	}, result)
+}
+
+func TestCertificatesUtilsTestSuite(t *testing.T) {
+	suite.Run(t, new(certificatesUtilsTestSuite))
+}
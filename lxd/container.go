@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"io"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/osarch"
+	"github.com/lxc/lxd/shared/version"
 )
 
 // Helper functions
@@ -30,6 +32,17 @@ func containerGetParentAndSnapshotName(name string) (string, string, bool) {
 	return fields[0], fields[1], true
 }
 
+// Returns the API URL of a container or snapshot given its project and
+// internal name.
+func containerURL(project string, name string) string {
+	cname, sname, isSnapshot := containerGetParentAndSnapshotName(projectUnprefix(project, name))
+	if isSnapshot {
+		return projectURL(fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, cname, sname), project)
+	}
+
+	return projectURL(fmt.Sprintf("/%s/containers/%s", version.APIVersion, cname), project)
+}
+
 func containerPath(name string, isSnapshot bool) string {
 	if isSnapshot {
 		return shared.VarPath("snapshots", name)
@@ -193,6 +206,17 @@ func containerValidDeviceConfigKey(t, k string) bool {
 		default:
 			return false
 		}
+	case "proxy":
+		switch k {
+		case "bind":
+			return true
+		case "connect":
+			return true
+		case "listen":
+			return true
+		default:
+			return false
+		}
 	case "none":
 		return false
 	default:
@@ -284,7 +308,7 @@ func containerValidDevices(d *Daemon, devices types.Devices, profile bool, expan
 			return fmt.Errorf("Missing device type for device '%s'", name)
 		}
 
-		if !shared.StringInSlice(m["type"], []string{"none", "nic", "disk", "unix-char", "unix-block", "usb", "gpu"}) {
+		if !shared.StringInSlice(m["type"], []string{"none", "nic", "disk", "unix-char", "unix-block", "usb", "gpu", "proxy"}) {
 			return fmt.Errorf("Invalid device type for device '%s'", name)
 		}
 
@@ -378,6 +402,32 @@ func containerValidDevices(d *Daemon, devices types.Devices, profile bool, expan
 		} else if m["type"] == "gpu" {
 			// Probably no checks needed, since we allow users to
 			// pass in all GPUs.
+		} else if m["type"] == "proxy" {
+			if m["listen"] == "" {
+				return fmt.Errorf("Proxy device entry is missing the required \"listen\" property.")
+			}
+
+			if m["connect"] == "" {
+				return fmt.Errorf("Proxy device entry is missing the required \"connect\" property.")
+			}
+
+			listenProto, _, err := proxyParseAddr(m["listen"])
+			if err != nil {
+				return err
+			}
+
+			connectProto, _, err := proxyParseAddr(m["connect"])
+			if err != nil {
+				return err
+			}
+
+			if (listenProto == "udp") != (connectProto == "udp") {
+				return fmt.Errorf("Proxy devices can't forward between datagram and stream sockets.")
+			}
+
+			if !shared.StringInSlice(m["bind"], []string{"", "host", "container"}) {
+				return fmt.Errorf("Invalid proxy bind \"%s\", must be one of host or container.", m["bind"])
+			}
 		} else if m["type"] == "none" {
 			continue
 		} else {
@@ -406,15 +456,31 @@ type containerArgs struct {
 	BaseImage    string
 	Config       map[string]string
 	CreationDate time.Time
+	ExpiryDate   time.Time
 	LastUsedDate time.Time
 	Ctype        containerType
 	Devices      types.Devices
 	Ephemeral    bool
 	Name         string
 	Profiles     []string
+	Project      string
 	Stateful     bool
 }
 
+// The arguments of a CRIU checkpoint, restore, pre-dump or feature check.
+// dumpDir and preDumpDir are relative to stateDir, the latter pointing to
+// the images of the previous pre-dump for incremental dumps.
+type CriuMigrationArgs struct {
+	cmd          uint
+	stateDir     string
+	function     string
+	stop         bool
+	actionScript bool
+	dumpDir      string
+	preDumpDir   string
+	features     lxc.CriuFeatures
+}
+
 // The container interface
 type container interface {
 	// Container actions
@@ -429,7 +495,7 @@ type container interface {
 	/* actionScript here is a script called action.sh in the stateDir, to
 	 * be passed to CRIU as --action-script
 	 */
-	Migrate(cmd uint, stateDir string, function string, stop bool, actionScript bool) error
+	Migrate(args *CriuMigrationArgs) error
 	Snapshots() ([]container, error)
 
 	// Config handling
@@ -462,7 +528,12 @@ type container interface {
 	         *      (the PID returned in the first return argument). It can however
 	         *      be used to e.g. forward signals.)
 	*/
-	Exec(command []string, env map[string]string, stdin *os.File, stdout *os.File, stderr *os.File, wait bool) (*exec.Cmd, int, int, error)
+	Exec(command []string, env map[string]string, stdin *os.File, stdout *os.File, stderr *os.File, wait bool, cwd string, uid uint32, gid uint32) (*exec.Cmd, int, int, error)
+
+	// Console - Returns a command attaching the terminal to the console
+	// when started. Only one client may be attached at a time.
+	Console(terminal *os.File) *exec.Cmd
+	ConsoleLog(opts lxc.ConsoleLogOptions) (string, error)
 
 	// Status
 	Render() (interface{}, interface{}, error)
@@ -482,6 +553,7 @@ type container interface {
 	// Properties
 	Id() int
 	Name() string
+	Project() string
 	Description() string
 	Architecture() int
 	CreationDate() time.Time
@@ -501,6 +573,7 @@ type container interface {
 	StatePath() string
 	LogFilePath() string
 	LogPath() string
+	ConsoleBufferLogPath() string
 
 	StoragePool() (string, error)
 
@@ -556,8 +629,13 @@ func containerCreateEmptySnapshot(d *Daemon, args containerArgs) (container, err
 }
 
 func containerCreateFromImage(d *Daemon, args containerArgs, hash string) (container, error) {
+	imagesProject, err := projectImagesProject(d.db, args.Project)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get the image properties
-	_, img, err := dbImageGet(d.db, hash, false, false)
+	_, img, err := dbImageGet(d.db, imagesProject, hash, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -578,7 +656,7 @@ func containerCreateFromImage(d *Daemon, args containerArgs, hash string) (conta
 		return nil, err
 	}
 
-	if err := dbImageLastAccessUpdate(d.db, hash, time.Now().UTC()); err != nil {
+	if err := dbImageLastAccessUpdate(d.db, imagesProject, hash, time.Now().UTC()); err != nil {
 		return nil, fmt.Errorf("Error updating image last use date: %s", err)
 	}
 
@@ -663,6 +741,120 @@ func containerCreateAsCopy(d *Daemon, args containerArgs, sourceContainer contai
 	return ct, nil
 }
 
+// containerRefreshAsCopy brings an existing copy of a container up to date
+// with its source. Only the snapshots the copy doesn't have yet are copied,
+// followed by the changes to the container itself.
+func containerRefreshAsCopy(d *Daemon, ct container, sourceContainer container, containerOnly bool) error {
+	if ct.IsRunning() {
+		return fmt.Errorf("Can't refresh a running container")
+	}
+
+	ourStart, err := ct.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer ct.StorageStop()
+	}
+
+	ourStart, err = sourceContainer.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer sourceContainer.StorageStop()
+	}
+
+	bwlimit := ""
+	poolwritable := ct.Storage().GetStoragePoolWritable()
+	if poolwritable.Config != nil {
+		bwlimit = poolwritable.Config["rsync.bwlimit"]
+	}
+
+	if !containerOnly {
+		sourceSnapshots, err := sourceContainer.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		targetSnapshots, err := ct.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		names := []string{}
+		for _, snap := range targetSnapshots {
+			names = append(names, shared.ExtractSnapshotName(snap.Name()))
+		}
+
+		for _, snap := range migrationRefreshContainers(sourceSnapshots, names) {
+			csArgs := containerArgs{
+				Architecture: snap.Architecture(),
+				Config:       snap.LocalConfig(),
+				Ctype:        cTypeSnapshot,
+				Devices:      snap.LocalDevices(),
+				Ephemeral:    snap.IsEphemeral(),
+				Name:         fmt.Sprintf("%s/%s", ct.Name(), shared.ExtractSnapshotName(snap.Name())),
+				Profiles:     snap.Profiles(),
+			}
+
+			err = containerRefreshCopySnapshot(d, ct, snap, csArgs, bwlimit)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	output, err := rsyncLocalCopy(sourceContainer.Path(), ct.Path(), bwlimit)
+	if err != nil {
+		return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+	}
+
+	// The files now carry the source's ids
+	srcIdmap, err := sourceContainer.LastIdmapSet()
+	if err != nil {
+		return err
+	}
+
+	return ShiftIfNecessary(ct, srcIdmap)
+}
+
+// containerRefreshCopySnapshot adds a copy of a source snapshot to ct.
+func containerRefreshCopySnapshot(d *Daemon, ct container, snap container, args containerArgs, bwlimit string) error {
+	ourStart, err := snap.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer snap.StorageStop()
+	}
+
+	if ct.Storage().GetStorageType() == storageTypeDir {
+		cs, err := containerCreateEmptySnapshot(d, args)
+		if err != nil {
+			return err
+		}
+
+		output, err := rsyncLocalCopy(snap.Path(), cs.Path(), bwlimit)
+		if err != nil {
+			cs.Delete()
+			return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+		}
+
+		return nil
+	}
+
+	// Copy the snapshot's content into the container and snapshot it
+	// from there.
+	output, err := rsyncLocalCopy(snap.Path(), ct.Path(), bwlimit)
+	if err != nil {
+		return fmt.Errorf("failed to rsync: %s: %s", string(output), err)
+	}
+
+	_, err = containerCreateAsSnapshot(d, args, ct)
+	return err
+}
+
 func containerCreateAsSnapshot(d *Daemon, args containerArgs, sourceContainer container) (container, error) {
 	// Deal with state
 	if args.Stateful {
@@ -691,7 +883,15 @@ func containerCreateAsSnapshot(d *Daemon, args containerArgs, sourceContainer co
 		 * after snapshotting will fail.
 		 */
 
-		err = sourceContainer.Migrate(lxc.MIGRATE_DUMP, stateDir, "snapshot", false, false)
+		criuMigrationArgs := CriuMigrationArgs{
+			cmd:          lxc.MIGRATE_DUMP,
+			stateDir:     stateDir,
+			function:     "snapshot",
+			stop:         false,
+			actionScript: false,
+		}
+
+		err = sourceContainer.Migrate(&criuMigrationArgs)
 		if err != nil {
 			os.RemoveAll(sourceContainer.StatePath())
 			return nil, err
@@ -754,9 +954,24 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 		args.Architecture = d.architectures[0]
 	}
 
+	if args.Project == "" {
+		args.Project = "default"
+	}
+
+	// Snapshots belong to the project of their container
+	if args.Ctype == cTypeSnapshot {
+		parentName, _, _ := containerGetParentAndSnapshotName(args.Name)
+		parentArgs, err := dbContainerGet(d.db, parentName)
+		if err == nil {
+			args.Project = parentArgs.Project
+		} else if err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
 	// Validate container name
 	if args.Ctype == cTypeRegular {
-		err := containerValidName(args.Name)
+		err := containerValidName(projectUnprefix(args.Project, args.Name))
 		if err != nil {
 			return nil, err
 		}
@@ -785,7 +1000,12 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 	}
 
 	// Validate profiles
-	profiles, err := dbProfiles(d.db)
+	profilesProject, err := projectProfilesProject(d.db, args.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := dbProfiles(d.db, profilesProject)
 	if err != nil {
 		return nil, err
 	}
@@ -804,7 +1024,7 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 			if shared.IsSnapshot(args.Name) {
 				thing = "Snapshot"
 			}
-			return nil, fmt.Errorf("%s '%s' already exists", thing, args.Name)
+			return nil, fmt.Errorf("%s '%s' already exists", thing, projectUnprefix(args.Project, args.Name))
 		}
 		return nil, err
 	}
@@ -828,6 +1048,12 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 		return nil, err
 	}
 
+	if args.Ctype == cTypeSnapshot {
+		eventSendLifecycle("container-snapshot-created", containerURL(args.Project, args.Name), nil)
+	} else {
+		eventSendLifecycle("container-created", containerURL(args.Project, args.Name), nil)
+	}
+
 	return c, nil
 }
 
@@ -847,7 +1073,7 @@ func containerConfigureInternal(c container) error {
 	storage := c.Storage()
 	if rootDiskDevice["size"] != "" {
 		storageTypeName := storage.GetStorageTypeName()
-		if storageTypeName == "lvm" && c.IsRunning() {
+		if (storageTypeName == "lvm" || storageTypeName == "ceph") && c.IsRunning() {
 			err = c.ConfigKeySet("volatile.apply_quota", rootDiskDevice["size"])
 			if err != nil {
 				return err
@@ -896,3 +1122,24 @@ func containerLoadByName(d *Daemon, name string) (container, error) {
 
 	return containerLXCLoad(d, args)
 }
+
+// Load a container given its project and its name within that project.
+// Internal names don't tell projects apart, "foo_c1" is the container "c1"
+// of project "foo" but could also be a container of the default project, so
+// the project of the container found is checked.
+func containerLoadByProjectAndName(d *Daemon, project string, name string) (container, error) {
+	if project == "" {
+		project = "default"
+	}
+
+	args, err := dbContainerGet(d.db, projectPrefix(project, name))
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Project != project {
+		return nil, sql.ErrNoRows
+	}
+
+	return containerLXCLoad(d, args)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+func containerBackupsGet(d *Daemon, r *http.Request) Response {
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	project := projectParam(r)
+	cname := mux.Vars(r)["name"]
+	c, err := containerLoadByProjectAndName(d, project, cname)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	names, err := dbContainerGetBackups(d.db, c.Name())
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []*api.ContainerBackup{}
+
+	for _, name := range names {
+		if recursion == 0 {
+			url := projectURL(fmt.Sprintf("/%s/containers/%s/backups/%s", version.APIVersion, cname, name), project)
+			resultString = append(resultString, url)
+		} else {
+			b, err := backupLoadByName(d, c.Name(), name)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, b.Render())
+		}
+	}
+
+	if recursion == 0 {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+func containerBackupsPost(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	c, err := containerLoadByProjectAndName(d, project, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	req := api.ContainerBackupsPost{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	if req.Name == "" {
+		// come up with a name
+		backups, err := dbContainerGetBackups(d.db, c.Name())
+		if err != nil {
+			return SmartError(err)
+		}
+
+		for i := 0; ; i++ {
+			req.Name = fmt.Sprintf("backup%d", i)
+			if !shared.StringInSlice(req.Name, backups) {
+				break
+			}
+		}
+	}
+
+	err = backupValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	if req.OptimizedStorage && !shared.StringInSlice(c.Storage().GetStorageTypeName(), []string{"btrfs", "zfs"}) {
+		return BadRequest(fmt.Errorf("Optimized backups are only supported on btrfs and zfs storage pools"))
+	}
+
+	_, err = dbContainerBackupID(d.db, c.Id(), req.Name)
+	if err == nil {
+		return Conflict
+	}
+
+	backup := func(op *operation) error {
+		args := backupArgs{
+			Name:             req.Name,
+			ExpiryDate:       req.ExpiryDate,
+			ContainerOnly:    req.ContainerOnly,
+			OptimizedStorage: req.OptimizedStorage,
+		}
+
+		return backupCreate(d, args, c)
+	}
+
+	resources := map[string][]string{}
+	resources["containers"] = []string{name}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, backup, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func containerBackupHandler(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+	containerName := mux.Vars(r)["name"]
+	backupName := mux.Vars(r)["backupName"]
+
+	c, err := containerLoadByProjectAndName(d, project, containerName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	b, err := backupLoadByName(d, c.Name(), backupName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	switch r.Method {
+	case "GET":
+		return SyncResponse(true, b.Render())
+	case "POST":
+		return containerBackupPost(d, r, b)
+	case "DELETE":
+		return containerBackupDelete(r, b)
+	default:
+		return NotFound
+	}
+}
+
+func containerBackupPost(d *Daemon, r *http.Request, b *backup) Response {
+	req := api.ContainerBackupPost{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	err := backupValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Check that the name isn't already in use
+	_, err = dbContainerBackupID(d.db, b.container.Id(), req.Name)
+	if err == nil {
+		return Conflict
+	}
+
+	rename := func(op *operation) error {
+		return b.Rename(req.Name)
+	}
+
+	project := b.container.Project()
+	resources := map[string][]string{}
+	resources["containers"] = []string{projectUnprefix(project, b.container.Name())}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, rename, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func containerBackupDelete(r *http.Request, b *backup) Response {
+	remove := func(op *operation) error {
+		return b.Delete()
+	}
+
+	project := b.container.Project()
+	resources := map[string][]string{}
+	resources["containers"] = []string{projectUnprefix(project, b.container.Name())}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, remove, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func containerBackupExportGet(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+	containerName := mux.Vars(r)["name"]
+	backupName := mux.Vars(r)["backupName"]
+
+	c, err := containerLoadByProjectAndName(d, project, containerName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	b, err := backupLoadByName(d, c.Name(), backupName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	ent := fileResponseEntry{
+		path:     b.Path(),
+		filename: fmt.Sprintf("%s-%s.tar.gz", containerName, backupName),
+	}
+
+	return FileResponse(r, []fileResponseEntry{ent}, nil, false)
+}
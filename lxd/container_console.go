@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"gopkg.in/lxc/go-lxc.v2"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+type consoleWs struct {
+	container container
+
+	conns            map[int]*websocket.Conn
+	connsLock        sync.Mutex
+	allConnected     chan bool
+	controlConnected chan bool
+	fds              map[int]string
+	width            int
+	height           int
+}
+
+func (s *consoleWs) Metadata() interface{} {
+	fds := shared.Jmap{}
+	for fd, secret := range s.fds {
+		if fd == -1 {
+			fds["control"] = secret
+		} else {
+			fds[strconv.Itoa(fd)] = secret
+		}
+	}
+
+	return shared.Jmap{"fds": fds}
+}
+
+func (s *consoleWs) Connect(op *operation, r *http.Request, w http.ResponseWriter) error {
+	secret := r.FormValue("secret")
+	if secret == "" {
+		return fmt.Errorf("missing secret")
+	}
+
+	for fd, fdSecret := range s.fds {
+		if secret == fdSecret {
+			conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return err
+			}
+
+			s.connsLock.Lock()
+			s.conns[fd] = conn
+			s.connsLock.Unlock()
+
+			if fd == -1 {
+				s.controlConnected <- true
+			} else {
+				s.allConnected <- true
+			}
+
+			return nil
+		}
+	}
+
+	/* If we didn't find the right secret, the user provided a bad one,
+	 * which 403, not 404, since this operation actually exists */
+	return os.ErrPermission
+}
+
+func (s *consoleWs) Do(op *operation) error {
+	<-s.allConnected
+
+	pty, tty, err := shared.OpenPty(0, 0)
+	if err != nil {
+		return err
+	}
+
+	if s.width > 0 && s.height > 0 {
+		shared.SetSize(int(pty.Fd()), s.width, s.height)
+	}
+
+	consCmd := s.container.Console(tty)
+	err = consCmd.Start()
+	if err != nil {
+		tty.Close()
+		pty.Close()
+		return err
+	}
+
+	consoleDone := make(chan bool, 1)
+	controlExit := make(chan bool)
+
+	// Handle window resizes from the control socket
+	go func() {
+		select {
+		case <-s.controlConnected:
+			break
+
+		case <-controlExit:
+			return
+		}
+
+		s.connsLock.Lock()
+		conn := s.conns[-1]
+		s.connsLock.Unlock()
+
+		for {
+			_, r, err := conn.NextReader()
+			if err != nil {
+				return
+			}
+
+			buf, err := ioutil.ReadAll(r)
+			if err != nil {
+				logger.Debugf("Failed to read message %s", err)
+				return
+			}
+
+			command := api.ContainerConsoleControl{}
+
+			err = json.Unmarshal(buf, &command)
+			if err != nil {
+				logger.Debugf("Failed to unmarshal control socket command: %s", err)
+				continue
+			}
+
+			if command.Command != "window-resize" {
+				continue
+			}
+
+			width, err := strconv.Atoi(command.Args["width"])
+			if err != nil {
+				logger.Debugf("Unable to extract window width: %s", err)
+				continue
+			}
+
+			height, err := strconv.Atoi(command.Args["height"])
+			if err != nil {
+				logger.Debugf("Unable to extract window height: %s", err)
+				continue
+			}
+
+			err = shared.SetSize(int(pty.Fd()), width, height)
+			if err != nil {
+				logger.Debugf("Failed to set window size to: %dx%d", width, height)
+				continue
+			}
+		}
+	}()
+
+	s.connsLock.Lock()
+	conn := s.conns[0]
+	s.connsLock.Unlock()
+
+	readDone, writeDone := shared.WebsocketExecMirror(conn, pty, pty, consoleDone, int(pty.Fd()))
+
+	cmdDone := make(chan error, 1)
+	go func() {
+		cmdDone <- consCmd.Wait()
+	}()
+
+	// Release the console when the client goes away
+	var cmdErr error
+	select {
+	case <-writeDone:
+		consCmd.Process.Kill()
+		<-cmdDone
+	case cmdErr = <-cmdDone:
+	}
+
+	tty.Close()
+	consoleDone <- true
+	<-readDone
+	conn.Close()
+
+	s.connsLock.Lock()
+	control := s.conns[-1]
+	s.connsLock.Unlock()
+
+	if control == nil {
+		close(controlExit)
+	} else {
+		control.Close()
+	}
+
+	if cmdErr != nil {
+		return fmt.Errorf("Failed to attach to the console: %s", cmdErr)
+	}
+
+	return nil
+}
+
+func containerConsolePost(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByProjectAndName(d, project, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if !c.IsRunning() {
+		return BadRequest(fmt.Errorf("Container is not running."))
+	}
+
+	if c.IsFrozen() {
+		return BadRequest(fmt.Errorf("Container is frozen."))
+	}
+
+	post := api.ContainerConsolePost{}
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = json.Unmarshal(buf, &post)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	ws := &consoleWs{}
+	ws.container = c
+	ws.width = post.Width
+	ws.height = post.Height
+
+	ws.conns = map[int]*websocket.Conn{}
+	ws.conns[-1] = nil
+	ws.conns[0] = nil
+	ws.allConnected = make(chan bool, 1)
+	ws.controlConnected = make(chan bool, 1)
+
+	ws.fds = map[int]string{}
+	for i := -1; i < len(ws.conns)-1; i++ {
+		ws.fds[i], err = shared.RandomCryptoString()
+		if err != nil {
+			return InternalError(err)
+		}
+	}
+
+	resources := map[string][]string{}
+	resources["containers"] = []string{name}
+
+	op, err := operationCreate(project, r, operationClassWebsocket, resources, ws.Metadata(), ws.Do, nil, ws.Connect)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func containerConsoleLogGet(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByProjectAndName(d, project, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if !lxc.VersionAtLeast(3, 0, 0) {
+		return BadRequest(fmt.Errorf("The console log requires liblxc 3.0 or higher"))
+	}
+
+	ent := fileResponseEntry{}
+	ent.filename = "console.log"
+
+	if c.IsRunning() {
+		// Get the current content of the ring buffer
+		log, err := c.ConsoleLog(lxc.ConsoleLogOptions{ReadLog: true})
+		if err != nil {
+			return SmartError(err)
+		}
+
+		ent.buffer = []byte(log)
+	} else if shared.PathExists(c.ConsoleBufferLogPath()) {
+		// Fall back to the log file of the last run
+		ent.path = c.ConsoleBufferLogPath()
+	} else {
+		ent.buffer = []byte{}
+	}
+
+	return FileResponse(r, []fileResponseEntry{ent}, nil, false)
+}
+
+func containerConsoleLogDelete(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByProjectAndName(d, project, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if !lxc.VersionAtLeast(3, 0, 0) {
+		return BadRequest(fmt.Errorf("The console log requires liblxc 3.0 or higher"))
+	}
+
+	if c.IsRunning() {
+		_, err = c.ConsoleLog(lxc.ConsoleLogOptions{ClearLog: true})
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	err = os.Truncate(c.ConsoleBufferLogPath(), 0)
+	if err != nil && !os.IsNotExist(err) {
+		return SmartError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+var containerConsoleCmd = Command{
+	name:   "containers/{name}/console",
+	get:    containerConsoleLogGet,
+	post:   containerConsolePost,
+	delete: containerConsoleLogDelete,
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -28,9 +29,12 @@ type execWs struct {
 	command   []string
 	container container
 	env       map[string]string
+	cwd       string
+	uid       uint32
+	gid       uint32
 
-	rootUid          int64
-	rootGid          int64
+	ptyUid           int64
+	ptyGid           int64
 	conns            map[int]*websocket.Conn
 	connsLock        sync.Mutex
 	allConnected     chan bool
@@ -39,9 +43,16 @@ type execWs struct {
 	fds              map[int]string
 	width            int
 	height           int
+
+	persistent bool
+	timeout    time.Duration
+	session    *execSession
 }
 
 func (s *execWs) Metadata() interface{} {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
 	fds := shared.Jmap{}
 	for fd, secret := range s.fds {
 		if fd == -1 {
@@ -60,13 +71,24 @@ func (s *execWs) Connect(op *operation, r *http.Request, w http.ResponseWriter)
 		return fmt.Errorf("missing secret")
 	}
 
-	for fd, fdSecret := range s.fds {
+	s.connsLock.Lock()
+	fds := s.fds
+	session := s.session
+	s.connsLock.Unlock()
+
+	for fd, fdSecret := range fds {
 		if secret == fdSecret {
 			conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
 			if err != nil {
 				return err
 			}
 
+			// Clients reattaching to a persistent session
+			if session != nil {
+				session.attach(fd, conn)
+				return nil
+			}
+
 			s.connsLock.Lock()
 			s.conns[fd] = conn
 			s.connsLock.Unlock()
@@ -95,9 +117,33 @@ func (s *execWs) Connect(op *operation, r *http.Request, w http.ResponseWriter)
 	return os.ErrPermission
 }
 
+// renewSecrets replaces the websocket secrets, so that a new client can
+// attach to a persistent session.
+func (s *execWs) renewSecrets() error {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
+	fds := map[int]string{}
+	for fd := range s.fds {
+		secret, err := shared.RandomCryptoString()
+		if err != nil {
+			return err
+		}
+
+		fds[fd] = secret
+	}
+	s.fds = fds
+
+	return nil
+}
+
 func (s *execWs) Do(op *operation) error {
 	<-s.allConnected
 
+	if s.persistent {
+		return s.doPersistent(op)
+	}
+
 	var err error
 	var ttys []*os.File
 	var ptys []*os.File
@@ -109,7 +155,7 @@ func (s *execWs) Do(op *operation) error {
 	if s.interactive {
 		ttys = make([]*os.File, 1)
 		ptys = make([]*os.File, 1)
-		ptys[0], ttys[0], err = shared.OpenPty(s.rootUid, s.rootGid)
+		ptys[0], ttys[0], err = shared.OpenPty(s.ptyUid, s.ptyGid)
 
 		stdin = ttys[0]
 		stdout = ttys[0]
@@ -187,38 +233,7 @@ func (s *execWs) Do(op *operation) error {
 					break
 				}
 
-				command := api.ContainerExecControl{}
-
-				if err := json.Unmarshal(buf, &command); err != nil {
-					logger.Debugf("Failed to unmarshal control socket command: %s", err)
-					continue
-				}
-
-				if command.Command == "window-resize" {
-					winchWidth, err := strconv.Atoi(command.Args["width"])
-					if err != nil {
-						logger.Debugf("Unable to extract window width: %s", err)
-						continue
-					}
-
-					winchHeight, err := strconv.Atoi(command.Args["height"])
-					if err != nil {
-						logger.Debugf("Unable to extract window height: %s", err)
-						continue
-					}
-
-					err = shared.SetSize(int(ptys[0].Fd()), winchWidth, winchHeight)
-					if err != nil {
-						logger.Debugf("Failed to set window size to: %dx%d", winchWidth, winchHeight)
-						continue
-					}
-				} else if command.Command == "signal" {
-					if err := syscall.Kill(attachedChildPid, syscall.Signal(command.Signal)); err != nil {
-						logger.Debugf("Failed forwarding signal '%s' to PID %d.", command.Signal, attachedChildPid)
-						continue
-					}
-					logger.Debugf("Forwarded signal '%d' to PID %d.", command.Signal, attachedChildPid)
-				}
+				execControlHandle(buf, ptys[0], attachedChildPid)
 			}
 		}()
 
@@ -296,7 +311,7 @@ func (s *execWs) Do(op *operation) error {
 		return cmdErr
 	}
 
-	cmd, _, attachedPid, err := s.container.Exec(s.command, s.env, stdin, stdout, stderr, false)
+	cmd, _, attachedPid, err := s.container.Exec(s.command, s.env, stdin, stdout, stderr, false, s.cwd, s.uid, s.gid)
 	if err != nil {
 		return err
 	}
@@ -305,30 +320,136 @@ func (s *execWs) Do(op *operation) error {
 		attachedChildIsBorn <- attachedPid
 	}
 
-	err = cmd.Wait()
+	return finisher(execExitCode(cmd.Wait()), nil)
+}
+
+// doPersistent runs an interactive command whose pty outlives the client.
+func (s *execWs) doPersistent(op *operation) error {
+	pty, tty, err := shared.OpenPty(s.ptyUid, s.ptyGid)
+	if err != nil {
+		return err
+	}
+
+	if s.width > 0 && s.height > 0 {
+		shared.SetSize(int(pty.Fd()), s.width, s.height)
+	}
+
+	cmd, _, attachedPid, err := s.container.Exec(s.command, s.env, tty, tty, tty, false, s.cwd, s.uid, s.gid)
+	if err != nil {
+		tty.Close()
+		pty.Close()
+		return err
+	}
+
+	session := &execSession{
+		id:        op.id,
+		container: s.container.Name(),
+		command:   s.command,
+		createdAt: time.Now(),
+		timeout:   s.timeout,
+		ws:        s,
+		pty:       pty,
+		pid:       attachedPid,
+	}
+
+	execSessionsLock.Lock()
+	execSessions[op.id] = session
+	execSessionsLock.Unlock()
+
+	// From now on, new connections go straight to the session
+	s.connsLock.Lock()
+	s.session = session
+	data := s.conns[0]
+	control := s.conns[-1]
+	s.connsLock.Unlock()
+
+	if control != nil {
+		session.attach(-1, control)
+	}
+	session.attach(0, data)
+
+	exited := make(chan bool, 1)
+	outputDone := session.pumpOutput(exited)
+
+	cmdResult := execExitCode(cmd.Wait())
+
+	tty.Close()
+	exited <- true
+	<-outputDone
+
+	session.finish(op, cmdResult)
+
+	execSessionsLock.Lock()
+	delete(execSessions, op.id)
+	execSessionsLock.Unlock()
+
+	pty.Close()
+
+	return nil
+}
+
+// execControlHandle applies a message received on the control socket.
+func execControlHandle(buf []byte, pty *os.File, pid int) {
+	command := api.ContainerExecControl{}
+
+	if err := json.Unmarshal(buf, &command); err != nil {
+		logger.Debugf("Failed to unmarshal control socket command: %s", err)
+		return
+	}
+
+	if command.Command == "window-resize" {
+		winchWidth, err := strconv.Atoi(command.Args["width"])
+		if err != nil {
+			logger.Debugf("Unable to extract window width: %s", err)
+			return
+		}
+
+		winchHeight, err := strconv.Atoi(command.Args["height"])
+		if err != nil {
+			logger.Debugf("Unable to extract window height: %s", err)
+			return
+		}
+
+		err = shared.SetSize(int(pty.Fd()), winchWidth, winchHeight)
+		if err != nil {
+			logger.Debugf("Failed to set window size to: %dx%d", winchWidth, winchHeight)
+			return
+		}
+	} else if command.Command == "signal" {
+		if err := syscall.Kill(pid, syscall.Signal(command.Signal)); err != nil {
+			logger.Debugf("Failed forwarding signal '%s' to PID %d.", command.Signal, pid)
+			return
+		}
+		logger.Debugf("Forwarded signal '%d' to PID %d.", command.Signal, pid)
+	}
+}
+
+// execExitCode turns the result of waiting for a command into its exit code.
+func execExitCode(err error) int {
 	if err == nil {
-		return finisher(0, nil)
+		return 0
 	}
 
 	exitErr, ok := err.(*exec.ExitError)
 	if ok {
 		status, ok := exitErr.Sys().(syscall.WaitStatus)
 		if ok {
-			return finisher(status.ExitStatus(), nil)
+			return status.ExitStatus()
 		}
 
 		if status.Signaled() {
 			// 128 + n == Fatal error signal "n"
-			return finisher(128+int(status.Signal()), nil)
+			return 128 + int(status.Signal())
 		}
 	}
 
-	return finisher(-1, nil)
+	return -1
 }
 
 func containerExecPost(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByProjectAndName(d, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -374,16 +495,17 @@ func containerExecPost(d *Daemon, r *http.Request) Response {
 		}
 	}
 
-	// Set default value for HOME
-	_, ok = env["HOME"]
-	if !ok {
-		env["HOME"] = "/root"
-	}
+	// Set default values for HOME and USER, only known for root
+	if post.User == 0 {
+		_, ok = env["HOME"]
+		if !ok {
+			env["HOME"] = "/root"
+		}
 
-	// Set default value for USER
-	_, ok = env["USER"]
-	if !ok {
-		env["USER"] = "root"
+		_, ok = env["USER"]
+		if !ok {
+			env["USER"] = "root"
+		}
 	}
 
 	// Set default value for USER
@@ -392,6 +514,14 @@ func containerExecPost(d *Daemon, r *http.Request) Response {
 		env["LANG"] = "C.UTF-8"
 	}
 
+	if post.Persistent && (!post.WaitForWS || !post.Interactive) {
+		return BadRequest(fmt.Errorf("Only interactive sessions can be persistent"))
+	}
+
+	if post.PersistentTimeout < 0 {
+		return BadRequest(fmt.Errorf("Invalid persistent session timeout"))
+	}
+
 	if post.WaitForWS {
 		ws := &execWs{}
 		ws.fds = map[int]string{}
@@ -402,7 +532,9 @@ func containerExecPost(d *Daemon, r *http.Request) Response {
 		}
 
 		if idmapset != nil {
-			ws.rootUid, ws.rootGid = idmapset.ShiftIntoNs(0, 0)
+			ws.ptyUid, ws.ptyGid = idmapset.ShiftIntoNs(int64(post.User), int64(post.Group))
+		} else {
+			ws.ptyUid, ws.ptyGid = int64(post.User), int64(post.Group)
 		}
 
 		ws.conns = map[int]*websocket.Conn{}
@@ -425,14 +557,23 @@ func containerExecPost(d *Daemon, r *http.Request) Response {
 		ws.command = post.Command
 		ws.container = c
 		ws.env = env
+		ws.cwd = post.Cwd
+		ws.uid = post.User
+		ws.gid = post.Group
 
 		ws.width = post.Width
 		ws.height = post.Height
 
+		ws.persistent = post.Persistent
+		ws.timeout = time.Duration(post.PersistentTimeout) * time.Second
+		if ws.timeout == 0 {
+			ws.timeout = execSessionDefaultTimeout * time.Second
+		}
+
 		resources := map[string][]string{}
-		resources["containers"] = []string{ws.container.Name()}
+		resources["containers"] = []string{name}
 
-		op, err := operationCreate(operationClassWebsocket, resources, ws.Metadata(), ws.Do, nil, ws.Connect)
+		op, err := operationCreate(project, r, operationClassWebsocket, resources, ws.Metadata(), ws.Do, nil, ws.Connect)
 		if err != nil {
 			return InternalError(err)
 		}
@@ -447,29 +588,29 @@ func containerExecPost(d *Daemon, r *http.Request) Response {
 
 		if post.RecordOutput {
 			// Prepare stdout and stderr recording
-			stdout, err := os.OpenFile(filepath.Join(c.LogPath(), fmt.Sprintf("exec_%s.stdout", op.id)), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+			stdout, err := os.OpenFile(filepath.Join(c.LogPath(), fmt.Sprintf("exec_%s.stdout", op.id)), os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0666)
 			if err != nil {
 				return err
 			}
 			defer stdout.Close()
 
-			stderr, err := os.OpenFile(filepath.Join(c.LogPath(), fmt.Sprintf("exec_%s.stderr", op.id)), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+			stderr, err := os.OpenFile(filepath.Join(c.LogPath(), fmt.Sprintf("exec_%s.stderr", op.id)), os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0666)
 			if err != nil {
 				return err
 			}
 			defer stderr.Close()
 
 			// Run the command
-			_, cmdResult, _, cmdErr = c.Exec(post.Command, env, nil, stdout, stderr, true)
+			_, cmdResult, _, cmdErr = c.Exec(post.Command, env, nil, stdout, stderr, true, post.Cwd, post.User, post.Group)
 
 			// Update metadata with the right URLs
 			metadata["return"] = cmdResult
 			metadata["output"] = shared.Jmap{
-				"1": fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, c.Name(), filepath.Base(stdout.Name())),
-				"2": fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, c.Name(), filepath.Base(stderr.Name())),
+				"1": projectURL(fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, name, filepath.Base(stdout.Name())), project),
+				"2": projectURL(fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, name, filepath.Base(stderr.Name())), project),
 			}
 		} else {
-			_, cmdResult, _, cmdErr = c.Exec(post.Command, env, nil, nil, nil, true)
+			_, cmdResult, _, cmdErr = c.Exec(post.Command, env, nil, nil, nil, true, post.Cwd, post.User, post.Group)
 			metadata["return"] = cmdResult
 		}
 
@@ -484,7 +625,7 @@ func containerExecPost(d *Daemon, r *http.Request) Response {
 	resources := map[string][]string{}
 	resources["containers"] = []string{name}
 
-	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
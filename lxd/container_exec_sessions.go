@@ -0,0 +1,376 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// Amount of output kept around for clients reattaching to a session
+const execSessionScrollbackSize = 64 * 1024
+
+// Default number of seconds a detached session is kept alive for
+const execSessionDefaultTimeout = 300
+
+// How long a write to a client may take before it's considered gone
+const execSessionWriteTimeout = 10 * time.Second
+
+// The persistent exec sessions, indexed by operation ID
+var execSessionsLock sync.Mutex
+var execSessions = map[string]*execSession{}
+
+// execSession is an interactive exec which outlives its client. When the
+// client goes away, the process keeps running on its pty for up to timeout
+// and the latest output is kept so it can be replayed to the next client.
+type execSession struct {
+	id        string
+	container string
+	command   []string
+	createdAt time.Time
+	timeout   time.Duration
+	ws        *execWs
+
+	pty *os.File
+	pid int
+
+	// Everything below is protected by lock
+	lock       sync.Mutex
+	scrollback []byte
+	data       *websocket.Conn
+	control    *websocket.Conn
+	expiresAt  time.Time
+	expiry     *time.Timer
+	exited     bool
+}
+
+func (s *execSession) Render() api.ContainerExecSession {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return api.ContainerExecSession{
+		ID:        s.id,
+		Command:   s.command,
+		Attached:  s.data != nil,
+		CreatedAt: s.createdAt,
+		ExpiresAt: s.expiresAt,
+	}
+}
+
+// attach hands a freshly connected websocket to the session, replacing
+// whichever client was attached before.
+func (s *execSession) attach(fd int, conn *websocket.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.exited {
+		conn.Close()
+		return
+	}
+
+	if fd == -1 {
+		if s.control != nil {
+			s.control.Close()
+		}
+		s.control = conn
+
+		go s.readControl(conn)
+		return
+	}
+
+	if s.data != nil {
+		s.data.Close()
+	}
+	s.data = conn
+
+	if s.expiry != nil {
+		s.expiry.Stop()
+		s.expiry = nil
+	}
+	s.expiresAt = time.Time{}
+
+	if len(s.scrollback) > 0 {
+		err := s.send(s.scrollback)
+		if err != nil {
+			s.detachLocked(conn)
+			return
+		}
+	}
+
+	go s.readData(conn)
+}
+
+// detach drops the client if it's still the attached one and starts the
+// countdown to the session being killed.
+func (s *execSession) detach(conn *websocket.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.detachLocked(conn)
+}
+
+func (s *execSession) detachLocked(conn *websocket.Conn) {
+	if s.data != conn || s.exited {
+		return
+	}
+
+	s.data.Close()
+	s.data = nil
+
+	if s.control != nil {
+		s.control.Close()
+		s.control = nil
+	}
+
+	logger.Debugf("Detached from exec session %s, keeping it for %s", s.id, s.timeout)
+
+	s.expiresAt = time.Now().Add(s.timeout)
+	s.expiry = time.AfterFunc(s.timeout, func() {
+		logger.Debugf("Exec session %s expired, killing pid %d", s.id, s.pid)
+		syscall.Kill(s.pid, syscall.SIGKILL)
+	})
+}
+
+// send writes a chunk of output to the attached client. The deadline makes
+// sure a client which silently vanished doesn't block the session.
+func (s *execSession) send(buf []byte) error {
+	s.data.SetWriteDeadline(time.Now().Add(execSessionWriteTimeout))
+	return s.data.WriteMessage(websocket.BinaryMessage, buf)
+}
+
+// pumpOutput records the process output and forwards it to the attached
+// client, if any. The returned channel is closed once the pty is drained.
+func (s *execSession) pumpOutput(exited chan bool) chan bool {
+	done := make(chan bool)
+
+	go func() {
+		for buf := range shared.ExecReaderToChannel(s.pty, -1, exited, int(s.pty.Fd())) {
+			s.lock.Lock()
+			s.scrollback = append(s.scrollback, buf...)
+			if len(s.scrollback) > execSessionScrollbackSize {
+				s.scrollback = s.scrollback[len(s.scrollback)-execSessionScrollbackSize:]
+			}
+
+			if s.data != nil {
+				err := s.send(buf)
+				if err != nil {
+					s.detachLocked(s.data)
+				}
+			}
+			s.lock.Unlock()
+		}
+
+		close(done)
+	}()
+
+	return done
+}
+
+// readData forwards the client input to the process. The client closing its
+// input (message barrier) detaches it, the same as losing the connection.
+func (s *execSession) readData(conn *websocket.Conn) {
+	for {
+		mt, r, err := conn.NextReader()
+		if err != nil || mt != websocket.BinaryMessage {
+			break
+		}
+
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			break
+		}
+
+		_, err = s.pty.Write(buf)
+		if err != nil {
+			break
+		}
+	}
+
+	s.detach(conn)
+}
+
+func (s *execSession) readControl(conn *websocket.Conn) {
+	for {
+		_, r, err := conn.NextReader()
+		if err != nil {
+			break
+		}
+
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			break
+		}
+
+		execControlHandle(buf, s.pty, s.pid)
+	}
+
+	s.lock.Lock()
+	if s.control == conn {
+		s.control.Close()
+		s.control = nil
+	}
+	s.lock.Unlock()
+}
+
+// finish disconnects the client once the process is gone, after the pty has
+// been drained. The exit code is set on the operation first so that the
+// client can tell an exit from a detach.
+func (s *execSession) finish(op *operation, cmdResult int) {
+	err := op.UpdateMetadata(shared.Jmap{"return": cmdResult})
+	if err != nil {
+		logger.Debugf("Failed to update exec session metadata: %s", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.exited = true
+
+	if s.expiry != nil {
+		s.expiry.Stop()
+	}
+
+	if s.data != nil {
+		s.data.SetWriteDeadline(time.Now().Add(execSessionWriteTimeout))
+		s.data.WriteMessage(websocket.TextMessage, []byte{})
+		s.data.Close()
+		s.data = nil
+	}
+
+	if s.control != nil {
+		s.control.Close()
+		s.control = nil
+	}
+}
+
+// execSessionsList returns the sessions of a container.
+func execSessionsList(name string) []*execSession {
+	execSessionsLock.Lock()
+	defer execSessionsLock.Unlock()
+
+	sessions := []*execSession{}
+	for _, session := range execSessions {
+		if session.container == name {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions
+}
+
+func execSessionGet(name string, id string) (*execSession, error) {
+	execSessionsLock.Lock()
+	session, ok := execSessions[id]
+	execSessionsLock.Unlock()
+
+	if !ok || session.container != name {
+		return nil, fmt.Errorf("Exec session '%s' doesn't exist", id)
+	}
+
+	return session, nil
+}
+
+func containerExecGet(d *Daemon, r *http.Request) Response {
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByProjectAndName(d, project, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []api.ContainerExecSession{}
+
+	for _, session := range execSessionsList(c.Name()) {
+		if recursion == 0 {
+			url := projectURL(fmt.Sprintf("/%s/containers/%s/exec/%s", version.APIVersion, name, session.id), project)
+			resultString = append(resultString, url)
+		} else {
+			resultMap = append(resultMap, session.Render())
+		}
+	}
+
+	if recursion == 0 {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+func containerExecSessionGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	id := mux.Vars(r)["id"]
+
+	if err := containerValidName(name); err != nil {
+		return BadRequest(err)
+	}
+
+	name = projectPrefix(projectParam(r), name)
+
+	session, err := execSessionGet(name, id)
+	if err != nil {
+		return NotFound
+	}
+
+	return SyncResponse(true, session.Render())
+}
+
+// containerExecSessionPost allows reattaching to a session. New secrets are
+// issued for the operation's websockets, which will then replace the
+// currently attached client, if any.
+func containerExecSessionPost(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	id := mux.Vars(r)["id"]
+
+	if err := containerValidName(name); err != nil {
+		return BadRequest(err)
+	}
+
+	name = projectPrefix(projectParam(r), name)
+
+	session, err := execSessionGet(name, id)
+	if err != nil {
+		return NotFound
+	}
+
+	op, err := operationGet(id)
+	if err != nil {
+		return NotFound
+	}
+
+	err = session.ws.renewSecrets()
+	if err != nil {
+		return InternalError(err)
+	}
+
+	err = op.UpdateMetadata(session.ws.Metadata())
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	return OperationResponse(op)
+}
+
+var containerExecSessionCmd = Command{
+	name: "containers/{name}/exec/{id}",
+	get:  containerExecSessionGet,
+	post: containerExecSessionPost,
+}
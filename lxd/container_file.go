@@ -14,8 +14,9 @@ import (
 )
 
 func containerFileHandler(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByProjectAndName(d, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -8,7 +8,8 @@ import (
 
 func containerGet(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	project := projectParam(r)
+	c, err := containerLoadByProjectAndName(d, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
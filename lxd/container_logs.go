@@ -1,18 +1,32 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	log "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
 )
 
+// How often followed log files are checked for new content
+const containerLogFollowInterval = 500 * time.Millisecond
+
+// Suffix of the copies made by log rotation (and of the previous lxc.log)
+var containerLogRotatedSuffix = regexp.MustCompile(`\.([0-9]+|old)$`)
+
 func containerLogsGet(d *Daemon, r *http.Request) Response {
 	/* Let's explicitly *not* try to do a containerLoadByName here. In some
 	 * cases (e.g. when container creation failed), the container won't
@@ -21,6 +35,7 @@ func containerLogsGet(d *Daemon, r *http.Request) Response {
 	 * However, we should check this name and ensure it's a valid container
 	 * name just so that people can't list arbitrary directories.
 	 */
+	project := projectParam(r)
 	name := mux.Vars(r)["name"]
 
 	if err := containerValidName(name); err != nil {
@@ -29,7 +44,7 @@ func containerLogsGet(d *Daemon, r *http.Request) Response {
 
 	result := []string{}
 
-	dents, err := ioutil.ReadDir(shared.LogPath(name))
+	dents, err := ioutil.ReadDir(shared.LogPath(projectPrefix(project, name)))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -39,7 +54,7 @@ func containerLogsGet(d *Daemon, r *http.Request) Response {
 			continue
 		}
 
-		result = append(result, fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, name, f.Name()))
+		result = append(result, projectURL(fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, name, f.Name()), project))
 	}
 
 	return SyncResponse(true, result)
@@ -54,8 +69,10 @@ func validLogFileName(fname string) bool {
 	/* Let's just require that the paths be relative, so that we don't have
 	 * to deal with any escaping or whatever.
 	 */
-	return fname == "lxc.log" ||
-		fname == "lxc.conf" ||
+	return fname == "lxc.conf" ||
+		strings.HasPrefix(fname, "lxc.log") ||
+		strings.HasPrefix(fname, "console.log") ||
+		strings.HasPrefix(fname, "proxy.") ||
 		strings.HasPrefix(fname, "migration_") ||
 		strings.HasPrefix(fname, "snapshot_") ||
 		strings.HasPrefix(fname, "exec_")
@@ -69,10 +86,21 @@ func containerLogGet(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
+	name = projectPrefix(projectParam(r), name)
+
 	if !validLogFileName(file) {
 		return BadRequest(fmt.Errorf("log file name %s not valid", file))
 	}
 
+	if shared.IsTrue(r.FormValue("follow")) {
+		path := shared.LogPath(name, file)
+		if !shared.PathExists(path) {
+			return NotFound
+		}
+
+		return &containerLogFollowServe{req: r, path: path}
+	}
+
 	ent := fileResponseEntry{
 		path:     shared.LogPath(name, file),
 		filename: file,
@@ -81,6 +109,106 @@ func containerLogGet(d *Daemon, r *http.Request) Response {
 	return FileResponse(r, []fileResponseEntry{ent}, nil, false)
 }
 
+type containerLogFollowServe struct {
+	req  *http.Request
+	path string
+}
+
+func (r *containerLogFollowServe) Render(w http.ResponseWriter) error {
+	return containerLogFollow(r.req, w, r.path)
+}
+
+func (r *containerLogFollowServe) String() string {
+	return "log follower"
+}
+
+// containerLogFollow sends the content of a log file over a websocket,
+// followed by every complete line appended to it. The file is read again
+// from the start when it gets replaced or truncated by rotation.
+func containerLogFollow(r *http.Request, w http.ResponseWriter, path string) error {
+	conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Notice the client going away
+	done := make(chan bool)
+	go func() {
+		for {
+			_, _, err := conn.NextReader()
+			if err != nil {
+				close(done)
+				return
+			}
+		}
+	}()
+
+	var file *os.File
+	var info os.FileInfo
+	var offset int64
+	var partial []byte
+
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		current, err := os.Stat(path)
+		if err == nil && (file == nil || !os.SameFile(info, current) || current.Size() < offset) {
+			if file != nil {
+				file.Close()
+				file = nil
+			}
+
+			// Don't hold back the end of the previous file
+			if len(partial) > 0 {
+				err = conn.WriteMessage(websocket.BinaryMessage, partial)
+				if err != nil {
+					return nil
+				}
+				partial = nil
+			}
+
+			file, err = os.Open(path)
+			if err == nil {
+				info = current
+				offset = 0
+			}
+		}
+
+		if file != nil {
+			for {
+				n, err := file.Read(buf)
+				offset += int64(n)
+				partial = append(partial, buf[:n]...)
+				if err != nil || n == 0 {
+					break
+				}
+			}
+
+			// Only send complete lines
+			end := bytes.LastIndex(partial, []byte("\n"))
+			if end >= 0 {
+				err = conn.WriteMessage(websocket.BinaryMessage, partial[:end+1])
+				if err != nil {
+					return nil
+				}
+				partial = append([]byte{}, partial[end+1:]...)
+			}
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-time.After(containerLogFollowInterval):
+		}
+	}
+}
+
 func containerLogDelete(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 	file := mux.Vars(r)["file"]
@@ -89,6 +217,8 @@ func containerLogDelete(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
+	name = projectPrefix(projectParam(r), name)
+
 	if !validLogFileName(file) {
 		return BadRequest(fmt.Errorf("log file name %s not valid", file))
 	}
@@ -96,6 +226,96 @@ func containerLogDelete(d *Daemon, r *http.Request) Response {
 	return SmartError(os.Remove(shared.LogPath(name, file)))
 }
 
+// containerLogRotatable tells whether a log file is subject to rotation. The
+// LXC config, the console log (rotated by LXC) and copies are left alone.
+func containerLogRotatable(fname string) bool {
+	if fname == "lxc.conf" || strings.HasPrefix(fname, "console.log") {
+		return false
+	}
+
+	return !containerLogRotatedSuffix.MatchString(fname)
+}
+
+// containerLogRotate moves the content of a log file to <path>.1, shifting
+// the previous copies and keeping at most count of them. The file is copied
+// and truncated rather than renamed as it may still be open for writing.
+func containerLogRotate(path string, count int) error {
+	// Drop the copies past the retention, including any left over from
+	// a higher count
+	copies, err := filepath.Glob(fmt.Sprintf("%s.*", path))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range copies {
+		i, err := strconv.Atoi(strings.TrimPrefix(entry, fmt.Sprintf("%s.", path)))
+		if err != nil || i < count {
+			continue
+		}
+
+		err = os.Remove(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	if count > 0 {
+		for i := count - 1; i > 0; i-- {
+			src := fmt.Sprintf("%s.%d", path, i)
+			if !shared.PathExists(src) {
+				continue
+			}
+
+			err := os.Rename(src, fmt.Sprintf("%s.%d", path, i+1))
+			if err != nil {
+				return err
+			}
+		}
+
+		err := shared.FileCopy(path, fmt.Sprintf("%s.1", path))
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Truncate(path, 0)
+}
+
+// rotateContainerLogs rotates the log files of all containers which grew
+// past logs.rotate_size.
+func rotateContainerLogs(d *Daemon) {
+	maxSize, err := shared.ParseByteSizeString(daemonConfig["logs.rotate_size"].Get())
+	if err != nil || maxSize <= 0 {
+		return
+	}
+
+	count := int(daemonConfig["logs.rotate_count"].GetInt64())
+
+	names, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		logger.Error("Unable to retrieve the list of containers", log.Ctx{"err": err})
+		return
+	}
+
+	for _, name := range names {
+		logs, err := ioutil.ReadDir(shared.LogPath(name))
+		if err != nil {
+			continue
+		}
+
+		for _, logfile := range logs {
+			if !logfile.Mode().IsRegular() || logfile.Size() <= maxSize || !containerLogRotatable(logfile.Name()) {
+				continue
+			}
+
+			err := containerLogRotate(shared.LogPath(name, logfile.Name()), count)
+			if err != nil {
+				logger.Error("Failed to rotate log file", log.Ctx{"container": name, "file": logfile.Name(), "err": err})
+			}
+		}
+	}
+}
+
 var containerLogCmd = Command{
 	name:   "containers/{name}/logs/{file}",
 	get:    containerLogGet,
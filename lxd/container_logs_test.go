@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_containerLogRotatable(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"lxc.log":                 true,
+		"exec_1234.stdout":        true,
+		"proxy.http.log":          true,
+		"lxc.conf":                false,
+		"console.log":             false,
+		"lxc.log.old":             false,
+		"lxc.log.1":               false,
+		"exec_1234.stdout.12":     false,
+		"migration_dump_1234.log": true,
+	} {
+		if containerLogRotatable(name) != expected {
+			t.Errorf("Unexpected rotation status for %s, expected %v", name, expected)
+		}
+	}
+}
+
+func Test_containerLogRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lxd_test_logs_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "lxc.log")
+
+	// Rotate four times with a retention of two, plus a leftover copy
+	err = ioutil.WriteFile(path+".5", []byte("stale"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 4; i++ {
+		err = ioutil.WriteFile(path, []byte(fmt.Sprintf("content %d", i)), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = containerLogRotate(path, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		"lxc.log":   "",
+		"lxc.log.1": "content 4",
+		"lxc.log.2": "content 3",
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(entries))
+	}
+
+	for name, content := range expected {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(buf) != content {
+			t.Errorf("Unexpected content for %s: %q, expected %q", name, buf, content)
+		}
+	}
+}
@@ -242,11 +242,13 @@ func containerLXCCreate(d *Daemon, args containerArgs) (container, error) {
 		id:           args.Id,
 		name:         args.Name,
 		description:  args.Description,
+		project:      args.Project,
 		ephemeral:    args.Ephemeral,
 		architecture: args.Architecture,
 		cType:        args.Ctype,
 		stateful:     args.Stateful,
 		creationDate: args.CreationDate,
+		expiryDate:   args.ExpiryDate,
 		lastUsedDate: args.LastUsedDate,
 		profiles:     args.Profiles,
 		localConfig:  args.Config,
@@ -310,7 +312,7 @@ func containerLXCCreate(d *Daemon, args containerArgs) (container, error) {
 	}
 
 	// Create a new database entry for the container's storage volume
-	_, err = dbStoragePoolVolumeCreate(d.db, args.Name, "", storagePoolVolumeTypeContainer, poolID, volumeConfig)
+	_, err = dbStoragePoolVolumeCreate(d.db, args.Project, args.Name, "", storagePoolVolumeTypeContainer, poolID, volumeConfig)
 	if err != nil {
 		c.Delete()
 		return nil, err
@@ -408,10 +410,12 @@ func containerLXCLoad(d *Daemon, args containerArgs) (container, error) {
 		id:           args.Id,
 		name:         args.Name,
 		description:  args.Description,
+		project:      args.Project,
 		ephemeral:    args.Ephemeral,
 		architecture: args.Architecture,
 		cType:        args.Ctype,
 		creationDate: args.CreationDate,
+		expiryDate:   args.ExpiryDate,
 		lastUsedDate: args.LastUsedDate,
 		profiles:     args.Profiles,
 		localConfig:  args.Config,
@@ -434,11 +438,13 @@ type containerLXC struct {
 	architecture int
 	cType        containerType
 	creationDate time.Time
+	expiryDate   time.Time
 	lastUsedDate time.Time
 	ephemeral    bool
 	id           int
 	name         string
 	description  string
+	project      string
 	stateful     bool
 
 	// Config
@@ -976,8 +982,27 @@ func (c *containerLXC) initLXC() error {
 		return err
 	}
 
+	// Keep the console output in a ring buffer and in a log file, which
+	// remains available once the container is stopped
+	if lxc.VersionAtLeast(3, 0, 0) {
+		err = lxcSetConfigItem(cc, "lxc.console.buffer.size", "auto")
+		if err != nil {
+			return err
+		}
+
+		err = lxcSetConfigItem(cc, "lxc.console.size", "auto")
+		if err != nil {
+			return err
+		}
+
+		err = lxcSetConfigItem(cc, "lxc.console.logfile", c.ConsoleBufferLogPath())
+		if err != nil {
+			return err
+		}
+	}
+
 	// Setup the hostname
-	err = lxcSetConfigItem(cc, "lxc.uts.name", c.Name())
+	err = lxcSetConfigItem(cc, "lxc.uts.name", projectUnprefix(c.project, c.Name()))
 	if err != nil {
 		return err
 	}
@@ -1499,9 +1524,14 @@ func (c *containerLXC) initStorage() error {
 func (c *containerLXC) expandConfig() error {
 	config := map[string]string{}
 
+	profilesProject, err := projectProfilesProject(c.daemon.db, c.project)
+	if err != nil {
+		return err
+	}
+
 	// Apply all the profiles
 	for _, name := range c.profiles {
-		profileConfig, err := dbProfileConfig(c.daemon.db, name)
+		profileConfig, err := dbProfileConfig(c.daemon.db, profilesProject, name)
 		if err != nil {
 			return err
 		}
@@ -1523,9 +1553,14 @@ func (c *containerLXC) expandConfig() error {
 func (c *containerLXC) expandDevices() error {
 	devices := types.Devices{}
 
+	profilesProject, err := projectProfilesProject(c.daemon.db, c.project)
+	if err != nil {
+		return err
+	}
+
 	// Apply all the profiles
 	for _, p := range c.profiles {
-		profileDevices, err := dbDevices(c.daemon.db, p, true)
+		profileDevices, err := dbDevices(c.daemon.db, profilesProject, p, true)
 		if err != nil {
 			return err
 		}
@@ -2022,7 +2057,15 @@ func (c *containerLXC) Start(stateful bool) error {
 			return fmt.Errorf("Container has no existing state to restore.")
 		}
 
-		err := c.Migrate(lxc.MIGRATE_RESTORE, c.StatePath(), "snapshot", false, false)
+		criuMigrationArgs := CriuMigrationArgs{
+			cmd:          lxc.MIGRATE_RESTORE,
+			stateDir:     c.StatePath(),
+			function:     "snapshot",
+			stop:         false,
+			actionScript: false,
+		}
+
+		err := c.Migrate(&criuMigrationArgs)
 		if err != nil && !c.IsRunning() {
 			return err
 		}
@@ -2036,7 +2079,15 @@ func (c *containerLXC) Start(stateful bool) error {
 			return err
 		}
 
+		// Start the proxy devices
+		err = c.startProxyDevices()
+		if err != nil {
+			logger.Error("Failed starting container", ctxMap)
+			return err
+		}
+
 		logger.Info("Started container", ctxMap)
+		eventSendLifecycle("container-started", containerURL(c.project, c.name), nil)
 
 		return err
 	} else if c.stateful {
@@ -2102,7 +2153,15 @@ func (c *containerLXC) Start(stateful bool) error {
 		return err
 	}
 
+	// Start the proxy devices
+	err = c.startProxyDevices()
+	if err != nil {
+		logger.Error("Failed starting container", ctxMap)
+		return err
+	}
+
 	logger.Info("Started container", ctxMap)
+	eventSendLifecycle("container-started", containerURL(c.project, c.name), nil)
 
 	return nil
 }
@@ -2240,7 +2299,15 @@ func (c *containerLXC) Stop(stateful bool) error {
 		}
 
 		// Checkpoint
-		err = c.Migrate(lxc.MIGRATE_DUMP, stateDir, "snapshot", true, false)
+		criuMigrationArgs := CriuMigrationArgs{
+			cmd:          lxc.MIGRATE_DUMP,
+			stateDir:     stateDir,
+			function:     "snapshot",
+			stop:         true,
+			actionScript: false,
+		}
+
+		err = c.Migrate(&criuMigrationArgs)
 		if err != nil {
 			op.Done(err)
 			logger.Error("Failed stopping container", ctxMap)
@@ -2419,8 +2486,16 @@ func (c *containerLXC) OnStop(target string) error {
 			logger.Error("Unable to remove network filters", log.Ctx{"container": c.Name(), "err": err})
 		}
 
+		// Stop all the proxy devices
+		err = c.removeProxyDevices()
+		if err != nil {
+			logger.Error("Unable to remove proxy devices", log.Ctx{"container": c.Name(), "err": err})
+		}
+
 		// Reboot the container
 		if target == "reboot" {
+			eventSendLifecycle("container-restarted", containerURL(c.project, c.name), nil)
+
 			// Start the container again
 			err = c.Start(false)
 			return
@@ -2435,6 +2510,8 @@ func (c *containerLXC) OnStop(target string) error {
 			logger.Error("Failed to set container state", log.Ctx{"container": c.Name(), "err": err})
 		}
 
+		eventSendLifecycle("container-stopped", containerURL(c.project, c.name), nil)
+
 		// Destroy ephemeral containers
 		if c.ephemeral {
 			err = c.Delete()
@@ -2563,8 +2640,9 @@ func (c *containerLXC) Render() (interface{}, interface{}, error) {
 			Ephemeral:       c.ephemeral,
 			ExpandedConfig:  c.expandedConfig,
 			ExpandedDevices: c.expandedDevices,
+			ExpiryDate:      c.expiryDate,
 			LastUsedDate:    c.lastUsedDate,
-			Name:            c.name,
+			Name:            projectUnprefix(c.project, c.name),
 			Profiles:        c.profiles,
 			Stateful:        c.stateful,
 		}, etag, nil
@@ -2579,7 +2657,7 @@ func (c *containerLXC) Render() (interface{}, interface{}, error) {
 		ct := api.Container{
 			ExpandedConfig:  c.expandedConfig,
 			ExpandedDevices: c.expandedDevices,
-			Name:            c.name,
+			Name:            projectUnprefix(c.project, c.name),
 			Status:          statusCode.String(),
 			StatusCode:      statusCode,
 		}
@@ -2745,7 +2823,15 @@ func (c *containerLXC) Restore(sourceContainer container) error {
 	// it as running?
 	if shared.PathExists(c.StatePath()) {
 		logger.Debug("Performing stateful restore", ctxMap)
-		err := c.Migrate(lxc.MIGRATE_RESTORE, c.StatePath(), "snapshot", false, false)
+		criuMigrationArgs := CriuMigrationArgs{
+			cmd:          lxc.MIGRATE_RESTORE,
+			stateDir:     c.StatePath(),
+			function:     "snapshot",
+			stop:         false,
+			actionScript: false,
+		}
+
+		err := c.Migrate(&criuMigrationArgs)
 		if err != nil {
 			return err
 		}
@@ -2831,6 +2917,9 @@ func (c *containerLXC) Delete() error {
 				return err
 			}
 		}
+
+		// Remove the container's backups
+		os.RemoveAll(shared.VarPath("backups", c.Name()))
 	}
 
 	// Remove the database record
@@ -2870,6 +2959,12 @@ func (c *containerLXC) Delete() error {
 
 	logger.Info("Deleted container", ctxMap)
 
+	if c.IsSnapshot() {
+		eventSendLifecycle("container-snapshot-deleted", containerURL(c.project, c.name), nil)
+	} else {
+		eventSendLifecycle("container-deleted", containerURL(c.project, c.name), nil)
+	}
+
 	return nil
 }
 
@@ -2890,7 +2985,7 @@ func (c *containerLXC) Rename(newName string) error {
 	}
 
 	// Sanity checks
-	if !c.IsSnapshot() && !shared.ValidHostname(newName) {
+	if !c.IsSnapshot() && !shared.ValidHostname(projectUnprefix(c.project, newName)) {
 		return fmt.Errorf("Invalid container name")
 	}
 
@@ -2911,6 +3006,15 @@ func (c *containerLXC) Rename(newName string) error {
 		}
 	}
 
+	// Rename the backups path
+	if !c.IsSnapshot() && shared.PathExists(shared.VarPath("backups", oldName)) {
+		err := os.Rename(shared.VarPath("backups", oldName), shared.VarPath("backups", newName))
+		if err != nil {
+			logger.Error("Failed renaming container", ctxMap)
+			return err
+		}
+	}
+
 	// Rename the storage entry
 	if c.IsSnapshot() {
 		err := c.storage.ContainerSnapshotRename(c, newName)
@@ -2980,6 +3084,12 @@ func (c *containerLXC) Rename(newName string) error {
 
 	logger.Info("Renamed container", ctxMap)
 
+	if c.IsSnapshot() {
+		eventSendLifecycle("container-snapshot-renamed", containerURL(c.project, oldName), map[string]interface{}{"new_name": projectUnprefix(c.project, newName)})
+	} else {
+		eventSendLifecycle("container-renamed", containerURL(c.project, oldName), map[string]interface{}{"new_name": projectUnprefix(c.project, newName)})
+	}
+
 	return nil
 }
 
@@ -3154,7 +3264,12 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 	}
 
 	// Validate the new profiles
-	profiles, err := dbProfiles(c.daemon.db)
+	profilesProject, err := projectProfilesProject(c.daemon.db, c.project)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := dbProfiles(c.daemon.db, profilesProject)
 	if err != nil {
 		return err
 	}
@@ -3436,7 +3551,7 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 	if newRootDiskDeviceSize != oldRootDiskDeviceSize {
 		storageTypeName := c.storage.GetStorageTypeName()
 		storageIsReady := c.storage.ContainerStorageReady(c.Name())
-		if storageTypeName == "lvm" && isRunning || !storageIsReady {
+		if (storageTypeName == "lvm" || storageTypeName == "ceph") && isRunning || !storageIsReady {
 			c.localConfig["volatile.apply_quota"] = newRootDiskDeviceSize
 		} else {
 			size, err := shared.ParseByteSizeString(newRootDiskDeviceSize)
@@ -3729,6 +3844,11 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 				if err != nil {
 					return err
 				}
+			} else if m["type"] == "proxy" {
+				err = c.stopProxyDevice(k)
+				if err != nil {
+					return err
+				}
 			} else if m["type"] == "usb" {
 				if usbs == nil {
 					usbs, err = deviceLoadUsb()
@@ -3821,6 +3941,11 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 				if err != nil {
 					return err
 				}
+			} else if m["type"] == "proxy" {
+				err = c.startProxyDevice(k, m)
+				if err != nil {
+					return err
+				}
 			} else if m["type"] == "usb" {
 				if usbs == nil {
 					usbs, err = deviceLoadUsb()
@@ -3996,7 +4121,7 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 		return err
 	}
 
-	err = dbContainerProfilesInsert(tx, c.id, c.profiles)
+	err = dbContainerProfilesInsert(tx, c.id, profilesProject, c.profiles)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -4303,14 +4428,16 @@ func getCRIULogErrors(imagesDir string, method string) (string, error) {
 	return strings.Join(ret, "\n"), nil
 }
 
-func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop bool, actionScript bool) error {
+func (c *containerLXC) Migrate(args *CriuMigrationArgs) error {
 	ctxMap := log.Ctx{"name": c.name,
 		"created":      c.creationDate,
 		"ephemeral":    c.ephemeral,
 		"used":         c.lastUsedDate,
-		"statedir":     stateDir,
-		"actionscript": actionScript,
-		"stop":         stop}
+		"statedir":     args.stateDir,
+		"actionscript": args.actionScript,
+		"predumpdir":   args.preDumpDir,
+		"features":     args.features,
+		"stop":         args.stop}
 
 	_, err := exec.LookPath("criu")
 	if err != nil {
@@ -4326,16 +4453,25 @@ func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop
 	}
 
 	prettyCmd := ""
-	switch cmd {
+	switch args.cmd {
 	case lxc.MIGRATE_PRE_DUMP:
 		prettyCmd = "pre-dump"
 	case lxc.MIGRATE_DUMP:
 		prettyCmd = "dump"
 	case lxc.MIGRATE_RESTORE:
 		prettyCmd = "restore"
+	case lxc.MIGRATE_FEATURE_CHECK:
+		prettyCmd = "feature-check"
 	default:
 		prettyCmd = "unknown"
-		logger.Warn("unknown migrate call", log.Ctx{"cmd": cmd})
+		logger.Warn("unknown migrate call", log.Ctx{"cmd": args.cmd})
+	}
+
+	// The images of a dump may be kept in a subdirectory of the state
+	// directory, next to the ones of the pre-dumps it's based on.
+	imagesDir := args.stateDir
+	if args.dumpDir != "" {
+		imagesDir = filepath.Join(args.stateDir, args.dumpDir)
 	}
 
 	preservesInodes := c.storage.PreservesInodes()
@@ -4352,7 +4488,7 @@ func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop
 	 * instead of having it be a child of LXD, so let's hijack the command
 	 * here and do the extra fork.
 	 */
-	if cmd == lxc.MIGRATE_RESTORE {
+	if args.cmd == lxc.MIGRATE_RESTORE {
 		// Run the shared start
 		_, err := c.startCommon()
 		if err != nil {
@@ -4376,7 +4512,7 @@ func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop
 				return err
 			}
 
-			err = idmapset.ShiftRootfs(stateDir)
+			err = idmapset.ShiftRootfs(args.stateDir)
 			if ourStart {
 				_, err2 := c.StorageStop()
 				if err != nil {
@@ -4398,7 +4534,7 @@ func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop
 			c.name,
 			c.daemon.lxcpath,
 			configPath,
-			stateDir,
+			imagesDir,
 			fmt.Sprintf("%v", preservesInodes))
 
 		if out != "" {
@@ -4407,6 +4543,18 @@ func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop
 			}
 		}
 
+	} else if args.cmd == lxc.MIGRATE_FEATURE_CHECK {
+		err := c.initLXC()
+		if err != nil {
+			return err
+		}
+
+		opts := lxc.MigrateOptions{
+			FeaturesToCheck: args.features,
+		}
+
+		// Nothing gets written to disk, so there's no log to collect
+		return c.c.Migrate(args.cmd, opts)
 	} else {
 		err := c.initLXC()
 		if err != nil {
@@ -4414,8 +4562,14 @@ func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop
 		}
 
 		script := ""
-		if actionScript {
-			script = filepath.Join(stateDir, "action.sh")
+		if args.actionScript {
+			script = filepath.Join(args.stateDir, "action.sh")
+		}
+
+		// CRIU expects the previous pre-dump relative to the images
+		preDumpDir := ""
+		if args.preDumpDir != "" {
+			preDumpDir = fmt.Sprintf("../%s", args.preDumpDir)
 		}
 
 		// TODO: make this configurable? Ultimately I think we don't
@@ -4427,27 +4581,28 @@ func (c *containerLXC) Migrate(cmd uint, stateDir string, function string, stop
 		ghostLimit := uint64(256 * 1024 * 1024)
 
 		opts := lxc.MigrateOptions{
-			Stop:            stop,
-			Directory:       stateDir,
+			Stop:            args.stop,
+			Directory:       imagesDir,
 			Verbose:         true,
 			PreservesInodes: preservesInodes,
 			ActionScript:    script,
 			GhostLimit:      ghostLimit,
+			PredumpDir:      preDumpDir,
 		}
 
-		migrateErr = c.c.Migrate(cmd, opts)
+		migrateErr = c.c.Migrate(args.cmd, opts)
 	}
 
-	collectErr := collectCRIULogFile(c, stateDir, function, prettyCmd)
+	collectErr := collectCRIULogFile(c, imagesDir, args.function, prettyCmd)
 	if collectErr != nil {
 		logger.Error("Error collecting checkpoint log file", log.Ctx{"err": collectErr})
 	}
 
 	if migrateErr != nil {
-		log, err2 := getCRIULogErrors(stateDir, prettyCmd)
+		log, err2 := getCRIULogErrors(imagesDir, prettyCmd)
 		if err2 == nil {
 			logger.Info("Failed migrating container", ctxMap)
-			migrateErr = fmt.Errorf("%s %s failed\n%s", function, prettyCmd, log)
+			migrateErr = fmt.Errorf("%s %s failed\n%s", args.function, prettyCmd, log)
 		}
 
 		return migrateErr
@@ -4576,7 +4731,7 @@ func (c *containerLXC) templateApplyNow(trigger string) error {
 
 		// Generate the metadata
 		containerMeta := make(map[string]string)
-		containerMeta["name"] = c.name
+		containerMeta["name"] = projectUnprefix(c.project, c.name)
 		containerMeta["architecture"] = arch
 
 		if c.ephemeral {
@@ -4927,14 +5082,14 @@ func (c *containerLXC) FileRemove(path string) error {
 	return nil
 }
 
-func (c *containerLXC) Exec(command []string, env map[string]string, stdin *os.File, stdout *os.File, stderr *os.File, wait bool) (*exec.Cmd, int, int, error) {
+func (c *containerLXC) Exec(command []string, env map[string]string, stdin *os.File, stdout *os.File, stderr *os.File, wait bool, cwd string, uid uint32, gid uint32) (*exec.Cmd, int, int, error) {
 	envSlice := []string{}
 
 	for k, v := range env {
 		envSlice = append(envSlice, fmt.Sprintf("%s=%s", k, v))
 	}
 
-	args := []string{execPath, "forkexec", c.name, c.daemon.lxcpath, filepath.Join(c.LogPath(), "lxc.conf")}
+	args := []string{execPath, "forkexec", c.name, c.daemon.lxcpath, filepath.Join(c.LogPath(), "lxc.conf"), cwd, fmt.Sprintf("%d", uid), fmt.Sprintf("%d", gid)}
 
 	args = append(args, "--")
 	args = append(args, "env")
@@ -4998,6 +5153,34 @@ func (c *containerLXC) Exec(command []string, env map[string]string, stdin *os.F
 	return nil, 0, attachedPid, nil
 }
 
+func (c *containerLXC) Console(terminal *os.File) *exec.Cmd {
+	args := []string{execPath, "forkconsole", c.name, c.daemon.lxcpath, filepath.Join(c.LogPath(), "lxc.conf"), "0", "-1"}
+
+	cmd := exec.Cmd{}
+	cmd.Path = execPath
+	cmd.Args = args
+	cmd.Stdin = terminal
+	cmd.Stdout = terminal
+	cmd.Stderr = terminal
+
+	return &cmd
+}
+
+func (c *containerLXC) ConsoleLog(opts lxc.ConsoleLogOptions) (string, error) {
+	// Load the go-lxc struct
+	err := c.initLXC()
+	if err != nil {
+		return "", err
+	}
+
+	msg, err := c.c.ConsoleLog(opts)
+	if err != nil {
+		return "", err
+	}
+
+	return string(msg), nil
+}
+
 func (c *containerLXC) cpuState() api.ContainerStateCPU {
 	cpu := api.ContainerStateCPU{}
 
@@ -6222,6 +6405,8 @@ func (c *containerLXC) createDiskDevice(name string, m types.Device) (string, er
 			volumeTypeName = storagePoolVolumeTypeNameCustom
 			fallthrough
 		case storagePoolVolumeTypeNameCustom:
+			// Custom volumes are looked up in the container's project
+			volumeName = projectPrefix(c.project, volumeName)
 			srcPath = shared.VarPath("storage-pools", m["pool"], volumeTypeName, volumeName)
 		case storagePoolVolumeTypeNameImage:
 			return "", fmt.Errorf("Using image storage volumes is not supported.")
@@ -6436,6 +6621,136 @@ func (c *containerLXC) removeDiskDevices() error {
 	return nil
 }
 
+// Proxy devices
+func (c *containerLXC) startProxyDevice(name string, m types.Device) error {
+	// The host side is where LXD itself runs
+	listenPid := 0
+	connectPid := c.InitPID()
+	if m["bind"] == "container" {
+		listenPid = c.InitPID()
+		connectPid = 0
+	}
+
+	if !shared.PathExists(c.DevicesPath()) {
+		err := os.Mkdir(c.DevicesPath(), 0711)
+		if err != nil {
+			return err
+		}
+	}
+
+	logPath := filepath.Join(c.LogPath(), fmt.Sprintf("proxy.%s.log", name))
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(
+		execPath,
+		"forkproxy",
+		fmt.Sprintf("%d", listenPid),
+		m["listen"],
+		fmt.Sprintf("%d", connectPid),
+		m["connect"])
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("Failed to start proxy device \"%s\": %s", name, err)
+	}
+
+	pidPath := filepath.Join(c.DevicesPath(), fmt.Sprintf("proxy.%s", name))
+	err = ioutil.WriteFile(pidPath, []byte(fmt.Sprintf("%d\n", cmd.Process.Pid)), 0600)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	go func() {
+		err := cmd.Wait()
+		if err != nil {
+			logger.Error("Proxy device exited", log.Ctx{"container": c.Name(), "device": name, "err": err, "log": logPath})
+		}
+	}()
+
+	return nil
+}
+
+func (c *containerLXC) startProxyDevices() error {
+	for _, name := range c.expandedDevices.DeviceNames() {
+		m := c.expandedDevices[name]
+		if m["type"] != "proxy" {
+			continue
+		}
+
+		err := c.startProxyDevice(name, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *containerLXC) stopProxyDevice(name string) error {
+	pidPath := filepath.Join(c.DevicesPath(), fmt.Sprintf("proxy.%s", name))
+	if !shared.PathExists(pidPath) {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(pidPath)
+	if err != nil {
+		return err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return err
+	}
+
+	// Make sure the pid wasn't recycled since the proxy was started
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err == nil && strings.Contains(string(cmdline), "forkproxy") {
+		err = syscall.Kill(pid, syscall.SIGTERM)
+		if err != nil && err != syscall.ESRCH {
+			return err
+		}
+	}
+
+	return os.Remove(pidPath)
+}
+
+func (c *containerLXC) removeProxyDevices() error {
+	// Check that we indeed have devices to remove
+	if !shared.PathExists(c.DevicesPath()) {
+		return nil
+	}
+
+	// Load the directory listing
+	dents, err := ioutil.ReadDir(c.DevicesPath())
+	if err != nil {
+		return err
+	}
+
+	// Go through all the proxy devices
+	for _, f := range dents {
+		// Skip non-proxy devices
+		if !strings.HasPrefix(f.Name(), "proxy.") {
+			continue
+		}
+
+		err := c.stopProxyDevice(strings.TrimPrefix(f.Name(), "proxy."))
+		if err != nil {
+			logger.Error("Failed to stop proxy device", log.Ctx{"err": err, "device": f.Name()})
+		}
+	}
+
+	return nil
+}
+
 // Block I/O limits
 func (c *containerLXC) getDiskLimits() (map[string]deviceBlockLimit, error) {
 	result := map[string]deviceBlockLimit{}
@@ -6889,6 +7204,10 @@ func (c *containerLXC) Name() string {
 	return c.name
 }
 
+func (c *containerLXC) Project() string {
+	return c.project
+}
+
 func (c *containerLXC) Description() string {
 	return c.description
 }
@@ -6928,6 +7247,10 @@ func (c *containerLXC) LogFilePath() string {
 	return filepath.Join(c.LogPath(), "lxc.log")
 }
 
+func (c *containerLXC) ConsoleBufferLogPath() string {
+	return filepath.Join(c.LogPath(), "console.log")
+}
+
 func (c *containerLXC) RootfsPath() string {
 	return filepath.Join(c.Path(), "rootfs")
 }
@@ -17,7 +17,8 @@ import (
 func containerPatch(d *Daemon, r *http.Request) Response {
 	// Get the container
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	project := projectParam(r)
+	c, err := containerLoadByProjectAndName(d, project, name)
 	if err != nil {
 		return NotFound
 	}
@@ -23,7 +23,8 @@ import (
 func containerPut(d *Daemon, r *http.Request) Response {
 	// Get the container
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	project := projectParam(r)
+	c, err := containerLoadByProjectAndName(d, project, name)
 	if err != nil {
 		return NotFound
 	}
@@ -69,14 +70,14 @@ func containerPut(d *Daemon, r *http.Request) Response {
 	} else {
 		// Snapshot Restore
 		do = func(op *operation) error {
-			return containerSnapRestore(d, name, configRaw.Restore)
+			return containerSnapRestore(d, project, name, configRaw.Restore)
 		}
 	}
 
 	resources := map[string][]string{}
 	resources["containers"] = []string{name}
 
-	op, err := operationCreate(operationClassTask, resources, nil, do, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, do, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -84,7 +85,7 @@ func containerPut(d *Daemon, r *http.Request) Response {
 	return OperationResponse(op)
 }
 
-func containerSnapRestore(d *Daemon, name string, snap string) error {
+func containerSnapRestore(d *Daemon, project string, name string, snap string) error {
 	// normalize snapshot name
 	if !shared.IsSnapshot(snap) {
 		snap = name + shared.SnapshotDelimiter + snap
@@ -96,7 +97,7 @@ func containerSnapRestore(d *Daemon, name string, snap string) error {
 			"snapshot":  snap,
 			"container": name})
 
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByProjectAndName(d, project, name)
 	if err != nil {
 		logger.Error(
 			"RESTORE => loadcontainerLXD() failed",
@@ -106,7 +107,7 @@ func containerSnapRestore(d *Daemon, name string, snap string) error {
 		return err
 	}
 
-	source, err := containerLoadByName(d, snap)
+	source, err := containerLoadByProjectAndName(d, project, snap)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
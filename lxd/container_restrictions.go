@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/types"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+/*
+ * Restricted clients, i.e. unprivileged local users and clients using a
+ * restricted certificate, can only create and modify containers and profiles
+ * with configurations which don't give them access to the host.
+ */
+
+// Container config keys which restricted clients can't set, on top of the
+// volatile.* and raw.* ones and security.privileged
+var restrictedForbiddenKeys = []string{
+	"linux.kernel_modules",
+	"security.idmap.base",
+}
+
+// Decodes the JSON body of a request, leaving it readable by its handler.
+func restrictedRequestBody(r *http.Request, req interface{}) error {
+	if !isJSONRequest(r) {
+		return fmt.Errorf("Restricted clients can only modify containers and profiles with JSON requests")
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = shared.BytesReadCloser{Buf: bytes.NewBuffer(body)}
+
+	return json.Unmarshal(body, req)
+}
+
+// Checks the config, devices and profiles of a new container. The caller is
+// responsible for checking that the source of a copy may be accessed.
+func restrictedCheckCreate(d *Daemon, project string, req api.ContainersPost) error {
+	err := restrictedCheckConfig(req.Config, req.Devices, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	profiles := req.Profiles
+
+	switch req.Source.Type {
+	case "image", "none":
+	case "copy":
+		// The copy gets the config and devices of its source
+		source, err := containerLoadByProjectAndName(d, project, req.Source.Source)
+		if err != nil {
+			return err
+		}
+
+		err = restrictedCheckConfig(source.LocalConfig(), source.LocalDevices(), nil, nil)
+		if err != nil {
+			return err
+		}
+
+		if profiles == nil {
+			profiles = source.Profiles()
+		}
+	default:
+		return fmt.Errorf("Restricted clients can't create containers from '%s' sources", req.Source.Type)
+	}
+
+	if profiles == nil {
+		profiles = []string{"default"}
+	}
+
+	return restrictedCheckProfiles(d, project, profiles, nil)
+}
+
+// Checks the new config, devices and profiles of an existing container.
+func restrictedCheckUpdate(d *Daemon, project string, name string, req api.ContainerPut) error {
+	// Snapshots were taken from a valid config
+	if req.Restore != "" {
+		return nil
+	}
+
+	// Missing containers are reported by the handler
+	c, err := containerLoadByProjectAndName(d, project, name)
+	if err == sql.ErrNoRows {
+		return restrictedCheckConfig(req.Config, req.Devices, nil, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = restrictedCheckConfig(req.Config, req.Devices, c.LocalConfig(), c.LocalDevices())
+	if err != nil {
+		return err
+	}
+
+	return restrictedCheckProfiles(d, project, req.Profiles, c.Profiles())
+}
+
+// Checks the profiles a container is going to use, apart from those it's
+// already using.
+func restrictedCheckProfiles(d *Daemon, project string, profiles []string, current []string) error {
+	profilesProject, err := projectProfilesProject(d.db, project)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range profiles {
+		if shared.StringInSlice(name, current) {
+			continue
+		}
+
+		_, profile, err := dbProfileGet(d.db, profilesProject, name)
+		if err != nil {
+			// Let the handler report missing profiles
+			continue
+		}
+
+		err = restrictedCheckConfig(profile.Config, profile.Devices, nil, nil)
+		if err != nil {
+			return fmt.Errorf("Profile '%s': %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// restrictedCheckConfig returns an error when a container or profile config
+// or its devices would give a restricted client access to the host. Values
+// which are unchanged from the old config and devices are accepted as is, so
+// that clients can keep using containers set up for them by an
+// administrator. Volatile keys, which include the idmap and the owner of the
+// container, can only be set by LXD itself.
+func restrictedCheckConfig(config map[string]string, devices types.Devices, oldConfig map[string]string, oldDevices types.Devices) error {
+	for key, value := range config {
+		if value == oldConfig[key] {
+			continue
+		}
+
+		if strings.HasPrefix(key, "volatile.") || strings.HasPrefix(key, "raw.") || shared.StringInSlice(key, restrictedForbiddenKeys) {
+			return fmt.Errorf("Restricted clients can't set '%s'", key)
+		}
+
+		if key == "security.privileged" && shared.IsTrue(value) {
+			return fmt.Errorf("Restricted clients can't use privileged containers")
+		}
+	}
+
+	for name, device := range devices {
+		old, ok := oldDevices[name]
+		if ok && restrictedDeviceEqual(old, device) {
+			continue
+		}
+
+		err := restrictedCheckDevice(device)
+		if err != nil {
+			return fmt.Errorf("Device '%s': %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+func restrictedDeviceEqual(old map[string]string, device map[string]string) bool {
+	if len(old) != len(device) {
+		return false
+	}
+
+	for k, v := range old {
+		if device[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Only bridged and p2p network interfaces and the root disk can be used by
+// restricted clients, all the other devices give access to host resources.
+func restrictedCheckDevice(device map[string]string) error {
+	switch device["type"] {
+	case "none":
+		return nil
+	case "nic":
+		if !shared.StringInSlice(device["nictype"], []string{"bridged", "p2p"}) {
+			return fmt.Errorf("Restricted clients can't use '%s' network interfaces", device["nictype"])
+		}
+
+		return nil
+	case "disk":
+		if device["source"] != "" {
+			return fmt.Errorf("Restricted clients can't use disks from the host")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("Restricted clients can't use '%s' devices", device["type"])
+}
+
+/*
+ * Restricted clients only see the containers they have access to, which
+ * also applies to the users of other objects, the network leases and the
+ * metrics.
+ */
+
+// restrictedAllowsContainer returns whether the client making a request may
+// see a container, given its name within its project.
+func restrictedAllowsContainer(d *Daemon, r *http.Request, project string, name string) (bool, error) {
+	perms := d.requestPermissions(r)
+	if perms != nil {
+		if len(perms.Projects) > 0 && !shared.StringInSlice(project, perms.Projects) {
+			return false, nil
+		}
+
+		if len(perms.Containers) > 0 && !shared.StringInSlice(name, perms.Containers) {
+			return false, nil
+		}
+	}
+
+	user := requestUnixUser(r)
+	if user != nil {
+		return unixUserOwnsContainer(d, user, project, name)
+	}
+
+	return true, nil
+}
+
+// restrictedFilterUsedBy drops the containers, and their snapshots, which
+// the client making a request can't see from the users of an object, along
+// with the profiles a restricted certificate doesn't allow.
+func restrictedFilterUsedBy(d *Daemon, r *http.Request, usedBy []string) ([]string, error) {
+	perms := d.requestPermissions(r)
+	if perms == nil && requestUnixUser(r) == nil {
+		return usedBy, nil
+	}
+
+	containersPrefix := fmt.Sprintf("/%s/containers/", version.APIVersion)
+	profilesPrefix := fmt.Sprintf("/%s/profiles/", version.APIVersion)
+
+	result := []string{}
+	for _, uri := range usedBy {
+		path, project := eventResourceSplit(uri)
+		if strings.HasPrefix(path, containersPrefix) {
+			name := strings.SplitN(strings.TrimPrefix(path, containersPrefix), "/", 2)[0]
+
+			allowed, err := restrictedAllowsContainer(d, r, project, name)
+			if err != nil {
+				return nil, err
+			}
+
+			if !allowed {
+				continue
+			}
+		}
+
+		if strings.HasPrefix(path, profilesPrefix) && perms != nil && len(perms.Profiles) > 0 {
+			name := strings.TrimPrefix(path, profilesPrefix)
+			if !shared.StringInSlice(name, perms.Profiles) {
+				continue
+			}
+		}
+
+		result = append(result, uri)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lxc/lxd/lxd/types"
+)
+
+func Test_restrictedCheckConfig(t *testing.T) {
+	oldConfig := map[string]string{"security.privileged": "true", "volatile.idmap.next": "[]"}
+	oldDevices := types.Devices{"home": {"type": "disk", "source": "/home/user", "path": "/home/user"}}
+
+	tests := []struct {
+		config  map[string]string
+		devices types.Devices
+		allowed bool
+	}{
+		{map[string]string{"limits.cpu": "2", "user.foo": "bar"}, nil, true},
+		{map[string]string{"security.privileged": "false"}, nil, true},
+		{map[string]string{"security.privileged": "true"}, nil, false},
+		{map[string]string{"raw.lxc": "lxc.aa_profile=unconfined"}, nil, false},
+		{map[string]string{"raw.idmap": "both 0 0"}, nil, false},
+		{map[string]string{"linux.kernel_modules": "zfs"}, nil, false},
+		{map[string]string{"security.idmap.base": "0"}, nil, false},
+		{map[string]string{"volatile.idmap.next": `[{"Isuid":true,"Isgid":true,"Hostid":0,"Nsid":0,"Maprange":65536}]`}, nil, false},
+		{map[string]string{"volatile.last_state.idmap": "[]"}, nil, false},
+		{map[string]string{"volatile.owner.uid": "0"}, nil, false},
+		{nil, types.Devices{"root": {"type": "disk", "path": "/", "pool": "default"}}, true},
+		{nil, types.Devices{"eth0": {"type": "nic", "nictype": "bridged", "parent": "lxdbr0"}}, true},
+		{nil, types.Devices{"eth0": {"type": "nic", "nictype": "physical", "parent": "eth0"}}, false},
+		{nil, types.Devices{"host": {"type": "disk", "source": "/", "path": "/mnt"}}, false},
+		{nil, types.Devices{"kvm": {"type": "unix-char", "path": "/dev/kvm"}}, false},
+		{nil, types.Devices{"proxy": {"type": "proxy", "listen": "tcp:0.0.0.0:80", "connect": "tcp:127.0.0.1:80"}}, false},
+	}
+
+	for i, test := range tests {
+		err := restrictedCheckConfig(test.config, test.devices, nil, nil)
+		if (err == nil) != test.allowed {
+			t.Errorf("Config %d: expected allowed to be %v, got error %v", i, test.allowed, err)
+		}
+	}
+
+	// Settings made by an administrator can be kept but not changed
+	err := restrictedCheckConfig(oldConfig, oldDevices, oldConfig, oldDevices)
+	if err != nil {
+		t.Errorf("Unchanged config: %v", err)
+	}
+
+	devices := types.Devices{"home": {"type": "disk", "source": "/", "path": "/home/user"}}
+	err = restrictedCheckConfig(oldConfig, devices, oldConfig, oldDevices)
+	if err == nil {
+		t.Errorf("Changed device: expected an error")
+	}
+
+	config := map[string]string{"security.privileged": "true", "volatile.idmap.next": `[{"Isuid":true,"Isgid":true,"Hostid":0,"Nsid":0,"Maprange":65536}]`}
+	err = restrictedCheckConfig(config, oldDevices, oldConfig, oldDevices)
+	if err == nil {
+		t.Errorf("Changed volatile key: expected an error")
+	}
+}
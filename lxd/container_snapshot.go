@@ -7,11 +7,17 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"gopkg.in/flosch/pongo2.v3"
+	log "gopkg.in/inconshreveable/log15.v2"
+	"gopkg.in/robfig/cron.v2"
 
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
 )
 
@@ -22,8 +28,9 @@ func containerSnapshotsGet(d *Daemon, r *http.Request) Response {
 		recursion = 0
 	}
 
+	project := projectParam(r)
 	cname := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, cname)
+	c, err := containerLoadByProjectAndName(d, project, cname)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -39,7 +46,7 @@ func containerSnapshotsGet(d *Daemon, r *http.Request) Response {
 	for _, snap := range snaps {
 		_, snapName, _ := containerGetParentAndSnapshotName(snap.Name())
 		if recursion == 0 {
-			url := fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, cname, snapName)
+			url := projectURL(fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, cname, snapName), project)
 			resultString = append(resultString, url)
 		} else {
 			render, _, err := snap.Render()
@@ -62,8 +69,8 @@ func containerSnapshotsGet(d *Daemon, r *http.Request) Response {
  * Note, the code below doesn't deal with snapshots of snapshots.
  * To do that, we'll need to weed out based on # slashes in names
  */
-func nextSnapshot(d *Daemon, name string) int {
-	base := name + shared.SnapshotDelimiter + "snap"
+func nextSnapshot(d *Daemon, name string, pattern string) int {
+	base := name + shared.SnapshotDelimiter + strings.SplitN(pattern, "%d", 2)[0]
 	length := len(base)
 	q := fmt.Sprintf("SELECT name FROM containers WHERE type=? AND SUBSTR(name,1,?)=?")
 	var numstr string
@@ -80,10 +87,9 @@ func nextSnapshot(d *Daemon, name string) int {
 		if len(numstr) <= length {
 			continue
 		}
-		substr := numstr[length:]
-		var num int
-		count, err := fmt.Sscanf(substr, "%d", &num)
-		if err != nil || count != 1 {
+		substr := numstr[len(name+shared.SnapshotDelimiter):]
+		num, ok := snapshotNameIndex(substr, pattern)
+		if !ok {
 			continue
 		}
 		if num >= max {
@@ -94,7 +100,34 @@ func nextSnapshot(d *Daemon, name string) int {
 	return max
 }
 
+// Returns the number a snapshot name was given in place of the first %d of
+// the pattern it was generated from. The rest of the pattern is user
+// provided, so it's only ever matched literally.
+func snapshotNameIndex(name string, pattern string) (int, bool) {
+	fields := strings.SplitN(pattern, "%d", 2)
+	if len(fields) != 2 {
+		return 0, false
+	}
+
+	escape := func(s string) string { return strings.Replace(s, "%", "%%", -1) }
+	format := escape(fields[0]) + "%d" + escape(fields[1])
+
+	var num int
+	count, err := fmt.Sscanf(name, format, &num)
+	if err != nil || count != 1 || num < 0 {
+		return 0, false
+	}
+
+	// Sscanf ignores trailing input and is lax with spaces
+	if name != fields[0]+strconv.Itoa(num)+fields[1] {
+		return 0, false
+	}
+
+	return num, true
+}
+
 func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
 	name := mux.Vars(r)["name"]
 
 	/*
@@ -103,7 +136,7 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
 	 * 2. copy the database info over
 	 * 3. copy over the rootfs
 	 */
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByProjectAndName(d, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -123,11 +156,11 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
 
 	if req.Name == "" {
 		// come up with a name
-		i := nextSnapshot(d, name)
+		i := nextSnapshot(d, c.Name(), "snap%d")
 		req.Name = fmt.Sprintf("snap%d", i)
 	}
 
-	fullName := name +
+	fullName := c.Name() +
 		shared.SnapshotDelimiter +
 		req.Name
 
@@ -155,7 +188,7 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
 	resources := map[string][]string{}
 	resources["containers"] = []string{name}
 
-	op, err := operationCreate(operationClassTask, resources, nil, snapshot, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, snapshot, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -164,11 +197,13 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
 }
 
 func snapshotHandler(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
 	containerName := mux.Vars(r)["name"]
 	snapshotName := mux.Vars(r)["snapshotName"]
 
-	sc, err := containerLoadByName(
+	sc, err := containerLoadByProjectAndName(
 		d,
+		project,
 		containerName+
 			shared.SnapshotDelimiter+
 			snapshotName)
@@ -182,7 +217,7 @@ func snapshotHandler(d *Daemon, r *http.Request) Response {
 	case "POST":
 		return snapshotPost(d, r, sc, containerName)
 	case "DELETE":
-		return snapshotDelete(sc, snapshotName)
+		return snapshotDelete(r, sc, snapshotName)
 	default:
 		return NotFound
 	}
@@ -234,7 +269,7 @@ func snapshotPost(d *Daemon, r *http.Request, sc container, containerName string
 				return InternalError(err)
 			}
 
-			op, err := operationCreate(operationClassTask, resources, nil, ws.Do, nil, nil)
+			op, err := operationCreate(sc.Project(), r, operationClassTask, resources, nil, ws.Do, nil, nil)
 			if err != nil {
 				return InternalError(err)
 			}
@@ -243,7 +278,7 @@ func snapshotPost(d *Daemon, r *http.Request, sc container, containerName string
 		}
 
 		// Pull mode
-		op, err := operationCreate(operationClassWebsocket, resources, ws.Metadata(), ws.Do, nil, ws.Connect)
+		op, err := operationCreate(sc.Project(), r, operationClassWebsocket, resources, ws.Metadata(), ws.Do, nil, ws.Connect)
 		if err != nil {
 			return InternalError(err)
 		}
@@ -256,7 +291,7 @@ func snapshotPost(d *Daemon, r *http.Request, sc container, containerName string
 		return BadRequest(err)
 	}
 
-	fullName := containerName + shared.SnapshotDelimiter + newName
+	fullName := projectPrefix(sc.Project(), containerName) + shared.SnapshotDelimiter + newName
 
 	// Check that the name isn't already in use
 	id, _ := dbContainerId(d.db, fullName)
@@ -271,7 +306,7 @@ func snapshotPost(d *Daemon, r *http.Request, sc container, containerName string
 	resources := map[string][]string{}
 	resources["containers"] = []string{containerName}
 
-	op, err := operationCreate(operationClassTask, resources, nil, rename, nil, nil)
+	op, err := operationCreate(sc.Project(), r, operationClassTask, resources, nil, rename, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -279,18 +314,266 @@ func snapshotPost(d *Daemon, r *http.Request, sc container, containerName string
 	return OperationResponse(op)
 }
 
-func snapshotDelete(sc container, name string) Response {
+func snapshotDelete(r *http.Request, sc container, name string) Response {
 	remove := func(op *operation) error {
 		return sc.Delete()
 	}
 
+	project := sc.Project()
 	resources := map[string][]string{}
-	resources["containers"] = []string{sc.Name()}
+	resources["containers"] = []string{projectUnprefix(project, sc.Name())}
 
-	op, err := operationCreate(operationClassTask, resources, nil, remove, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, remove, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
 
 	return OperationResponse(op)
 }
+
+// containerSnapshotScheduledName renders the snapshots.pattern of a container
+// into the name of its next scheduled snapshot.
+func containerSnapshotScheduledName(d *Daemon, c container) (string, error) {
+	pattern := c.ExpandedConfig()["snapshots.pattern"]
+	if pattern == "" {
+		pattern = "snap%d"
+	}
+
+	tpl, err := pongo2.FromString("{% autoescape off %}" + pattern + "{% endautoescape %}")
+	if err != nil {
+		return "", err
+	}
+
+	name, err := tpl.Execute(pongo2.Context{"creation_date": time.Now()})
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" || strings.Contains(name, shared.SnapshotDelimiter) {
+		return "", fmt.Errorf("Invalid snapshot name '%s' generated from pattern '%s'", name, pattern)
+	}
+
+	if !strings.Contains(name, "%d") {
+		// Fixed names get a counter appended on conflict
+		id, _ := dbContainerId(d.db, c.Name()+shared.SnapshotDelimiter+name)
+		if id <= 0 {
+			return name, nil
+		}
+
+		name = name + "-%d"
+	}
+
+	i := nextSnapshot(d, c.Name(), name)
+	return strings.Replace(name, "%d", strconv.Itoa(i), 1), nil
+}
+
+// containerSnapshotScheduled checks whether a container has a snapshot due
+// in the minute starting at the given time.
+func containerSnapshotScheduled(c container, minute time.Time) bool {
+	config := c.ExpandedConfig()
+
+	spec := config["snapshots.schedule"]
+	if spec == "" {
+		return false
+	}
+
+	if !c.IsRunning() && !shared.IsTrue(config["snapshots.schedule.stopped"]) {
+		return false
+	}
+
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		logger.Error("Invalid snapshot schedule", log.Ctx{"container": c.Name(), "schedule": spec, "err": err})
+		return false
+	}
+
+	return schedule.Next(minute.Add(-time.Second)).Equal(minute)
+}
+
+func autoCreateContainerSnapshots(d *Daemon) {
+	minute := time.Now().Truncate(time.Minute)
+
+	names, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		logger.Error("Unable to retrieve the list of containers", log.Ctx{"err": err})
+		return
+	}
+
+	containers := []container{}
+	for _, name := range names {
+		c, err := containerLoadByName(d, name)
+		if err != nil {
+			logger.Error("Error loading container", log.Ctx{"container": name, "err": err})
+			continue
+		}
+
+		if !containerSnapshotScheduled(c, minute) {
+			continue
+		}
+
+		containers = append(containers, c)
+	}
+
+	if len(containers) == 0 {
+		return
+	}
+
+	// Operation resources are named within their project, so each project
+	// gets its own operation
+	projects := map[string][]container{}
+	for _, c := range containers {
+		projects[c.Project()] = append(projects[c.Project()], c)
+	}
+
+	logger.Infof("Creating scheduled container snapshots")
+
+	for project, containers := range projects {
+		containers := containers
+
+		snapshot := func(op *operation) error {
+			for _, c := range containers {
+				err := autoCreateContainerSnapshot(d, c)
+				if err != nil {
+					logger.Error("Error creating scheduled snapshot", log.Ctx{"container": c.Name(), "err": err})
+				}
+			}
+
+			return nil
+		}
+
+		resources := map[string][]string{}
+		resources["containers"] = []string{}
+		for _, c := range containers {
+			resources["containers"] = append(resources["containers"], projectUnprefix(project, c.Name()))
+		}
+
+		op, err := operationCreate(project, nil, operationClassTask, resources, nil, snapshot, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start snapshot operation", log.Ctx{"project": project, "err": err})
+			continue
+		}
+
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to create scheduled container snapshots", log.Ctx{"project": project, "err": err})
+			continue
+		}
+
+		op.WaitFinal(-1)
+	}
+
+	logger.Infof("Done creating scheduled container snapshots")
+}
+
+func autoCreateContainerSnapshot(d *Daemon, c container) error {
+	name, err := containerSnapshotScheduledName(d, c)
+	if err != nil {
+		return err
+	}
+
+	var expiry time.Time
+	expiryStr := c.ExpandedConfig()["snapshots.expiry"]
+	if expiryStr != "" {
+		expiry, err = shared.GetSnapshotExpiry(time.Now(), expiryStr)
+		if err != nil {
+			return err
+		}
+	}
+
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	args := containerArgs{
+		Name:         c.Name() + shared.SnapshotDelimiter + name,
+		Ctype:        cTypeSnapshot,
+		Config:       c.LocalConfig(),
+		Profiles:     c.Profiles(),
+		Ephemeral:    c.IsEphemeral(),
+		BaseImage:    c.ExpandedConfig()["volatile.base_image"],
+		Architecture: c.Architecture(),
+		Devices:      c.LocalDevices(),
+		ExpiryDate:   expiry,
+	}
+
+	_, err = containerCreateAsSnapshot(d, args, c)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func pruneExpiredContainerSnapshots(d *Daemon) {
+	names, err := dbContainerGetExpiredSnapshots(d.db, time.Now())
+	if err != nil {
+		logger.Error("Unable to retrieve the list of expired snapshots", log.Ctx{"err": err})
+		return
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	// Operation resources are named within their project, so each project
+	// gets its own operation
+	projects := map[string][]container{}
+	for _, name := range names {
+		sc, err := containerLoadByName(d, name)
+		if err != nil {
+			logger.Error("Error loading expired snapshot", log.Ctx{"snapshot": name, "err": err})
+			continue
+		}
+
+		projects[sc.Project()] = append(projects[sc.Project()], sc)
+	}
+
+	logger.Infof("Pruning expired container snapshots")
+
+	for project, snapshots := range projects {
+		snapshots := snapshots
+
+		prune := func(op *operation) error {
+			for _, sc := range snapshots {
+				err := sc.Delete()
+				if err != nil {
+					logger.Error("Error deleting expired snapshot", log.Ctx{"snapshot": sc.Name(), "err": err})
+					continue
+				}
+
+				logger.Info("Deleted expired snapshot", log.Ctx{"snapshot": sc.Name()})
+			}
+
+			return nil
+		}
+
+		resources := map[string][]string{}
+		resources["containers"] = []string{}
+		for _, sc := range snapshots {
+			cname, _, _ := containerGetParentAndSnapshotName(projectUnprefix(project, sc.Name()))
+			if !shared.StringInSlice(cname, resources["containers"]) {
+				resources["containers"] = append(resources["containers"], cname)
+			}
+		}
+
+		op, err := operationCreate(project, nil, operationClassTask, resources, nil, prune, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start snapshot expiry operation", log.Ctx{"project": project, "err": err})
+			continue
+		}
+
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to prune expired container snapshots", log.Ctx{"project": project, "err": err})
+			continue
+		}
+
+		op.WaitFinal(-1)
+	}
+
+	logger.Infof("Done pruning expired container snapshots")
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_snapshotNameIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		num     int
+		ok      bool
+	}{
+		{"snap3", "snap%d", 3, true},
+		{"snap12-daily", "snap%d-daily", 12, true},
+		{"100%-5", "100%-%d", 5, true},
+		{"%s5", "%s%d", 5, true},
+		{"snap3-extra", "snap%d", 0, false},
+		{"other3", "snap%d", 0, false},
+		{"snap-3", "snap%d", 0, false},
+		{"snap3", "snap", 0, false},
+	}
+
+	for _, test := range tests {
+		num, ok := snapshotNameIndex(test.name, test.pattern)
+		if ok != test.ok || num != test.num {
+			t.Errorf("%q with pattern %q: got %d, %v", test.name, test.pattern, num, ok)
+		}
+	}
+}
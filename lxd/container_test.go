@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"testing"
 
@@ -41,6 +42,7 @@ func (suite *containerTestSuite) TestContainer_ProfilesMulti() {
 	// Create an unprivileged profile
 	_, err := dbProfileCreate(
 		suite.d.db,
+		"default",
 		"unprivileged",
 		"unprivileged",
 		map[string]string{"security.privileged": "true"},
@@ -48,7 +50,7 @@ func (suite *containerTestSuite) TestContainer_ProfilesMulti() {
 
 	suite.Req.Nil(err, "Failed to create the unprivileged profile.")
 	defer func() {
-		dbProfileDelete(suite.d.db, "unprivileged")
+		dbProfileDelete(suite.d.db, "default", "unprivileged")
 	}()
 
 	args := containerArgs{
@@ -134,6 +136,30 @@ func (suite *containerTestSuite) TestContainer_LoadFromDB() {
 		"The loaded container isn't excactly the same as the created one.")
 }
 
+func (suite *containerTestSuite) TestContainer_LoadByProjectAndName() {
+	_, err := dbProjectCreate(suite.d.db, "foo", "", map[string]string{})
+	suite.Req.Nil(err)
+	defer dbProjectDelete(suite.d.db, "foo")
+
+	args := containerArgs{
+		Ctype:     cTypeRegular,
+		Ephemeral: false,
+		Name:      "foo_testFoo",
+		Project:   "foo",
+	}
+
+	c, err := containerCreateInternal(suite.d, args)
+	suite.Req.Nil(err)
+	defer c.Delete()
+
+	_, err = containerLoadByProjectAndName(suite.d, "foo", "testFoo")
+	suite.Req.Nil(err)
+
+	// The internal name isn't a name in the default project
+	_, err = containerLoadByProjectAndName(suite.d, "default", "foo_testFoo")
+	suite.Req.Equal(sql.ErrNoRows, err)
+}
+
 func (suite *containerTestSuite) TestContainer_Path_Regular() {
 	// Regular
 	args := containerArgs{
@@ -53,8 +53,27 @@ var containerSnapshotCmd = Command{
 	delete: snapshotHandler,
 }
 
+var containerBackupsCmd = Command{
+	name: "containers/{name}/backups",
+	get:  containerBackupsGet,
+	post: containerBackupsPost,
+}
+
+var containerBackupCmd = Command{
+	name:   "containers/{name}/backups/{backupName}",
+	get:    containerBackupHandler,
+	post:   containerBackupHandler,
+	delete: containerBackupHandler,
+}
+
+var containerBackupExportCmd = Command{
+	name: "containers/{name}/backups/{backupName}/export",
+	get:  containerBackupExportGet,
+}
+
 var containerExecCmd = Command{
 	name: "containers/{name}/exec",
+	get:  containerExecGet,
 	post: containerExecPost,
 }
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
@@ -12,7 +13,7 @@ import (
 
 func containersGet(d *Daemon, r *http.Request) Response {
 	for i := 0; i < 100; i++ {
-		result, err := doContainersGet(d, d.isRecursionRequest(r))
+		result, err := doContainersGet(d, projectParam(r), d.isRecursionRequest(r), d.requestPermissions(r), requestUnixUser(r))
 		if err == nil {
 			return SyncResponse(true, result)
 		}
@@ -30,8 +31,8 @@ func containersGet(d *Daemon, r *http.Request) Response {
 	return InternalError(fmt.Errorf("DB is locked"))
 }
 
-func doContainersGet(d *Daemon, recursion bool) (interface{}, error) {
-	result, err := dbContainersList(d.db, cTypeRegular)
+func doContainersGet(d *Daemon, project string, recursion bool, perms *dbCertInfo, user *ucred) (interface{}, error) {
+	result, err := dbContainersProjectList(d.db, project, cTypeRegular)
 	if err != nil {
 		return nil, err
 	}
@@ -43,11 +44,30 @@ func doContainersGet(d *Daemon, recursion bool) (interface{}, error) {
 	}
 
 	for _, container := range result {
+		container = projectUnprefix(project, container)
+
+		// Hide the containers a restricted client can't access
+		if perms != nil && len(perms.Containers) > 0 && !shared.StringInSlice(container, perms.Containers) {
+			continue
+		}
+
+		// Hide the containers of other users from unprivileged users
+		if user != nil {
+			owned, err := unixUserOwnsContainer(d, user, project, container)
+			if err != nil {
+				return nil, err
+			}
+
+			if !owned {
+				continue
+			}
+		}
+
 		if !recursion {
 			url := fmt.Sprintf("/%s/containers/%s", version.APIVersion, container)
 			resultString = append(resultString, url)
 		} else {
-			c, err := doContainerGet(d, container)
+			c, err := doContainerGet(d, project, container)
 			if err != nil {
 				c = &api.Container{
 					Name:       container,
@@ -65,8 +85,8 @@ func doContainersGet(d *Daemon, recursion bool) (interface{}, error) {
 	return resultList, nil
 }
 
-func doContainerGet(d *Daemon, cname string) (*api.Container, error) {
-	c, err := containerLoadByName(d, cname)
+func doContainerGet(d *Daemon, project string, cname string) (*api.Container, error) {
+	c, err := containerLoadByProjectAndName(d, project, cname)
 	if err != nil {
 		return nil, err
 	}
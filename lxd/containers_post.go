@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/dustinkirkland/golang-petname"
@@ -20,17 +24,22 @@ import (
 	log "gopkg.in/inconshreveable/log15.v2"
 )
 
-func createFromImage(d *Daemon, req *api.ContainersPost) Response {
+func createFromImage(d *Daemon, r *http.Request, project string, req *api.ContainersPost) Response {
 	var hash string
 	var err error
 
+	imagesProject, err := projectImagesProject(d.db, project)
+	if err != nil {
+		return SmartError(err)
+	}
+
 	if req.Source.Fingerprint != "" {
 		hash = req.Source.Fingerprint
 	} else if req.Source.Alias != "" {
 		if req.Source.Server != "" {
 			hash = req.Source.Alias
 		} else {
-			_, alias, err := dbImageAliasGet(d.db, req.Source.Alias, true)
+			_, alias, err := dbImageAliasGet(d.db, imagesProject, req.Source.Alias, true)
 			if err != nil {
 				return SmartError(err)
 			}
@@ -42,7 +51,7 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 			return BadRequest(fmt.Errorf("Property match is only supported for local images"))
 		}
 
-		hashes, err := dbImagesGet(d.db, false)
+		hashes, err := dbImagesGet(d.db, imagesProject, false)
 		if err != nil {
 			return SmartError(err)
 		}
@@ -50,7 +59,7 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 		var image *api.Image
 
 		for _, imageHash := range hashes {
-			_, img, err := dbImageGet(d.db, imageHash, false, true)
+			_, img, err := dbImageGet(d.db, imagesProject, imageHash, false, true)
 			if err != nil {
 				continue
 			}
@@ -89,20 +98,21 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 			Ctype:     cTypeRegular,
 			Devices:   req.Devices,
 			Ephemeral: req.Ephemeral,
-			Name:      req.Name,
+			Name:      projectPrefix(project, req.Name),
 			Profiles:  req.Profiles,
+			Project:   project,
 		}
 
 		var info *api.Image
 		if req.Source.Server != "" {
 			info, err = d.ImageDownload(
-				op, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.Secret,
+				op, imagesProject, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.Secret,
 				hash, true, daemonConfig["images.auto_update_cached"].GetBool(), "", true)
 			if err != nil {
 				return err
 			}
 		} else {
-			_, info, err = dbImageGet(d.db, hash, false, false)
+			_, info, err = dbImageGet(d.db, imagesProject, hash, false, false)
 			if err != nil {
 				return err
 			}
@@ -120,7 +130,7 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 	resources := map[string][]string{}
 	resources["containers"] = []string{req.Name}
 
-	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -128,14 +138,15 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 	return OperationResponse(op)
 }
 
-func createFromNone(d *Daemon, req *api.ContainersPost) Response {
+func createFromNone(d *Daemon, r *http.Request, project string, req *api.ContainersPost) Response {
 	args := containerArgs{
 		Config:    req.Config,
 		Ctype:     cTypeRegular,
 		Devices:   req.Devices,
 		Ephemeral: req.Ephemeral,
-		Name:      req.Name,
+		Name:      projectPrefix(project, req.Name),
 		Profiles:  req.Profiles,
+		Project:   project,
 	}
 
 	if req.Architecture != "" {
@@ -154,7 +165,7 @@ func createFromNone(d *Daemon, req *api.ContainersPost) Response {
 	resources := map[string][]string{}
 	resources["containers"] = []string{req.Name}
 
-	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -162,12 +173,34 @@ func createFromNone(d *Daemon, req *api.ContainersPost) Response {
 	return OperationResponse(op)
 }
 
-func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
+func createFromMigration(d *Daemon, r *http.Request, project string, req *api.ContainersPost) Response {
 	// Validate migration mode
 	if req.Source.Mode != "pull" && req.Source.Mode != "push" {
 		return NotImplemented
 	}
 
+	if req.Source.Refresh && req.Source.Live {
+		return BadRequest(fmt.Errorf("Live migration can't be used to refresh a container"))
+	}
+
+	// An existing container gets refreshed in place, otherwise this is a
+	// regular copy.
+	if req.Source.Refresh {
+		_, err := dbContainerId(d.db, projectPrefix(project, req.Name))
+		if err == nil {
+			c, err := containerLoadByProjectAndName(d, project, req.Name)
+			if err != nil {
+				return SmartError(err)
+			}
+
+			if c.IsRunning() {
+				return BadRequest(fmt.Errorf("Can't refresh a running container"))
+			}
+
+			return createFromMigrationSink(d, r, req, c, true)
+		}
+	}
+
 	var c container
 
 	// Parse the architecture name
@@ -184,8 +217,9 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 		Ctype:        cTypeRegular,
 		Devices:      req.Devices,
 		Ephemeral:    req.Ephemeral,
-		Name:         req.Name,
+		Name:         projectPrefix(project, req.Name),
 		Profiles:     req.Profiles,
+		Project:      project,
 	}
 
 	// Grab the container's root device if one is specified
@@ -210,8 +244,13 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 
 	// If we don't have a valid pool yet, look through profiles
 	if storagePool == "" {
+		profilesProject, err := projectProfilesProject(d.db, project)
+		if err != nil {
+			return SmartError(err)
+		}
+
 		for _, pName := range req.Profiles {
-			_, p, err := dbProfileGet(d.db, pName)
+			_, p, err := dbProfileGet(d.db, profilesProject, pName)
 			if err != nil {
 				return SmartError(err)
 			}
@@ -285,7 +324,12 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 	 * point and just negotiate it over the migration control
 	 * socket. Anyway, it'll happen later :)
 	 */
-	_, _, err = dbImageGet(d.db, req.Source.BaseImage, false, true)
+	imagesProject, err := projectImagesProject(d.db, project)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, _, err = dbImageGet(d.db, imagesProject, req.Source.BaseImage, false, true)
 	if err != nil {
 		c, err = containerCreateAsEmpty(d, args)
 		if err != nil {
@@ -327,24 +371,63 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 		}
 	}
 
+	return createFromMigrationSink(d, r, req, c, false)
+}
+
+// createFromMigrationSink transfers the container from its source. When
+// refreshing, the container already existed so only what was added to it is
+// removed on failure.
+func createFromMigrationSink(d *Daemon, r *http.Request, req *api.ContainersPost, c container, refresh bool) Response {
+	// Snapshots which were there before a refresh
+	existingSnapshots := []string{}
+	if refresh {
+		snapshots, err := c.Snapshots()
+		if err != nil {
+			return SmartError(err)
+		}
+
+		for _, snap := range snapshots {
+			existingSnapshots = append(existingSnapshots, snap.Name())
+		}
+	}
+
+	revert := func() {
+		if !refresh {
+			c.Delete()
+			return
+		}
+
+		snapshots, err := c.Snapshots()
+		if err != nil {
+			return
+		}
+
+		for _, snap := range snapshots {
+			if !shared.StringInSlice(snap.Name(), existingSnapshots) {
+				snap.Delete()
+			}
+		}
+	}
+
+	var err error
 	var cert *x509.Certificate
 	if req.Source.Certificate != "" {
 		certBlock, _ := pem.Decode([]byte(req.Source.Certificate))
 		if certBlock == nil {
-			c.Delete()
+			revert()
 			return InternalError(fmt.Errorf("Invalid certificate"))
 		}
 
 		cert, err = x509.ParseCertificate(certBlock.Bytes)
 		if err != nil {
-			c.Delete()
+			revert()
 			return InternalError(err)
 		}
 	}
 
 	config, err := shared.GetTLSConfig("", "", "", cert)
 	if err != nil {
-		c.Delete()
+		revert()
 		return InternalError(err)
 	}
 
@@ -363,11 +446,12 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 		Push:          push,
 		Live:          req.Source.Live,
 		ContainerOnly: req.Source.ContainerOnly,
+		Refresh:       refresh,
 	}
 
 	sink, err := NewMigrationSink(&migrationArgs)
 	if err != nil {
-		c.Delete()
+		revert()
 		return InternalError(err)
 	}
 
@@ -376,13 +460,13 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 		err = sink.Do(op)
 		if err != nil {
 			logger.Error("Error during migration sink", log.Ctx{"err": err})
-			c.Delete()
+			revert()
 			return fmt.Errorf("Error transferring container data: %s", err)
 		}
 
 		err = c.TemplateApply("copy")
 		if err != nil {
-			c.Delete()
+			revert()
 			return err
 		}
 
@@ -394,12 +478,12 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 
 	var op *operation
 	if push {
-		op, err = operationCreate(operationClassWebsocket, resources, sink.Metadata(), run, nil, sink.Connect)
+		op, err = operationCreate(c.Project(), r, operationClassWebsocket, resources, sink.Metadata(), run, nil, sink.Connect)
 		if err != nil {
 			return InternalError(err)
 		}
 	} else {
-		op, err = operationCreate(operationClassTask, resources, nil, run, nil, nil)
+		op, err = operationCreate(c.Project(), r, operationClassTask, resources, nil, run, nil, nil)
 		if err != nil {
 			return InternalError(err)
 		}
@@ -408,16 +492,50 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 	return OperationResponse(op)
 }
 
-func createFromCopy(d *Daemon, req *api.ContainersPost) Response {
+func createFromCopy(d *Daemon, r *http.Request, project string, req *api.ContainersPost) Response {
 	if req.Source.Source == "" {
 		return BadRequest(fmt.Errorf("must specify a source container"))
 	}
 
-	source, err := containerLoadByName(d, req.Source.Source)
+	source, err := containerLoadByProjectAndName(d, project, req.Source.Source)
 	if err != nil {
 		return SmartError(err)
 	}
 
+	// An existing copy gets refreshed in place, its configuration is left
+	// untouched.
+	if req.Source.Refresh {
+		_, err := dbContainerId(d.db, projectPrefix(project, req.Name))
+		if err == nil {
+			if req.Name == req.Source.Source {
+				return BadRequest(fmt.Errorf("A container can't be refreshed from itself"))
+			}
+
+			ct, err := containerLoadByProjectAndName(d, project, req.Name)
+			if err != nil {
+				return SmartError(err)
+			}
+
+			if ct.IsRunning() {
+				return BadRequest(fmt.Errorf("Can't refresh a running container"))
+			}
+
+			run := func(op *operation) error {
+				return containerRefreshAsCopy(d, ct, source, req.Source.ContainerOnly)
+			}
+
+			resources := map[string][]string{}
+			resources["containers"] = []string{req.Name, req.Source.Source}
+
+			op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
+			if err != nil {
+				return InternalError(err)
+			}
+
+			return OperationResponse(op)
+		}
+	}
+
 	// Config override
 	sourceConfig := source.LocalConfig()
 
@@ -468,8 +586,9 @@ func createFromCopy(d *Daemon, req *api.ContainersPost) Response {
 		Ctype:        cTypeRegular,
 		Devices:      req.Devices,
 		Ephemeral:    req.Ephemeral,
-		Name:         req.Name,
+		Name:         projectPrefix(project, req.Name),
 		Profiles:     req.Profiles,
+		Project:      project,
 	}
 
 	run := func(op *operation) error {
@@ -483,8 +602,102 @@ func createFromCopy(d *Daemon, req *api.ContainersPost) Response {
 	resources := map[string][]string{}
 	resources["containers"] = []string{req.Name, req.Source.Source}
 
-	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func createFromBackup(d *Daemon, r *http.Request, project string, data io.Reader, pool string) Response {
+	// Write the data to a temporary file
+	f, err := ioutil.TempFile(shared.VarPath("backups"), "lxd_backup_")
+	if err != nil {
+		return InternalError(err)
+	}
+
+	_, err = io.Copy(f, data)
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return InternalError(err)
+	}
+
+	// Parse the backup information
+	index, err := backupGetIndex(f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return BadRequest(err)
+	}
+
+	_, err = dbContainerId(d.db, projectPrefix(project, index.Name))
+	if err == nil {
+		os.Remove(f.Name())
+		return BadRequest(fmt.Errorf("The container \"%s\" already exists", index.Name))
+	}
+
+	// Restore onto the original storage pool if it exists here
+	if pool == "" {
+		_, err := dbStoragePoolGetID(d.db, index.Pool)
+		if err == nil {
+			pool = index.Pool
+		}
+	}
+
+	// Otherwise use the default profile's pool or the only pool around
+	if pool == "" {
+		profilesProject, err := projectProfilesProject(d.db, project)
+		if err != nil {
+			os.Remove(f.Name())
+			return SmartError(err)
+		}
+
+		_, p, err := dbProfileGet(d.db, profilesProject, "default")
+		if err == nil {
+			k, v, _ := containerGetRootDiskDevice(p.Devices)
+			if k != "" && v["pool"] != "" {
+				pool = v["pool"]
+			}
+		}
+	}
+
+	if pool == "" {
+		pools, err := dbStoragePools(d.db)
+		if err == nil && len(pools) == 1 {
+			pool = pools[0]
+		}
+	}
+
+	if pool == "" {
+		os.Remove(f.Name())
+		return BadRequest(fmt.Errorf("Can't find a storage pool for the container to use"))
+	}
+
+	_, storagePool, err := dbStoragePoolGet(d.db, pool)
 	if err != nil {
+		os.Remove(f.Name())
+		return SmartError(err)
+	}
+
+	if index.Optimized && storagePool.Driver != index.Backend {
+		os.Remove(f.Name())
+		return BadRequest(fmt.Errorf("Optimized %s backups can't be restored onto the %s storage pool \"%s\"", index.Backend, storagePool.Driver, pool))
+	}
+
+	run := func(op *operation) error {
+		defer os.Remove(f.Name())
+
+		_, err := containerCreateFromBackup(d, project, index, f.Name(), pool)
+		return err
+	}
+
+	resources := map[string][]string{}
+	resources["containers"] = []string{index.Name}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		os.Remove(f.Name())
 		return InternalError(err)
 	}
 
@@ -494,6 +707,13 @@ func createFromCopy(d *Daemon, req *api.ContainersPost) Response {
 func containersPost(d *Daemon, r *http.Request) Response {
 	logger.Debugf("Responding to container create")
 
+	project := projectParam(r)
+
+	// A binary body is a backup tarball to restore
+	if r.Header.Get("Content-Type") == "application/octet-stream" {
+		return createFromBackup(d, r, project, r.Body, r.Header.Get("X-LXD-pool"))
+	}
+
 	req := api.ContainersPost{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return BadRequest(err)
@@ -506,7 +726,7 @@ func containersPost(d *Daemon, r *http.Request) Response {
 	}
 
 	if req.Name == "" {
-		cs, err := dbContainersList(d.db, cTypeRegular)
+		cs, err := dbContainersProjectList(d.db, project, cTypeRegular)
 		if err != nil {
 			return SmartError(err)
 		}
@@ -515,7 +735,7 @@ func containersPost(d *Daemon, r *http.Request) Response {
 		for {
 			i++
 			req.Name = strings.ToLower(petname.Generate(2, "-"))
-			if !shared.StringInSlice(req.Name, cs) {
+			if !shared.StringInSlice(projectPrefix(project, req.Name), cs) {
 				break
 			}
 
@@ -534,19 +754,25 @@ func containersPost(d *Daemon, r *http.Request) Response {
 		req.Config = map[string]string{}
 	}
 
+	// Containers of unprivileged users belong to them
+	user := requestUnixUser(r)
+	if user != nil {
+		req.Config[unixUserOwnerKey] = strconv.FormatInt(user.uid, 10)
+	}
+
 	if strings.Contains(req.Name, shared.SnapshotDelimiter) {
 		return BadRequest(fmt.Errorf("Invalid container name: '%s' is reserved for snapshots", shared.SnapshotDelimiter))
 	}
 
 	switch req.Source.Type {
 	case "image":
-		return createFromImage(d, &req)
+		return createFromImage(d, r, project, &req)
 	case "none":
-		return createFromNone(d, &req)
+		return createFromNone(d, r, project, &req)
 	case "migration":
-		return createFromMigration(d, &req)
+		return createFromMigration(d, r, project, &req)
 	case "copy":
-		return createFromCopy(d, &req)
+		return createFromCopy(d, r, project, &req)
 	default:
 		return BadRequest(fmt.Errorf("unknown source type %s", req.Source.Type))
 	}
@@ -31,6 +31,7 @@ import (
 
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/logging"
 	"github.com/lxc/lxd/shared/osarch"
@@ -70,6 +71,7 @@ type Daemon struct {
 	architectures       []int
 	BackingFs           string
 	clientCerts         []x509.Certificate
+	clientPermissions   map[string]*dbCertInfo
 	db                  *sql.DB
 	group               string
 	IdmapSet            *shared.IdmapSet
@@ -210,7 +212,8 @@ func (d *Daemon) createCmd(version string, c Command) {
 	d.mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		if d.isTrustedClient(r) {
+		trusted := d.isTrustedClient(r)
+		if trusted {
 			logger.Debug(
 				"handling",
 				log.Ctx{"method": r.Method, "url": r.URL.RequestURI(), "ip": r.RemoteAddr})
@@ -230,6 +233,73 @@ func (d *Daemon) createCmd(version string, c Command) {
 			return
 		}
 
+		// Record all the requests modifying the server, except for the
+		// internal ones coming from LXD itself. Only the successful
+		// requests of untrusted clients are recorded, anyone could
+		// otherwise fill the audit log.
+		var audit *api.AuditEntry
+		if version != "internal" && r.Method != "GET" && r.Method != "HEAD" {
+			audit = d.auditRequestStart(w, r)
+		}
+
+		auditDone := func(resp Response, renderErr error) {
+			_, failed := resp.(*errorResponse)
+			if !trusted && (failed || renderErr != nil) {
+				return
+			}
+
+			d.auditRequestDone(audit, resp, renderErr)
+		}
+
+		// Reject requests targeting a project which doesn't exist
+		project := r.URL.Query().Get("project")
+		if project != "" {
+			_, _, err := dbProjectGet(d.db, project)
+			if err != nil {
+				resp := SmartError(err)
+				err := resp.Render(w)
+				auditDone(resp, err)
+				return
+			}
+		}
+
+		// Enforce the restrictions of the client's certificate
+		perms := d.requestPermissions(r)
+		if perms != nil {
+			err := certificateCheckPermissions(d, perms, version, c, r)
+			if err != nil {
+				logger.Warn(
+					"rejecting request from restricted client",
+					log.Ctx{"method": r.Method, "url": r.URL.RequestURI(), "ip": r.RemoteAddr, "err": err})
+				resp := ForbiddenError(err)
+				err := resp.Render(w)
+				auditDone(resp, err)
+				return
+			}
+		}
+
+		// Scope the requests of unprivileged local users to their own
+		// containers
+		user, err := d.unixUserScope(w, r)
+		if err == nil && user != nil {
+			err = unixUserCheckPermissions(d, user, version, c, r)
+		}
+
+		if err != nil {
+			logger.Warn(
+				"rejecting request from unprivileged user",
+				log.Ctx{"method": r.Method, "url": r.URL.RequestURI(), "err": err})
+			resp := ForbiddenError(err)
+			err := resp.Render(w)
+			auditDone(resp, err)
+			return
+		}
+
+		if user != nil {
+			unixUserRequestStart(r, user)
+			defer unixUserRequestDone(r)
+		}
+
 		if debug && r.Method != "GET" && isJSONRequest(r) {
 			newBody := &bytes.Buffer{}
 			captured := &bytes.Buffer{}
@@ -271,13 +341,16 @@ func (d *Daemon) createCmd(version string, c Command) {
 			resp = NotFound
 		}
 
-		if err := resp.Render(w); err != nil {
-			err := InternalError(err).Render(w)
+		renderErr := resp.Render(w)
+		if renderErr != nil {
+			err := InternalError(renderErr).Render(w)
 			if err != nil {
 				logger.Errorf("Failed writing error for error, giving up")
 			}
 		}
 
+		auditDone(resp, renderErr)
+
 		/*
 		 * When we create a new lxc.Container, it adds a finalizer (via
 		 * SetFinalizer) that frees the struct. However, it sometimes
@@ -709,6 +782,9 @@ func (d *Daemon) Init() error {
 	if err := os.MkdirAll(shared.VarPath(), 0711); err != nil {
 		return err
 	}
+	if err := os.MkdirAll(shared.VarPath("backups"), 0700); err != nil {
+		return err
+	}
 	if err := os.MkdirAll(shared.CachePath(), 0700); err != nil {
 		return err
 	}
@@ -855,6 +931,9 @@ func (d *Daemon) Init() error {
 		daemonConfig["core.proxy_ignore_hosts"].Get(),
 	)
 
+	/* Start delivering events to the webhook, if any */
+	webhookInit(d)
+
 	/* Setup some mounts (nice to have) */
 	if !d.MockMode {
 		// Attempt to mount the shmounts tmpfs
@@ -955,6 +1034,13 @@ func (d *Daemon) Init() error {
 
 		for _, listener := range listeners {
 			if shared.PathExists(listener.Addr().String()) {
+				if daemonConfig["core.unix_unprivileged_users"].GetBool() {
+					err := unixSocketSetMode(listener.Addr().String(), true)
+					if err != nil {
+						return err
+					}
+				}
+
 				d.UnixSocket = &Socket{Socket: listener, CloseOnExit: false}
 			} else {
 				tlsListener := tls.NewListener(listener, d.tlsConfig)
@@ -993,18 +1079,14 @@ func (d *Daemon) Init() error {
 			return fmt.Errorf("cannot listen on unix socket: %v", err)
 		}
 
-		if err := os.Chmod(localSocketPath, 0660); err != nil {
+		err = unixSocketSetMode(localSocketPath, daemonConfig["core.unix_unprivileged_users"].GetBool())
+		if err != nil {
 			return err
 		}
 
-		var gid int
-		if d.group != "" {
-			gid, err = shared.GroupId(d.group)
-			if err != nil {
-				return err
-			}
-		} else {
-			gid = os.Getgid()
+		gid, err := d.unixSocketGid()
+		if err != nil {
+			return err
 		}
 
 		if err := os.Chown(localSocketPath, os.Getuid(), gid); err != nil {
@@ -1037,7 +1119,12 @@ func (d *Daemon) Init() error {
 	logger.Infof("REST API daemon:")
 	if d.UnixSocket != nil {
 		logger.Info(" - binding Unix socket", log.Ctx{"socket": d.UnixSocket.Socket.Addr()})
-		d.tomb.Go(func() error { return http.Serve(d.UnixSocket.Socket, &lxdHttpServer{d.mux, d}) })
+		// Track the credentials of the peers for the audit log
+		server := &http.Server{
+			Handler:   &lxdHttpServer{d.mux, d},
+			ConnState: pidMapper.ConnStateHandler,
+		}
+		d.tomb.Go(func() error { return server.Serve(d.UnixSocket.Socket) })
 	}
 
 	if d.TCPSocket != nil {
@@ -1107,6 +1194,20 @@ func (d *Daemon) Ready() error {
 		}
 	}()
 
+	/* Scheduled container snapshots, backup expiry and log rotation */
+	go func() {
+		for {
+			// Run at the start of every minute
+			now := time.Now()
+			time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+			autoCreateContainerSnapshots(d)
+			pruneExpiredContainerSnapshots(d)
+			pruneExpiredContainerBackups(d)
+			rotateContainerLogs(d)
+		}
+	}()
+
 	/* Restore containers */
 	containersRestart(d)
 
@@ -174,6 +174,8 @@ func (k *daemonConfigKey) GetInt64() int64 {
 func daemonConfigInit(db *sql.DB) error {
 	// Set all the keys
 	daemonConfig = map[string]*daemonConfigKey{
+		"audit.max_entries": {valueType: "int", defaultValue: "10000"},
+
 		"core.https_address":             {valueType: "string", setter: daemonConfigSetAddress},
 		"core.https_allowed_headers":     {valueType: "string"},
 		"core.https_allowed_methods":     {valueType: "string"},
@@ -183,12 +185,19 @@ func daemonConfigInit(db *sql.DB) error {
 		"core.proxy_https":               {valueType: "string", setter: daemonConfigSetProxy},
 		"core.proxy_ignore_hosts":        {valueType: "string", setter: daemonConfigSetProxy},
 		"core.trust_password":            {valueType: "string", hiddenValue: true, setter: daemonConfigSetPassword},
+		"core.unix_unprivileged_users":   {valueType: "bool", setter: daemonConfigSetUnixUsers},
+		"core.webhook_secret":            {valueType: "string", hiddenValue: true, setter: daemonConfigSetWebhook},
+		"core.webhook_types":             {valueType: "string", defaultValue: "operation,lifecycle", validator: daemonConfigValidateWebhookTypes, setter: daemonConfigSetWebhook},
+		"core.webhook_url":               {valueType: "string", validator: daemonConfigValidateWebhookURL, setter: daemonConfigSetWebhook},
 
 		"images.auto_update_cached":    {valueType: "bool", defaultValue: "true"},
 		"images.auto_update_interval":  {valueType: "int", defaultValue: "6"},
 		"images.compression_algorithm": {valueType: "string", validator: daemonConfigValidateCompression, defaultValue: "gzip"},
 		"images.remote_cache_expiry":   {valueType: "int", defaultValue: "10", trigger: daemonConfigTriggerExpiry},
 
+		"logs.rotate_count": {valueType: "int", defaultValue: "5"},
+		"logs.rotate_size":  {valueType: "string", validator: daemonConfigValidateSize},
+
 		// Keys deprecated since the implementation of the storage api.
 		"storage.lvm_fstype":           {valueType: "string", defaultValue: "ext4", validValues: []string{"ext4", "xfs"}, validator: storageDeprecatedKeys},
 		"storage.lvm_mount_options":    {valueType: "string", defaultValue: "discard", validator: storageDeprecatedKeys},
@@ -272,6 +281,20 @@ func daemonConfigSetAddress(d *Daemon, key string, value string) (string, error)
 	return value, nil
 }
 
+func daemonConfigSetUnixUsers(d *Daemon, key string, value string) (string, error) {
+	if d.UnixSocket == nil {
+		return value, nil
+	}
+
+	// Update the permissions of the unix socket
+	err := unixSocketSetMode(d.UnixSocket.Socket.Addr().String(), shared.IsTrue(value))
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
 func daemonConfigSetProxy(d *Daemon, key string, value string) (string, error) {
 	// Get the current config
 	config := map[string]string{}
@@ -313,6 +336,15 @@ func daemonConfigValidateCompression(d *Daemon, key string, value string) error
 	return err
 }
 
+func daemonConfigValidateSize(d *Daemon, key string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	_, err := shared.ParseByteSizeString(value)
+	return err
+}
+
 func storageDeprecatedKeys(d *Daemon, key string, value string) error {
 	if value == "" || daemonConfig[key].defaultValue == value {
 		return nil
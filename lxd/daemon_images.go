@@ -92,7 +92,7 @@ func imageLoadStreamCache(d *Daemon) error {
 }
 
 // ImageDownload resolves the image fingerprint and if not in the database, downloads it
-func (d *Daemon) ImageDownload(op *operation, server string, protocol string, certificate string, secret string, alias string, forContainer bool, autoUpdate bool, storagePool string, preferCached bool) (*api.Image, error) {
+func (d *Daemon) ImageDownload(op *operation, project string, server string, protocol string, certificate string, secret string, alias string, forContainer bool, autoUpdate bool, storagePool string, preferCached bool) (*api.Image, error) {
 	var err error
 	var ctxMap log.Ctx
 
@@ -230,14 +230,20 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	// auto-update is on).
 	interval := daemonConfig["images.auto_update_interval"].GetInt64()
 	if preferCached && interval > 0 && alias != fp {
-		cachedFingerprint, err := dbImageSourceGetCachedFingerprint(d.db, server, protocol, alias)
+		cachedFingerprint, err := dbImageSourceGetCachedFingerprint(d.db, project, server, protocol, alias)
 		if err == nil && cachedFingerprint != fp {
 			fp = cachedFingerprint
 		}
 	}
 
 	// Check if the image already exists (partial hash match)
-	_, imgInfo, err := dbImageGet(d.db, fp, false, true)
+	_, imgInfo, err := dbImageGet(d.db, project, fp, false, true)
+	if err != nil {
+		// The image may already be part of another project, in which
+		// case its files and storage volumes can be shared.
+		imgInfo, err = imageShareWithProject(d, project, fp, server, protocol, certificate, alias, autoUpdate, forContainer)
+	}
+
 	if err == nil {
 		logger.Debug("Image already exists in the db", log.Ctx{"image": fp})
 		info = imgInfo
@@ -291,7 +297,11 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 		<-waitChannel
 
 		// Grab the database entry
-		_, imgInfo, err := dbImageGet(d.db, fp, false, true)
+		_, imgInfo, err := dbImageGet(d.db, project, fp, false, true)
+		if err != nil {
+			imgInfo, err = imageShareWithProject(d, project, fp, server, protocol, certificate, alias, autoUpdate, forContainer)
+		}
+
 		if err != nil {
 			// Other download failed, lets try again
 			logger.Error("Other image download didn't succeed", log.Ctx{"image": fp})
@@ -504,7 +514,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	}
 
 	// Create the database entry
-	err = dbImageInsert(d.db, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
+	err = dbImageInsert(d.db, project, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
 	if err != nil {
 		return nil, err
 	}
@@ -513,7 +523,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	failure = false
 
 	if alias != fp {
-		id, _, err := dbImageGet(d.db, fp, false, true)
+		id, _, err := dbImageGet(d.db, project, fp, false, true)
 		if err != nil {
 			return nil, err
 		}
@@ -534,7 +544,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 
 	// Mark the image as "cached" if downloading for a container
 	if forContainer {
-		err := dbImageLastAccessInit(d.db, fp)
+		err := dbImageLastAccessInit(d.db, project, fp)
 		if err != nil {
 			return nil, err
 		}
@@ -543,3 +553,57 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	logger.Info("Image downloaded", ctxMap)
 	return info, nil
 }
+
+// imageShareWithProject adds an image which is already part of another
+// project to the given one, sharing the existing image files and storage
+// volumes rather than downloading it again.
+func imageShareWithProject(d *Daemon, project string, fp string, server string, protocol string, certificate string, alias string, autoUpdate bool, forContainer bool) (*api.Image, error) {
+	projects, err := dbImageProjects(d.db, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(projects) == 0 {
+		return nil, NoSuchObjectError
+	}
+
+	_, info, err := dbImageGet(d.db, projects[0], fp, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Same rules as for a freshly downloaded image
+	info.Public = false
+	info.AutoUpdate = false
+	if alias != fp {
+		info.AutoUpdate = autoUpdate
+	}
+
+	err = dbImageInsert(d.db, project, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	id, info, err := dbImageGet(d.db, project, fp, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if alias != fp {
+		err = dbImageSourceInsert(d.db, id, server, protocol, certificate, alias)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Mark the image as "cached" if downloading for a container
+	if forContainer {
+		err := dbImageLastAccessInit(d.db, project, fp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Debug("Image shared with project", log.Ctx{"image": fp, "project": project})
+	return info, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// dbAuditFilter selects entries of the audit log. Empty fields match all
+// entries.
+type dbAuditFilter struct {
+	Requestor string
+	Method    string
+	URL       string // Substring of the request URL
+	Since     time.Time
+	Limit     int // Only return the latest entries
+}
+
+// dbAuditAdd records a request in the audit log and returns the ID of the
+// new entry.
+func dbAuditAdd(db *sql.DB, entry *api.AuditEntry) (int64, error) {
+	result, err := dbExec(db, `
+INSERT INTO audit (date, requestor, uid, address, method, url, body, operation, status)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Date.UTC(), entry.Requestor, entry.UID, entry.Address, entry.Method, entry.URL, entry.Body, entry.Operation, entry.Status)
+	if err != nil {
+		return -1, err
+	}
+
+	return result.LastInsertId()
+}
+
+// dbAuditUpdateStatus records the final status of the operation started by
+// a request.
+func dbAuditUpdateStatus(db *sql.DB, id int64, status string) error {
+	_, err := dbExec(db, "UPDATE audit SET status=? WHERE id=?", status, id)
+	return err
+}
+
+// dbAuditGet returns the entries of the audit log matching the filter, oldest
+// first.
+func dbAuditGet(db *sql.DB, filter dbAuditFilter) ([]api.AuditEntry, error) {
+	query := `
+SELECT id, date, requestor, uid, address, method, url, body, operation, status
+    FROM audit
+    WHERE (? = '' OR requestor LIKE ?) AND (? = '' OR method = ?) AND (? = '' OR instr(url, ?) > 0)
+    ORDER BY id`
+	rows, err := dbQuery(db, query,
+		filter.Requestor, filter.Requestor+"%", filter.Method, filter.Method, filter.URL, filter.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []api.AuditEntry{}
+	for rows.Next() {
+		entry := api.AuditEntry{}
+		err := rows.Scan(&entry.ID, &entry.Date, &entry.Requestor, &entry.UID, &entry.Address,
+			&entry.Method, &entry.URL, &entry.Body, &entry.Operation, &entry.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Date.Before(filter.Since) {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+
+	return entries, nil
+}
+
+// dbAuditPrune removes all but the latest entries of the audit log.
+func dbAuditPrune(db *sql.DB, keep int64) error {
+	_, err := dbExec(db, "DELETE FROM audit WHERE id <= (SELECT MAX(id) FROM audit) - ?", keep)
+	return err
+}
@@ -1,11 +1,20 @@
 package main
 
 import (
+	"crypto/subtle"
 	"database/sql"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Kinds of objects a certificate can be restricted to
+const (
+	certPermissionContainer = iota
+	certPermissionProfile
+	certPermissionProject
+)
+
 // dbCertInfo is here to pass the certificates content
 // from the database around
 type dbCertInfo struct {
@@ -14,20 +23,28 @@ type dbCertInfo struct {
 	Type        int
 	Name        string
 	Certificate string
+
+	ReadOnly   bool
+	Containers []string
+	Profiles   []string
+	Projects   []string
+}
+
+// Whether the certificate is restricted in any way.
+func (cert *dbCertInfo) isRestricted() bool {
+	return cert.ReadOnly || len(cert.Containers) > 0 || len(cert.Profiles) > 0 || len(cert.Projects) > 0
 }
 
 // dbCertsGet returns all certificates from the DB as CertBaseInfo objects.
 func dbCertsGet(db *sql.DB) (certs []*dbCertInfo, err error) {
 	rows, err := dbQuery(
 		db,
-		"SELECT id, fingerprint, type, name, certificate FROM certificates",
+		"SELECT id, fingerprint, type, name, certificate, read_only FROM certificates",
 	)
 	if err != nil {
 		return certs, err
 	}
 
-	defer rows.Close()
-
 	for rows.Next() {
 		cert := new(dbCertInfo)
 		rows.Scan(
@@ -36,9 +53,18 @@ func dbCertsGet(db *sql.DB) (certs []*dbCertInfo, err error) {
 			&cert.Type,
 			&cert.Name,
 			&cert.Certificate,
+			&cert.ReadOnly,
 		)
 		certs = append(certs, cert)
 	}
+	rows.Close()
+
+	for _, cert := range certs {
+		err = dbCertPermissionsGet(db, cert)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return certs, nil
 }
@@ -58,11 +84,12 @@ func dbCertGet(db *sql.DB, fingerprint string) (cert *dbCertInfo, err error) {
 		&cert.Type,
 		&cert.Name,
 		&cert.Certificate,
+		&cert.ReadOnly,
 	}
 
 	query := `
 		SELECT
-			id, fingerprint, type, name, certificate
+			id, fingerprint, type, name, certificate, read_only
 		FROM
 			certificates
 		WHERE fingerprint LIKE ?`
@@ -71,9 +98,75 @@ func dbCertGet(db *sql.DB, fingerprint string) (cert *dbCertInfo, err error) {
 		return nil, err
 	}
 
+	err = dbCertPermissionsGet(db, cert)
+	if err != nil {
+		return nil, err
+	}
+
 	return cert, err
 }
 
+// dbCertPermissionsGet fills in the containers, profiles and projects the
+// certificate is restricted to.
+func dbCertPermissionsGet(db *sql.DB, cert *dbCertInfo) error {
+	var permType int
+	var name string
+	query := "SELECT type, name FROM certificates_permissions WHERE certificate_id=? ORDER BY name"
+	inargs := []interface{}{cert.ID}
+	outfmt := []interface{}{permType, name}
+	results, err := dbQueryScan(db, query, inargs, outfmt)
+	if err != nil {
+		return err
+	}
+
+	cert.Containers = []string{}
+	cert.Profiles = []string{}
+	cert.Projects = []string{}
+
+	for _, r := range results {
+		permType = r[0].(int)
+		name = r[1].(string)
+
+		switch permType {
+		case certPermissionContainer:
+			cert.Containers = append(cert.Containers, name)
+		case certPermissionProfile:
+			cert.Profiles = append(cert.Profiles, name)
+		case certPermissionProject:
+			cert.Projects = append(cert.Projects, name)
+		}
+	}
+
+	return nil
+}
+
+// dbCertPermissionsAdd records the containers, profiles and projects the
+// certificate with the given ID is restricted to.
+func dbCertPermissionsAdd(tx *sql.Tx, id int64, cert *dbCertInfo) error {
+	stmt, err := tx.Prepare("INSERT INTO certificates_permissions (certificate_id, type, name) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	permissions := map[int][]string{
+		certPermissionContainer: cert.Containers,
+		certPermissionProfile:   cert.Profiles,
+		certPermissionProject:   cert.Projects,
+	}
+
+	for permType, names := range permissions {
+		for _, name := range names {
+			_, err = stmt.Exec(id, permType, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // dbCertSave stores a CertBaseInfo object in the db,
 // it will ignore the ID field from the dbCertInfo.
 func dbCertSave(db *sql.DB, cert *dbCertInfo) error {
@@ -86,25 +179,39 @@ func dbCertSave(db *sql.DB, cert *dbCertInfo) error {
 				fingerprint,
 				type,
 				name,
-				certificate
-			) VALUES (?, ?, ?, ?)`,
+				certificate,
+				read_only
+			) VALUES (?, ?, ?, ?, ?)`,
 	)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
-	_, err = stmt.Exec(
+	result, err := stmt.Exec(
 		cert.Fingerprint,
 		cert.Type,
 		cert.Name,
 		cert.Certificate,
+		cert.ReadOnly,
 	)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbCertPermissionsAdd(tx, id, cert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	return txCommit(tx)
 }
 
@@ -118,13 +225,33 @@ func dbCertDelete(db *sql.DB, fingerprint string) error {
 	return nil
 }
 
-func dbCertUpdate(db *sql.DB, fingerprint string, certName string, certType int) error {
+// dbCertUpdate updates the name, type and permissions of a certificate.
+func dbCertUpdate(db *sql.DB, fingerprint string, cert *dbCertInfo) error {
 	tx, err := dbBegin(db)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec("UPDATE certificates SET name=?, type=? WHERE fingerprint=?", certName, certType, fingerprint)
+	_, err = tx.Exec("UPDATE certificates SET name=?, type=?, read_only=? WHERE fingerprint=?", cert.Name, cert.Type, cert.ReadOnly, fingerprint)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var id int64
+	err = tx.QueryRow("SELECT id FROM certificates WHERE fingerprint=?", fingerprint).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM certificates_permissions WHERE certificate_id=?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbCertPermissionsAdd(tx, id, cert)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -134,3 +261,126 @@ func dbCertUpdate(db *sql.DB, fingerprint string, certName string, certType int)
 
 	return err
 }
+
+// dbCertTokenInfo is a single-use token letting a client add its own
+// certificate to the trust store.
+type dbCertTokenInfo struct {
+	ID        int
+	Name      string
+	Secret    string
+	ExpiresAt time.Time
+}
+
+// dbCertTokensGet returns all the tokens which haven't expired yet, removing
+// the expired ones.
+func dbCertTokensGet(db *sql.DB) ([]*dbCertTokenInfo, error) {
+	rows, err := dbQuery(db, "SELECT id, name, secret, expiry_date FROM certificates_tokens ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []*dbCertTokenInfo{}
+	expired := []*dbCertTokenInfo{}
+	for rows.Next() {
+		token := new(dbCertTokenInfo)
+		err := rows.Scan(&token.ID, &token.Name, &token.Secret, &token.ExpiresAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if token.ExpiresAt.Before(time.Now()) {
+			expired = append(expired, token)
+			continue
+		}
+
+		tokens = append(tokens, token)
+	}
+	rows.Close()
+
+	for _, token := range expired {
+		_, err := dbExec(db, "DELETE FROM certificates_tokens WHERE id=?", token.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tokens, nil
+}
+
+// dbCertTokenGet returns the token with the given name, or NoSuchObjectError
+// if there's none or it expired.
+func dbCertTokenGet(db *sql.DB, name string) (*dbCertTokenInfo, error) {
+	tokens, err := dbCertTokensGet(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		if token.Name == name {
+			return token, nil
+		}
+	}
+
+	return nil, NoSuchObjectError
+}
+
+// dbCertTokenCreate stores a new token.
+func dbCertTokenCreate(db *sql.DB, token *dbCertTokenInfo) error {
+	// Expired tokens don't hold on to their name
+	_, err := dbCertTokensGet(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbExec(db, "INSERT INTO certificates_tokens (name, secret, expiry_date) VALUES (?, ?, ?)", token.Name, token.Secret, token.ExpiresAt)
+	return err
+}
+
+// dbCertTokenDelete revokes the token with the given name.
+func dbCertTokenDelete(db *sql.DB, name string) error {
+	_, err := dbExec(db, "DELETE FROM certificates_tokens WHERE name=?", name)
+	return err
+}
+
+// dbCertTokenFind returns the token with the given secret, without
+// consuming it, or NoSuchObjectError if it doesn't exist or expired.
+func dbCertTokenFind(db *sql.DB, secret string) (*dbCertTokenInfo, error) {
+	tokens, err := dbCertTokensGet(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Secret), []byte(secret)) == 1 {
+			return token, nil
+		}
+	}
+
+	return nil, NoSuchObjectError
+}
+
+// dbCertTokenUse consumes the token with the given secret, returning
+// NoSuchObjectError if it doesn't exist, expired or was used concurrently.
+func dbCertTokenUse(db *sql.DB, secret string) (*dbCertTokenInfo, error) {
+	token, err := dbCertTokenFind(db, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := dbExec(db, "DELETE FROM certificates_tokens WHERE id=?", token.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if count != 1 {
+		return nil, NoSuchObjectError
+	}
+
+	return token, nil
+}
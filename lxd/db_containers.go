@@ -52,7 +52,8 @@ func dbContainerId(db *sql.DB, name string) (int, error) {
 }
 
 func dbContainerGet(db *sql.DB, name string) (containerArgs, error) {
-	var used *time.Time // Hold the db-returned time
+	var used *time.Time   // Hold the db-returned time
+	var expiry *time.Time // Hold the db-returned time
 	description := sql.NullString{}
 
 	args := containerArgs{}
@@ -60,9 +61,11 @@ func dbContainerGet(db *sql.DB, name string) (containerArgs, error) {
 
 	ephemInt := -1
 	statefulInt := -1
-	q := "SELECT id, description, architecture, type, ephemeral, stateful, creation_date, last_use_date FROM containers WHERE name=?"
+	q := `SELECT containers.id, containers.description, architecture, type, ephemeral, stateful, creation_date, last_use_date, expiry_date, projects.name
+FROM containers JOIN projects ON containers.project_id=projects.id
+WHERE containers.name=?`
 	arg1 := []interface{}{name}
-	arg2 := []interface{}{&args.Id, &description, &args.Architecture, &args.Ctype, &ephemInt, &statefulInt, &args.CreationDate, &used}
+	arg2 := []interface{}{&args.Id, &description, &args.Architecture, &args.Ctype, &ephemInt, &statefulInt, &args.CreationDate, &used, &expiry, &args.Project}
 	err := dbQueryRowScan(db, q, arg1, arg2)
 	if err != nil {
 		return args, err
@@ -88,6 +91,10 @@ func dbContainerGet(db *sql.DB, name string) (containerArgs, error) {
 		args.LastUsedDate = time.Unix(0, 0).UTC()
 	}
 
+	if expiry != nil {
+		args.ExpiryDate = *expiry
+	}
+
 	config, err := dbContainerConfig(db, args.Id)
 	if err != nil {
 		return args, err
@@ -102,7 +109,7 @@ func dbContainerGet(db *sql.DB, name string) (containerArgs, error) {
 
 	/* get container_devices */
 	args.Devices = types.Devices{}
-	newdevs, err := dbDevices(db, name, false)
+	newdevs, err := dbDevices(db, args.Project, name, false)
 	if err != nil {
 		return args, err
 	}
@@ -120,6 +127,15 @@ func dbContainerCreate(db *sql.DB, args containerArgs) (int, error) {
 		return 0, DbErrAlreadyDefined
 	}
 
+	if args.Project == "" {
+		args.Project = "default"
+	}
+
+	profilesProject, err := projectProfilesProject(db, args.Project)
+	if err != nil {
+		return 0, err
+	}
+
 	tx, err := dbBegin(db)
 	if err != nil {
 		return 0, err
@@ -138,14 +154,19 @@ func dbContainerCreate(db *sql.DB, args containerArgs) (int, error) {
 	args.CreationDate = time.Now().UTC()
 	args.LastUsedDate = time.Unix(0, 0).UTC()
 
-	str := fmt.Sprintf("INSERT INTO containers (name, architecture, type, ephemeral, creation_date, last_use_date, stateful) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	var expiryDate interface{}
+	if !args.ExpiryDate.IsZero() {
+		expiryDate = args.ExpiryDate.Unix()
+	}
+
+	str := fmt.Sprintf("INSERT INTO containers (project_id, name, architecture, type, ephemeral, creation_date, last_use_date, stateful, expiry_date) VALUES ((SELECT id FROM projects WHERE name=?), ?, ?, ?, ?, ?, ?, ?, ?)")
 	stmt, err := tx.Prepare(str)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 	defer stmt.Close()
-	result, err := stmt.Exec(args.Name, args.Architecture, args.Ctype, ephemInt, args.CreationDate.Unix(), args.LastUsedDate.Unix(), statefulInt)
+	result, err := stmt.Exec(args.Project, args.Name, args.Architecture, args.Ctype, ephemInt, args.CreationDate.Unix(), args.LastUsedDate.Unix(), statefulInt, expiryDate)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
@@ -163,7 +184,7 @@ func dbContainerCreate(db *sql.DB, args containerArgs) (int, error) {
 		return 0, err
 	}
 
-	if err := dbContainerProfilesInsert(tx, id, args.Profiles); err != nil {
+	if err := dbContainerProfilesInsert(tx, id, profilesProject, args.Profiles); err != nil {
 		tx.Rollback()
 		return 0, err
 	}
@@ -241,17 +262,19 @@ func dbContainerSetStateful(db *sql.DB, id int, stateful bool) error {
 	return err
 }
 
-func dbContainerProfilesInsert(tx *sql.Tx, id int, profiles []string) error {
+// Link a container to the given profiles of a project.
+func dbContainerProfilesInsert(tx *sql.Tx, id int, project string, profiles []string) error {
 	applyOrder := 1
 	str := `INSERT INTO containers_profiles (container_id, profile_id, apply_order) VALUES
-		(?, (SELECT id FROM profiles WHERE name=?), ?);`
+		(?, (SELECT profiles.id FROM profiles JOIN projects ON profiles.project_id=projects.id
+		     WHERE projects.name=? AND profiles.name=?), ?);`
 	stmt, err := tx.Prepare(str)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 	for _, p := range profiles {
-		_, err = stmt.Exec(id, p, applyOrder)
+		_, err = stmt.Exec(id, project, p, applyOrder)
 		if err != nil {
 			logger.Debugf("Error adding profile %s to container: %s",
 				p, err)
@@ -334,6 +357,27 @@ func dbContainersList(db *sql.DB, cType containerType) ([]string, error) {
 	return ret, nil
 }
 
+// Get the internal names of all the containers of a project.
+func dbContainersProjectList(db *sql.DB, project string, cType containerType) ([]string, error) {
+	q := `SELECT containers.name FROM containers
+JOIN projects ON containers.project_id=projects.id
+WHERE projects.name=? AND containers.type=? ORDER BY containers.name`
+	inargs := []interface{}{project, cType}
+	var container string
+	outfmt := []interface{}{container}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []string{}
+	for _, container := range result {
+		ret = append(ret, container[0].(string))
+	}
+
+	return ret, nil
+}
+
 func dbContainerSetState(db *sql.DB, id int, state string) error {
 	tx, err := dbBegin(db)
 	if err != nil {
@@ -445,6 +489,26 @@ func dbContainerGetSnapshots(db *sql.DB, name string) ([]string, error) {
 	return result, nil
 }
 
+// Get the names of all snapshots which expired before the given date.
+func dbContainerGetExpiredSnapshots(db *sql.DB, date time.Time) ([]string, error) {
+	result := []string{}
+
+	name := ""
+	q := "SELECT name FROM containers WHERE type=? AND expiry_date IS NOT NULL AND expiry_date <= ?"
+	inargs := []interface{}{cTypeSnapshot, date.Unix()}
+	outfmt := []interface{}{name}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return result, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
 // Get the storage pool of a given container.
 func dbContainerPool(db *sql.DB, containerName string) (string, error) {
 	// Get container storage volume. Since container names are globally
@@ -468,3 +532,133 @@ WHERE storage_volumes.name=? AND storage_volumes.type=?`
 
 	return poolName, nil
 }
+
+// Get the names of all backups of a given container.
+func dbContainerGetBackups(db *sql.DB, name string) ([]string, error) {
+	result := []string{}
+
+	q := `SELECT containers_backups.name FROM containers_backups
+JOIN containers ON containers_backups.container_id=containers.id
+WHERE containers.name=?`
+	inargs := []interface{}{name}
+	outfmt := []interface{}{name}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
+// Get a given backup of a container.
+func dbContainerBackupGet(db *sql.DB, containerName string, name string) (backupArgs, error) {
+	var expiry *time.Time // Hold the db-returned time
+	containerOnlyInt := -1
+	optimizedStorageInt := -1
+
+	args := backupArgs{}
+	args.Name = name
+
+	q := `SELECT containers_backups.id, containers_backups.container_id,
+    containers_backups.creation_date, containers_backups.expiry_date,
+    containers_backups.container_only, containers_backups.optimized_storage
+FROM containers_backups
+JOIN containers ON containers_backups.container_id=containers.id
+WHERE containers.name=? AND containers_backups.name=?`
+	arg1 := []interface{}{containerName, name}
+	arg2 := []interface{}{&args.ID, &args.ContainerID, &args.CreationDate, &expiry, &containerOnlyInt, &optimizedStorageInt}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return args, NoSuchObjectError
+		}
+
+		return args, err
+	}
+
+	if expiry != nil {
+		args.ExpiryDate = *expiry
+	}
+
+	if containerOnlyInt == 1 {
+		args.ContainerOnly = true
+	}
+
+	if optimizedStorageInt == 1 {
+		args.OptimizedStorage = true
+	}
+
+	return args, nil
+}
+
+func dbContainerBackupCreate(db *sql.DB, args backupArgs) error {
+	_, err := dbContainerBackupID(db, args.ContainerID, args.Name)
+	if err == nil {
+		return DbErrAlreadyDefined
+	}
+
+	containerOnlyInt := 0
+	if args.ContainerOnly {
+		containerOnlyInt = 1
+	}
+
+	optimizedStorageInt := 0
+	if args.OptimizedStorage {
+		optimizedStorageInt = 1
+	}
+
+	var expiryDate interface{}
+	if !args.ExpiryDate.IsZero() {
+		expiryDate = args.ExpiryDate.Unix()
+	}
+
+	q := "INSERT INTO containers_backups (container_id, name, creation_date, expiry_date, container_only, optimized_storage) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err = dbExec(db, q, args.ContainerID, args.Name, args.CreationDate.Unix(), expiryDate, containerOnlyInt, optimizedStorageInt)
+	return err
+}
+
+func dbContainerBackupID(db *sql.DB, containerID int, name string) (int, error) {
+	q := "SELECT id FROM containers_backups WHERE container_id=? AND name=?"
+	id := -1
+	arg1 := []interface{}{containerID, name}
+	arg2 := []interface{}{&id}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	return id, err
+}
+
+func dbContainerBackupRemove(db *sql.DB, id int) error {
+	_, err := dbExec(db, "DELETE FROM containers_backups WHERE id=?", id)
+	return err
+}
+
+func dbContainerBackupRename(db *sql.DB, id int, newName string) error {
+	_, err := dbExec(db, "UPDATE containers_backups SET name=? WHERE id=?", newName, id)
+	return err
+}
+
+// Get the container and backup names of all expired backups.
+func dbContainerGetExpiredBackups(db *sql.DB, date time.Time) ([][2]string, error) {
+	result := [][2]string{}
+
+	containerName := ""
+	name := ""
+	q := `SELECT containers.name, containers_backups.name FROM containers_backups
+JOIN containers ON containers_backups.container_id=containers.id
+WHERE containers_backups.expiry_date IS NOT NULL AND containers_backups.expiry_date <= ?`
+	inargs := []interface{}{date.Unix()}
+	outfmt := []interface{}{containerName, name}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, [2]string{r[0].(string), r[1].(string)})
+	}
+
+	return result, nil
+}
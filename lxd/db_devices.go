@@ -25,6 +25,8 @@ func dbDeviceTypeToString(t int) (string, error) {
 		return "usb", nil
 	case 6:
 		return "gpu", nil
+	case 7:
+		return "proxy", nil
 	default:
 		return "", fmt.Errorf("Invalid device type %d", t)
 	}
@@ -46,6 +48,8 @@ func dbDeviceTypeToInt(t string) (int, error) {
 		return 5, nil
 	case "gpu":
 		return 6, nil
+	case "proxy":
+		return 7, nil
 	default:
 		return -1, fmt.Errorf("Invalid device type %s", t)
 	}
@@ -130,22 +134,24 @@ func dbDeviceConfig(db *sql.DB, id int, isprofile bool) (types.Device, error) {
 	return newdev, nil
 }
 
-func dbDevices(db *sql.DB, qName string, isprofile bool) (types.Devices, error) {
+func dbDevices(db *sql.DB, project string, qName string, isprofile bool) (types.Devices, error) {
 	var q string
 	if isprofile {
 		q = `SELECT profiles_devices.id, profiles_devices.name, profiles_devices.type
 			FROM profiles_devices JOIN profiles
 			ON profiles_devices.profile_id = profiles.id
-   		WHERE profiles.name=?`
+			JOIN projects ON profiles.project_id = projects.id
+   		WHERE projects.name=? AND profiles.name=?`
 	} else {
 		q = `SELECT containers_devices.id, containers_devices.name, containers_devices.type
 			FROM containers_devices JOIN containers
 			ON containers_devices.container_id = containers.id
-			WHERE containers.name=?`
+			JOIN projects ON containers.project_id = projects.id
+			WHERE projects.name=? AND containers.name=?`
 	}
 	var id, dtype int
 	var name, stype string
-	inargs := []interface{}{qName}
+	inargs := []interface{}{project, qName}
 	outfmt := []interface{}{id, name, dtype}
 	results, err := dbQueryScan(db, q, inargs, outfmt)
 	if err != nil {
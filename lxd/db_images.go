@@ -17,14 +17,17 @@ var dbImageSourceProtocol = map[int]string{
 	2: "simplestreams",
 }
 
-func dbImagesGet(db *sql.DB, public bool) ([]string, error) {
-	q := "SELECT fingerprint FROM images"
+// Get the fingerprints of the images of a project.
+func dbImagesGet(db *sql.DB, project string, public bool) ([]string, error) {
+	q := `SELECT fingerprint FROM images
+JOIN projects ON images.project_id=projects.id
+WHERE projects.name=?`
 	if public == true {
-		q = "SELECT fingerprint FROM images WHERE public=1"
+		q += " AND public=1"
 	}
 
 	var fp string
-	inargs := []interface{}{}
+	inargs := []interface{}{project}
 	outfmt := []interface{}{fp}
 	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
 	if err != nil {
@@ -39,8 +42,9 @@ func dbImagesGet(db *sql.DB, public bool) ([]string, error) {
 	return results, nil
 }
 
-func dbImagesGetExpired(db *sql.DB, expiry int64) ([]string, error) {
-	q := `SELECT fingerprint FROM images WHERE cached=1 AND creation_date<=strftime('%s', date('now', '-` + fmt.Sprintf("%d", expiry) + ` day'))`
+// Get the fingerprints of the images of all projects, each listed once.
+func dbImagesGetAllProjects(db *sql.DB) ([]string, error) {
+	q := "SELECT DISTINCT fingerprint FROM images"
 
 	var fp string
 	inargs := []interface{}{}
@@ -58,6 +62,51 @@ func dbImagesGetExpired(db *sql.DB, expiry int64) ([]string, error) {
 	return results, nil
 }
 
+// Get the project and fingerprint of all expired cached images.
+func dbImagesGetExpired(db *sql.DB, expiry int64) ([][2]string, error) {
+	q := `SELECT projects.name, fingerprint FROM images
+JOIN projects ON images.project_id=projects.id
+WHERE cached=1 AND creation_date<=strftime('%s', date('now', '-` + fmt.Sprintf("%d", expiry) + ` day'))`
+
+	var project, fp string
+	inargs := []interface{}{}
+	outfmt := []interface{}{project, fp}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return [][2]string{}, err
+	}
+
+	results := [][2]string{}
+	for _, r := range dbResults {
+		results = append(results, [2]string{r[0].(string), r[1].(string)})
+	}
+
+	return results, nil
+}
+
+// Get the names of all the projects an image with the given fingerprint
+// belongs to.
+func dbImageProjects(db *sql.DB, fingerprint string) ([]string, error) {
+	q := `SELECT projects.name FROM images
+JOIN projects ON images.project_id=projects.id
+WHERE fingerprint=? ORDER BY projects.id`
+
+	var project string
+	inargs := []interface{}{fingerprint}
+	outfmt := []interface{}{project}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return []string{}, err
+	}
+
+	results := []string{}
+	for _, r := range dbResults {
+		results = append(results, r[0].(string))
+	}
+
+	return results, nil
+}
+
 func dbImageSourceInsert(db *sql.DB, imageId int, server string, protocol string, certificate string, alias string) error {
 	stmt := `INSERT INTO images_source (image_id, server, protocol, certificate, alias) values (?, ?, ?, ?, ?)`
 
@@ -108,7 +157,7 @@ func dbImageSourceGet(db *sql.DB, imageId int) (int, api.ImageSource, error) {
 // Try to find a source entry of a locally cached image that matches
 // the given remote details (server, protocol and alias). Return the
 // fingerprint linked to the matching entry, if any.
-func dbImageSourceGetCachedFingerprint(db *sql.DB, server string, protocol string, alias string) (string, error) {
+func dbImageSourceGetCachedFingerprint(db *sql.DB, project string, server string, protocol string, alias string) (string, error) {
 	protocolInt := -1
 	for protoInt, protoString := range dbImageSourceProtocol {
 		if protoString == protocol {
@@ -124,12 +173,14 @@ func dbImageSourceGetCachedFingerprint(db *sql.DB, server string, protocol strin
 			FROM images_source
 			INNER JOIN images
 			ON images_source.image_id=images.id
-			WHERE server=? AND protocol=? AND alias=? AND auto_update=1
+			INNER JOIN projects
+			ON images.project_id=projects.id
+			WHERE projects.name=? AND server=? AND protocol=? AND alias=? AND auto_update=1
 			ORDER BY creation_date DESC`
 
 	fingerprint := ""
 
-	arg1 := []interface{}{server, protocolInt, alias}
+	arg1 := []interface{}{project, server, protocolInt, alias}
 	arg2 := []interface{}{&fingerprint}
 	err := dbQueryRowScan(db, q, arg1, arg2)
 	if err != nil {
@@ -143,12 +194,14 @@ func dbImageSourceGetCachedFingerprint(db *sql.DB, server string, protocol strin
 	return fingerprint, nil
 }
 
-// Whether an image with the given fingerprint exists.
-func dbImageExists(db *sql.DB, fingerprint string) (bool, error) {
+// Whether an image with the given fingerprint exists in a project.
+func dbImageExists(db *sql.DB, project string, fingerprint string) (bool, error) {
 	var exists bool
 	var err error
-	query := "SELECT COUNT(*) > 0 FROM images WHERE fingerprint=?"
-	inargs := []interface{}{fingerprint}
+	query := `SELECT COUNT(*) > 0 FROM images
+JOIN projects ON images.project_id=projects.id
+WHERE projects.name=? AND fingerprint=?`
+	inargs := []interface{}{project, fingerprint}
 	outargs := []interface{}{&exists}
 	err = dbQueryRowScan(db, query, inargs, outargs)
 	return exists, err
@@ -157,9 +210,9 @@ func dbImageExists(db *sql.DB, fingerprint string) (bool, error) {
 // dbImageGet gets an Image object from the database.
 // If strictMatching is false, The fingerprint argument will be queried with a LIKE query, means you can
 // pass a shortform and will get the full fingerprint.
-// There can never be more than one image with a given fingerprint, as it is
-// enforced by a UNIQUE constraint in the schema.
-func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool) (int, *api.Image, error) {
+// There can never be more than one image with a given fingerprint in a
+// project, as it is enforced by a UNIQUE constraint in the schema.
+func dbImageGet(db *sql.DB, project string, fingerprint string, public bool, strictMatching bool) (int, *api.Image, error) {
 	var err error
 	var create, expire, used, upload *time.Time // These hold the db-returned times
 
@@ -176,15 +229,16 @@ func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool
 	var inargs []interface{}
 	query := `
         SELECT
-            id, fingerprint, filename, size, cached, public, auto_update, architecture,
+            images.id, fingerprint, filename, size, cached, public, auto_update, architecture,
             creation_date, expiry_date, last_use_date, upload_date
-        FROM images`
+        FROM images
+        JOIN projects ON images.project_id=projects.id`
 	if strictMatching {
-		inargs = []interface{}{fingerprint}
-		query += " WHERE fingerprint = ?"
+		inargs = []interface{}{project, fingerprint}
+		query += " WHERE projects.name = ? AND fingerprint = ?"
 	} else {
-		inargs = []interface{}{fingerprint + "%"}
-		query += " WHERE fingerprint LIKE ?"
+		inargs = []interface{}{project, fingerprint + "%"}
+		query += " WHERE projects.name = ? AND fingerprint LIKE ?"
 	}
 
 	if public {
@@ -198,7 +252,9 @@ func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool
 
 	// Validate we only have a single match
 	if !strictMatching {
-		query = "SELECT COUNT(id) FROM images WHERE fingerprint LIKE ?"
+		query = `SELECT COUNT(images.id) FROM images
+JOIN projects ON images.project_id=projects.id
+WHERE projects.name = ? AND fingerprint LIKE ?`
 		count := 0
 		outfmt := []interface{}{&count}
 
@@ -291,12 +347,36 @@ func dbImageDelete(db *sql.DB, id int) error {
 	return nil
 }
 
-func dbImageAliasGet(db *sql.DB, name string, isTrustedClient bool) (int, api.ImageAliasesEntry, error) {
+// Get the names of all the aliases of a project.
+func dbImageAliases(db *sql.DB, project string) ([]string, error) {
+	q := `SELECT images_aliases.name FROM images_aliases
+JOIN projects ON images_aliases.project_id=projects.id
+WHERE projects.name=?`
+
+	var name string
+	inargs := []interface{}{project}
+	outfmt := []interface{}{name}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return []string{}, err
+	}
+
+	results := []string{}
+	for _, r := range dbResults {
+		results = append(results, r[0].(string))
+	}
+
+	return results, nil
+}
+
+func dbImageAliasGet(db *sql.DB, project string, name string, isTrustedClient bool) (int, api.ImageAliasesEntry, error) {
 	q := `SELECT images_aliases.id, images.fingerprint, images_aliases.description
 			 FROM images_aliases
 			 INNER JOIN images
 			 ON images_aliases.image_id=images.id
-			 WHERE images_aliases.name=?`
+			 INNER JOIN projects
+			 ON images_aliases.project_id=projects.id
+			 WHERE projects.name=? AND images_aliases.name=?`
 	if !isTrustedClient {
 		q = q + ` AND images.public=1`
 	}
@@ -305,7 +385,7 @@ func dbImageAliasGet(db *sql.DB, name string, isTrustedClient bool) (int, api.Im
 	id := -1
 	entry := api.ImageAliasesEntry{}
 
-	arg1 := []interface{}{name}
+	arg1 := []interface{}{project, name}
 	arg2 := []interface{}{&id, &fingerprint, &description}
 	err := dbQueryRowScan(db, q, arg1, arg2)
 	if err != nil {
@@ -328,8 +408,8 @@ func dbImageAliasRename(db *sql.DB, id int, name string) error {
 	return err
 }
 
-func dbImageAliasDelete(db *sql.DB, name string) error {
-	_, err := dbExec(db, "DELETE FROM images_aliases WHERE name=?", name)
+func dbImageAliasDelete(db *sql.DB, project string, name string) error {
+	_, err := dbExec(db, "DELETE FROM images_aliases WHERE project_id=(SELECT id FROM projects WHERE name=?) AND name=?", project, name)
 	return err
 }
 
@@ -339,9 +419,9 @@ func dbImageAliasesMove(db *sql.DB, source int, destination int) error {
 }
 
 // Insert an alias ento the database.
-func dbImageAliasAdd(db *sql.DB, name string, imageID int, desc string) error {
-	stmt := `INSERT INTO images_aliases (name, image_id, description) values (?, ?, ?)`
-	_, err := dbExec(db, stmt, name, imageID, desc)
+func dbImageAliasAdd(db *sql.DB, project string, name string, imageID int, desc string) error {
+	stmt := `INSERT INTO images_aliases (project_id, name, image_id, description) values ((SELECT id FROM projects WHERE name=?), ?, ?, ?)`
+	_, err := dbExec(db, stmt, project, name, imageID, desc)
 	return err
 }
 
@@ -351,15 +431,15 @@ func dbImageAliasUpdate(db *sql.DB, id int, imageID int, desc string) error {
 	return err
 }
 
-func dbImageLastAccessUpdate(db *sql.DB, fingerprint string, date time.Time) error {
-	stmt := `UPDATE images SET last_use_date=? WHERE fingerprint=?`
-	_, err := dbExec(db, stmt, date, fingerprint)
+func dbImageLastAccessUpdate(db *sql.DB, project string, fingerprint string, date time.Time) error {
+	stmt := `UPDATE images SET last_use_date=? WHERE project_id=(SELECT id FROM projects WHERE name=?) AND fingerprint=?`
+	_, err := dbExec(db, stmt, date, project, fingerprint)
 	return err
 }
 
-func dbImageLastAccessInit(db *sql.DB, fingerprint string) error {
-	stmt := `UPDATE images SET cached=1, last_use_date=strftime("%s") WHERE fingerprint=?`
-	_, err := dbExec(db, stmt, fingerprint)
+func dbImageLastAccessInit(db *sql.DB, project string, fingerprint string) error {
+	stmt := `UPDATE images SET cached=1, last_use_date=strftime("%s") WHERE project_id=(SELECT id FROM projects WHERE name=?) AND fingerprint=?`
+	_, err := dbExec(db, stmt, project, fingerprint)
 	return err
 }
 
@@ -420,7 +500,7 @@ func dbImageUpdate(db *sql.DB, id int, fname string, sz int64, public bool, auto
 	return nil
 }
 
-func dbImageInsert(db *sql.DB, fp string, fname string, sz int64, public bool, autoUpdate bool, architecture string, createdAt time.Time, expiresAt time.Time, properties map[string]string) error {
+func dbImageInsert(db *sql.DB, project string, fp string, fname string, sz int64, public bool, autoUpdate bool, architecture string, createdAt time.Time, expiresAt time.Time, properties map[string]string) error {
 	arch, err := osarch.ArchitectureId(architecture)
 	if err != nil {
 		arch = 0
@@ -441,14 +521,14 @@ func dbImageInsert(db *sql.DB, fp string, fname string, sz int64, public bool, a
 		autoUpdateInt = 1
 	}
 
-	stmt, err := tx.Prepare(`INSERT INTO images (fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?, strftime("%s"))`)
+	stmt, err := tx.Prepare(`INSERT INTO images (project_id, fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date) VALUES ((SELECT id FROM projects WHERE name=?), ?, ?, ?, ?, ?, ?, ?, ?, strftime("%s"))`)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(fp, fname, sz, publicInt, autoUpdateInt, arch, createdAt, expiresAt)
+	result, err := stmt.Exec(project, fp, fname, sz, publicInt, autoUpdateInt, arch, createdAt, expiresAt)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -10,10 +10,12 @@ import (
 	"github.com/lxc/lxd/shared/api"
 )
 
-// dbProfiles returns a string list of profiles.
-func dbProfiles(db *sql.DB) ([]string, error) {
-	q := fmt.Sprintf("SELECT name FROM profiles")
-	inargs := []interface{}{}
+// dbProfiles returns a string list of the profiles of a project.
+func dbProfiles(db *sql.DB, project string) ([]string, error) {
+	q := `SELECT profiles.name FROM profiles
+JOIN projects ON profiles.project_id=projects.id
+WHERE projects.name=?`
+	inargs := []interface{}{project}
 	var name string
 	outfmt := []interface{}{name}
 	result, err := dbQueryScan(db, q, inargs, outfmt)
@@ -29,24 +31,26 @@ func dbProfiles(db *sql.DB) ([]string, error) {
 	return response, nil
 }
 
-func dbProfileGet(db *sql.DB, name string) (int64, *api.Profile, error) {
+func dbProfileGet(db *sql.DB, project string, name string) (int64, *api.Profile, error) {
 	id := int64(-1)
 	description := sql.NullString{}
 
-	q := "SELECT id, description FROM profiles WHERE name=?"
-	arg1 := []interface{}{name}
+	q := `SELECT profiles.id, profiles.description FROM profiles
+JOIN projects ON profiles.project_id=projects.id
+WHERE projects.name=? AND profiles.name=?`
+	arg1 := []interface{}{project, name}
 	arg2 := []interface{}{&id, &description}
 	err := dbQueryRowScan(db, q, arg1, arg2)
 	if err != nil {
 		return -1, nil, err
 	}
 
-	config, err := dbProfileConfig(db, name)
+	config, err := dbProfileConfig(db, project, name)
 	if err != nil {
 		return -1, nil, err
 	}
 
-	devices, err := dbDevices(db, name, true)
+	devices, err := dbDevices(db, project, name, true)
 	if err != nil {
 		return -1, nil, err
 	}
@@ -62,14 +66,14 @@ func dbProfileGet(db *sql.DB, name string) (int64, *api.Profile, error) {
 	return id, &profile, nil
 }
 
-func dbProfileCreate(db *sql.DB, profile string, description string, config map[string]string,
+func dbProfileCreate(db *sql.DB, project string, profile string, description string, config map[string]string,
 	devices types.Devices) (int64, error) {
 
 	tx, err := dbBegin(db)
 	if err != nil {
 		return -1, err
 	}
-	result, err := tx.Exec("INSERT INTO profiles (name, description) VALUES (?, ?)", profile, description)
+	result, err := tx.Exec("INSERT INTO profiles (project_id, name, description) VALUES ((SELECT id FROM projects WHERE name=?), ?, ?)", project, profile, description)
 	if err != nil {
 		tx.Rollback()
 		return -1, err
@@ -101,14 +105,14 @@ func dbProfileCreate(db *sql.DB, profile string, description string, config map[
 }
 
 func dbProfileCreateDefault(db *sql.DB) error {
-	id, _, _ := dbProfileGet(db, "default")
+	id, _, _ := dbProfileGet(db, "default", "default")
 
 	if id != -1 {
 		// default profile already exists
 		return nil
 	}
 
-	_, err := dbProfileCreate(db, "default", "Default LXD profile", map[string]string{}, types.Devices{})
+	_, err := dbProfileCreate(db, "default", "default", "Default LXD profile", map[string]string{}, types.Devices{})
 	if err != nil {
 		return err
 	}
@@ -117,15 +121,16 @@ func dbProfileCreateDefault(db *sql.DB) error {
 }
 
 // Get the profile configuration map from the DB
-func dbProfileConfig(db *sql.DB, name string) (map[string]string, error) {
+func dbProfileConfig(db *sql.DB, project string, name string) (map[string]string, error) {
 	var key, value string
 	query := `
         SELECT
             key, value
         FROM profiles_config
         JOIN profiles ON profiles_config.profile_id=profiles.id
-		WHERE name=?`
-	inargs := []interface{}{name}
+        JOIN projects ON profiles.project_id=projects.id
+		WHERE projects.name=? AND profiles.name=?`
+	inargs := []interface{}{project, name}
 	outfmt := []interface{}{key, value}
 	results, err := dbQueryScan(db, query, inargs, outfmt)
 	if err != nil {
@@ -137,9 +142,11 @@ func dbProfileConfig(db *sql.DB, name string) (map[string]string, error) {
 		 * If we didn't get any rows here, let's check to make sure the
 		 * profile really exists; if it doesn't, let's send back a 404.
 		 */
-		query := "SELECT id FROM profiles WHERE name=?"
+		query := `SELECT profiles.id FROM profiles
+JOIN projects ON profiles.project_id=projects.id
+WHERE projects.name=? AND profiles.name=?`
 		var id int
-		results, err := dbQueryScan(db, query, []interface{}{name}, []interface{}{id})
+		results, err := dbQueryScan(db, query, []interface{}{project, name}, []interface{}{id})
 		if err != nil {
 			return nil, err
 		}
@@ -161,8 +168,8 @@ func dbProfileConfig(db *sql.DB, name string) (map[string]string, error) {
 	return config, nil
 }
 
-func dbProfileDelete(db *sql.DB, name string) error {
-	id, _, err := dbProfileGet(db, name)
+func dbProfileDelete(db *sql.DB, project string, name string) error {
+	id, _, err := dbProfileGet(db, project, name)
 	if err != nil {
 		return err
 	}
@@ -175,13 +182,13 @@ func dbProfileDelete(db *sql.DB, name string) error {
 	return nil
 }
 
-func dbProfileUpdate(db *sql.DB, name string, newName string) error {
+func dbProfileUpdate(db *sql.DB, project string, name string, newName string) error {
 	tx, err := dbBegin(db)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec("UPDATE profiles SET name=? WHERE name=?", newName, name)
+	_, err = tx.Exec("UPDATE profiles SET name=? WHERE name=? AND project_id=(SELECT id FROM projects WHERE name=?)", newName, name, project)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -233,16 +240,20 @@ func dbProfileConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
 	return nil
 }
 
-func dbProfileContainersGet(db *sql.DB, profile string) ([]string, error) {
-	q := `SELECT containers.name FROM containers JOIN containers_profiles
+// Get the project and name of all the containers using a given profile.
+// Containers of other projects may use the profiles of the default project.
+func dbProfileContainersGet(db *sql.DB, project string, profile string) ([][2]string, error) {
+	q := `SELECT container_projects.name, containers.name FROM containers JOIN containers_profiles
 		ON containers.id == containers_profiles.container_id
 		JOIN profiles ON containers_profiles.profile_id == profiles.id
-		WHERE profiles.name == ?`
+		JOIN projects ON profiles.project_id == projects.id
+		JOIN projects AS container_projects ON containers.project_id == container_projects.id
+		WHERE projects.name == ? AND profiles.name == ?`
 
-	results := []string{}
-	inargs := []interface{}{profile}
-	var name string
-	outfmt := []interface{}{name}
+	results := [][2]string{}
+	inargs := []interface{}{project, profile}
+	var containerProject, name string
+	outfmt := []interface{}{containerProject, name}
 
 	output, err := dbQueryScan(db, q, inargs, outfmt)
 	if err != nil {
@@ -250,7 +261,7 @@ func dbProfileContainersGet(db *sql.DB, profile string) ([]string, error) {
 	}
 
 	for _, r := range output {
-		results = append(results, r[0].(string))
+		results = append(results, [2]string{r[0].(string), r[1].(string)})
 	}
 
 	return results, nil
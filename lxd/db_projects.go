@@ -0,0 +1,220 @@
+package main
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// dbProjects returns a string list of projects.
+func dbProjects(db *sql.DB) ([]string, error) {
+	q := "SELECT name FROM projects ORDER BY name"
+	inargs := []interface{}{}
+	var name string
+	outfmt := []interface{}{name}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return []string{}, err
+	}
+
+	response := []string{}
+	for _, r := range result {
+		response = append(response, r[0].(string))
+	}
+
+	return response, nil
+}
+
+func dbProjectGet(db *sql.DB, name string) (int64, *api.Project, error) {
+	id := int64(-1)
+	description := sql.NullString{}
+
+	q := "SELECT id, description FROM projects WHERE name=?"
+	arg1 := []interface{}{name}
+	arg2 := []interface{}{&id, &description}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, NoSuchObjectError
+		}
+
+		return -1, nil, err
+	}
+
+	config, err := dbProjectConfig(db, id)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	project := api.Project{
+		Name: name,
+	}
+
+	project.Config = config
+	project.Description = description.String
+
+	return id, &project, nil
+}
+
+func dbProjectCreate(db *sql.DB, name string, description string, config map[string]string) (int64, error) {
+	tx, err := dbBegin(db)
+	if err != nil {
+		return -1, err
+	}
+
+	result, err := tx.Exec("INSERT INTO projects (name, description) VALUES (?, ?)", name, description)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = dbProjectConfigAdd(tx, id, config)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = txCommit(tx)
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+func dbProjectCreateDefault(db *sql.DB) error {
+	id, _, _ := dbProjectGet(db, "default")
+
+	if id != -1 {
+		// default project already exists
+		return nil
+	}
+
+	config := map[string]string{
+		"features.images":   "true",
+		"features.profiles": "true",
+	}
+
+	_, err := dbProjectCreate(db, "default", "Default LXD project", config)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get the project configuration map from the DB
+func dbProjectConfig(db *sql.DB, id int64) (map[string]string, error) {
+	var key, value string
+	query := "SELECT key, value FROM projects_config WHERE project_id=?"
+	inargs := []interface{}{id}
+	outfmt := []interface{}{key, value}
+	results, err := dbQueryScan(db, query, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	config := map[string]string{}
+
+	for _, r := range results {
+		key = r[0].(string)
+		value = r[1].(string)
+
+		config[key] = value
+	}
+
+	return config, nil
+}
+
+func dbProjectUpdate(db *sql.DB, id int64, description string, config map[string]string) error {
+	tx, err := dbBegin(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE projects SET description=? WHERE id=?", description, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM projects_config WHERE project_id=?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbProjectConfigAdd(tx, id, config)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return txCommit(tx)
+}
+
+func dbProjectConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	stmt, err := tx.Prepare("INSERT INTO projects_config (project_id, key, value) VALUES(?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dbProjectRename(db *sql.DB, name string, newName string) error {
+	_, err := dbExec(db, "UPDATE projects SET name=? WHERE name=?", newName, name)
+	return err
+}
+
+func dbProjectDelete(db *sql.DB, name string) error {
+	_, err := dbExec(db, "DELETE FROM projects WHERE name=?", name)
+	return err
+}
+
+// Whether the given project has its own set of the given feature ("images"
+// or "profiles") rather than using the one of the default project.
+func dbProjectHasFeature(db *sql.DB, project string, feature string) (bool, error) {
+	if project == "default" {
+		return true, nil
+	}
+
+	value := ""
+	query := `SELECT projects_config.value FROM projects_config
+JOIN projects ON projects_config.project_id=projects.id
+WHERE projects.name=? AND projects_config.key=?`
+	inargs := []interface{}{project, "features." + feature}
+	outargs := []interface{}{&value}
+
+	err := dbQueryRowScan(db, query, inargs, outargs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return shared.IsTrue(value), nil
+}
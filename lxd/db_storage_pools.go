@@ -337,6 +337,73 @@ func dbStoragePoolVolumesGetType(db *sql.DB, volumeType int, poolID int64) ([]st
 	return response, nil
 }
 
+// Get all storage volumes of a given project attached to a given storage pool
+// of a given volume type.
+func dbStoragePoolVolumesGetTypeProject(db *sql.DB, project string, volumeType int, poolID int64) ([]string, error) {
+	var volumeName string
+	query := `SELECT storage_volumes.name FROM storage_volumes
+JOIN projects ON storage_volumes.project_id=projects.id
+WHERE projects.name=? AND storage_pool_id=? AND type=?`
+	inargs := []interface{}{project, poolID, volumeType}
+	outargs := []interface{}{volumeName}
+
+	result, err := dbQueryScan(db, query, inargs, outargs)
+	if err != nil {
+		return []string{}, err
+	}
+
+	response := []string{}
+	for _, r := range result {
+		response = append(response, r[0].(string))
+	}
+
+	return response, nil
+}
+
+// Get the pool and name of all custom storage volumes of a given project.
+func dbStoragePoolVolumesGetCustomProject(db *sql.DB, project string) ([][2]string, error) {
+	var poolName, volumeName string
+	query := `SELECT storage_pools.name, storage_volumes.name FROM storage_volumes
+JOIN storage_pools ON storage_volumes.storage_pool_id=storage_pools.id
+JOIN projects ON storage_volumes.project_id=projects.id
+WHERE projects.name=? AND storage_volumes.type=?`
+	inargs := []interface{}{project, storagePoolVolumeTypeCustom}
+	outargs := []interface{}{poolName, volumeName}
+
+	result, err := dbQueryScan(db, query, inargs, outargs)
+	if err != nil {
+		return [][2]string{}, err
+	}
+
+	response := [][2]string{}
+	for _, r := range result {
+		response = append(response, [2]string{r[0].(string), r[1].(string)})
+	}
+
+	return response, nil
+}
+
+// Get the name of the project a storage volume belongs to.
+func dbStoragePoolVolumeProjectGet(db *sql.DB, volumeName string, volumeType int, poolID int64) (string, error) {
+	project := ""
+	query := `SELECT projects.name FROM storage_volumes
+JOIN projects ON storage_volumes.project_id=projects.id
+WHERE storage_volumes.storage_pool_id=? AND storage_volumes.name=? AND storage_volumes.type=?`
+	inargs := []interface{}{poolID, volumeName, volumeType}
+	outargs := []interface{}{&project}
+
+	err := dbQueryRowScan(db, query, inargs, outargs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", NoSuchObjectError
+		}
+
+		return "", err
+	}
+
+	return project, nil
+}
+
 // Get a single storage volume attached to a given storage pool of a given type.
 func dbStoragePoolVolumeGetType(db *sql.DB, volumeName string, volumeType int, poolID int64) (int64, *api.StorageVolume, error) {
 	volumeID, err := dbStoragePoolVolumeGetTypeID(db, volumeName, volumeType, poolID)
@@ -439,14 +506,14 @@ func dbStoragePoolVolumeRename(db *sql.DB, oldVolumeName string, newVolumeName s
 }
 
 // Create new storage volume attached to a given storage pool.
-func dbStoragePoolVolumeCreate(db *sql.DB, volumeName, volumeDescription string, volumeType int, poolID int64, volumeConfig map[string]string) (int64, error) {
+func dbStoragePoolVolumeCreate(db *sql.DB, project string, volumeName, volumeDescription string, volumeType int, poolID int64, volumeConfig map[string]string) (int64, error) {
 	tx, err := dbBegin(db)
 	if err != nil {
 		return -1, err
 	}
 
-	result, err := tx.Exec("INSERT INTO storage_volumes (storage_pool_id, type, name, description) VALUES (?, ?, ?, ?)",
-		poolID, volumeType, volumeName, volumeDescription)
+	result, err := tx.Exec("INSERT INTO storage_volumes (storage_pool_id, type, project_id, name, description) VALUES (?, ?, (SELECT id FROM projects WHERE name=?), ?, ?)",
+		poolID, volumeType, project, volumeName, volumeDescription)
 	if err != nil {
 		tx.Rollback()
 		return -1, err
@@ -2,8 +2,11 @@ package main
 
 import (
 	"database/sql"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/shared/api"
 )
 
 // Get config of a storage volume.
@@ -82,3 +85,74 @@ func dbStorageVolumeConfigClear(tx *sql.Tx, volumeID int64) error {
 
 	return nil
 }
+
+// Get the names of all snapshots of a storage volume, oldest first.
+func dbStoragePoolVolumeSnapshotsGet(db *sql.DB, volumeID int64) ([]string, error) {
+	result := []string{}
+
+	name := ""
+	query := "SELECT name FROM storage_volumes_snapshots WHERE storage_volume_id=? ORDER BY id"
+	inargs := []interface{}{volumeID}
+	outargs := []interface{}{name}
+
+	results, err := dbQueryScan(db, query, inargs, outargs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
+// Get a given snapshot of a storage volume.
+func dbStoragePoolVolumeSnapshotGet(db *sql.DB, volumeID int64, name string) (int64, *api.StorageVolumeSnapshot, error) {
+	snapshotID := int64(-1)
+	var creationDate *time.Time // Hold the db-returned time
+
+	query := "SELECT id, creation_date FROM storage_volumes_snapshots WHERE storage_volume_id=? AND name=?"
+	inargs := []interface{}{volumeID, name}
+	outargs := []interface{}{&snapshotID, &creationDate}
+
+	err := dbQueryRowScan(db, query, inargs, outargs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, NoSuchObjectError
+		}
+
+		return -1, nil, err
+	}
+
+	snapshot := api.StorageVolumeSnapshot{}
+	snapshot.Name = name
+	if creationDate != nil {
+		snapshot.CreationDate = *creationDate
+	}
+
+	return snapshotID, &snapshot, nil
+}
+
+// Create a new snapshot of a storage volume.
+func dbStoragePoolVolumeSnapshotCreate(db *sql.DB, volumeID int64, name string, creationDate time.Time) (int64, error) {
+	result, err := dbExec(db, "INSERT INTO storage_volumes_snapshots (storage_volume_id, name, creation_date) VALUES (?, ?, ?)",
+		volumeID, name, creationDate.Unix())
+	if err != nil {
+		return -1, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Rename a snapshot of a storage volume.
+func dbStoragePoolVolumeSnapshotRename(db *sql.DB, snapshotID int64, newName string) error {
+	_, err := dbExec(db, "UPDATE storage_volumes_snapshots SET name=? WHERE id=?", newName, snapshotID)
+	return err
+}
+
+// Delete a snapshot of a storage volume.
+func dbStoragePoolVolumeSnapshotDelete(db *sql.DB, snapshotID int64) error {
+	_, err := dbExec(db, "DELETE FROM storage_volumes_snapshots WHERE id=?", snapshotID)
+	return err
+}
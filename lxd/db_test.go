@@ -215,6 +215,15 @@ CREATE TABLE IF NOT EXISTS containers_config (
     FOREIGN KEY (container_id) REFERENCES containers (id),
     UNIQUE (container_id, key)
 );
+DROP TABLE images_aliases;
+CREATE TABLE images_aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    image_id INTEGER NOT NULL,
+    description VARCHAR(255),
+    FOREIGN KEY (image_id) REFERENCES images (id),
+    UNIQUE (name)
+);
 
 INSERT INTO containers (name, architecture, type) VALUES ('thename', 1, 1);
 INSERT INTO containers_config (container_id, key, value) VALUES (1, 'thekey', 'thevalue');`
@@ -342,7 +351,7 @@ func (s *dbTestSuite) Test_dbImageGet_finds_image_for_fingerprint() {
 	var err error
 	var result *api.Image
 
-	_, result, err = dbImageGet(s.db, "fingerprint", false, false)
+	_, result, err = dbImageGet(s.db, "default", "fingerprint", false, false)
 	s.Nil(err)
 	s.NotNil(result)
 	s.Equal(result.Filename, "filename")
@@ -354,14 +363,14 @@ func (s *dbTestSuite) Test_dbImageGet_finds_image_for_fingerprint() {
 func (s *dbTestSuite) Test_dbImageGet_for_missing_fingerprint() {
 	var err error
 
-	_, _, err = dbImageGet(s.db, "unknown", false, false)
+	_, _, err = dbImageGet(s.db, "default", "unknown", false, false)
 	s.Equal(err, sql.ErrNoRows)
 }
 
 func (s *dbTestSuite) Test_dbImageExists_true() {
 	var err error
 
-	exists, err := dbImageExists(s.db, "fingerprint")
+	exists, err := dbImageExists(s.db, "default", "fingerprint")
 	s.Nil(err)
 	s.True(exists)
 }
@@ -369,7 +378,7 @@ func (s *dbTestSuite) Test_dbImageExists_true() {
 func (s *dbTestSuite) Test_dbImageExists_false() {
 	var err error
 
-	exists, err := dbImageExists(s.db, "foobar")
+	exists, err := dbImageExists(s.db, "default", "foobar")
 	s.Nil(err)
 	s.False(exists)
 }
@@ -377,7 +386,7 @@ func (s *dbTestSuite) Test_dbImageExists_false() {
 func (s *dbTestSuite) Test_dbImageAliasGet_alias_exists() {
 	var err error
 
-	_, alias, err := dbImageAliasGet(s.db, "somealias", true)
+	_, alias, err := dbImageAliasGet(s.db, "default", "somealias", true)
 	s.Nil(err)
 	s.Equal(alias.Target, "fingerprint")
 }
@@ -385,41 +394,41 @@ func (s *dbTestSuite) Test_dbImageAliasGet_alias_exists() {
 func (s *dbTestSuite) Test_dbImageAliasGet_alias_does_not_exists() {
 	var err error
 
-	_, _, err = dbImageAliasGet(s.db, "whatever", true)
+	_, _, err = dbImageAliasGet(s.db, "default", "whatever", true)
 	s.Equal(err, NoSuchObjectError)
 }
 
 func (s *dbTestSuite) Test_dbImageAliasAdd() {
 	var err error
 
-	err = dbImageAliasAdd(s.db, "Chaosphere", 1, "Someone will like the name")
+	err = dbImageAliasAdd(s.db, "default", "Chaosphere", 1, "Someone will like the name")
 	s.Nil(err)
 
-	_, alias, err := dbImageAliasGet(s.db, "Chaosphere", true)
+	_, alias, err := dbImageAliasGet(s.db, "default", "Chaosphere", true)
 	s.Nil(err)
 	s.Equal(alias.Target, "fingerprint")
 }
 
 func (s *dbTestSuite) Test_dbImageSourceGetCachedFingerprint() {
-	imageID, _, err := dbImageGet(s.db, "fingerprint", false, false)
+	imageID, _, err := dbImageGet(s.db, "default", "fingerprint", false, false)
 	s.Nil(err)
 
 	err = dbImageSourceInsert(s.db, imageID, "server.remote", "simplestreams", "", "test")
 	s.Nil(err)
 
-	fingerprint, err := dbImageSourceGetCachedFingerprint(s.db, "server.remote", "simplestreams", "test")
+	fingerprint, err := dbImageSourceGetCachedFingerprint(s.db, "default", "server.remote", "simplestreams", "test")
 	s.Nil(err)
 	s.Equal(fingerprint, "fingerprint")
 }
 
 func (s *dbTestSuite) Test_dbImageSourceGetCachedFingerprint_no_match() {
-	imageID, _, err := dbImageGet(s.db, "fingerprint", false, false)
+	imageID, _, err := dbImageGet(s.db, "default", "fingerprint", false, false)
 	s.Nil(err)
 
 	err = dbImageSourceInsert(s.db, imageID, "server.remote", "simplestreams", "", "test")
 	s.Nil(err)
 
-	_, err = dbImageSourceGetCachedFingerprint(s.db, "server.remote", "lxd", "test")
+	_, err = dbImageSourceGetCachedFingerprint(s.db, "default", "server.remote", "lxd", "test")
 	s.Equal(err, NoSuchObjectError)
 }
 
@@ -450,7 +459,7 @@ func (s *dbTestSuite) Test_dbProfileConfig() {
 	_, err = s.db.Exec("INSERT INTO profiles_config (profile_id, key, value) VALUES (2, 'something', 'something else');")
 	s.Nil(err)
 
-	result, err = dbProfileConfig(s.db, "theprofile")
+	result, err = dbProfileConfig(s.db, "default", "theprofile")
 	s.Nil(err)
 
 	expected = map[string]string{"thekey": "thevalue", "something": "something else"}
@@ -482,7 +491,7 @@ func (s *dbTestSuite) Test_dbDevices_profiles() {
 	var subresult types.Device
 	var expected types.Device
 
-	result, err = dbDevices(s.db, "theprofile", true)
+	result, err = dbDevices(s.db, "default", "theprofile", true)
 	s.Nil(err)
 
 	expected = types.Device{"type": "nic", "devicekey": "devicevalue"}
@@ -500,7 +509,7 @@ func (s *dbTestSuite) Test_dbDevices_containers() {
 	var subresult types.Device
 	var expected types.Device
 
-	result, err = dbDevices(s.db, "thename", false)
+	result, err = dbDevices(s.db, "default", "thename", false)
 	s.Nil(err)
 
 	expected = types.Device{"type": "nic", "configkey": "configvalue"}
@@ -511,3 +520,172 @@ func (s *dbTestSuite) Test_dbDevices_containers() {
 			fmt.Sprintf("Mismatching value for key %s: %s != %s", key, subresult[key], value))
 	}
 }
+
+func (s *dbTestSuite) Test_dbProjectCreate() {
+	id, err := dbProjectCreate(s.db, "myproject", "My project", map[string]string{"features.images": "true"})
+	s.Nil(err)
+
+	gotID, project, err := dbProjectGet(s.db, "myproject")
+	s.Nil(err)
+	s.Equal(id, gotID)
+	s.Equal("My project", project.Description)
+	s.Equal(map[string]string{"features.images": "true"}, project.Config)
+
+	projects, err := dbProjects(s.db)
+	s.Nil(err)
+	s.Equal([]string{"default", "myproject"}, projects)
+}
+
+func (s *dbTestSuite) Test_dbProjectGet_missing() {
+	_, _, err := dbProjectGet(s.db, "unknown")
+	s.Equal(NoSuchObjectError, err)
+}
+
+func (s *dbTestSuite) Test_dbProjectHasFeature() {
+	_, err := dbProjectCreate(s.db, "myproject", "", map[string]string{"features.images": "true"})
+	s.Nil(err)
+
+	hasImages, err := dbProjectHasFeature(s.db, "myproject", "images")
+	s.Nil(err)
+	s.True(hasImages)
+
+	hasProfiles, err := dbProjectHasFeature(s.db, "myproject", "profiles")
+	s.Nil(err)
+	s.False(hasProfiles)
+
+	// The default project always has its own images and profiles
+	hasProfiles, err = dbProjectHasFeature(s.db, "default", "profiles")
+	s.Nil(err)
+	s.True(hasProfiles)
+}
+
+func (s *dbTestSuite) Test_deleting_a_project_cascades_on_related_tables() {
+	_, err := dbProjectCreate(s.db, "myproject", "", map[string]string{"features.profiles": "true"})
+	s.Nil(err)
+
+	_, err = dbProfileCreate(s.db, "myproject", "default", "", map[string]string{"thekey": "thevalue"}, types.Devices{})
+	s.Nil(err)
+
+	err = dbProjectDelete(s.db, "myproject")
+	s.Nil(err)
+
+	profiles, err := dbProfiles(s.db, "myproject")
+	s.Nil(err)
+	s.Equal([]string{}, profiles)
+
+	var count int
+	err = s.db.QueryRow("SELECT count(*) FROM projects_config").Scan(&count)
+	s.Nil(err)
+	s.Equal(2, count, "Only the configuration of the default project should remain.")
+}
+
+func (s *dbTestSuite) Test_dbCertPermissions() {
+	cert := &dbCertInfo{
+		Fingerprint: "abcdef",
+		Type:        1,
+		Name:        "ci",
+		Certificate: "PEM",
+		ReadOnly:    true,
+		Containers:  []string{"c1", "c2"},
+		Projects:    []string{"default"}}
+
+	err := dbCertSave(s.db, cert)
+	s.Nil(err)
+
+	result, err := dbCertGet(s.db, "abcdef")
+	s.Nil(err)
+	s.True(result.ReadOnly)
+	s.Equal([]string{"c1", "c2"}, result.Containers)
+	s.Equal(0, len(result.Profiles))
+	s.Equal([]string{"default"}, result.Projects)
+
+	// Updating replaces the restrictions
+	err = dbCertUpdate(s.db, "abcdef", &dbCertInfo{Name: "ci", Type: 1, Profiles: []string{"p1"}})
+	s.Nil(err)
+
+	result, err = dbCertGet(s.db, "abcdef")
+	s.Nil(err)
+	s.False(result.ReadOnly)
+	s.Equal(0, len(result.Containers))
+	s.Equal([]string{"p1"}, result.Profiles)
+	s.Equal(0, len(result.Projects))
+	s.True(result.isRestricted())
+}
+
+func (s *dbTestSuite) Test_dbCertTokens() {
+	err := dbCertTokenCreate(s.db, &dbCertTokenInfo{Name: "ci", Secret: "secret", ExpiresAt: time.Now().Add(time.Hour)})
+	s.Nil(err)
+
+	err = dbCertTokenCreate(s.db, &dbCertTokenInfo{Name: "old", Secret: "expired", ExpiresAt: time.Now().Add(-time.Hour)})
+	s.Nil(err)
+
+	// Expired tokens are left out
+	tokens, err := dbCertTokensGet(s.db)
+	s.Nil(err)
+	s.Equal(1, len(tokens))
+	s.Equal("ci", tokens[0].Name)
+
+	_, err = dbCertTokenUse(s.db, "expired")
+	s.Equal(NoSuchObjectError, err)
+
+	// Looking a token up doesn't consume it
+	token, err := dbCertTokenFind(s.db, "secret")
+	s.Nil(err)
+	s.Equal("ci", token.Name)
+
+	_, err = dbCertTokenFind(s.db, "secre")
+	s.Equal(NoSuchObjectError, err)
+
+	// Tokens can only be used once
+	token, err = dbCertTokenUse(s.db, "secret")
+	s.Nil(err)
+	s.Equal("ci", token.Name)
+
+	_, err = dbCertTokenUse(s.db, "secret")
+	s.Equal(NoSuchObjectError, err)
+
+	_, err = dbCertTokenGet(s.db, "ci")
+	s.Equal(NoSuchObjectError, err)
+}
+
+func (s *dbTestSuite) Test_dbAudit() {
+	for _, url := range []string{"/1.0/containers/c1", "/1.0/containers/c2", "/1.0/networks/lxdbr0"} {
+		_, err := dbAuditAdd(s.db, &api.AuditEntry{Date: time.Now(), Requestor: "unix socket", UID: 1000, Method: "PUT", URL: url})
+		s.Nil(err)
+	}
+
+	id, err := dbAuditAdd(s.db, &api.AuditEntry{Date: time.Now(), Requestor: "abcdef", UID: -1, Method: "DELETE", URL: "/1.0/containers/c1", Status: "Running"})
+	s.Nil(err)
+
+	err = dbAuditUpdateStatus(s.db, id, "Success")
+	s.Nil(err)
+
+	entries, err := dbAuditGet(s.db, dbAuditFilter{URL: "/containers/c1"})
+	s.Nil(err)
+	s.Equal(2, len(entries))
+	s.Equal("unix socket", entries[0].Requestor)
+	s.Equal(int64(1000), entries[0].UID)
+	s.Equal("Success", entries[1].Status)
+
+	entries, err = dbAuditGet(s.db, dbAuditFilter{Requestor: "abc", Method: "DELETE"})
+	s.Nil(err)
+	s.Equal(1, len(entries))
+
+	entries, err = dbAuditGet(s.db, dbAuditFilter{Since: time.Now().Add(time.Hour)})
+	s.Nil(err)
+	s.Equal(0, len(entries))
+
+	// Only the latest entries are kept
+	err = dbAuditPrune(s.db, 2)
+	s.Nil(err)
+
+	entries, err = dbAuditGet(s.db, dbAuditFilter{})
+	s.Nil(err)
+	s.Equal(2, len(entries))
+	s.Equal("/1.0/networks/lxdbr0", entries[0].URL)
+
+	entries, err = dbAuditGet(s.db, dbAuditFilter{Limit: 1})
+	s.Nil(err)
+	s.Equal(1, len(entries))
+	s.Equal(id, entries[0].ID)
+}
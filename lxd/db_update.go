@@ -66,6 +66,13 @@ var dbUpdates = []dbUpdate{
 	{version: 34, run: dbUpdateFromV33},
 	{version: 35, run: dbUpdateFromV34},
 	{version: 36, run: dbUpdateFromV35},
+	{version: 37, run: dbUpdateFromV36},
+	{version: 38, run: dbUpdateFromV37},
+	{version: 39, run: dbUpdateFromV38},
+	{version: 40, run: dbUpdateFromV39},
+	{version: 41, run: dbUpdateFromV40},
+	{version: 42, run: dbUpdateFromV41},
+	{version: 43, run: dbUpdateFromV42},
 }
 
 type dbUpdate struct {
@@ -135,6 +142,172 @@ func dbUpdatesApplyAll(db *sql.DB, doBackup bool, postApply func(int) error) err
 }
 
 // Schema updates begin here
+func dbUpdateFromV42(currentVersion int, version int, db *sql.DB) error {
+	stmts := `
+CREATE TABLE IF NOT EXISTS audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    date DATETIME NOT NULL,
+    requestor VARCHAR(255) NOT NULL,
+    uid INTEGER NOT NULL DEFAULT -1,
+    address VARCHAR(255) NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    body TEXT NOT NULL,
+    operation VARCHAR(255) NOT NULL,
+    status TEXT NOT NULL
+);`
+	_, err := db.Exec(stmts)
+	return err
+}
+
+func dbUpdateFromV41(currentVersion int, version int, db *sql.DB) error {
+	stmts := `
+CREATE TABLE IF NOT EXISTS certificates_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    expiry_date DATETIME NOT NULL,
+    UNIQUE (name),
+    UNIQUE (secret)
+);`
+	_, err := db.Exec(stmts)
+	return err
+}
+
+func dbUpdateFromV40(currentVersion int, version int, db *sql.DB) error {
+	stmts := `
+ALTER TABLE certificates ADD COLUMN read_only INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS certificates_permissions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    certificate_id INTEGER NOT NULL,
+    type INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (certificate_id, type, name),
+    FOREIGN KEY (certificate_id) REFERENCES certificates (id) ON DELETE CASCADE
+);`
+	_, err := db.Exec(stmts)
+	return err
+}
+
+func dbUpdateFromV39(currentVersion int, version int, db *sql.DB) error {
+	stmts := `
+PRAGMA foreign_keys=OFF; -- So that the table rebuilds don't cascade
+
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (name)
+);
+CREATE TABLE IF NOT EXISTS projects_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (project_id, key),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+INSERT INTO projects (id, name, description) VALUES (1, 'default', 'Default LXD project');
+INSERT INTO projects_config (project_id, key, value) VALUES (1, 'features.images', 'true');
+INSERT INTO projects_config (project_id, key, value) VALUES (1, 'features.profiles', 'true');
+
+ALTER TABLE containers ADD COLUMN project_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE storage_volumes ADD COLUMN project_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE tmp (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (project_id, name),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+INSERT INTO tmp (id, name, description)
+    SELECT id, name, description
+    FROM profiles;
+DROP TABLE profiles;
+ALTER TABLE tmp RENAME TO profiles;
+
+CREATE TABLE tmp (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
+    cached INTEGER NOT NULL DEFAULT 0,
+    fingerprint VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    size INTEGER NOT NULL,
+    public INTEGER NOT NULL DEFAULT 0,
+    auto_update INTEGER NOT NULL DEFAULT 0,
+    architecture INTEGER NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    upload_date DATETIME NOT NULL,
+    last_use_date DATETIME,
+    UNIQUE (project_id, fingerprint),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+INSERT INTO tmp (id, cached, fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date, last_use_date)
+    SELECT id, cached, fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date, last_use_date
+    FROM images;
+DROP TABLE images;
+ALTER TABLE tmp RENAME TO images;
+
+CREATE TABLE tmp (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
+    name VARCHAR(255) NOT NULL,
+    image_id INTEGER NOT NULL,
+    description TEXT,
+    FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    UNIQUE (project_id, name)
+);
+INSERT INTO tmp (id, name, image_id, description)
+    SELECT id, name, image_id, description
+    FROM images_aliases;
+DROP TABLE images_aliases;
+ALTER TABLE tmp RENAME TO images_aliases;
+
+PRAGMA foreign_keys=ON; -- Make sure we turn integrity checks back on.`
+	_, err := db.Exec(stmts)
+	return err
+}
+
+func dbUpdateFromV38(currentVersion int, version int, db *sql.DB) error {
+	stmts := `
+CREATE TABLE IF NOT EXISTS storage_volumes_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_volume_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    creation_date DATETIME,
+    UNIQUE (storage_volume_id, name),
+    FOREIGN KEY (storage_volume_id) REFERENCES storage_volumes (id) ON DELETE CASCADE
+);`
+	_, err := db.Exec(stmts)
+	return err
+}
+
+func dbUpdateFromV37(currentVersion int, version int, db *sql.DB) error {
+	stmts := `
+CREATE TABLE IF NOT EXISTS containers_backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    container_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    container_only INTEGER NOT NULL default 0,
+    optimized_storage INTEGER NOT NULL default 0,
+    FOREIGN KEY (container_id) REFERENCES containers (id) ON DELETE CASCADE,
+    UNIQUE (container_id, name)
+);`
+	_, err := db.Exec(stmts)
+	return err
+}
+
+func dbUpdateFromV36(currentVersion int, version int, db *sql.DB) error {
+	_, err := db.Exec("ALTER TABLE containers ADD COLUMN expiry_date DATETIME;")
+	return err
+}
+
 func dbUpdateFromV35(currentVersion int, version int, db *sql.DB) error {
 	stmts := `
 CREATE TABLE tmp (
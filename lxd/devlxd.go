@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/gorilla/mux"
@@ -68,7 +69,7 @@ var configKeyGet = devLxdHandler{"/1.0/config/{key}", func(c container, r *http.
 
 var metadataGet = devLxdHandler{"/1.0/meta-data", func(c container, r *http.Request) *devLxdResponse {
 	value := c.ExpandedConfig()["user.meta-data"]
-	return okResponse(fmt.Sprintf("#cloud-config\ninstance-id: %s\nlocal-hostname: %s\n%s", c.Name(), c.Name(), value), "raw")
+	return okResponse(fmt.Sprintf("#cloud-config\ninstance-id: %s\nlocal-hostname: %s\n%s", c.Name(), projectUnprefix(c.Project(), c.Name()), value), "raw")
 }}
 
 var handlers = []devLxdHandler{
@@ -87,7 +88,7 @@ var handlers = []devLxdHandler{
 func hoistReq(f func(container, *http.Request) *devLxdResponse, d *Daemon) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		conn := extractUnderlyingConn(w)
-		cred, ok := pidMapper.m[conn]
+		cred, ok := pidMapper.get(conn)
 		if !ok {
 			http.Error(w, pidNotInContainerErr.Error(), 500)
 			return
@@ -210,11 +211,24 @@ type ucred struct {
 }
 
 type ConnPidMapper struct {
-	m map[*net.UnixConn]*ucred
+	m    map[*net.UnixConn]*ucred
+	lock sync.Mutex
+}
+
+func (m *ConnPidMapper) get(conn *net.UnixConn) (*ucred, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	cred, ok := m.m[conn]
+	return cred, ok
 }
 
 func (m *ConnPidMapper) ConnStateHandler(conn net.Conn, state http.ConnState) {
 	unixConn := conn.(*net.UnixConn)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	switch state {
 	case http.StateNew:
 		cred, err := getCred(unixConn)
@@ -310,6 +324,42 @@ func extractUnderlyingConn(w http.ResponseWriter) *net.UnixConn {
 	return unixConnPtr
 }
 
+/*
+ * Same as extractUnderlyingConn, but for handlers which may also be reached
+ * over TCP. This returns the credentials of the peer if the request came in
+ * over a unix socket whose server tracks them with pidMapper, nil otherwise.
+ */
+func extractUnderlyingCred(w http.ResponseWriter) *ucred {
+	v := reflect.Indirect(reflect.ValueOf(w))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	connPtr := v.FieldByName("conn")
+	if !connPtr.IsValid() || connPtr.Kind() != reflect.Ptr || connPtr.IsNil() {
+		return nil
+	}
+
+	conn := reflect.Indirect(connPtr)
+	rwc := conn.FieldByName("rwc")
+	if !rwc.IsValid() || rwc.Kind() != reflect.Interface {
+		return nil
+	}
+
+	netConnPtr := (*net.Conn)(unsafe.Pointer(rwc.UnsafeAddr()))
+	unixConnPtr, ok := (*netConnPtr).(*net.UnixConn)
+	if !ok {
+		return nil
+	}
+
+	cred, ok := pidMapper.get(unixConnPtr)
+	if !ok {
+		return nil
+	}
+
+	return cred
+}
+
 var pidNotInContainerErr = fmt.Errorf("pid not in container?")
 
 func findContainerForPid(pid int32, d *Daemon) (container, error) {
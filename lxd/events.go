@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,9 +15,13 @@ import (
 	log "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 )
 
+// Number of events kept around for listeners reconnecting with "since"
+const eventsBufferSize = 1000
+
 type eventsHandler struct {
 }
 
@@ -46,34 +52,161 @@ func (h eventsHandler) Log(r *log.Record) error {
 var eventsLock sync.Mutex
 var eventListeners map[string]*eventListener = make(map[string]*eventListener)
 
+// The ring buffer of past events, protected by eventsLock. Logging events
+// aren't kept as they would quickly push everything else out.
+var eventsLastID int64
+var eventsDroppedID int64
+var eventsBuffer []*eventRecord
+
 type eventListener struct {
-	connection   *websocket.Conn
-	messageTypes []string
-	active       chan bool
-	id           string
-	msgLock      sync.Mutex
+	connection *websocket.Conn
+	filter     eventFilter
+	active     chan bool
+	id         string
+	msgLock    sync.Mutex
+}
+
+// eventFilter restricts the events sent to a listener. Resources only apply
+// to operation and lifecycle events and the level only to logging events.
+// Listeners with an owner only get the operation events of that user, and
+// listeners with permissions those of the operations they may access.
+type eventFilter struct {
+	types       []string
+	resources   []string
+	level       log.Lvl
+	owner       int64
+	permissions *dbCertInfo
+}
+
+// eventRecord is a rendered event along with the fields it may be filtered on.
+type eventRecord struct {
+	id        int64
+	eventType string
+	body      []byte
+	resources []string
+	level     log.Lvl
+	owner     int64
+
+	// The project and resources of the operation of operation events
+	opProject   string
+	opResources map[string][]string
+}
+
+func (f *eventFilter) match(event *eventRecord) bool {
+	if !shared.StringInSlice(event.eventType, f.types) {
+		return false
+	}
+
+	if f.owner >= 0 && (event.eventType != "operation" || event.owner != f.owner) {
+		return false
+	}
+
+	if f.permissions != nil && (event.eventType != "operation" || !certificateAllowsOperation(f.permissions, event.opProject, event.opResources)) {
+		return false
+	}
+
+	switch event.eventType {
+	case "logging":
+		return event.level <= f.level
+	case "operation", "lifecycle":
+		if len(f.resources) == 0 {
+			return true
+		}
+
+		for _, resource := range event.resources {
+			for _, filter := range f.resources {
+				if eventResourceMatch(resource, filter) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// Splits an API URL into its path and project, the default project when the
+// URL has none.
+func eventResourceSplit(uri string) (string, string) {
+	fields, err := url.Parse(uri)
+	if err != nil {
+		return uri, "default"
+	}
+
+	project := fields.Query().Get("project")
+	if project == "" {
+		project = "default"
+	}
+
+	return fields.Path, project
+}
+
+// Whether the URL of an object is the filter URL or below it, in the same
+// project.
+func eventResourceMatch(resource string, filter string) bool {
+	resourcePath, resourceProject := eventResourceSplit(resource)
+	filterPath, filterProject := eventResourceSplit(filter)
+
+	if resourceProject != filterProject {
+		return false
+	}
+
+	return resourcePath == filterPath || strings.HasPrefix(resourcePath, filterPath+"/")
+}
+
+// eventsParseFilter reads the filtering arguments of an events request.
+// Resource URLs without a project refer to the project of the request.
+func eventsParseFilter(r *http.Request) (eventFilter, error) {
+	filter := eventFilter{level: log.LvlDebug, owner: -1}
+
+	typeStr := r.FormValue("type")
+	if typeStr == "" {
+		typeStr = "logging,operation,lifecycle"
+	}
+	filter.types = strings.Split(typeStr, ",")
+
+	resourceStr := r.FormValue("resource")
+	if resourceStr != "" {
+		for _, resource := range strings.Split(resourceStr, ",") {
+			if !strings.Contains(resource, "?") {
+				resource = projectURL(resource, projectParam(r))
+			}
+
+			filter.resources = append(filter.resources, resource)
+		}
+	}
+
+	levelStr := r.FormValue("level")
+	if levelStr != "" {
+		level, err := log.LvlFromString(levelStr)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid log level \"%s\"", levelStr)
+		}
+		filter.level = level
+	}
+
+	return filter, nil
 }
 
 type eventsServe struct {
-	req *http.Request
+	req    *http.Request
+	filter eventFilter
+	since  int64
 }
 
 func (r *eventsServe) Render(w http.ResponseWriter) error {
-	return eventsSocket(r.req, w)
+	return eventsSocket(r.req, w, r.filter, r.since)
 }
 
 func (r *eventsServe) String() string {
 	return "event handler"
 }
 
-func eventsSocket(r *http.Request, w http.ResponseWriter) error {
+func eventsSocket(r *http.Request, w http.ResponseWriter, filter eventFilter, since int64) error {
 	listener := eventListener{}
 
-	typeStr := r.FormValue("type")
-	if typeStr == "" {
-		typeStr = "logging,operation"
-	}
-
 	c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return err
@@ -82,12 +215,34 @@ func eventsSocket(r *http.Request, w http.ResponseWriter) error {
 	listener.active = make(chan bool, 1)
 	listener.connection = c
 	listener.id = uuid.NewRandom().String()
-	listener.messageTypes = strings.Split(typeStr, ",")
+	listener.filter = filter
+
+	// Grab the backlog and register the listener in one go so that nothing
+	// gets lost in between. Holding msgLock until the backlog has been
+	// sent ensures that new events only go out after it.
+	backlog := []*eventRecord{}
 
+	listener.msgLock.Lock()
 	eventsLock.Lock()
+	if since >= 0 {
+		for _, event := range eventsBuffer {
+			if event.id > since && filter.match(event) {
+				backlog = append(backlog, event)
+			}
+		}
+	}
 	eventListeners[listener.id] = &listener
 	eventsLock.Unlock()
 
+	for _, event := range backlog {
+		err = listener.connection.WriteMessage(websocket.TextMessage, event.body)
+		if err != nil {
+			listener.active <- false
+			break
+		}
+	}
+	listener.msgLock.Unlock()
+
 	logger.Debugf("New events listener: %s", listener.id)
 
 	<-listener.active
@@ -103,26 +258,106 @@ func eventsSocket(r *http.Request, w http.ResponseWriter) error {
 }
 
 func eventsGet(d *Daemon, r *http.Request) Response {
-	return &eventsServe{r}
+	filter, err := eventsParseFilter(r)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	user := requestUnixUser(r)
+	if user != nil {
+		filter.owner = user.uid
+	}
+	filter.permissions = d.requestPermissions(r)
+
+	since := int64(-1)
+
+	sinceStr := r.FormValue("since")
+	if sinceStr != "" {
+		since, err = strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || since < 0 {
+			return BadRequest(fmt.Errorf("Invalid event ID \"%s\"", sinceStr))
+		}
+
+		eventsLock.Lock()
+		lastID := eventsLastID
+		droppedID := eventsDroppedID
+		eventsLock.Unlock()
+
+		// An ID from the future most likely comes from before a restart
+		if since > lastID {
+			return BadRequest(fmt.Errorf("Unknown event ID %d", since))
+		}
+
+		if since < droppedID {
+			return BadRequest(fmt.Errorf("Events after %d are no longer available", since))
+		}
+	}
+
+	return &eventsServe{req: r, filter: filter, since: since}
 }
 
 var eventsCmd = Command{name: "events", get: eventsGet}
 
 func eventSend(eventType string, eventMessage interface{}) error {
+	return eventSendRecord(eventType, eventMessage, &eventRecord{owner: -1})
+}
+
+// eventSendOperation notifies the event listeners of a change to an
+// operation, recording who may see it. The caller renders the operation.
+func eventSendOperation(op *operation, md *api.Operation) error {
+	record := &eventRecord{owner: op.owner, opProject: op.project, opResources: op.resources}
+	return eventSendRecord("operation", md, record)
+}
+
+func eventSendRecord(eventType string, eventMessage interface{}, record *eventRecord) error {
 	event := shared.Jmap{}
 	event["type"] = eventType
 	event["timestamp"] = time.Now()
 	event["metadata"] = eventMessage
 
+	record.eventType = eventType
+	record.level = log.LvlDebug
+
+	switch md := eventMessage.(type) {
+	case *api.Operation:
+		for _, urls := range md.Resources {
+			record.resources = append(record.resources, urls...)
+		}
+	case api.EventLifecycle:
+		record.resources = []string{md.Source}
+	case shared.Jmap:
+		level, err := md.GetString("level")
+		if err == nil {
+			record.level, _ = log.LvlFromString(level)
+		}
+	}
+
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	eventsLastID++
+	record.id = eventsLastID
+	event["id"] = record.id
+
 	body, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
+	record.body = body
 
-	eventsLock.Lock()
-	listeners := eventListeners
-	for _, listener := range listeners {
-		if !shared.StringInSlice(eventType, listener.messageTypes) {
+	webhookQueueEvent(eventType, body)
+
+	if eventType != "logging" {
+		if len(eventsBuffer) >= eventsBufferSize {
+			eventsDroppedID = eventsBuffer[0].id
+			eventsBuffer[0] = nil
+			eventsBuffer = eventsBuffer[1:]
+		}
+		eventsBuffer = append(eventsBuffer, record)
+	}
+
+	for _, listener := range eventListeners {
+		if !listener.filter.match(record) {
 			continue
 		}
 
@@ -132,7 +367,7 @@ func eventSend(eventType string, eventMessage interface{}) error {
 			}
 
 			listener.msgLock.Lock()
-			err = listener.connection.WriteMessage(websocket.TextMessage, body)
+			err := listener.connection.WriteMessage(websocket.TextMessage, body)
 			listener.msgLock.Unlock()
 
 			if err != nil {
@@ -140,7 +375,16 @@ func eventSend(eventType string, eventMessage interface{}) error {
 			}
 		}(listener, body)
 	}
-	eventsLock.Unlock()
 
 	return nil
 }
+
+// eventSendLifecycle notifies the event listeners of a change to one of the
+// API objects. The action is a stable identifier of what happened (e.g.
+// "container-started") and source is the URL of the affected object.
+func eventSendLifecycle(action string, source string, context map[string]interface{}) error {
+	return eventSend("lifecycle", api.EventLifecycle{
+		Action:  action,
+		Source:  source,
+		Context: context})
+}
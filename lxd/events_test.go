@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+func Test_eventFilter_match(t *testing.T) {
+	req, err := http.NewRequest("GET", "/1.0/events?type=operation,logging&resource=/1.0/containers/web1&level=warn", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := eventsParseFilter(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		event *eventRecord
+		match bool
+	}{
+		{&eventRecord{eventType: "operation", resources: []string{"/1.0/containers/web1"}}, true},
+		{&eventRecord{eventType: "operation", resources: []string{"/1.0/containers/web1/snapshots/snap0"}}, true},
+		{&eventRecord{eventType: "operation", resources: []string{"/1.0/containers/web10"}}, false},
+		{&eventRecord{eventType: "operation"}, false},
+		{&eventRecord{eventType: "lifecycle", resources: []string{"/1.0/containers/web1"}}, false},
+		{&eventRecord{eventType: "logging", level: log.LvlError}, true},
+		{&eventRecord{eventType: "logging", level: log.LvlInfo}, false},
+	}
+
+	for i, test := range tests {
+		if filter.match(test.event) != test.match {
+			t.Errorf("Event %d: expected match to be %v", i, test.match)
+		}
+	}
+}
+
+func Test_eventFilter_matchProject(t *testing.T) {
+	req, err := http.NewRequest("GET", "/1.0/events?project=ci&resource=/1.0/containers/web1,/1.0/images/abcdef%3Fproject%3Ddefault", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := eventsParseFilter(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		event *eventRecord
+		match bool
+	}{
+		{&eventRecord{eventType: "operation", resources: []string{"/1.0/containers/web1?project=ci"}}, true},
+		{&eventRecord{eventType: "operation", resources: []string{"/1.0/containers/web1/snapshots/snap0?project=ci"}}, true},
+		{&eventRecord{eventType: "operation", resources: []string{"/1.0/containers/web1"}}, false},
+		{&eventRecord{eventType: "operation", resources: []string{"/1.0/containers/web1?project=other"}}, false},
+		{&eventRecord{eventType: "lifecycle", resources: []string{"/1.0/images/abcdef"}}, true},
+		{&eventRecord{eventType: "lifecycle", resources: []string{"/1.0/images/abcdef?project=ci"}}, false},
+	}
+
+	for i, test := range tests {
+		if filter.match(test.event) != test.match {
+			t.Errorf("Event %d: expected match to be %v", i, test.match)
+		}
+	}
+}
+
+func Test_eventFilter_matchOwner(t *testing.T) {
+	filter := eventFilter{types: []string{"logging", "operation", "lifecycle"}, level: log.LvlDebug, owner: 1000}
+
+	tests := []struct {
+		event *eventRecord
+		match bool
+	}{
+		{&eventRecord{eventType: "operation", owner: 1000}, true},
+		{&eventRecord{eventType: "operation", owner: 1001}, false},
+		{&eventRecord{eventType: "operation", owner: -1}, false},
+		{&eventRecord{eventType: "lifecycle", owner: -1}, false},
+		{&eventRecord{eventType: "logging", level: log.LvlError, owner: -1}, false},
+	}
+
+	for i, test := range tests {
+		if filter.match(test.event) != test.match {
+			t.Errorf("Event %d: expected match to be %v", i, test.match)
+		}
+	}
+}
+
+func Test_eventFilter_matchPermissions(t *testing.T) {
+	filter := eventFilter{types: []string{"logging", "operation", "lifecycle"}, level: log.LvlDebug, owner: -1}
+	filter.permissions = &dbCertInfo{Containers: []string{"c1"}, Projects: []string{"ci"}}
+
+	tests := []struct {
+		event *eventRecord
+		match bool
+	}{
+		{&eventRecord{eventType: "operation", opProject: "ci", opResources: map[string][]string{"containers": {"c1"}}}, true},
+		{&eventRecord{eventType: "operation", opProject: "ci", opResources: map[string][]string{"containers": {"c1/snap0"}}}, true},
+		{&eventRecord{eventType: "operation", opProject: "ci", opResources: map[string][]string{"containers": {"c1", "c2"}}}, false},
+		{&eventRecord{eventType: "operation", opProject: "default", opResources: map[string][]string{"containers": {"c1"}}}, false},
+		{&eventRecord{eventType: "operation", opProject: "ci"}, false},
+		{&eventRecord{eventType: "operation"}, false},
+		{&eventRecord{eventType: "lifecycle", resources: []string{"/1.0/containers/c1"}}, false},
+		{&eventRecord{eventType: "logging", level: log.LvlError}, false},
+	}
+
+	for i, test := range tests {
+		if filter.match(test.event) != test.match {
+			t.Errorf("Event %d: expected match to be %v", i, test.match)
+		}
+	}
+}
+
+func Test_eventsParseFilter_invalidLevel(t *testing.T) {
+	req, err := http.NewRequest("GET", "/1.0/events?level=loud", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = eventsParseFilter(req)
+	if err == nil {
+		t.Fatal("Expected an error for an invalid log level")
+	}
+}
+
+// The creation event of an operation already tells who may see it, so that
+// scoped listeners get it, live or replayed.
+func Test_eventSendOperation_created(t *testing.T) {
+	r, err := http.NewRequest("POST", "/1.0/containers/c1/exec?project=ci", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unixUserRequestStart(r, &ucred{uid: 1000})
+	defer unixUserRequestDone(r)
+
+	op, err := operationCreate("ci", r, operationClassToken, map[string][]string{"containers": {"c1"}}, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventsLock.Lock()
+	record := eventsBuffer[len(eventsBuffer)-1]
+	eventsLock.Unlock()
+
+	if !strings.Contains(string(record.body), op.id) {
+		t.Fatalf("The last event isn't the creation of %s", op.id)
+	}
+
+	// Resources are named within the project of the operation
+	if len(record.resources) != 1 || record.resources[0] != "/1.0/containers/c1?project=ci" {
+		t.Errorf("Unexpected resources %v", record.resources)
+	}
+
+	filter := eventFilter{types: []string{"operation"}, level: log.LvlDebug, owner: 1000}
+	filter.permissions = &dbCertInfo{Containers: []string{"c1"}, Projects: []string{"ci"}}
+	if !filter.match(record) {
+		t.Errorf("The creation event doesn't match its owner and project")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// Number of events waiting for delivery before new ones get dropped
+const webhookQueueSize = 1000
+
+// Number of delivery attempts for a single event
+const webhookAttempts = 5
+
+// Delay before the first retry, doubled on every following one
+const webhookBackoff = time.Second
+
+// The event types which can be sent to the webhook
+var webhookValidTypes = []string{"logging", "operation", "lifecycle"}
+
+// The current webhook configuration, cached from the daemon config so that
+// eventSend doesn't have to look at it (it may be called before it's loaded).
+var webhookLock sync.Mutex
+var webhookURL string
+var webhookSecret string
+var webhookTypes []string
+var webhookDropped int
+
+type webhookEvent struct {
+	eventType string
+	body      []byte
+}
+
+var webhookQueue = make(chan webhookEvent, webhookQueueSize)
+
+// webhookInit loads the webhook configuration and starts the delivery loop.
+func webhookInit(d *Daemon) {
+	webhookConfigure(
+		daemonConfig["core.webhook_url"].Get(),
+		daemonConfig["core.webhook_secret"].Get(),
+		daemonConfig["core.webhook_types"].Get())
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				return d.proxy(req)
+			},
+		},
+	}
+
+	go func() {
+		for event := range webhookQueue {
+			webhookDeliver(client, event)
+		}
+	}()
+}
+
+func webhookConfigure(target string, secret string, types string) {
+	webhookLock.Lock()
+	defer webhookLock.Unlock()
+
+	webhookURL = target
+	webhookSecret = secret
+	webhookTypes = strings.Split(types, ",")
+}
+
+// webhookQueueEvent schedules an event for delivery to the webhook. This is
+// called with eventsLock held so must neither block nor log.
+func webhookQueueEvent(eventType string, body []byte) {
+	webhookLock.Lock()
+	defer webhookLock.Unlock()
+
+	if webhookURL == "" || !shared.StringInSlice(eventType, webhookTypes) {
+		return
+	}
+
+	select {
+	case webhookQueue <- webhookEvent{eventType: eventType, body: body}:
+	default:
+		webhookDropped++
+	}
+}
+
+// webhookDeliver sends an event to the webhook, retrying with an increasing
+// delay until it's accepted or the attempts run out.
+func webhookDeliver(client *http.Client, event webhookEvent) {
+	webhookLock.Lock()
+	dropped := webhookDropped
+	webhookDropped = 0
+	webhookLock.Unlock()
+
+	if dropped > 0 {
+		logger.Warn("Dropped webhook events as the queue was full", log.Ctx{"count": dropped})
+	}
+
+	delay := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		err := webhookPost(client, event)
+		if err == nil {
+			return
+		}
+
+		if attempt == webhookAttempts {
+			// Don't log failures of logging events, as that would
+			// feed more events to a webhook which is already failing
+			if event.eventType != "logging" {
+				logger.Warn("Failed to deliver event to webhook", log.Ctx{"err": err})
+			}
+			return
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// webhookPost makes a single delivery attempt using the current configuration.
+func webhookPost(client *http.Client, event webhookEvent) error {
+	webhookLock.Lock()
+	target := webhookURL
+	secret := webhookSecret
+	webhookLock.Unlock()
+
+	// The webhook was removed in the meantime
+	if target == "" {
+		return nil
+	}
+
+	req, err := http.NewRequest("POST", target, bytes.NewReader(event.body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent)
+	req.Header.Set("X-LXD-Event", event.eventType)
+	if secret != "" {
+		req.Header.Set("X-LXD-Signature", fmt.Sprintf("sha256=%s", webhookSign(secret, event.body)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook returned: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// webhookSign returns the hex encoded HMAC-SHA256 of the body.
+func webhookSign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func daemonConfigValidateWebhookURL(d *Daemon, key string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	if !shared.StringInSlice(u.Scheme, []string{"http", "https"}) || u.Host == "" {
+		return fmt.Errorf("Invalid webhook URL \"%s\", must be a http or https URL", value)
+	}
+
+	return nil
+}
+
+func daemonConfigValidateWebhookTypes(d *Daemon, key string, value string) error {
+	for _, eventType := range strings.Split(value, ",") {
+		if !shared.StringInSlice(eventType, webhookValidTypes) {
+			return fmt.Errorf("Invalid event type \"%s\", must be one of %s", eventType, strings.Join(webhookValidTypes, ", "))
+		}
+	}
+
+	return nil
+}
+
+func daemonConfigSetWebhook(d *Daemon, key string, value string) (string, error) {
+	config := map[string]string{}
+	config["core.webhook_url"] = daemonConfig["core.webhook_url"].Get()
+	config["core.webhook_secret"] = daemonConfig["core.webhook_secret"].Get()
+	config["core.webhook_types"] = daemonConfig["core.webhook_types"].Get()
+
+	// Apply the change
+	config[key] = value
+	if config[key] == "" {
+		config[key] = daemonConfig[key].defaultValue
+	}
+
+	webhookConfigure(config["core.webhook_url"], config["core.webhook_secret"], config["core.webhook_types"])
+
+	return value, nil
+}
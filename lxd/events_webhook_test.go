@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_webhookPost(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	webhookConfigure(server.URL, "secret", "lifecycle")
+	defer webhookConfigure("", "", "")
+
+	body := []byte(`{"type": "lifecycle"}`)
+	err := webhookPost(http.DefaultClient, webhookEvent{eventType: "lifecycle", body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-received
+	if string(<-bodies) != string(body) {
+		t.Fatal("The webhook didn't receive the event")
+	}
+
+	if r.Header.Get("X-LXD-Event") != "lifecycle" {
+		t.Fatalf("Unexpected event type header: %s", r.Header.Get("X-LXD-Event"))
+	}
+
+	if r.Header.Get("X-LXD-Signature") != "sha256="+webhookSign("secret", body) {
+		t.Fatalf("Unexpected signature header: %s", r.Header.Get("X-LXD-Signature"))
+	}
+}
+
+func Test_webhookPost_failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhookConfigure(server.URL, "", "lifecycle")
+	defer webhookConfigure("", "", "")
+
+	err := webhookPost(http.DefaultClient, webhookEvent{eventType: "lifecycle", body: []byte("{}")})
+	if err == nil {
+		t.Fatal("Expected the delivery to fail")
+	}
+}
+
+func Test_webhookSign(t *testing.T) {
+	// Reference value from RFC 4231, test case 2
+	signature := webhookSign("Jefe", []byte("what do ya want for nothing?"))
+	if signature != "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843" {
+		t.Fatalf("Unexpected signature: %s", signature)
+	}
+}
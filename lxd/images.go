@@ -130,7 +130,7 @@ func unpack(d *Daemon, file string, path string, sType storageType) error {
 
 		// Check if we're running out of space
 		if int64(fs.Bfree) < int64(2*fs.Bsize) {
-			if sType == storageTypeLvm {
+			if sType == storageTypeLvm || sType == storageTypeCeph {
 				return fmt.Errorf("Unable to unpack image, run out of disk space (consider increasing your pool's volume.size).")
 			} else {
 				return fmt.Errorf("Unable to unpack image, run out of disk space.")
@@ -222,7 +222,7 @@ type imageMetadata struct {
  * This function takes a container or snapshot from the local image server and
  * exports it as an image.
  */
-func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir string) (*api.Image, error) {
+func imgPostContInfo(d *Daemon, r *http.Request, project string, req api.ImagesPost, builddir string) (*api.Image, error) {
 	info := api.Image{}
 	info.Properties = map[string]string{}
 	name := req.Source.Name
@@ -252,7 +252,7 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 		info.Public = false
 	}
 
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByProjectAndName(d, projectParam(r), name)
 	if err != nil {
 		return nil, err
 	}
@@ -303,7 +303,7 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 
 	info.Fingerprint = fmt.Sprintf("%x", sha256.Sum(nil))
 
-	_, _, err = dbImageGet(d.db, info.Fingerprint, false, true)
+	_, _, err = dbImageGet(d.db, project, info.Fingerprint, false, true)
 	if err == nil {
 		return nil, fmt.Errorf("The image already exists: %s", info.Fingerprint)
 	}
@@ -319,7 +319,7 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 	info.Properties = req.Properties
 
 	// Create the database entry
-	err = dbImageInsert(d.db, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
+	err = dbImageInsert(d.db, project, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
 	if err != nil {
 		return nil, err
 	}
@@ -327,7 +327,7 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 	return &info, nil
 }
 
-func imgPostRemoteInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, error) {
+func imgPostRemoteInfo(d *Daemon, project string, req api.ImagesPost, op *operation) (*api.Image, error) {
 	var err error
 	var hash string
 
@@ -339,12 +339,12 @@ func imgPostRemoteInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image
 		return nil, fmt.Errorf("must specify one of alias or fingerprint for init from image")
 	}
 
-	info, err := d.ImageDownload(op, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.Secret, hash, false, req.AutoUpdate, "", false)
+	info, err := d.ImageDownload(op, project, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.Secret, hash, false, req.AutoUpdate, "", false)
 	if err != nil {
 		return nil, err
 	}
 
-	id, info, err := dbImageGet(d.db, info.Fingerprint, false, true)
+	id, info, err := dbImageGet(d.db, project, info.Fingerprint, false, true)
 	if err != nil {
 		return nil, err
 	}
@@ -365,7 +365,7 @@ func imgPostRemoteInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image
 	return info, nil
 }
 
-func imgPostURLInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, error) {
+func imgPostURLInfo(d *Daemon, project string, req api.ImagesPost, op *operation) (*api.Image, error) {
 	var err error
 
 	if req.Source.URL == "" {
@@ -408,12 +408,12 @@ func imgPostURLInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, e
 	}
 
 	// Import the image
-	info, err := d.ImageDownload(op, url, "direct", "", "", hash, false, req.AutoUpdate, "", false)
+	info, err := d.ImageDownload(op, project, url, "direct", "", "", hash, false, req.AutoUpdate, "", false)
 	if err != nil {
 		return nil, err
 	}
 
-	id, info, err := dbImageGet(d.db, info.Fingerprint, false, false)
+	id, info, err := dbImageGet(d.db, project, info.Fingerprint, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -433,7 +433,7 @@ func imgPostURLInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, e
 	return info, nil
 }
 
-func getImgPostInfo(d *Daemon, r *http.Request, builddir string, post *os.File) (*api.Image, error) {
+func getImgPostInfo(d *Daemon, r *http.Request, project string, builddir string, post *os.File) (*api.Image, error) {
 	info := api.Image{}
 	var imageMeta *imageMetadata
 	logger := logging.AddContext(logger.Log, log.Ctx{"function": "getImgPostInfo"})
@@ -621,7 +621,7 @@ func getImgPostInfo(d *Daemon, r *http.Request, builddir string, post *os.File)
 	}
 
 	// Check if the image already exists
-	exists, err := dbImageExists(d.db, info.Fingerprint)
+	exists, err := dbImageExists(d.db, project, info.Fingerprint)
 	if err != nil {
 		return nil, err
 	}
@@ -629,7 +629,7 @@ func getImgPostInfo(d *Daemon, r *http.Request, builddir string, post *os.File)
 		return nil, fmt.Errorf("Image with same fingerprint already exists")
 	}
 	// Create the database entry
-	err = dbImageInsert(d.db, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
+	err = dbImageInsert(d.db, project, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
 	if err != nil {
 		return nil, err
 	}
@@ -713,6 +713,12 @@ func imagesPost(d *Daemon, r *http.Request) Response {
 		return InternalError(fmt.Errorf("Invalid images JSON"))
 	}
 
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		cleanup(builddir, post)
+		return SmartError(err)
+	}
+
 	// Begin background operation
 	run := func(op *operation) error {
 		var err error
@@ -723,18 +729,18 @@ func imagesPost(d *Daemon, r *http.Request) Response {
 
 		if imageUpload {
 			/* Processing image upload */
-			info, err = getImgPostInfo(d, r, builddir, post)
+			info, err = getImgPostInfo(d, r, project, builddir, post)
 		} else {
 			if req.Source.Type == "image" {
 				/* Processing image copy from remote */
-				info, err = imgPostRemoteInfo(d, req, op)
+				info, err = imgPostRemoteInfo(d, project, req, op)
 			} else if req.Source.Type == "url" {
 				/* Processing image copy from URL */
-				info, err = imgPostURLInfo(d, req, op)
+				info, err = imgPostURLInfo(d, project, req, op)
 			} else {
 				/* Processing image creation from container */
 				imagePublishLock.Lock()
-				info, err = imgPostContInfo(d, r, req, builddir)
+				info, err = imgPostContInfo(d, r, project, req, builddir)
 				imagePublishLock.Unlock()
 			}
 		}
@@ -744,22 +750,24 @@ func imagesPost(d *Daemon, r *http.Request) Response {
 
 		// Apply any provided alias
 		for _, alias := range req.Aliases {
-			_, _, err := dbImageAliasGet(d.db, alias.Name, true)
+			_, _, err := dbImageAliasGet(d.db, project, alias.Name, true)
 			if err == nil {
 				return fmt.Errorf("Alias already exists: %s", alias.Name)
 			}
 
-			id, _, err := dbImageGet(d.db, info.Fingerprint, false, false)
+			id, _, err := dbImageGet(d.db, project, info.Fingerprint, false, false)
 			if err != nil {
 				return err
 			}
 
-			err = dbImageAliasAdd(d.db, alias.Name, id, alias.Description)
+			err = dbImageAliasAdd(d.db, project, alias.Name, id, alias.Description)
 			if err != nil {
 				return err
 			}
 		}
 
+		eventSendLifecycle("image-created", projectURL(fmt.Sprintf("/%s/images/%s", version.APIVersion, info.Fingerprint), project), nil)
+
 		// Set the metadata
 		metadata := make(map[string]string)
 		metadata["fingerprint"] = info.Fingerprint
@@ -768,7 +776,7 @@ func imagesPost(d *Daemon, r *http.Request) Response {
 		return nil
 	}
 
-	op, err := operationCreate(operationClassTask, nil, nil, run, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, nil, nil, run, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -819,8 +827,8 @@ func getImageMetadata(fname string) (*imageMetadata, error) {
 	return &metadata, nil
 }
 
-func doImagesGet(d *Daemon, recursion bool, public bool) (interface{}, error) {
-	results, err := dbImagesGet(d.db, public)
+func doImagesGet(d *Daemon, project string, recursion bool, public bool) (interface{}, error) {
+	results, err := dbImagesGet(d.db, project, public)
 	if err != nil {
 		return []string{}, err
 	}
@@ -833,7 +841,7 @@ func doImagesGet(d *Daemon, recursion bool, public bool) (interface{}, error) {
 			url := fmt.Sprintf("/%s/images/%s", version.APIVersion, name)
 			resultString[i] = url
 		} else {
-			image, response := doImageGet(d, name, public)
+			image, response := doImageGet(d, project, name, public)
 			if response != nil {
 				continue
 			}
@@ -853,7 +861,12 @@ func doImagesGet(d *Daemon, recursion bool, public bool) (interface{}, error) {
 func imagesGet(d *Daemon, r *http.Request) Response {
 	public := !d.isTrustedClient(r)
 
-	result, err := doImagesGet(d, d.isRecursionRequest(r), public)
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	result, err := doImagesGet(d, project, d.isRecursionRequest(r), public)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -865,24 +878,32 @@ var imagesCmd = Command{name: "images", post: imagesPost, untrustedGet: true, ge
 func autoUpdateImages(d *Daemon) {
 	logger.Infof("Updating images")
 
-	images, err := dbImagesGet(d.db, false)
+	projects, err := dbProjects(d.db)
 	if err != nil {
-		logger.Error("Unable to retrieve the list of images", log.Ctx{"err": err})
+		logger.Error("Unable to retrieve the list of projects", log.Ctx{"err": err})
 		return
 	}
 
-	for _, fingerprint := range images {
-		id, info, err := dbImageGet(d.db, fingerprint, false, true)
+	for _, project := range projects {
+		images, err := dbImagesGet(d.db, project, false)
 		if err != nil {
-			logger.Error("Error loading image", log.Ctx{"err": err, "fp": info.Fingerprint})
+			logger.Error("Unable to retrieve the list of images", log.Ctx{"err": err, "project": project})
 			continue
 		}
 
-		if !info.AutoUpdate {
-			continue
-		}
+		for _, fingerprint := range images {
+			id, info, err := dbImageGet(d.db, project, fingerprint, false, true)
+			if err != nil {
+				logger.Error("Error loading image", log.Ctx{"err": err, "fp": fingerprint})
+				continue
+			}
 
-		autoUpdateImage(d, nil, id, info)
+			if !info.AutoUpdate {
+				continue
+			}
+
+			autoUpdateImage(d, nil, project, id, info)
+		}
 	}
 
 	logger.Infof("Done updating images")
@@ -890,7 +911,7 @@ func autoUpdateImages(d *Daemon) {
 
 // Update a single image.  The operation can be nil, if no progress tracking is needed.
 // Returns whether the image has been updated.
-func autoUpdateImage(d *Daemon, op *operation, id int, info *api.Image) error {
+func autoUpdateImage(d *Daemon, op *operation, project string, id int, info *api.Image) error {
 	fingerprint := info.Fingerprint
 	_, source, err := dbImageSourceGet(d.db, id)
 	if err != nil {
@@ -918,6 +939,14 @@ func autoUpdateImage(d *Daemon, op *operation, id int, info *api.Image) error {
 		poolNames = append(poolNames, "")
 	}
 
+	// The image files and storage volumes are shared between projects,
+	// only remove them along with the last reference.
+	inUse, err := imageSharedWithOtherProjects(d, project, fingerprint)
+	if err != nil {
+		logger.Error("Error getting image projects", log.Ctx{"err": err, "fp": fingerprint})
+		return err
+	}
+
 	logger.Debug("Processing image", log.Ctx{"fp": fingerprint, "server": source.Server, "protocol": source.Protocol, "alias": source.Alias})
 
 	// Set operation metadata to indicate whether a refresh happened
@@ -933,7 +962,7 @@ func autoUpdateImage(d *Daemon, op *operation, id int, info *api.Image) error {
 	// Update the image on each pool where it currently exists.
 	hash := fingerprint
 	for _, poolName := range poolNames {
-		newInfo, err := d.ImageDownload(op, source.Server, source.Protocol, source.Certificate, "", source.Alias, false, true, poolName, false)
+		newInfo, err := d.ImageDownload(op, project, source.Server, source.Protocol, source.Certificate, "", source.Alias, false, true, poolName, false)
 
 		if err != nil {
 			logger.Error("Failed to update the image", log.Ctx{"err": err, "fp": fingerprint})
@@ -946,13 +975,13 @@ func autoUpdateImage(d *Daemon, op *operation, id int, info *api.Image) error {
 			continue
 		}
 
-		newId, _, err := dbImageGet(d.db, hash, false, true)
+		newId, _, err := dbImageGet(d.db, project, hash, false, true)
 		if err != nil {
 			logger.Error("Error loading image", log.Ctx{"err": err, "fp": hash})
 			continue
 		}
 
-		err = dbImageLastAccessUpdate(d.db, hash, info.LastUsedAt)
+		err = dbImageLastAccessUpdate(d.db, project, hash, info.LastUsedAt)
 		if err != nil {
 			logger.Error("Error setting last use date", log.Ctx{"err": err, "fp": hash})
 			continue
@@ -965,8 +994,9 @@ func autoUpdateImage(d *Daemon, op *operation, id int, info *api.Image) error {
 		}
 
 		// If we do have optimized pools, make sure we remove
-		// the volumes associated with the image.
-		if poolName != "" {
+		// the volumes associated with the image, unless another
+		// project still uses it.
+		if poolName != "" && !inUse {
 			err = doDeleteImageFromPool(d, fingerprint, poolName)
 			if err != nil {
 				logger.Error("Error deleting image from pool", log.Ctx{"err": err, "fp": fingerprint})
@@ -980,27 +1010,15 @@ func autoUpdateImage(d *Daemon, op *operation, id int, info *api.Image) error {
 		return nil
 	}
 
-	// Remove main image file.
-	fname := shared.VarPath("images", fingerprint)
-	if shared.PathExists(fname) {
-		err = os.Remove(fname)
-		if err != nil {
-			logger.Debugf("Error deleting image file %s: %s", fname, err)
-		}
-	}
-
-	// Remove the rootfs file for the image.
-	fname = shared.VarPath("images", fingerprint) + ".rootfs"
-	if shared.PathExists(fname) {
-		err = os.Remove(fname)
-		if err != nil {
-			logger.Debugf("Error deleting image file %s: %s", fname, err)
-		}
+	if !inUse {
+		imageDeleteFiles(fingerprint)
 	}
 
 	// Remove the database entry for the image.
 	if err = dbImageDelete(d.db, id); err != nil {
-		logger.Debugf("Error deleting image from database %s: %s", fname, err)
+		logger.Debugf("Error deleting image %s from database: %s", fingerprint, err)
+	} else {
+		eventSendLifecycle("image-deleted", projectURL(fmt.Sprintf("/%s/images/%s", version.APIVersion, fingerprint), project), nil)
 	}
 
 	setRefreshResult(true)
@@ -1019,47 +1037,43 @@ func pruneExpiredImages(d *Daemon) {
 	}
 
 	// Delete them
-	for _, fp := range images {
-		// Get the IDs of all storage pools on which a storage volume
-		// for the requested image currently exists.
-		poolIDs, err := dbImageGetPools(d.db, fp)
-		if err != nil {
-			continue
-		}
+	for _, image := range images {
+		project := image[0]
+		fp := image[1]
 
-		// Translate the IDs to poolNames.
-		poolNames, err := dbImageGetPoolNamesFromIDs(d.db, poolIDs)
+		// The image files and storage volumes are shared between
+		// projects, only remove them along with the last reference.
+		inUse, err := imageSharedWithOtherProjects(d, project, fp)
 		if err != nil {
 			continue
 		}
 
-		for _, pool := range poolNames {
-			err := doDeleteImageFromPool(d, fp, pool)
+		if !inUse {
+			// Get the IDs of all storage pools on which a storage
+			// volume for the requested image currently exists.
+			poolIDs, err := dbImageGetPools(d.db, fp)
 			if err != nil {
-				logger.Debugf("Error deleting image %s from storage pool %: %s", fp, pool, err)
 				continue
 			}
-		}
 
-		// Remove main image file.
-		fname := shared.VarPath("images", fp)
-		if shared.PathExists(fname) {
-			err = os.Remove(fname)
+			// Translate the IDs to poolNames.
+			poolNames, err := dbImageGetPoolNamesFromIDs(d.db, poolIDs)
 			if err != nil {
-				logger.Debugf("Error deleting image file %s: %s", fname, err)
+				continue
 			}
-		}
 
-		// Remove the rootfs file for the image.
-		fname = shared.VarPath("images", fp) + ".rootfs"
-		if shared.PathExists(fname) {
-			err = os.Remove(fname)
-			if err != nil {
-				logger.Debugf("Error deleting image file %s: %s", fname, err)
+			for _, pool := range poolNames {
+				err := doDeleteImageFromPool(d, fp, pool)
+				if err != nil {
+					logger.Debugf("Error deleting image %s from storage pool %: %s", fp, pool, err)
+					continue
+				}
 			}
+
+			imageDeleteFiles(fp)
 		}
 
-		imgID, _, err := dbImageGet(d.db, fp, false, false)
+		imgID, _, err := dbImageGet(d.db, project, fp, false, false)
 		if err != nil {
 			logger.Debugf("Error retrieving image info %s: %s", fp, err)
 		}
@@ -1067,12 +1081,52 @@ func pruneExpiredImages(d *Daemon) {
 		// Remove the database entry for the image.
 		if err = dbImageDelete(d.db, imgID); err != nil {
 			logger.Debugf("Error deleting image %s from database: %s", fp, err)
+		} else {
+			eventSendLifecycle("image-deleted", projectURL(fmt.Sprintf("/%s/images/%s", version.APIVersion, fp), project), nil)
 		}
 	}
 
 	logger.Infof("Done pruning expired images")
 }
 
+// Whether an image with the given fingerprint is also part of a project
+// other than the given one.
+func imageSharedWithOtherProjects(d *Daemon, project string, fingerprint string) (bool, error) {
+	projects, err := dbImageProjects(d.db, fingerprint)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range projects {
+		if p != project {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Remove the image files of the given fingerprint from the image store.
+func imageDeleteFiles(fingerprint string) {
+	// Remove main image file.
+	fname := shared.VarPath("images", fingerprint)
+	if shared.PathExists(fname) {
+		err := os.Remove(fname)
+		if err != nil {
+			logger.Debugf("Error deleting image file %s: %s", fname, err)
+		}
+	}
+
+	// Remove the rootfs file for the image.
+	fname = shared.VarPath("images", fingerprint) + ".rootfs"
+	if shared.PathExists(fname) {
+		err := os.Remove(fname)
+		if err != nil {
+			logger.Debugf("Error deleting image file %s: %s", fname, err)
+		}
+	}
+}
+
 func doDeleteImageFromPool(d *Daemon, fingerprint string, storagePool string) error {
 	// Initialize a new storage interface.
 	s, err := storagePoolVolumeImageInit(d, storagePool, fingerprint)
@@ -1092,51 +1146,56 @@ func doDeleteImageFromPool(d *Daemon, fingerprint string, storagePool string) er
 func imageDelete(d *Daemon, r *http.Request) Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
 
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
 	deleteFromAllPools := func() error {
 		// Use the fingerprint we received in a LIKE query and use the full
 		// fingerprint we receive from the database in all further queries.
-		imgID, imgInfo, err := dbImageGet(d.db, fingerprint, false, false)
-		if err != nil {
-			return err
-		}
-
-		poolIDs, err := dbImageGetPools(d.db, imgInfo.Fingerprint)
+		imgID, imgInfo, err := dbImageGet(d.db, project, fingerprint, false, false)
 		if err != nil {
 			return err
 		}
 
-		pools, err := dbImageGetPoolNamesFromIDs(d.db, poolIDs)
+		// The image files and storage volumes are shared between
+		// projects, only remove them along with the last reference.
+		inUse, err := imageSharedWithOtherProjects(d, project, imgInfo.Fingerprint)
 		if err != nil {
 			return err
 		}
 
-		for _, pool := range pools {
-			err := doDeleteImageFromPool(d, imgInfo.Fingerprint, pool)
+		if !inUse {
+			poolIDs, err := dbImageGetPools(d.db, imgInfo.Fingerprint)
 			if err != nil {
 				return err
 			}
-		}
 
-		// Remove main image file.
-		fname := shared.VarPath("images", imgInfo.Fingerprint)
-		if shared.PathExists(fname) {
-			err = os.Remove(fname)
+			pools, err := dbImageGetPoolNamesFromIDs(d.db, poolIDs)
 			if err != nil {
-				logger.Debugf("Error deleting image file %s: %s", fname, err)
+				return err
 			}
-		}
 
-		// Remove the rootfs file for the image.
-		fname = shared.VarPath("images", imgInfo.Fingerprint) + ".rootfs"
-		if shared.PathExists(fname) {
-			err = os.Remove(fname)
-			if err != nil {
-				logger.Debugf("Error deleting image file %s: %s", fname, err)
+			for _, pool := range pools {
+				err := doDeleteImageFromPool(d, imgInfo.Fingerprint, pool)
+				if err != nil {
+					return err
+				}
 			}
+
+			imageDeleteFiles(imgInfo.Fingerprint)
 		}
 
 		// Remove the database entry for the image.
-		return dbImageDelete(d.db, imgID)
+		err = dbImageDelete(d.db, imgID)
+		if err != nil {
+			return err
+		}
+
+		eventSendLifecycle("image-deleted", projectURL(fmt.Sprintf("/%s/images/%s", version.APIVersion, imgInfo.Fingerprint), project), nil)
+
+		return nil
 	}
 
 	rmimg := func(op *operation) error {
@@ -1146,7 +1205,7 @@ func imageDelete(d *Daemon, r *http.Request) Response {
 	resources := map[string][]string{}
 	resources["images"] = []string{fingerprint}
 
-	op, err := operationCreate(operationClassTask, resources, nil, rmimg, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, rmimg, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -1154,8 +1213,8 @@ func imageDelete(d *Daemon, r *http.Request) Response {
 	return OperationResponse(op)
 }
 
-func doImageGet(d *Daemon, fingerprint string, public bool) (*api.Image, Response) {
-	_, imgInfo, err := dbImageGet(d.db, fingerprint, public, false)
+func doImageGet(d *Daemon, project string, fingerprint string, public bool) (*api.Image, Response) {
+	_, imgInfo, err := dbImageGet(d.db, project, fingerprint, public, false)
 	if err != nil {
 		return nil, SmartError(err)
 	}
@@ -1195,10 +1254,16 @@ func imageValidSecret(fingerprint string, secret string) bool {
 
 func imageGet(d *Daemon, r *http.Request) Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
 	public := !d.isTrustedClient(r)
 	secret := r.FormValue("secret")
 
-	info, response := doImageGet(d, fingerprint, false)
+	info, response := doImageGet(d, project, fingerprint, false)
 	if response != nil {
 		return response
 	}
@@ -1214,7 +1279,13 @@ func imageGet(d *Daemon, r *http.Request) Response {
 func imagePut(d *Daemon, r *http.Request) Response {
 	// Get current value
 	fingerprint := mux.Vars(r)["fingerprint"]
-	id, info, err := dbImageGet(d.db, fingerprint, false, false)
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, info, err := dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1236,13 +1307,21 @@ func imagePut(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("image-updated", projectURL(fmt.Sprintf("/%s/images/%s", version.APIVersion, info.Fingerprint), project), nil)
+
 	return EmptySyncResponse
 }
 
 func imagePatch(d *Daemon, r *http.Request) Response {
 	// Get current value
 	fingerprint := mux.Vars(r)["fingerprint"]
-	id, info, err := dbImageGet(d.db, fingerprint, false, false)
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, info, err := dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1302,6 +1381,8 @@ func imagePatch(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("image-updated", projectURL(fmt.Sprintf("/%s/images/%s", version.APIVersion, info.Fingerprint), project), nil)
+
 	return EmptySyncResponse
 }
 
@@ -1317,46 +1398,51 @@ func aliasesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("name and target are required"))
 	}
 
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// This is just to see if the alias name already exists.
-	_, _, err := dbImageAliasGet(d.db, req.Name, true)
+	_, _, err = dbImageAliasGet(d.db, project, req.Name, true)
 	if err == nil {
 		return Conflict
 	}
 
-	id, _, err := dbImageGet(d.db, req.Target, false, false)
+	id, _, err := dbImageGet(d.db, project, req.Target, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	err = dbImageAliasAdd(d.db, req.Name, id, req.Description)
+	err = dbImageAliasAdd(d.db, project, req.Name, id, req.Description)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, req.Name))
+	return SyncResponseLocation(true, nil, projectURL(fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, req.Name), project))
 }
 
 func aliasesGet(d *Daemon, r *http.Request) Response {
 	recursion := d.isRecursionRequest(r)
 
-	q := "SELECT name FROM images_aliases"
-	var name string
-	inargs := []interface{}{}
-	outfmt := []interface{}{name}
-	results, err := dbQueryScan(d.db, q, inargs, outfmt)
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	results, err := dbImageAliases(d.db, project)
 	if err != nil {
 		return BadRequest(err)
 	}
 	responseStr := []string{}
 	responseMap := []api.ImageAliasesEntry{}
-	for _, res := range results {
-		name = res[0].(string)
+	for _, name := range results {
 		if !recursion {
 			url := fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, name)
 			responseStr = append(responseStr, url)
 
 		} else {
-			_, alias, err := dbImageAliasGet(d.db, name, d.isTrustedClient(r))
+			_, alias, err := dbImageAliasGet(d.db, project, name, d.isTrustedClient(r))
 			if err != nil {
 				continue
 			}
@@ -1374,7 +1460,12 @@ func aliasesGet(d *Daemon, r *http.Request) Response {
 func aliasGet(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
-	_, alias, err := dbImageAliasGet(d.db, name, d.isTrustedClient(r))
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, alias, err := dbImageAliasGet(d.db, project, name, d.isTrustedClient(r))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1384,12 +1475,18 @@ func aliasGet(d *Daemon, r *http.Request) Response {
 
 func aliasDelete(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
-	_, _, err := dbImageAliasGet(d.db, name, true)
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, _, err = dbImageAliasGet(d.db, project, name, true)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	err = dbImageAliasDelete(d.db, name)
+	err = dbImageAliasDelete(d.db, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1400,7 +1497,13 @@ func aliasDelete(d *Daemon, r *http.Request) Response {
 func aliasPut(d *Daemon, r *http.Request) Response {
 	// Get current value
 	name := mux.Vars(r)["name"]
-	id, alias, err := dbImageAliasGet(d.db, name, true)
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, alias, err := dbImageAliasGet(d.db, project, name, true)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1420,7 +1523,7 @@ func aliasPut(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("The target field is required"))
 	}
 
-	imageId, _, err := dbImageGet(d.db, req.Target, false, false)
+	imageId, _, err := dbImageGet(d.db, project, req.Target, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1436,7 +1539,13 @@ func aliasPut(d *Daemon, r *http.Request) Response {
 func aliasPatch(d *Daemon, r *http.Request) Response {
 	// Get current value
 	name := mux.Vars(r)["name"]
-	id, alias, err := dbImageAliasGet(d.db, name, true)
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, alias, err := dbImageAliasGet(d.db, project, name, true)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1472,7 +1581,7 @@ func aliasPatch(d *Daemon, r *http.Request) Response {
 		alias.Description = description
 	}
 
-	imageId, _, err := dbImageGet(d.db, alias.Target, false, false)
+	imageId, _, err := dbImageGet(d.db, project, alias.Target, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1488,18 +1597,23 @@ func aliasPatch(d *Daemon, r *http.Request) Response {
 func aliasPost(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
 	req := api.ImageAliasesEntryPost{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return BadRequest(err)
 	}
 
 	// Check that the name isn't already in use
-	id, _, _ := dbImageAliasGet(d.db, req.Name, true)
+	id, _, _ := dbImageAliasGet(d.db, project, req.Name, true)
 	if id > 0 {
 		return Conflict
 	}
 
-	id, _, err := dbImageAliasGet(d.db, name, true)
+	id, _, err = dbImageAliasGet(d.db, project, name, true)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1509,16 +1623,21 @@ func aliasPost(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, req.Name))
+	return SyncResponseLocation(true, nil, projectURL(fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, req.Name), project))
 }
 
 func imageExport(d *Daemon, r *http.Request) Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
 
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
 	public := !d.isTrustedClient(r)
 	secret := r.FormValue("secret")
 
-	_, imgInfo, err := dbImageGet(d.db, fingerprint, false, false)
+	_, imgInfo, err := dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1568,7 +1687,13 @@ func imageExport(d *Daemon, r *http.Request) Response {
 
 func imageSecret(d *Daemon, r *http.Request) Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
-	_, imgInfo, err := dbImageGet(d.db, fingerprint, false, false)
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, imgInfo, err := dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1585,7 +1710,7 @@ func imageSecret(d *Daemon, r *http.Request) Response {
 	resources := map[string][]string{}
 	resources["images"] = []string{imgInfo.Fingerprint}
 
-	op, err := operationCreate(operationClassToken, resources, meta, nil, nil, nil)
+	op, err := operationCreate(project, r, operationClassToken, resources, meta, nil, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -1595,17 +1720,23 @@ func imageSecret(d *Daemon, r *http.Request) Response {
 
 func imageRefresh(d *Daemon, r *http.Request) Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
-	imageId, imageInfo, err := dbImageGet(d.db, fingerprint, false, false)
+
+	project, err := projectImagesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	imageId, imageInfo, err := dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
 
 	// Begin background operation
 	run := func(op *operation) error {
-		return autoUpdateImage(d, op, imageId, imageInfo)
+		return autoUpdateImage(d, op, project, imageId, imageInfo)
 	}
 
-	op, err := operationCreate(operationClassTask, nil, nil, run, nil, nil)
+	op, err := operationCreate(project, r, operationClassTask, nil, nil, run, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -137,6 +137,8 @@ func run() error {
 		fmt.Printf("        How long to wait before failing\n")
 
 		fmt.Printf("\n\nInternal commands (don't call these directly):\n")
+		fmt.Printf("    forkconsole\n")
+		fmt.Printf("        Attach to the console of a container\n")
 		fmt.Printf("    forkexec\n")
 		fmt.Printf("        Execute a command in a container\n")
 		fmt.Printf("    forkgetnet\n")
@@ -145,6 +147,8 @@ func run() error {
 		fmt.Printf("        Grab a file from a running container\n")
 		fmt.Printf("    forkmigrate\n")
 		fmt.Printf("        Restore a container after migration\n")
+		fmt.Printf("    forkproxy\n")
+		fmt.Printf("        Forward a port into or out of a container\n")
 		fmt.Printf("    forkputfile\n")
 		fmt.Printf("        Push a file to a running container\n")
 		fmt.Printf("    forkstart\n")
@@ -199,7 +203,7 @@ func run() error {
 	// Process sub-commands
 	if len(os.Args) > 1 {
 		// "forkputfile", "forkgetfile", "forkmount" and "forkumount" are handled specially in main_nsexec.go
-		// "forkgetnet" is partially handled in nsexec.go (setns)
+		// "forkgetnet" and "forkproxy" are partially handled in nsexec.go (setns)
 		switch os.Args[1] {
 		// Main commands
 		case "activateifneeded":
@@ -220,10 +224,14 @@ func run() error {
 			return cmdImport(os.Args[1:])
 
 		// Internal commands
+		case "forkconsole":
+			return cmdForkConsole(os.Args[1:])
 		case "forkgetnet":
 			return cmdForkGetNet()
 		case "forkmigrate":
 			return cmdForkMigrate(os.Args[1:])
+		case "forkproxy":
+			return cmdForkProxy(os.Args[1:])
 		case "forkstart":
 			return cmdForkStart(os.Args[1:])
 		case "forkexec":
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/lxc/go-lxc.v2"
+)
+
+/*
+ * This is called by lxd when called as
+ * "lxd forkconsole <container> <lxcpath> <config> <tty> <escape>".
+ * The console is attached to stdin, stdout and stderr until either side
+ * goes away. An escape character of -1 disables the escape sequences.
+ */
+func cmdForkConsole(args []string) error {
+	if len(args) != 6 {
+		return fmt.Errorf("Bad arguments: %q", args)
+	}
+
+	name := args[1]
+	lxcpath := args[2]
+	configPath := args[3]
+
+	tty, err := strconv.Atoi(args[4])
+	if err != nil {
+		return fmt.Errorf("Invalid tty: %q", err)
+	}
+
+	escape, err := strconv.Atoi(args[5])
+	if err != nil {
+		return fmt.Errorf("Invalid escape character: %q", err)
+	}
+
+	c, err := lxc.NewContainer(name, lxcpath)
+	if err != nil {
+		return fmt.Errorf("Error initializing container for console: %q", err)
+	}
+
+	err = c.LoadConfigFile(configPath)
+	if err != nil {
+		return fmt.Errorf("Error opening startup config file: %q", err)
+	}
+
+	opts := lxc.ConsoleOptions{}
+	opts.Tty = tty
+	opts.StdinFd = os.Stdin.Fd()
+	opts.StdoutFd = os.Stdout.Fd()
+	opts.StderrFd = os.Stderr.Fd()
+	opts.EscapeCharacter = rune(escape)
+
+	err = c.Console(opts)
+	if err != nil {
+		return fmt.Errorf("Failed to attach to the console: %q", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -13,16 +14,29 @@ import (
 )
 
 /*
- * This is called by lxd when called as "lxd forkexec <container>"
+ * This is called by lxd when called as
+ * "lxd forkexec <container> <lxcpath> <config> <cwd> <uid> <gid> -- env [...] -- cmd [...]".
+ * An empty <cwd> means the home directory from the environment.
  */
 func cmdForkExec(args []string) (int, error) {
-	if len(args) < 6 {
+	if len(args) < 9 {
 		return -1, fmt.Errorf("Bad arguments: %q", args)
 	}
 
 	name := args[1]
 	lxcpath := args[2]
 	configPath := args[3]
+	cwd := args[4]
+
+	uid, err := strconv.ParseUint(args[5], 10, 32)
+	if err != nil {
+		return -1, fmt.Errorf("Invalid uid: %q", err)
+	}
+
+	gid, err := strconv.ParseUint(args[6], 10, 32)
+	if err != nil {
+		return -1, fmt.Errorf("Invalid gid: %q", err)
+	}
 
 	c, err := lxc.NewContainer(name, lxcpath)
 	if err != nil {
@@ -47,6 +61,8 @@ func cmdForkExec(args []string) (int, error) {
 	opts.StdinFd = 200
 	opts.StdoutFd = 201
 	opts.StderrFd = 202
+	opts.UID = int(uid)
+	opts.GID = int(gid)
 
 	logPath := shared.LogPath(name, "forkexec.log")
 	if shared.PathExists(logPath) {
@@ -63,7 +79,7 @@ func cmdForkExec(args []string) (int, error) {
 	cmd := []string{}
 
 	section := ""
-	for _, arg := range args[5:] {
+	for _, arg := range args[8:] {
 		// The "cmd" section must come last as it may contain a --
 		if arg == "--" && section != "cmd" {
 			section = ""
@@ -77,7 +93,7 @@ func cmdForkExec(args []string) (int, error) {
 
 		if section == "env" {
 			fields := strings.SplitN(arg, "=", 2)
-			if len(fields) == 2 && fields[0] == "HOME" {
+			if len(fields) == 2 && fields[0] == "HOME" && cwd == "" {
 				opts.Cwd = fields[1]
 			}
 			env = append(env, arg)
@@ -88,6 +104,10 @@ func cmdForkExec(args []string) (int, error) {
 		}
 	}
 
+	if cwd != "" {
+		opts.Cwd = cwd
+	}
+
 	opts.Env = env
 
 	status, err := c.RunCommandNoWait(cmd, opts)
@@ -0,0 +1,340 @@
+package main
+
+/*
+extern int forkproxy_child_pid;
+extern int forkproxy_fd;
+*/
+import "C"
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// How long a UDP client may stay idle before its session is dropped
+const forkProxyUDPTimeout = 30 * time.Second
+
+// proxyParseAddr splits a proxy address of the form <proto>:<address> into
+// its protocol and address parts.
+func proxyParseAddr(addr string) (string, string, error) {
+	fields := strings.SplitN(addr, ":", 2)
+	if len(fields) != 2 || fields[1] == "" {
+		return "", "", fmt.Errorf("Invalid proxy address \"%s\", expected <protocol>:<address>", addr)
+	}
+
+	switch fields[0] {
+	case "tcp", "udp":
+		_, _, err := net.SplitHostPort(fields[1])
+		if err != nil {
+			return "", "", fmt.Errorf("Invalid proxy address \"%s\": %s", addr, err)
+		}
+	case "unix":
+		// Either an abstract socket or a normalized absolute path
+		path := fields[1]
+		if !strings.HasPrefix(path, "@") && (!filepath.IsAbs(path) || filepath.Clean(path) != path) {
+			return "", "", fmt.Errorf("Invalid proxy address \"%s\", unix sockets must have an absolute path", addr)
+		}
+	default:
+		return "", "", fmt.Errorf("Invalid proxy protocol \"%s\", must be one of tcp, udp or unix", fields[0])
+	}
+
+	return fields[0], fields[1], nil
+}
+
+/*
+ * This is called by lxd when called as
+ * "lxd forkproxy <listen pid> <listen addr> <connect pid> <connect addr>".
+ *
+ * By the time we get here, main_nsexec.go has forked. The child is attached
+ * to the namespaces of <listen pid>, sets up the listener there and passes
+ * it to the parent before exiting. The parent is attached to the namespaces
+ * of <connect pid> and forwards every connection it receives on that
+ * listener to <connect addr>. A pid of 0 refers to the host.
+ */
+func cmdForkProxy(args []string) error {
+	if len(args) != 5 {
+		return fmt.Errorf("Bad arguments %q", args)
+	}
+
+	listenAddr := args[2]
+	connectAddr := args[4]
+
+	listenProto, listenAddress, err := proxyParseAddr(listenAddr)
+	if err != nil {
+		return err
+	}
+
+	connectProto, connectAddress, err := proxyParseAddr(connectAddr)
+	if err != nil {
+		return err
+	}
+
+	file := os.NewFile(uintptr(C.forkproxy_fd), "forkproxy")
+	conn, err := net.FileConn(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	sock, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("Bad forkproxy socket")
+	}
+
+	if C.forkproxy_child_pid == 0 {
+		return forkProxySendListener(sock, listenProto, listenAddress)
+	}
+
+	listener, err := forkProxyRecvListener(sock, listenProto, int(C.forkproxy_child_pid))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Forwarding %s to %s\n", listenAddr, connectAddr)
+
+	switch l := listener.(type) {
+	case net.Listener:
+		return forkProxyStream(l, connectProto, connectAddress)
+	case net.PacketConn:
+		return forkProxyDatagram(l, connectProto, connectAddress)
+	}
+
+	return fmt.Errorf("Unsupported listener type")
+}
+
+func forkProxySendListener(sock *net.UnixConn, proto string, addr string) error {
+	var file *os.File
+
+	switch proto {
+	case "udp":
+		udpAddr, err := net.ResolveUDPAddr(proto, addr)
+		if err != nil {
+			return err
+		}
+
+		conn, err := net.ListenUDP(proto, udpAddr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		file, err = conn.File()
+		if err != nil {
+			return err
+		}
+	case "unix":
+		// Clear any stale socket left behind by a previous proxy, but
+		// never anything else living at that path
+		if !strings.HasPrefix(addr, "@") {
+			fi, err := os.Lstat(addr)
+			if err == nil {
+				if fi.Mode()&os.ModeSocket == 0 {
+					return fmt.Errorf("Can't listen on \"%s\", the path exists and isn't a socket", addr)
+				}
+
+				err = os.Remove(addr)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Closing a net.UnixListener removes the socket, so set it up by hand
+		fd, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+		if err != nil {
+			return err
+		}
+
+		file = os.NewFile(uintptr(fd), addr)
+
+		err = syscall.Bind(fd, &syscall.SockaddrUnix{Name: addr})
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		err = syscall.Listen(fd, syscall.SOMAXCONN)
+		if err != nil {
+			file.Close()
+			return err
+		}
+	default:
+		listener, err := net.Listen(proto, addr)
+		if err != nil {
+			return err
+		}
+		defer listener.Close()
+
+		file, err = listener.(*net.TCPListener).File()
+		if err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+
+	_, _, err := sock.WriteMsgUnix([]byte{0}, syscall.UnixRights(int(file.Fd())), nil)
+	return err
+}
+
+func forkProxyRecvListener(sock *net.UnixConn, proto string, childPid int) (interface{}, error) {
+	buf := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(4))
+
+	_, oobn, _, _, err := sock.ReadMsgUnix(buf, oob)
+
+	// Reap the child before anything else
+	var status syscall.WaitStatus
+	_, waitErr := syscall.Wait4(childPid, &status, 0, nil)
+	if waitErr == nil && status.ExitStatus() != 0 {
+		return nil, fmt.Errorf("Failed to set up the listener")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("Didn't receive the listener")
+	}
+
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fds) != 1 {
+		return nil, fmt.Errorf("Didn't receive the listener")
+	}
+
+	file := os.NewFile(uintptr(fds[0]), "listener")
+	defer file.Close()
+
+	if proto == "udp" {
+		return net.FilePacketConn(file)
+	}
+
+	return net.FileListener(file)
+}
+
+func forkProxyStream(listener net.Listener, proto string, addr string) error {
+	for {
+		src, err := listener.Accept()
+		if err != nil {
+			netErr, ok := err.(net.Error)
+			if ok && netErr.Temporary() {
+				fmt.Fprintf(os.Stderr, "Failed to accept new connection: %s\n", err)
+				continue
+			}
+
+			return err
+		}
+
+		go func(src net.Conn) {
+			dst, err := net.Dial(proto, addr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to %s:%s: %s\n", proto, addr, err)
+				src.Close()
+				return
+			}
+
+			wg := sync.WaitGroup{}
+			wg.Add(2)
+
+			go func() {
+				io.Copy(dst, src)
+				forkProxyCloseWrite(dst)
+				wg.Done()
+			}()
+
+			go func() {
+				io.Copy(src, dst)
+				forkProxyCloseWrite(src)
+				wg.Done()
+			}()
+
+			wg.Wait()
+			src.Close()
+			dst.Close()
+		}(src)
+	}
+}
+
+// forkProxyCloseWrite propagates an EOF to the other end of a stream
+// connection while still allowing data to come back.
+func forkProxyCloseWrite(conn net.Conn) {
+	switch c := conn.(type) {
+	case *net.TCPConn:
+		c.CloseWrite()
+	case *net.UnixConn:
+		c.CloseWrite()
+	default:
+		c.Close()
+	}
+}
+
+func forkProxyDatagram(listener net.PacketConn, proto string, addr string) error {
+	sessionsLock := sync.Mutex{}
+	sessions := map[string]net.Conn{}
+
+	buf := make([]byte, 65535)
+	for {
+		n, client, err := listener.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		sessionsLock.Lock()
+		dst, ok := sessions[client.String()]
+		if !ok {
+			dst, err = net.Dial(proto, addr)
+			if err != nil {
+				sessionsLock.Unlock()
+				fmt.Fprintf(os.Stderr, "Failed to connect to %s:%s: %s\n", proto, addr, err)
+				continue
+			}
+
+			sessions[client.String()] = dst
+
+			// Relay the replies until the client goes quiet
+			go func(client net.Addr, dst net.Conn) {
+				reply := make([]byte, 65535)
+				for {
+					dst.SetReadDeadline(time.Now().Add(forkProxyUDPTimeout))
+					n, err := dst.Read(reply)
+					if err != nil {
+						break
+					}
+
+					_, err = listener.WriteTo(reply[:n], client)
+					if err != nil {
+						break
+					}
+				}
+
+				sessionsLock.Lock()
+				delete(sessions, client.String())
+				sessionsLock.Unlock()
+				dst.Close()
+			}(client, dst)
+		}
+		sessionsLock.Unlock()
+
+		_, err = dst.Write(buf[:n])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to forward datagram to %s:%s: %s\n", proto, addr, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_proxyParseAddr(t *testing.T) {
+	tests := []struct {
+		addr  string
+		valid bool
+	}{
+		{"tcp:127.0.0.1:80", true},
+		{"udp:[::1]:53", true},
+		{"unix:/run/app.sock", true},
+		{"unix:@app", true},
+		{"tcp:127.0.0.1", false},
+		{"unix:app.sock", false},
+		{"unix:/run/../etc/passwd", false},
+		{"unix:/run/app.sock/", false},
+		{"sctp:127.0.0.1:80", false},
+		{"unix:", false},
+	}
+
+	for _, test := range tests {
+		_, _, err := proxyParseAddr(test.addr)
+		if (err == nil) != test.valid {
+			t.Errorf("%q: expected valid to be %v, got error %v", test.addr, test.valid, err)
+		}
+	}
+}
@@ -643,6 +643,12 @@ func (cmd *CmdInit) availableStoragePoolsDrivers() []string {
 			continue
 		}
 
+		// ceph needs an existing cluster so its pools have to be
+		// created through "lxc storage create".
+		if driver == "ceph" {
+			continue
+		}
+
 		// btrfs can work in user namespaces too. (If
 		// source=/some/path/on/btrfs is used.)
 		if cmd.RunningInUserns && driver != "btrfs" {
@@ -39,6 +39,7 @@ package main
 #include <ifaddrs.h>
 #include <dirent.h>
 #include <grp.h>
+#include <sys/socket.h>
 
 // This expects:
 //  ./lxd forkputfile /source/path <pid> /target/path
@@ -681,6 +682,79 @@ void forkgetnet(char *buf, char *cur, ssize_t size) {
 	// The rest happens in Go
 }
 
+// Set by forkproxy for the Go side. The child process ends up with a
+// forkproxy_child_pid of 0, the parent with the pid of the child.
+int forkproxy_child_pid = -1;
+int forkproxy_fd = -1;
+
+int forkproxy_setns(int pid, char *addr) {
+	// A pid of 0 means the host, which is where we already are.
+	if (pid <= 0)
+		return 0;
+
+	if (dosetns(pid, "net") < 0) {
+		fprintf(stderr, "Failed setns to container network namespace: %s\n", strerror(errno));
+		return -1;
+	}
+
+	// Unix sockets which aren't abstract live on the filesystem.
+	if (strncmp(addr, "unix:", 5) == 0 && addr[5] != '@') {
+		if (dosetns(pid, "mnt") < 0) {
+			fprintf(stderr, "Failed setns to container mount namespace: %s\n", strerror(errno));
+			return -1;
+		}
+	}
+
+	return 0;
+}
+
+void forkproxy(char *buf, char *cur, ssize_t size) {
+	int listen_pid, connect_pid;
+	char *listen_addr, *connect_addr;
+	int sk_fds[2];
+	pid_t pid;
+
+	ADVANCE_ARG_REQUIRED();
+	listen_pid = atoi(cur);
+	ADVANCE_ARG_REQUIRED();
+	listen_addr = cur;
+	ADVANCE_ARG_REQUIRED();
+	connect_pid = atoi(cur);
+	ADVANCE_ARG_REQUIRED();
+	connect_addr = cur;
+
+	if (socketpair(AF_UNIX, SOCK_STREAM, 0, sk_fds) < 0) {
+		fprintf(stderr, "Failed to create socket pair: %s\n", strerror(errno));
+		_exit(1);
+	}
+
+	pid = fork();
+	if (pid < 0) {
+		fprintf(stderr, "Failed to fork: %s\n", strerror(errno));
+		_exit(1);
+	}
+
+	if (pid == 0) {
+		// The child sets up the listener and hands it over
+		close(sk_fds[0]);
+		forkproxy_fd = sk_fds[1];
+		forkproxy_child_pid = 0;
+
+		if (forkproxy_setns(listen_pid, listen_addr) < 0)
+			_exit(1);
+	} else {
+		// The parent receives the listener and does the forwarding
+		close(sk_fds[1]);
+		forkproxy_fd = sk_fds[0];
+		forkproxy_child_pid = pid;
+
+		if (forkproxy_setns(connect_pid, connect_addr) < 0)
+			_exit(1);
+	}
+
+	// The rest happens in Go
+}
+
 __attribute__((constructor)) void init(void) {
 	int cmdline;
 	char buf[CMDLINE_SIZE];
@@ -723,6 +797,8 @@ __attribute__((constructor)) void init(void) {
 		forkumount(buf, cur, size);
 	} else if (strcmp(cur, "forkgetnet") == 0) {
 		forkgetnet(buf, cur, size);
+	} else if (strcmp(cur, "forkproxy") == 0) {
+		forkproxy(buf, cur, size);
 	}
 }
 */
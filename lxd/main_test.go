@@ -99,7 +99,7 @@ func (suite *lxdTestSuite) SetupTest() {
 	devicesMap := map[string]map[string]string{}
 	devicesMap["root"] = rootDev
 
-	defaultID, _, err := dbProfileGet(suite.d.db, "default")
+	defaultID, _, err := dbProfileGet(suite.d.db, "default", "default")
 	if err != nil {
 		os.Exit(1)
 	}
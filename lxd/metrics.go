@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// metricType is the OpenMetrics type of a metric family.
+type metricType string
+
+const (
+	metricTypeCounter metricType = "counter"
+	metricTypeGauge   metricType = "gauge"
+)
+
+type metricSample struct {
+	labels map[string]string
+	value  float64
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	mType   metricType
+	samples []metricSample
+}
+
+// metricSet collects metric families and renders them in the OpenMetrics
+// text exposition format. Families are rendered in the order in which they
+// were first declared.
+type metricSet struct {
+	families []*metricFamily
+	index    map[string]*metricFamily
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{index: map[string]*metricFamily{}}
+}
+
+// declare registers a metric family. Declaring a family without ever adding
+// a sample to it still renders its metadata, which lets scrapers know about
+// the metric even when there's currently nothing to report.
+func (m *metricSet) declare(name string, mType metricType, help string) {
+	_, ok := m.index[name]
+	if ok {
+		return
+	}
+
+	family := &metricFamily{name: name, help: help, mType: mType}
+	m.families = append(m.families, family)
+	m.index[name] = family
+}
+
+// add appends a sample to a previously declared metric family.
+func (m *metricSet) add(name string, labels map[string]string, value float64) {
+	family, ok := m.index[name]
+	if !ok {
+		logger.Debugf("Dropping sample for undeclared metric \"%s\"", name)
+		return
+	}
+
+	family.samples = append(family.samples, metricSample{labels: labels, value: value})
+}
+
+func (m *metricSet) String() string {
+	var buf bytes.Buffer
+
+	for _, family := range m.families {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", family.name, metricsEscape(family.help, false))
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", family.name, family.mType)
+
+		sampleName := family.name
+		if family.mType == metricTypeCounter {
+			sampleName = fmt.Sprintf("%s_total", family.name)
+		}
+
+		for _, sample := range family.samples {
+			buf.WriteString(sampleName)
+			buf.WriteString(metricsLabels(sample.labels))
+			fmt.Fprintf(&buf, " %v\n", sample.value)
+		}
+	}
+
+	buf.WriteString("# EOF\n")
+
+	return buf.String()
+}
+
+// metricsLabels renders a label set, sorted by label name so that the output
+// is stable across scrapes.
+func metricsLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := []string{}
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", k, metricsEscape(labels[k], true)))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
+
+// metricsEscape escapes backslashes and newlines and, for label values,
+// double quotes.
+func metricsEscape(value string, quote bool) string {
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "\n", "\\n", -1)
+	if quote {
+		value = strings.Replace(value, "\"", "\\\"", -1)
+	}
+
+	return value
+}
+
+// Metrics response
+type metricsResponse struct {
+	metrics *metricSet
+}
+
+func (r *metricsResponse) Render(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	_, err := w.Write([]byte(r.metrics.String()))
+	return err
+}
+
+func (r *metricsResponse) String() string {
+	return "metrics"
+}
+
+func metricsGet(d *Daemon, r *http.Request) Response {
+	metrics := newMetricSet()
+
+	err := metricsContainers(d, r, metrics)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	metricsOperations(metrics)
+
+	err = metricsImages(metrics)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return &metricsResponse{metrics: metrics}
+}
+
+var metricsCmd = Command{name: "metrics", get: metricsGet}
+
+// metricsContainers gathers the runtime state of the containers the client
+// can see along with the disk usage of their root disks, aggregated per
+// storage pool.
+func metricsContainers(d *Daemon, r *http.Request, metrics *metricSet) error {
+	metrics.declare("lxd_container_running", metricTypeGauge, "Whether the container is running.")
+	metrics.declare("lxd_container_cpu_usage_seconds", metricTypeCounter, "CPU time consumed by the container.")
+	metrics.declare("lxd_container_memory_usage_bytes", metricTypeGauge, "Memory used by the container.")
+	metrics.declare("lxd_container_memory_usage_peak_bytes", metricTypeGauge, "Peak memory used by the container.")
+	metrics.declare("lxd_container_swap_usage_bytes", metricTypeGauge, "Swap used by the container.")
+	metrics.declare("lxd_container_swap_usage_peak_bytes", metricTypeGauge, "Peak swap used by the container.")
+	metrics.declare("lxd_container_processes", metricTypeGauge, "Number of processes in the container.")
+	metrics.declare("lxd_container_disk_usage_bytes", metricTypeGauge, "Disk space used by the container's root disk.")
+	metrics.declare("lxd_container_network_receive_bytes", metricTypeCounter, "Bytes received on the container's network interface.")
+	metrics.declare("lxd_container_network_transmit_bytes", metricTypeCounter, "Bytes sent on the container's network interface.")
+	metrics.declare("lxd_container_network_receive_packets", metricTypeCounter, "Packets received on the container's network interface.")
+	metrics.declare("lxd_container_network_transmit_packets", metricTypeCounter, "Packets sent on the container's network interface.")
+	metrics.declare("lxd_storage_pool_containers", metricTypeGauge, "Number of containers on the storage pool.")
+	metrics.declare("lxd_storage_pool_space_used_bytes", metricTypeGauge, "Space used on the storage pool.")
+	metrics.declare("lxd_storage_pool_space_total_bytes", metricTypeGauge, "Total space of the storage pool.")
+
+	names, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return err
+	}
+
+	poolContainers := map[string]int64{}
+
+	for _, name := range names {
+		c, err := containerLoadByName(d, name)
+		if err != nil {
+			logger.Debugf("Failed to load container \"%s\" for metrics: %s", name, err)
+			continue
+		}
+
+		allowed, err := restrictedAllowsContainer(d, r, c.Project(), projectUnprefix(c.Project(), name))
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			continue
+		}
+
+		state, err := c.RenderState()
+		if err != nil {
+			logger.Debugf("Failed to get state of container \"%s\" for metrics: %s", name, err)
+			continue
+		}
+
+		if c.Storage() != nil {
+			_, poolName := c.Storage().GetContainerPoolInfo()
+			poolContainers[poolName]++
+		}
+
+		// Containers are named within their project
+		labels := map[string]string{"name": projectUnprefix(c.Project(), name), "project": c.Project()}
+		if !c.IsRunning() {
+			metrics.add("lxd_container_running", labels, 0)
+			continue
+		}
+		metrics.add("lxd_container_running", labels, 1)
+
+		metrics.add("lxd_container_cpu_usage_seconds", labels, float64(state.CPU.Usage)/1e9)
+		metrics.add("lxd_container_memory_usage_bytes", labels, float64(state.Memory.Usage))
+		metrics.add("lxd_container_memory_usage_peak_bytes", labels, float64(state.Memory.UsagePeak))
+		metrics.add("lxd_container_swap_usage_bytes", labels, float64(state.Memory.SwapUsage))
+		metrics.add("lxd_container_swap_usage_peak_bytes", labels, float64(state.Memory.SwapUsagePeak))
+		metrics.add("lxd_container_processes", labels, float64(state.Processes))
+
+		for dev, disk := range state.Disk {
+			metrics.add("lxd_container_disk_usage_bytes", metricsDeviceLabels(labels, dev), float64(disk.Usage))
+		}
+
+		for dev, network := range state.Network {
+			devLabels := metricsDeviceLabels(labels, dev)
+			metrics.add("lxd_container_network_receive_bytes", devLabels, float64(network.Counters.BytesReceived))
+			metrics.add("lxd_container_network_transmit_bytes", devLabels, float64(network.Counters.BytesSent))
+			metrics.add("lxd_container_network_receive_packets", devLabels, float64(network.Counters.PacketsReceived))
+			metrics.add("lxd_container_network_transmit_packets", devLabels, float64(network.Counters.PacketsSent))
+		}
+	}
+
+	pools, err := dbStoragePools(d.db)
+	if err != nil && err != NoSuchObjectError {
+		return err
+	}
+	sort.Strings(pools)
+
+	for _, pool := range pools {
+		labels := map[string]string{"pool": pool}
+		metrics.add("lxd_storage_pool_containers", labels, float64(poolContainers[pool]))
+
+		res, err := storagePoolResources(d, pool)
+		if err != nil {
+			logger.Debugf("Failed to get resources of storage pool \"%s\" for metrics: %s", pool, err)
+			continue
+		}
+
+		metrics.add("lxd_storage_pool_space_used_bytes", labels, float64(res.Space.Used))
+		metrics.add("lxd_storage_pool_space_total_bytes", labels, float64(res.Space.Total))
+	}
+
+	return nil
+}
+
+// metricsDeviceLabels returns the labels of a container along with the name
+// of one of its devices.
+func metricsDeviceLabels(labels map[string]string, device string) map[string]string {
+	devLabels := map[string]string{"device": device}
+	for k, v := range labels {
+		devLabels[k] = v
+	}
+
+	return devLabels
+}
+
+// metricsOperations counts the current operations by class.
+func metricsOperations(metrics *metricSet) {
+	metrics.declare("lxd_operations", metricTypeGauge, "Number of operations known to the daemon.")
+
+	counts := map[operationClass]int{
+		operationClassTask:      0,
+		operationClassWebsocket: 0,
+		operationClassToken:     0,
+	}
+
+	operationsLock.Lock()
+	for _, op := range operations {
+		counts[op.class]++
+	}
+	operationsLock.Unlock()
+
+	for _, class := range []operationClass{operationClassTask, operationClassWebsocket, operationClassToken} {
+		metrics.add("lxd_operations", map[string]string{"class": class.String()}, float64(counts[class]))
+	}
+}
+
+// metricsImages reports the size of the local image cache.
+func metricsImages(metrics *metricSet) error {
+	metrics.declare("lxd_image_cache_size_bytes", metricTypeGauge, "Size of the local image cache.")
+
+	size := int64(0)
+
+	path := shared.VarPath("images")
+	if shared.PathExists(path) {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if !entry.Mode().IsRegular() {
+				continue
+			}
+
+			size += entry.Size()
+		}
+	}
+
+	metrics.add("lxd_image_cache_size_bytes", nil, float64(size))
+
+	return nil
+}
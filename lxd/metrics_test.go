@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_metricSet_render(t *testing.T) {
+	metrics := newMetricSet()
+	metrics.declare("lxd_test_bytes", metricTypeCounter, "Test\ncounter.")
+	metrics.declare("lxd_test_gauge", metricTypeGauge, "Test gauge.")
+	metrics.add("lxd_test_bytes", map[string]string{"name": "c1", "device": "eth\"0"}, 42)
+	metrics.add("lxd_test_gauge", nil, 1.5)
+	metrics.add("lxd_test_missing", nil, 1)
+
+	expected := `# HELP lxd_test_bytes Test\ncounter.
+# TYPE lxd_test_bytes counter
+lxd_test_bytes_total{device="eth\"0",name="c1"} 42
+# HELP lxd_test_gauge Test gauge.
+# TYPE lxd_test_gauge gauge
+lxd_test_gauge 1.5
+# EOF
+`
+
+	result := metrics.String()
+	if result != expected {
+		t.Fatalf("Unexpected metrics output:\n%s\nExpected:\n%s", result, expected)
+	}
+}
+
+func Test_metricsDeviceLabels(t *testing.T) {
+	labels := map[string]string{"name": "c1", "project": "ci"}
+
+	result := metricsLabels(metricsDeviceLabels(labels, "eth0"))
+	if result != `{device="eth0",name="c1",project="ci"}` {
+		t.Fatalf("Unexpected device labels: %s", result)
+	}
+
+	if len(labels) != 2 {
+		t.Fatalf("The container labels were modified: %v", labels)
+	}
+}
@@ -7,6 +7,7 @@ package main
 
 import (
 	"crypto/x509"
+	"encoding/binary"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
@@ -15,6 +16,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -27,6 +29,10 @@ import (
 	"github.com/lxc/lxd/shared/logger"
 )
 
+// The magic numbers at the start of CRIU's stats-dump image
+const criuImageServiceMagic = 0x55105940
+const criuStatsMagic = 0x57093306
+
 type migrationFields struct {
 	live bool
 
@@ -394,8 +400,11 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 
 	driver, fsErr := s.container.Storage().MigrationSource(s.container, s.containerOnly)
 
+	myType := s.container.Storage().MigrationType()
+
 	snapshots := []*Snapshot{}
 	snapshotNames := []string{}
+	snapshotIdentities := []string{}
 	// Only send snapshots when requested.
 	if !s.containerOnly {
 		if fsErr == nil {
@@ -404,18 +413,34 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 				snapshots = append(snapshots, snapshotToProtobuf(snap))
 				snapshotNames = append(snapshotNames, shared.ExtractSnapshotName(snap.Name()))
 			}
+
+			// Let the target check that its snapshots are copies
+			// of ours before it asks for incremental streams.
+			if myType == MigrationFSType_ZFS || myType == MigrationFSType_BTRFS {
+				for _, snap := range fullSnaps {
+					snapshotIdentities = append(snapshotIdentities, migrationSnapshotIdentity(s.container.Storage(), snap.Name()))
+				}
+			}
 		}
 	}
 
+	// Only offer pre-copy when it's enabled and CRIU supports it.
+	usePreDumps := false
+	maxDumpIterations := 0
+	if s.live {
+		usePreDumps, maxDumpIterations = s.checkForPreDumpSupport()
+	}
+
 	// The protocol says we have to send a header no matter what, so let's
 	// do that, but then immediately send an error.
-	myType := s.container.Storage().MigrationType()
 	header := MigrationHeader{
-		Fs:            &myType,
-		Criu:          criuType,
-		Idmap:         idmaps,
-		SnapshotNames: snapshotNames,
-		Snapshots:     snapshots,
+		Fs:                 &myType,
+		Criu:               criuType,
+		Idmap:              idmaps,
+		SnapshotNames:      snapshotNames,
+		Snapshots:          snapshots,
+		Predump:            proto.Bool(usePreDumps),
+		SnapshotIdentities: snapshotIdentities,
 	}
 
 	err = s.send(&header)
@@ -449,6 +474,12 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 		}
 	}
 
+	// The target already has a copy of the container, only send what it's
+	// missing.
+	if header.GetRefresh() {
+		driver.Refresh(header.SnapshotNames)
+	}
+
 	// All failure paths need to do a few things to correctly handle errors before returning.
 	// Unfortunately, handling errors is not well-suited to defer as the code depends on the
 	// status of driver and the error value.  The error value is especially tricky due to the
@@ -481,6 +512,20 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 			return abort(err)
 		}
 
+		// Targets which don't know about pre-copy don't echo the flag
+		// back, in which case everything is sent in the final dump.
+		dumpDir := ""
+		preDumpDir := ""
+		if header.GetPredump() {
+			preDumpDir, err = s.preDumpLoop(migrateOp, checkpointDir, maxDumpIterations, bwlimit)
+			if err != nil {
+				os.RemoveAll(checkpointDir)
+				return abort(err)
+			}
+
+			dumpDir = "final"
+		}
+
 		if lxc.VersionAtLeast(2, 0, 4) {
 			/* What happens below is slightly convoluted. Due to various
 			 * complications with networking, there's no easy way for criu
@@ -506,6 +551,8 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 			}
 
 			actionScriptOp, err := operationCreate(
+				s.container.Project(),
+				nil,
 				operationClassWebsocket,
 				nil,
 				nil,
@@ -556,7 +603,17 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 			}
 
 			go func() {
-				dumpSuccess <- s.container.Migrate(lxc.MIGRATE_DUMP, checkpointDir, "migration", true, true)
+				criuMigrationArgs := CriuMigrationArgs{
+					cmd:          lxc.MIGRATE_DUMP,
+					stateDir:     checkpointDir,
+					function:     "migration",
+					stop:         true,
+					actionScript: true,
+					dumpDir:      dumpDir,
+					preDumpDir:   preDumpDir,
+				}
+
+				dumpSuccess <- s.container.Migrate(&criuMigrationArgs)
 				os.RemoveAll(checkpointDir)
 			}()
 
@@ -570,7 +627,17 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 			}
 		} else {
 			defer os.RemoveAll(checkpointDir)
-			err = s.container.Migrate(lxc.MIGRATE_DUMP, checkpointDir, "migration", true, false)
+			criuMigrationArgs := CriuMigrationArgs{
+				cmd:          lxc.MIGRATE_DUMP,
+				stateDir:     checkpointDir,
+				function:     "migration",
+				stop:         true,
+				actionScript: false,
+				dumpDir:      dumpDir,
+				preDumpDir:   preDumpDir,
+			}
+
+			err = s.container.Migrate(&criuMigrationArgs)
 			if err != nil {
 				return abort(err)
 			}
@@ -619,6 +686,181 @@ func (s *migrationSourceWs) Do(migrateOp *operation) error {
 	return nil
 }
 
+// checkForPreDumpSupport tells whether the memory of the container should be
+// pre-dumped and if so, the maximum number of pre-dumps to do.
+func (s *migrationSourceWs) checkForPreDumpSupport() (bool, int) {
+	config := s.container.ExpandedConfig()
+	if !shared.IsTrue(config["migration.incremental.memory"]) {
+		return false, 0
+	}
+
+	// Ask CRIU whether it can track the memory changes on this system
+	criuMigrationArgs := CriuMigrationArgs{
+		cmd:          lxc.MIGRATE_FEATURE_CHECK,
+		stateDir:     "",
+		function:     "feature-check",
+		stop:         false,
+		actionScript: false,
+		features:     lxc.FEATURE_MEM_TRACK,
+	}
+
+	err := s.container.Migrate(&criuMigrationArgs)
+	if err != nil {
+		logger.Debugf("CRIU doesn't support memory tracking, not using pre-copy: %s", err)
+		return false, 0
+	}
+
+	maxIterations := 10
+	if config["migration.incremental.memory.iterations"] != "" {
+		maxIterations, err = strconv.Atoi(config["migration.incremental.memory.iterations"])
+		if err != nil || maxIterations < 1 {
+			maxIterations = 1
+		}
+	}
+
+	return true, maxIterations
+}
+
+// preDumpLoop pre-dumps the memory of the running container into successive
+// directories of checkpointDir, each one only containing the pages which
+// changed since the previous one, and sends them to the target. It stops
+// once enough pages are unchanged or after maxIterations and returns the
+// directory of the last pre-dump, which the final dump is based on.
+func (s *migrationSourceWs) preDumpLoop(migrateOp *operation, checkpointDir string, maxIterations int, bwlimit string) (string, error) {
+	goal := 70
+	config := s.container.ExpandedConfig()
+	if config["migration.incremental.memory.goal"] != "" {
+		value, err := strconv.Atoi(config["migration.incremental.memory.goal"])
+		if err == nil {
+			goal = value
+		}
+	}
+
+	ctName, _, _ := containerGetParentAndSnapshotName(s.container.Name())
+	preDumpDir := ""
+
+	for i := 1; ; i++ {
+		dumpDir := fmt.Sprintf("%03d", i)
+
+		criuMigrationArgs := CriuMigrationArgs{
+			cmd:          lxc.MIGRATE_PRE_DUMP,
+			stateDir:     checkpointDir,
+			function:     "migration",
+			stop:         false,
+			actionScript: false,
+			dumpDir:      dumpDir,
+			preDumpDir:   preDumpDir,
+		}
+
+		err := s.container.Migrate(&criuMigrationArgs)
+		if err != nil {
+			return "", err
+		}
+
+		stats, err := readCriuStatsDump(filepath.Join(checkpointDir, dumpDir))
+		if err != nil {
+			return "", err
+		}
+
+		// The share of the memory which didn't change since the
+		// previous pre-dump, that is which is already on the target.
+		written := stats.GetPagesWritten()
+		skipped := stats.GetPagesSkippedParent()
+		skippedPercent := 100
+		if written+skipped > 0 {
+			skippedPercent = int(skipped * 100 / (written + skipped))
+		}
+
+		final := skippedPercent >= goal || i >= maxIterations
+		logger.Debugf("Memory pre-dump %d/%d of %s: %d%% unchanged (goal %d%%)", i, maxIterations, ctName, skippedPercent, goal)
+
+		wrapper := StorageProgressReader(migrateOp, "fs_progress", fmt.Sprintf("Memory pre-dump %d/%d", i, maxIterations))
+		err = RsyncSend(ctName, shared.AddSlash(checkpointDir), s.criuConn, wrapper, bwlimit)
+		if err != nil {
+			return "", err
+		}
+
+		syncMsg := MigrationSync{
+			FinalPreDump: proto.Bool(final),
+		}
+
+		data, err := proto.Marshal(&syncMsg)
+		if err != nil {
+			return "", err
+		}
+
+		err = s.criuConn.WriteMessage(websocket.BinaryMessage, data)
+		if err != nil {
+			return "", err
+		}
+
+		preDumpDir = dumpDir
+		if final {
+			return preDumpDir, nil
+		}
+	}
+}
+
+// migrationRecvSync reads the message sent by the source after a pre-dump.
+func migrationRecvSync(conn *websocket.Conn) (*MigrationSync, error) {
+	mt, r, err := conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+
+	if mt != websocket.BinaryMessage {
+		return nil, fmt.Errorf("Only binary messages allowed")
+	}
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	syncMsg := MigrationSync{}
+	err = proto.Unmarshal(buf, &syncMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syncMsg, nil
+}
+
+// readCriuStatsDump reads the statistics CRIU writes after a (pre-)dump.
+// The file starts with the magic numbers of the image type, followed by the
+// size of the protobuf encoded entry and the entry itself.
+func readCriuStatsDump(path string) (*DumpStatsEntry, error) {
+	in, err := ioutil.ReadFile(filepath.Join(path, "stats-dump"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(in) < 12 {
+		return nil, fmt.Errorf("Invalid CRIU dump statistics")
+	}
+
+	if binary.LittleEndian.Uint32(in[0:4]) != criuImageServiceMagic || binary.LittleEndian.Uint32(in[4:8]) != criuStatsMagic {
+		return nil, fmt.Errorf("Invalid CRIU dump statistics magic")
+	}
+
+	size := binary.LittleEndian.Uint32(in[8:12])
+	if uint64(len(in)) < 12+uint64(size) {
+		return nil, fmt.Errorf("Truncated CRIU dump statistics")
+	}
+
+	stats := StatsEntry{}
+	err = proto.Unmarshal(in[12:12+size], &stats)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.GetDump() == nil {
+		return nil, fmt.Errorf("No dump entry in the CRIU statistics")
+	}
+
+	return stats.GetDump(), nil
+}
+
 type migrationSink struct {
 	// We are pulling the container from src in pull mode.
 	src migrationFields
@@ -631,6 +873,7 @@ type migrationSink struct {
 	dialer       websocket.Dialer
 	allConnected chan bool
 	push         bool
+	refresh      bool
 }
 
 type MigrationSinkArgs struct {
@@ -641,15 +884,17 @@ type MigrationSinkArgs struct {
 	Push          bool
 	Live          bool
 	ContainerOnly bool
+	Refresh       bool
 }
 
 func NewMigrationSink(args *MigrationSinkArgs) (*migrationSink, error) {
 	sink := migrationSink{
-		src:    migrationFields{container: args.Container, containerOnly: args.ContainerOnly},
-		dest:   migrationFields{containerOnly: args.ContainerOnly},
-		url:    args.Url,
-		dialer: args.Dialer,
-		push:   args.Push,
+		src:     migrationFields{container: args.Container, containerOnly: args.ContainerOnly},
+		dest:    migrationFields{containerOnly: args.ContainerOnly},
+		url:     args.Url,
+		dialer:  args.Dialer,
+		push:    args.Push,
+		refresh: args.Refresh,
 	}
 
 	if sink.push {
@@ -837,14 +1082,62 @@ func (c *migrationSink) Do(migrateOp *operation) error {
 		Criu: criuType,
 	}
 
+	// Accept memory pre-dumps if the source offers them.
+	if live {
+		resp.Predump = proto.Bool(header.GetPredump())
+	}
+
 	// If the storage type the source has doesn't match what we have, then
 	// we have to use rsync.
 	if *header.Fs != *resp.Fs {
-		mySink = rsyncMigrationSink
+		mySink = c.rsyncSink
 		myType = MigrationFSType_RSYNC
 		resp.Fs = &myType
 	}
 
+	// When refreshing an existing container, tell the source which
+	// snapshots we already have so that only the others get sent.
+	sourceSnapshots := header.Snapshots
+	if c.refresh {
+		targetSnapshots := []string{}
+		ctSnapshots, err := c.src.container.Snapshots()
+		if err != nil {
+			controller(err)
+			return err
+		}
+
+		for _, snap := range ctSnapshots {
+			targetSnapshots = append(targetSnapshots, shared.ExtractSnapshotName(snap.Name()))
+		}
+
+		resp.Refresh = proto.Bool(true)
+		resp.SnapshotNames = targetSnapshots
+
+		sourceSnapshots = []*Snapshot{}
+		for _, snap := range header.Snapshots {
+			if !shared.StringInSlice(snap.GetName(), targetSnapshots) {
+				sourceSnapshots = append(sourceSnapshots, snap)
+			}
+		}
+
+		// Incremental streams can only be applied on top of the
+		// latest snapshot we have and only if it's a copy of the
+		// source's snapshot of the same name, anything else has to go
+		// through rsync. Files removed on the source are then deleted
+		// here.
+		incremental := false
+		if (myType == MigrationFSType_ZFS || myType == MigrationFSType_BTRFS) && len(ctSnapshots) > 0 {
+			latest := migrationSnapshotIdentity(c.src.container.Storage(), ctSnapshots[len(ctSnapshots)-1].Name())
+			incremental = migrationRefreshIncremental(header.SnapshotNames, header.SnapshotIdentities, targetSnapshots, latest)
+		}
+
+		if !incremental {
+			mySink = c.rsyncSink
+			myType = MigrationFSType_RSYNC
+			resp.Fs = &myType
+		}
+	}
+
 	err = sender(&resp)
 	if err != nil {
 		controller(err)
@@ -887,7 +1180,7 @@ func (c *migrationSink) Do(migrateOp *operation) error {
 					snapshots = append(snapshots, base)
 				}
 			} else {
-				snapshots = header.Snapshots
+				snapshots = sourceSnapshots
 			}
 
 			var fsConn *websocket.Conn
@@ -929,6 +1222,29 @@ func (c *migrationSink) Do(migrateOp *operation) error {
 				criuConn = c.src.criuConn
 			}
 
+			// Receive the pre-dumps until the source tells us the
+			// next transfer is the final dump.
+			if resp.GetPredump() {
+				for i := 1; ; i++ {
+					wrapper := StorageProgressWriter(migrateOp, "fs_progress", fmt.Sprintf("Memory pre-dump %d", i))
+					err = RsyncRecv(shared.AddSlash(imagesDir), criuConn, wrapper)
+					if err != nil {
+						restore <- err
+						return
+					}
+
+					syncMsg, err := migrationRecvSync(criuConn)
+					if err != nil {
+						restore <- err
+						return
+					}
+
+					if syncMsg.GetFinalPreDump() {
+						break
+					}
+				}
+			}
+
 			err = RsyncRecv(shared.AddSlash(imagesDir), criuConn, nil)
 			if err != nil {
 				restore <- err
@@ -943,7 +1259,20 @@ func (c *migrationSink) Do(migrateOp *operation) error {
 		}
 
 		if live {
-			err = c.src.container.Migrate(lxc.MIGRATE_RESTORE, imagesDir, "migration", false, false)
+			criuMigrationArgs := CriuMigrationArgs{
+				cmd:          lxc.MIGRATE_RESTORE,
+				stateDir:     imagesDir,
+				function:     "migration",
+				stop:         false,
+				actionScript: false,
+			}
+
+			// The final dump is next to the pre-dumps it's based on.
+			if resp.GetPredump() {
+				criuMigrationArgs.dumpDir = "final"
+			}
+
+			err = c.src.container.Migrate(&criuMigrationArgs)
 			if err != nil {
 				restore <- err
 				return
@@ -983,3 +1312,75 @@ func (c *migrationSink) Do(migrateOp *operation) error {
 		}
 	}
 }
+
+// rsyncSink receives the container through rsync. When refreshing an
+// existing container, whatever was removed on the source gets deleted.
+func (c *migrationSink) rsyncSink(live bool, container container, snapshots []*Snapshot, conn *websocket.Conn, srcIdmap *shared.IdmapSet, op *operation, containerOnly bool) error {
+	rsyncArgs := []string{}
+	if c.refresh {
+		rsyncArgs = append(rsyncArgs, "--delete")
+	}
+
+	return rsyncMigrationSink(live, container, snapshots, conn, srcIdmap, op, containerOnly, rsyncArgs...)
+}
+
+// migrationRefreshIncremental tells whether the snapshots missing on the
+// target can be sent as incremental streams, that is whether the latest
+// snapshot of the target is on the source with the same identity and all
+// the missing ones come after it in the source's order.
+func migrationRefreshIncremental(sourceSnapshots []string, sourceIdentities []string, targetSnapshots []string, targetIdentity string) bool {
+	if len(targetSnapshots) == 0 || targetIdentity == "" {
+		return false
+	}
+
+	// Older sources don't send the identities
+	if len(sourceIdentities) != len(sourceSnapshots) {
+		return false
+	}
+
+	latest := targetSnapshots[len(targetSnapshots)-1]
+	common := false
+	for i, name := range sourceSnapshots {
+		if name == latest {
+			if sourceIdentities[i] != targetIdentity {
+				return false
+			}
+
+			common = true
+			continue
+		}
+
+		// Everything before the latest snapshot must be on the
+		// target and nothing after it.
+		if common == shared.StringInSlice(name, targetSnapshots) {
+			return false
+		}
+	}
+
+	return common
+}
+
+// migrationSnapshotIdentity returns what identifies a container snapshot on
+// the given storage, such that copies made with incremental streams share
+// it: the GUID of ZFS snapshots and the UUID btrfs looks parents up with.
+// It returns "" when the storage has no such thing or it can't be found.
+func migrationSnapshotIdentity(s storage, name string) string {
+	var identity string
+	var err error
+
+	switch st := s.(type) {
+	case *storageZfs:
+		identity, err = st.zfsSnapshotGUID(name)
+	case *storageBtrfs:
+		identity, err = btrfsSubVolumeIdentity(getSnapshotMountPoint(st.pool.Name, name))
+	default:
+		return ""
+	}
+
+	if err != nil {
+		logger.Debugf("Failed to get the identity of snapshot \"%s\": %s", name, err)
+		return ""
+	}
+
+	return identity
+}
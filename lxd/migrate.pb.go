@@ -6,15 +6,20 @@
 Package main is a generated protocol buffer package.
 
 It is generated from these files:
+
 	lxd/migrate.proto
 
 It has these top-level messages:
+
 	IDMapType
 	Config
 	Device
 	Snapshot
 	MigrationHeader
 	MigrationControl
+	MigrationSync
+	DumpStatsEntry
+	StatsEntry
 */
 package main
 
@@ -255,12 +260,15 @@ func (m *Snapshot) GetStateful() bool {
 }
 
 type MigrationHeader struct {
-	Fs               *MigrationFSType `protobuf:"varint,1,req,name=fs,enum=main.MigrationFSType" json:"fs,omitempty"`
-	Criu             *CRIUType        `protobuf:"varint,2,opt,name=criu,enum=main.CRIUType" json:"criu,omitempty"`
-	Idmap            []*IDMapType     `protobuf:"bytes,3,rep,name=idmap" json:"idmap,omitempty"`
-	SnapshotNames    []string         `protobuf:"bytes,4,rep,name=snapshotNames" json:"snapshotNames,omitempty"`
-	Snapshots        []*Snapshot      `protobuf:"bytes,5,rep,name=snapshots" json:"snapshots,omitempty"`
-	XXX_unrecognized []byte           `json:"-"`
+	Fs                 *MigrationFSType `protobuf:"varint,1,req,name=fs,enum=main.MigrationFSType" json:"fs,omitempty"`
+	Criu               *CRIUType        `protobuf:"varint,2,opt,name=criu,enum=main.CRIUType" json:"criu,omitempty"`
+	Idmap              []*IDMapType     `protobuf:"bytes,3,rep,name=idmap" json:"idmap,omitempty"`
+	SnapshotNames      []string         `protobuf:"bytes,4,rep,name=snapshotNames" json:"snapshotNames,omitempty"`
+	Snapshots          []*Snapshot      `protobuf:"bytes,5,rep,name=snapshots" json:"snapshots,omitempty"`
+	Refresh            *bool            `protobuf:"varint,6,opt,name=refresh" json:"refresh,omitempty"`
+	Predump            *bool            `protobuf:"varint,7,opt,name=predump" json:"predump,omitempty"`
+	SnapshotIdentities []string         `protobuf:"bytes,8,rep,name=snapshotIdentities" json:"snapshotIdentities,omitempty"`
+	XXX_unrecognized   []byte           `json:"-"`
 }
 
 func (m *MigrationHeader) Reset()         { *m = MigrationHeader{} }
@@ -302,6 +310,27 @@ func (m *MigrationHeader) GetSnapshots() []*Snapshot {
 	return nil
 }
 
+func (m *MigrationHeader) GetRefresh() bool {
+	if m != nil && m.Refresh != nil {
+		return *m.Refresh
+	}
+	return false
+}
+
+func (m *MigrationHeader) GetPredump() bool {
+	if m != nil && m.Predump != nil {
+		return *m.Predump
+	}
+	return false
+}
+
+func (m *MigrationHeader) GetSnapshotIdentities() []string {
+	if m != nil {
+		return m.SnapshotIdentities
+	}
+	return nil
+}
+
 type MigrationControl struct {
 	Success *bool `protobuf:"varint,1,req,name=success" json:"success,omitempty"`
 	// optional failure message if sending a failure
@@ -327,6 +356,102 @@ func (m *MigrationControl) GetMessage() string {
 	return ""
 }
 
+type MigrationSync struct {
+	FinalPreDump     *bool  `protobuf:"varint,1,req,name=finalPreDump" json:"finalPreDump,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *MigrationSync) Reset()         { *m = MigrationSync{} }
+func (m *MigrationSync) String() string { return proto.CompactTextString(m) }
+func (*MigrationSync) ProtoMessage()    {}
+
+func (m *MigrationSync) GetFinalPreDump() bool {
+	if m != nil && m.FinalPreDump != nil {
+		return *m.FinalPreDump
+	}
+	return false
+}
+
+type DumpStatsEntry struct {
+	FreezingTime       *uint32 `protobuf:"varint,1,req,name=freezingTime" json:"freezingTime,omitempty"`
+	FrozenTime         *uint32 `protobuf:"varint,2,req,name=frozenTime" json:"frozenTime,omitempty"`
+	MemdumpTime        *uint32 `protobuf:"varint,3,req,name=memdumpTime" json:"memdumpTime,omitempty"`
+	MemwriteTime       *uint32 `protobuf:"varint,4,req,name=memwriteTime" json:"memwriteTime,omitempty"`
+	PagesScanned       *uint64 `protobuf:"varint,5,req,name=pagesScanned" json:"pagesScanned,omitempty"`
+	PagesSkippedParent *uint64 `protobuf:"varint,6,req,name=pagesSkippedParent" json:"pagesSkippedParent,omitempty"`
+	PagesWritten       *uint64 `protobuf:"varint,7,req,name=pagesWritten" json:"pagesWritten,omitempty"`
+	XXX_unrecognized   []byte  `json:"-"`
+}
+
+func (m *DumpStatsEntry) Reset()         { *m = DumpStatsEntry{} }
+func (m *DumpStatsEntry) String() string { return proto.CompactTextString(m) }
+func (*DumpStatsEntry) ProtoMessage()    {}
+
+func (m *DumpStatsEntry) GetFreezingTime() uint32 {
+	if m != nil && m.FreezingTime != nil {
+		return *m.FreezingTime
+	}
+	return 0
+}
+
+func (m *DumpStatsEntry) GetFrozenTime() uint32 {
+	if m != nil && m.FrozenTime != nil {
+		return *m.FrozenTime
+	}
+	return 0
+}
+
+func (m *DumpStatsEntry) GetMemdumpTime() uint32 {
+	if m != nil && m.MemdumpTime != nil {
+		return *m.MemdumpTime
+	}
+	return 0
+}
+
+func (m *DumpStatsEntry) GetMemwriteTime() uint32 {
+	if m != nil && m.MemwriteTime != nil {
+		return *m.MemwriteTime
+	}
+	return 0
+}
+
+func (m *DumpStatsEntry) GetPagesScanned() uint64 {
+	if m != nil && m.PagesScanned != nil {
+		return *m.PagesScanned
+	}
+	return 0
+}
+
+func (m *DumpStatsEntry) GetPagesSkippedParent() uint64 {
+	if m != nil && m.PagesSkippedParent != nil {
+		return *m.PagesSkippedParent
+	}
+	return 0
+}
+
+func (m *DumpStatsEntry) GetPagesWritten() uint64 {
+	if m != nil && m.PagesWritten != nil {
+		return *m.PagesWritten
+	}
+	return 0
+}
+
+type StatsEntry struct {
+	Dump             *DumpStatsEntry `protobuf:"bytes,1,opt,name=dump" json:"dump,omitempty"`
+	XXX_unrecognized []byte          `json:"-"`
+}
+
+func (m *StatsEntry) Reset()         { *m = StatsEntry{} }
+func (m *StatsEntry) String() string { return proto.CompactTextString(m) }
+func (*StatsEntry) ProtoMessage()    {}
+
+func (m *StatsEntry) GetDump() *DumpStatsEntry {
+	if m != nil {
+		return m.Dump
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("main.MigrationFSType", MigrationFSType_name, MigrationFSType_value)
 	proto.RegisterEnum("main.CRIUType", CRIUType_name, CRIUType_value)
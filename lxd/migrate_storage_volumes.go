@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// Custom storage volumes are always transferred with rsync, regardless of the
+// storage driver in use on either side. The protocol is the same as for
+// containers minus the CRIU and snapshot bits.
+
+type migrationStorageVolumeSourceWs struct {
+	migrationFields
+
+	storage    storage
+	volumeName string
+
+	allConnected chan bool
+}
+
+func NewStorageVolumeMigrationSource(s storage, volumeName string) (*migrationStorageVolumeSourceWs, error) {
+	ret := migrationStorageVolumeSourceWs{
+		storage:      s,
+		volumeName:   volumeName,
+		allConnected: make(chan bool, 1),
+	}
+
+	var err error
+	ret.controlSecret, err = shared.RandomCryptoString()
+	if err != nil {
+		return nil, err
+	}
+
+	ret.fsSecret, err = shared.RandomCryptoString()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *migrationStorageVolumeSourceWs) Metadata() interface{} {
+	return shared.Jmap{
+		"control": s.controlSecret,
+		"fs":      s.fsSecret,
+	}
+}
+
+func (s *migrationStorageVolumeSourceWs) Connect(op *operation, r *http.Request, w http.ResponseWriter) error {
+	secret := r.FormValue("secret")
+	if secret == "" {
+		return fmt.Errorf("missing secret")
+	}
+
+	var conn **websocket.Conn
+
+	switch secret {
+	case s.controlSecret:
+		conn = &s.controlConn
+	case s.fsSecret:
+		conn = &s.fsConn
+	default:
+		// If we didn't find the right secret, the user provided a bad
+		// one, which 403, not 404, since this operation actually
+		// exists.
+		return os.ErrPermission
+	}
+
+	c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	*conn = c
+
+	if s.controlConn != nil && s.fsConn != nil {
+		s.allConnected <- true
+	}
+
+	return nil
+}
+
+func (s *migrationStorageVolumeSourceWs) Do(migrateOp *operation) error {
+	<-s.allConnected
+
+	ourMount, err := s.storage.StoragePoolVolumeMount()
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+	if ourMount {
+		defer s.storage.StoragePoolVolumeUmount()
+	}
+
+	myType := MigrationFSType_RSYNC
+	header := MigrationHeader{
+		Fs: &myType,
+	}
+
+	err = s.send(&header)
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+
+	err = s.recv(&header)
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+
+	if *header.Fs != myType {
+		err := fmt.Errorf("Storage volumes can only be transferred using rsync")
+		s.sendControl(err)
+		return err
+	}
+
+	_, poolName := s.storage.GetContainerPoolInfo()
+	path := getStoragePoolVolumeMountPoint(poolName, s.volumeName)
+	bwlimit := s.storage.GetStoragePoolWritable().Config["rsync.bwlimit"]
+	wrapper := StorageProgressReader(migrateOp, "fs_progress", s.volumeName)
+
+	err = RsyncSend(s.volumeName, shared.AddSlash(path), s.fsConn, wrapper, bwlimit)
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+
+	msg := MigrationControl{}
+	err = s.recv(&msg)
+	if err != nil {
+		s.disconnect()
+		return err
+	}
+
+	if !*msg.Success {
+		return fmt.Errorf(*msg.Message)
+	}
+
+	return nil
+}
+
+type migrationStorageVolumeSink struct {
+	// The storage volume is always pulled from src.
+	src migrationFields
+
+	storage    storage
+	volumeName string
+
+	url    string
+	dialer websocket.Dialer
+}
+
+type StorageVolumeMigrationSinkArgs struct {
+	Url        string
+	Dialer     websocket.Dialer
+	Storage    storage
+	VolumeName string
+	Secrets    map[string]string
+}
+
+func NewStorageVolumeMigrationSink(args *StorageVolumeMigrationSinkArgs) (*migrationStorageVolumeSink, error) {
+	sink := migrationStorageVolumeSink{
+		storage:    args.Storage,
+		volumeName: args.VolumeName,
+		url:        args.Url,
+		dialer:     args.Dialer,
+	}
+
+	var ok bool
+	sink.src.controlSecret, ok = args.Secrets["control"]
+	if !ok {
+		return nil, fmt.Errorf("Missing control secret")
+	}
+
+	sink.src.fsSecret, ok = args.Secrets["fs"]
+	if !ok {
+		return nil, fmt.Errorf("Missing fs secret")
+	}
+
+	return &sink, nil
+}
+
+func (c *migrationStorageVolumeSink) connectWithSecret(secret string) (*websocket.Conn, error) {
+	query := url.Values{"secret": []string{secret}}
+
+	// The URL is a https URL to the operation, mangle to be a wss URL to the secret
+	wsUrl := fmt.Sprintf("wss://%s/websocket?%s", strings.TrimPrefix(c.url, "https://"), query.Encode())
+
+	conn, _, err := c.dialer.Dial(wsUrl, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (c *migrationStorageVolumeSink) Do(migrateOp *operation) error {
+	var err error
+
+	c.src.controlConn, err = c.connectWithSecret(c.src.controlSecret)
+	if err != nil {
+		return err
+	}
+	defer c.src.disconnect()
+
+	c.src.fsConn, err = c.connectWithSecret(c.src.fsSecret)
+	if err != nil {
+		c.src.sendControl(err)
+		return err
+	}
+
+	header := MigrationHeader{}
+	err = c.src.recv(&header)
+	if err != nil {
+		c.src.sendControl(err)
+		return err
+	}
+
+	myType := MigrationFSType_RSYNC
+	resp := MigrationHeader{
+		Fs: &myType,
+	}
+
+	err = c.src.send(&resp)
+	if err != nil {
+		c.src.sendControl(err)
+		return err
+	}
+
+	if *header.Fs != myType {
+		err := fmt.Errorf("Storage volumes can only be transferred using rsync")
+		c.src.sendControl(err)
+		return err
+	}
+
+	fsTransfer := make(chan error)
+	go func() {
+		ourMount, err := c.storage.StoragePoolVolumeMount()
+		if err != nil {
+			fsTransfer <- err
+			return
+		}
+		if ourMount {
+			defer c.storage.StoragePoolVolumeUmount()
+		}
+
+		_, poolName := c.storage.GetContainerPoolInfo()
+		path := getStoragePoolVolumeMountPoint(poolName, c.volumeName)
+		wrapper := StorageProgressWriter(migrateOp, "fs_progress", c.volumeName)
+
+		fsTransfer <- RsyncRecv(shared.AddSlash(path), c.src.fsConn, wrapper)
+	}()
+
+	source := c.src.controlChannel()
+
+	for {
+		select {
+		case err = <-fsTransfer:
+			c.src.sendControl(err)
+			return err
+		case msg, ok := <-source:
+			if !ok {
+				c.src.disconnect()
+				return fmt.Errorf("Got error reading source")
+			}
+			if !*msg.Success {
+				c.src.disconnect()
+				return fmt.Errorf(*msg.Message)
+			} else {
+				// The source can only tell us it failed. We have to
+				// tell the source whether or not the transfer was
+				// successful.
+				logger.Debugf("Unknown message %v from source", msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func Test_migrationRefreshIncremental(t *testing.T) {
+	source := []string{"snap0", "snap1", "snap2", "snap3"}
+	identities := []string{"id0", "id1", "id2", "id3"}
+
+	tests := []struct {
+		target   []string
+		identity string
+		expected bool
+	}{
+		{[]string{}, "", false},
+		{[]string{"snap0"}, "id0", true},
+		{[]string{"snap0", "snap1"}, "id1", true},
+		{[]string{"snap0", "snap1", "snap2", "snap3"}, "id3", true},
+		{[]string{"snap1"}, "id1", false},
+		{[]string{"snap0", "snap2"}, "id2", false},
+		{[]string{"snap0", "snap2", "snap1"}, "id1", false},
+		{[]string{"snap0", "other"}, "", false},
+		{[]string{"other", "snap0"}, "id0", true},
+		// Same name, different snapshot
+		{[]string{"snap0"}, "other", false},
+		{[]string{"snap0", "snap1"}, "id0", false},
+		// Unknown identity
+		{[]string{"snap0"}, "", false},
+	}
+
+	for _, test := range tests {
+		if migrationRefreshIncremental(source, identities, test.target, test.identity) != test.expected {
+			t.Errorf("Unexpected result for target snapshots %v (%q), expected %v", test.target, test.identity, test.expected)
+		}
+	}
+
+	// Sources which don't send identities
+	if migrationRefreshIncremental(source, nil, []string{"snap0"}, "id0") {
+		t.Error("Expected no incremental refresh without source identities")
+	}
+}
+
+func Test_readCriuStatsDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lxd_stats_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stats := StatsEntry{
+		Dump: &DumpStatsEntry{
+			FreezingTime:       proto.Uint32(1),
+			FrozenTime:         proto.Uint32(2),
+			MemdumpTime:        proto.Uint32(3),
+			MemwriteTime:       proto.Uint32(4),
+			PagesScanned:       proto.Uint64(100),
+			PagesSkippedParent: proto.Uint64(70),
+			PagesWritten:       proto.Uint64(30),
+		},
+	}
+
+	data, err := proto.Marshal(&stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 12, 12+len(data))
+	binary.LittleEndian.PutUint32(buf[0:4], criuImageServiceMagic)
+	binary.LittleEndian.PutUint32(buf[4:8], criuStatsMagic)
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(len(data)))
+	buf = append(buf, data...)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "stats-dump"), buf, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dump, err := readCriuStatsDump(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dump.GetPagesSkippedParent() != 70 || dump.GetPagesWritten() != 30 {
+		t.Errorf("Unexpected statistics: %v", dump)
+	}
+
+	// A truncated file must be rejected
+	err = ioutil.WriteFile(filepath.Join(dir, "stats-dump"), buf[:len(buf)-1], 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readCriuStatsDump(dir)
+	if err == nil {
+		t.Error("Expected an error for truncated statistics")
+	}
+}
@@ -39,7 +39,7 @@ func networksGet(d *Daemon, r *http.Request) Response {
 		if recursion == 0 {
 			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s", version.APIVersion, iface))
 		} else {
-			net, err := doNetworkGet(d, iface)
+			net, err := doNetworkGet(d, r, iface)
 			if err != nil {
 				continue
 			}
@@ -144,7 +144,10 @@ func networksPost(d *Daemon, r *http.Request) Response {
 		return InternalError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name))
+	url := fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name)
+	eventSendLifecycle("network-created", url, nil)
+
+	return SyncResponseLocation(true, nil, url)
 }
 
 var networksCmd = Command{name: "networks", get: networksGet, post: networksPost}
@@ -152,7 +155,7 @@ var networksCmd = Command{name: "networks", get: networksGet, post: networksPost
 func networkGet(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
-	n, err := doNetworkGet(d, name)
+	n, err := doNetworkGet(d, r, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -162,7 +165,7 @@ func networkGet(d *Daemon, r *http.Request) Response {
 	return SyncResponseETag(true, &n, etag)
 }
 
-func doNetworkGet(d *Daemon, name string) (api.Network, error) {
+func doNetworkGet(d *Daemon, r *http.Request, name string) (api.Network, error) {
 	// Get some information
 	osInfo, _ := net.InterfaceByName(name)
 	_, dbInfo, _ := dbNetworkGet(d.db, name)
@@ -191,10 +194,15 @@ func doNetworkGet(d *Daemon, name string) (api.Network, error) {
 		}
 
 		if networkIsInUse(c, n.Name) {
-			n.UsedBy = append(n.UsedBy, fmt.Sprintf("/%s/containers/%s", version.APIVersion, ct))
+			n.UsedBy = append(n.UsedBy, containerURL(c.Project(), ct))
 		}
 	}
 
+	n.UsedBy, err = restrictedFilterUsedBy(d, r, n.UsedBy)
+	if err != nil {
+		return api.Network{}, err
+	}
+
 	// Set the device type as needed
 	if osInfo != nil && shared.IsLoopback(osInfo) {
 		n.Type = "loopback"
@@ -244,6 +252,8 @@ func networkDelete(d *Daemon, r *http.Request) Response {
 		os.RemoveAll(shared.VarPath("networks", n.name))
 	}
 
+	eventSendLifecycle("network-deleted", fmt.Sprintf("/%s/networks/%s", version.APIVersion, name), nil)
+
 	return EmptySyncResponse
 }
 
@@ -289,6 +299,8 @@ func networkPost(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("network-renamed", fmt.Sprintf("/%s/networks/%s", version.APIVersion, name), map[string]interface{}{"new_name": req.Name})
+
 	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name))
 }
 
@@ -379,11 +391,149 @@ func doNetworkUpdate(d *Daemon, name string, oldConfig map[string]string, req ap
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("network-updated", fmt.Sprintf("/%s/networks/%s", version.APIVersion, name), nil)
+
 	return EmptySyncResponse
 }
 
 var networkCmd = Command{name: "networks/{name}", get: networkGet, delete: networkDelete, post: networkPost, put: networkPut, patch: networkPatch}
 
+func networkLeasesGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Only managed networks have leases
+	_, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	leases := []api.NetworkLease{}
+	owners := map[string]container{}
+	hidden := map[string]bool{}
+
+	// Get all static leases
+	cts, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	for _, ct := range cts {
+		c, err := containerLoadByName(d, ct)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		// The leases of the containers the client can't see are hidden
+		allowed, err := restrictedAllowsContainer(d, r, c.Project(), projectUnprefix(c.Project(), ct))
+		if err != nil {
+			return SmartError(err)
+		}
+
+		for k, dev := range c.ExpandedDevices() {
+			if dev["type"] != "nic" || dev["nictype"] != "bridged" || dev["parent"] != name {
+				continue
+			}
+
+			// Fill in the hwaddr from volatile
+			hwaddr := dev["hwaddr"]
+			if hwaddr == "" {
+				hwaddr = c.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", k)]
+			}
+
+			if hwaddr == "" {
+				continue
+			}
+			hwaddr = strings.ToLower(hwaddr)
+			if !allowed {
+				hidden[hwaddr] = true
+				continue
+			}
+
+			owners[hwaddr] = c
+
+			for _, key := range []string{"ipv4.address", "ipv6.address"} {
+				if dev[key] == "" {
+					continue
+				}
+
+				leases = append(leases, api.NetworkLease{
+					Hostname:  projectUnprefix(c.Project(), ct),
+					Hwaddr:    hwaddr,
+					Address:   dev[key],
+					Type:      "static",
+					Container: projectUnprefix(c.Project(), ct),
+					Project:   c.Project(),
+				})
+			}
+		}
+	}
+
+	// Get all the dynamic leases
+	leaseFile := shared.VarPath("networks", name, "dnsmasq.leases")
+	if !shared.PathExists(leaseFile) {
+		return SyncResponse(true, leases)
+	}
+
+	content, err := ioutil.ReadFile(leaseFile)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	for _, lease := range networkParseLeases(string(content)) {
+		if hidden[lease.Hwaddr] {
+			continue
+		}
+
+		// Skip the reservations we already know about
+		found := false
+		for _, entry := range leases {
+			if entry.Hwaddr == lease.Hwaddr && entry.Address == lease.Address {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			continue
+		}
+
+		owner, ok := owners[lease.Hwaddr]
+		if ok {
+			lease.Container = projectUnprefix(owner.Project(), owner.Name())
+			lease.Project = owner.Project()
+		}
+
+		leases = append(leases, lease)
+	}
+
+	return SyncResponse(true, leases)
+}
+
+var networkLeasesCmd = Command{name: "networks/{name}/leases", get: networkLeasesGet}
+
+func networkStateGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Unmanaged interfaces are fine, as long as they exist
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", name)) {
+		_, _, err := dbNetworkGet(d.db, name)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		return BadRequest(fmt.Errorf("Network interface \"%s\" isn't available", name))
+	}
+
+	state, err := networkGetState(name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponse(true, state)
+}
+
+var networkStateCmd = Command{name: "networks/{name}/state", get: networkStateGet}
+
 // The network structs and functions
 func networkLoadByName(d *Daemon, name string) (*network, error) {
 	id, dbInfo, err := dbNetworkGet(d.db, name)
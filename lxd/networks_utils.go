@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 func networkAutoAttach(d *Daemon, devName string) error {
@@ -872,6 +873,39 @@ func networkGetMacSlice(hwaddr string) []string {
 	return buf
 }
 
+// networkParseLeases parses the content of a dnsmasq lease file. IPv4 leases
+// carry the MAC address directly while IPv6 leases only have the client DUID,
+// which for link-layer DUIDs ends with the MAC address.
+func networkParseLeases(content string) []api.NetworkLease {
+	leases := []api.NetworkLease{}
+
+	for _, lease := range strings.Split(content, "\n") {
+		fields := strings.Fields(lease)
+		if len(fields) < 5 {
+			continue
+		}
+
+		hwaddr := strings.Join(networkGetMacSlice(fields[1]), ":")
+		if len(hwaddr) < 17 && len(fields[4]) >= 17 {
+			hwaddr = strings.ToLower(fields[4][len(fields[4])-17:])
+		}
+
+		hostname := fields[3]
+		if hostname == "*" {
+			hostname = ""
+		}
+
+		leases = append(leases, api.NetworkLease{
+			Hostname: hostname,
+			Hwaddr:   hwaddr,
+			Address:  fields[2],
+			Type:     "dynamic",
+		})
+	}
+
+	return leases
+}
+
 func networkClearLease(d *Daemon, network string, hwaddr string) error {
 	leaseFile := shared.VarPath("networks", network, "dnsmasq.leases")
 
@@ -934,3 +968,215 @@ func networkClearLease(d *Daemon, network string, hwaddr string) error {
 
 	return nil
 }
+
+func networkGetAddressScope(address string) string {
+	if strings.HasPrefix(address, "127") || address == "::1" {
+		return "local"
+	}
+
+	if strings.HasPrefix(address, "169.254") || strings.HasPrefix(address, "fe80:") {
+		return "link"
+	}
+
+	return "global"
+}
+
+// networkGetState returns the runtime state of a host network interface.
+func networkGetState(name string) (*api.NetworkState, error) {
+	netIf, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	state := api.NetworkState{
+		Addresses: []api.NetworkStateAddress{},
+		Counters:  api.NetworkStateCounters{},
+		Hwaddr:    netIf.HardwareAddr.String(),
+		Mtu:       netIf.MTU,
+		State:     "down",
+		Type:      "unknown",
+	}
+
+	if netIf.Flags&net.FlagBroadcast > 0 {
+		state.Type = "broadcast"
+	}
+
+	if netIf.Flags&net.FlagPointToPoint > 0 {
+		state.Type = "point-to-point"
+	}
+
+	if netIf.Flags&net.FlagLoopback > 0 {
+		state.Type = "loopback"
+	}
+
+	if netIf.Flags&net.FlagUp > 0 {
+		state.State = "up"
+	}
+
+	// Addresses
+	addrs, err := netIf.Addrs()
+	if err == nil {
+		for _, addr := range addrs {
+			fields := strings.SplitN(addr.String(), "/", 2)
+			if len(fields) != 2 {
+				continue
+			}
+
+			family := "inet"
+			if strings.Contains(fields[0], ":") {
+				family = "inet6"
+			}
+
+			state.Addresses = append(state.Addresses, api.NetworkStateAddress{
+				Family:  family,
+				Address: fields[0],
+				Netmask: fields[1],
+				Scope:   networkGetAddressScope(fields[0]),
+			})
+		}
+	}
+
+	// Counters
+	readCounter := func(counter string) int64 {
+		content, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/statistics/%s", name, counter))
+		if err != nil {
+			return 0
+		}
+
+		value, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+		if err != nil {
+			return 0
+		}
+
+		return value
+	}
+
+	state.Counters.BytesReceived = readCounter("rx_bytes")
+	state.Counters.BytesSent = readCounter("tx_bytes")
+	state.Counters.PacketsReceived = readCounter("rx_packets")
+	state.Counters.PacketsSent = readCounter("tx_packets")
+
+	// Bridge specific information
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/bridge", name)) {
+		state.Bridge, err = networkGetBridgeState(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &state, nil
+}
+
+func networkGetBridgeState(name string) (*api.NetworkStateBridge, error) {
+	readAttribute := func(attribute string) string {
+		content, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/bridge/%s", name, attribute))
+		if err != nil {
+			return ""
+		}
+
+		return strings.TrimSpace(string(content))
+	}
+
+	bridge := api.NetworkStateBridge{
+		ID:            readAttribute("bridge_id"),
+		STP:           readAttribute("stp_state") == "1",
+		VLANFiltering: readAttribute("vlan_filtering") == "1",
+		Ports:         []api.NetworkStateBridgePort{},
+	}
+
+	// The kernel exposes the forward delay in hundredths of a second
+	forwardDelay, err := strconv.ParseUint(readAttribute("forward_delay"), 10, 64)
+	if err == nil {
+		bridge.ForwardDelay = forwardDelay / 100
+	}
+
+	vlanDefault, err := strconv.ParseUint(readAttribute("default_pvid"), 10, 64)
+	if err == nil {
+		bridge.VLANDefault = vlanDefault
+	}
+
+	// VLANs are only available through iproute2
+	vlans := map[string][]api.NetworkStateBridgeVLAN{}
+	output, err := shared.RunCommand("bridge", "vlan", "show")
+	if err == nil {
+		vlans = networkParseBridgeVLANs(output)
+	}
+
+	ents, err := ioutil.ReadDir(fmt.Sprintf("/sys/class/net/%s/brif", name))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ent := range ents {
+		port := api.NetworkStateBridgePort{
+			Name:  ent.Name(),
+			VLANs: vlans[ent.Name()],
+		}
+
+		if port.VLANs == nil {
+			port.VLANs = []api.NetworkStateBridgeVLAN{}
+		}
+
+		bridge.Ports = append(bridge.Ports, port)
+	}
+
+	return &bridge, nil
+}
+
+// networkParseBridgeVLANs parses the output of "bridge vlan show" into the
+// list of VLANs of each port. Entries for the following VLANs of a port are
+// indented and don't repeat the port name.
+func networkParseBridgeVLANs(output string) map[string][]api.NetworkStateBridgeVLAN {
+	vlans := map[string][]api.NetworkStateBridgeVLAN{}
+
+	port := ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Skip the header
+		if fields[0] == "port" && strings.Contains(line, "vlan") {
+			continue
+		}
+
+		if line[0] != ' ' && line[0] != '\t' {
+			port = fields[0]
+			fields = fields[1:]
+		}
+
+		if port == "" || len(fields) == 0 {
+			continue
+		}
+
+		// VLANs may be listed as ranges
+		first := fields[0]
+		last := fields[0]
+		if strings.Contains(fields[0], "-") {
+			bounds := strings.SplitN(fields[0], "-", 2)
+			first = bounds[0]
+			last = bounds[1]
+		}
+
+		firstID, err := strconv.ParseUint(first, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		lastID, err := strconv.ParseUint(last, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		for id := firstID; id <= lastID; id++ {
+			vlans[port] = append(vlans[port], api.NetworkStateBridgeVLAN{
+				ID:       id,
+				PVID:     shared.StringInSlice("PVID", fields[1:]),
+				Untagged: shared.StringInSlice("Untagged", fields[1:]),
+			})
+		}
+	}
+
+	return vlans
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_networkParseLeases(t *testing.T) {
+	content := `1510000000 00:16:3e:3d:f6:8c 10.62.42.143 c1 01:00:16:3e:3d:f6:8c
+1510000000 00:16:3e:f2:80:a1 10.62.42.88 * *
+duid 00:01:00:01:21:8e:2d:6b:52:54:00:d0:0d:5c
+1510000000 1013907852 fd42:ef7a:aee7:82ec::27 c1 00:01:00:01:21:8e:2d:6b:00:16:3e:3d:f6:8c
+`
+
+	leases := networkParseLeases(content)
+	if len(leases) != 3 {
+		t.Fatalf("Expected 3 leases, got %d", len(leases))
+	}
+
+	if leases[0].Hwaddr != "00:16:3e:3d:f6:8c" || leases[0].Address != "10.62.42.143" || leases[0].Hostname != "c1" {
+		t.Errorf("Bad IPv4 lease: %v", leases[0])
+	}
+
+	if leases[1].Hostname != "" {
+		t.Errorf("Expected no hostname, got \"%s\"", leases[1].Hostname)
+	}
+
+	if leases[2].Hwaddr != "00:16:3e:3d:f6:8c" || leases[2].Address != "fd42:ef7a:aee7:82ec::27" {
+		t.Errorf("Bad IPv6 lease: %v", leases[2])
+	}
+
+	for _, lease := range leases {
+		if lease.Type != "dynamic" {
+			t.Errorf("Expected a dynamic lease, got \"%s\"", lease.Type)
+		}
+	}
+}
+
+func Test_networkParseBridgeVLANs(t *testing.T) {
+	output := `port              vlan-id  
+lxdbr0            1 PVID Egress Untagged
+veth0             1 PVID Egress Untagged
+                  10
+                  20-21
+`
+
+	vlans := networkParseBridgeVLANs(output)
+	if len(vlans["lxdbr0"]) != 1 {
+		t.Fatalf("Expected 1 VLAN on lxdbr0, got %d", len(vlans["lxdbr0"]))
+	}
+
+	if len(vlans["veth0"]) != 4 {
+		t.Fatalf("Expected 4 VLANs on veth0, got %d", len(vlans["veth0"]))
+	}
+
+	if !vlans["veth0"][0].PVID || !vlans["veth0"][0].Untagged {
+		t.Errorf("Expected VLAN 1 to be the untagged PVID")
+	}
+
+	if vlans["veth0"][1].ID != 10 || vlans["veth0"][1].PVID || vlans["veth0"][1].Untagged {
+		t.Errorf("Bad VLAN entry: %v", vlans["veth0"][1])
+	}
+
+	if vlans["veth0"][3].ID != 21 {
+		t.Errorf("Expected VLAN 21, got %d", vlans["veth0"][3].ID)
+	}
+}
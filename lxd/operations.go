@@ -50,6 +50,12 @@ type operation struct {
 	readonly  bool
 	canceler  *cancel.Canceler
 
+	// The project the operation's resources belong to and the uid of the
+	// unprivileged local user who started it, -1 for everyone else. Both
+	// are set on creation and never change.
+	project string
+	owner   int64
+
 	// Those functions are called at various points in the operation lifecycle
 	onRun     func(*operation) error
 	onCancel  func(*operation) error
@@ -124,7 +130,7 @@ func (op *operation) Run() (chan error, error) {
 				logger.Debugf("Failure for %s operation: %s: %s", op.class.String(), op.id, err)
 
 				_, md, _ := op.Render()
-				eventSend("operation", md)
+				eventSendOperation(op, md)
 				return
 			}
 
@@ -137,7 +143,7 @@ func (op *operation) Run() (chan error, error) {
 			op.lock.Lock()
 			logger.Debugf("Success for %s operation: %s", op.class.String(), op.id)
 			_, md, _ := op.Render()
-			eventSend("operation", md)
+			eventSendOperation(op, md)
 			op.lock.Unlock()
 		}(op, chanRun)
 	}
@@ -145,7 +151,7 @@ func (op *operation) Run() (chan error, error) {
 
 	logger.Debugf("Started %s operation: %s", op.class.String(), op.id)
 	_, md, _ := op.Render()
-	eventSend("operation", md)
+	eventSendOperation(op, md)
 
 	return chanRun, nil
 }
@@ -177,7 +183,7 @@ func (op *operation) Cancel() (chan error, error) {
 
 				logger.Debugf("Failed to cancel %s operation: %s: %s", op.class.String(), op.id, err)
 				_, md, _ := op.Render()
-				eventSend("operation", md)
+				eventSendOperation(op, md)
 				return
 			}
 
@@ -189,13 +195,13 @@ func (op *operation) Cancel() (chan error, error) {
 
 			logger.Debugf("Cancelled %s operation: %s", op.class.String(), op.id)
 			_, md, _ := op.Render()
-			eventSend("operation", md)
+			eventSendOperation(op, md)
 		}(op, oldStatus, chanCancel)
 	}
 
 	logger.Debugf("Cancelling %s operation: %s", op.class.String(), op.id)
 	_, md, _ := op.Render()
-	eventSend("operation", md)
+	eventSendOperation(op, md)
 
 	if op.canceler != nil {
 		err := op.canceler.Cancel()
@@ -214,7 +220,7 @@ func (op *operation) Cancel() (chan error, error) {
 
 	logger.Debugf("Cancelled %s operation: %s", op.class.String(), op.id)
 	_, md, _ = op.Render()
-	eventSend("operation", md)
+	eventSendOperation(op, md)
 
 	return chanCancel, nil
 }
@@ -276,7 +282,7 @@ func (op *operation) Render() (string, *api.Operation, error) {
 		for key, value := range resources {
 			var values []string
 			for _, c := range value {
-				values = append(values, fmt.Sprintf("/%s/%s/%s", version.APIVersion, key, c))
+				values = append(values, projectURL(fmt.Sprintf("/%s/%s/%s", version.APIVersion, key, c), op.project))
 			}
 			tmpResources[key] = values
 		}
@@ -340,7 +346,7 @@ func (op *operation) UpdateResources(opResources map[string][]string) error {
 
 	logger.Debugf("Updated resources for %s operation: %s", op.class.String(), op.id)
 	_, md, _ := op.Render()
-	eventSend("operation", md)
+	eventSendOperation(op, md)
 
 	return nil
 }
@@ -366,12 +372,16 @@ func (op *operation) UpdateMetadata(opMetadata interface{}) error {
 
 	logger.Debugf("Updated metadata for %s operation: %s", op.class.String(), op.id)
 	_, md, _ := op.Render()
-	eventSend("operation", md)
+	eventSendOperation(op, md)
 
 	return nil
 }
 
-func operationCreate(opClass operationClass, opResources map[string][]string, opMetadata interface{},
+// operationCreate creates a new operation on resources of the given project.
+// Resources are named as seen from within the project. The request starting
+// the operation decides who may see and use it, r is nil for the operations
+// LXD starts on its own.
+func operationCreate(project string, r *http.Request, opClass operationClass, opResources map[string][]string, opMetadata interface{},
 	onRun func(*operation) error,
 	onCancel func(*operation) error,
 	onConnect func(*operation, *http.Request, http.ResponseWriter) error) (*operation, error) {
@@ -386,6 +396,15 @@ func operationCreate(opClass operationClass, opResources map[string][]string, op
 	op.url = fmt.Sprintf("/%s/operations/%s", version.APIVersion, op.id)
 	op.resources = opResources
 	op.chanDone = make(chan error)
+	op.project = project
+	op.owner = -1
+
+	if r != nil {
+		user := requestUnixUser(r)
+		if user != nil {
+			op.owner = user.uid
+		}
+	}
 
 	newMetadata, err := shared.ParseMetadata(opMetadata)
 	if err != nil {
@@ -421,7 +440,7 @@ func operationCreate(opClass operationClass, opResources map[string][]string, op
 
 	logger.Debugf("New %s operation: %s", op.class.String(), op.id)
 	_, md, _ := op.Render()
-	eventSend("operation", md)
+	eventSendOperation(&op, md)
 
 	return &op, nil
 }
@@ -484,7 +503,26 @@ func operationsAPIGet(d *Daemon, r *http.Request) Response {
 	ops := operations
 	operationsLock.Unlock()
 
+	user := requestUnixUser(r)
+	perms := d.requestPermissions(r)
+
 	for _, v := range ops {
+		v.lock.Lock()
+		owner := v.owner
+		project := v.project
+		resources := v.resources
+		v.lock.Unlock()
+
+		// Only show unprivileged users their own operations
+		if user != nil && owner != user.uid {
+			continue
+		}
+
+		// And restricted clients those on the objects they can access
+		if perms != nil && !certificateAllowsOperation(perms, project, resources) {
+			continue
+		}
+
 		status := strings.ToLower(v.status.String())
 		_, ok := md[status]
 		if !ok {
@@ -21,29 +21,38 @@ import (
 
 /* This is used for both profiles post and profile put */
 func profilesGet(d *Daemon, r *http.Request) Response {
-	results, err := dbProfiles(d.db)
+	project, err := projectProfilesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	results, err := dbProfiles(d.db, project)
 	if err != nil {
 		return SmartError(err)
 	}
 
 	recursion := d.isRecursionRequest(r)
+	perms := d.requestPermissions(r)
 
-	resultString := make([]string, len(results))
-	resultMap := make([]*api.Profile, len(results))
-	i := 0
+	resultString := []string{}
+	resultMap := []*api.Profile{}
 	for _, name := range results {
+		// Hide the profiles a restricted client can't access
+		if perms != nil && len(perms.Profiles) > 0 && !shared.StringInSlice(name, perms.Profiles) {
+			continue
+		}
+
 		if !recursion {
 			url := fmt.Sprintf("/%s/profiles/%s", version.APIVersion, name)
-			resultString[i] = url
+			resultString = append(resultString, url)
 		} else {
-			profile, err := doProfileGet(d, name)
+			profile, err := doProfileGet(d, r, project, name)
 			if err != nil {
 				logger.Error("Failed to get profile", log.Ctx{"profile": name})
 				continue
 			}
-			resultMap[i] = profile
+			resultMap = append(resultMap, profile)
 		}
-		i++
 	}
 
 	if !recursion {
@@ -64,7 +73,12 @@ func profilesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("No name provided"))
 	}
 
-	_, profile, _ := dbProfileGet(d.db, req.Name)
+	project, err := projectProfilesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, profile, _ := dbProfileGet(d.db, project, req.Name)
 	if profile != nil {
 		return BadRequest(fmt.Errorf("The profile already exists"))
 	}
@@ -77,7 +91,7 @@ func profilesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("Invalid profile name '%s'", req.Name))
 	}
 
-	err := containerValidConfig(d, req.Config, true, false)
+	err = containerValidConfig(d, req.Config, true, false)
 	if err != nil {
 		return BadRequest(err)
 	}
@@ -88,13 +102,16 @@ func profilesPost(d *Daemon, r *http.Request) Response {
 	}
 
 	// Update DB entry
-	_, err = dbProfileCreate(d.db, req.Name, req.Description, req.Config, req.Devices)
+	_, err = dbProfileCreate(d.db, project, req.Name, req.Description, req.Config, req.Devices)
 	if err != nil {
 		return SmartError(
 			fmt.Errorf("Error inserting %s into database: %s", req.Name, err))
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
+	url := projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name), project)
+	eventSendLifecycle("profile-created", url, nil)
+
+	return SyncResponseLocation(true, nil, url)
 }
 
 var profilesCmd = Command{
@@ -102,22 +119,26 @@ var profilesCmd = Command{
 	get:  profilesGet,
 	post: profilesPost}
 
-func doProfileGet(d *Daemon, name string) (*api.Profile, error) {
-	_, profile, err := dbProfileGet(d.db, name)
+func doProfileGet(d *Daemon, r *http.Request, project string, name string) (*api.Profile, error) {
+	_, profile, err := dbProfileGet(d.db, project, name)
 	if err != nil {
 		return nil, err
 	}
 
-	cts, err := dbProfileContainersGet(d.db, name)
+	cts, err := dbProfileContainersGet(d.db, project, name)
 	if err != nil {
 		return nil, err
 	}
 
 	usedBy := []string{}
 	for _, ct := range cts {
-		usedBy = append(usedBy, fmt.Sprintf("/%s/containers/%s", version.APIVersion, ct))
+		usedBy = append(usedBy, containerURL(ct[0], ct[1]))
+	}
+
+	profile.UsedBy, err = restrictedFilterUsedBy(d, r, usedBy)
+	if err != nil {
+		return nil, err
 	}
-	profile.UsedBy = usedBy
 
 	return profile, nil
 }
@@ -125,7 +146,12 @@ func doProfileGet(d *Daemon, name string) (*api.Profile, error) {
 func profileGet(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
-	resp, err := doProfileGet(d, name)
+	project, err := projectProfilesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resp, err := doProfileGet(d, r, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -134,18 +160,18 @@ func profileGet(d *Daemon, r *http.Request) Response {
 	return SyncResponseETag(true, resp, etag)
 }
 
-func getContainersWithProfile(d *Daemon, profile string) []container {
+func getContainersWithProfile(d *Daemon, project string, profile string) []container {
 	results := []container{}
 
-	output, err := dbProfileContainersGet(d.db, profile)
+	output, err := dbProfileContainersGet(d.db, project, profile)
 	if err != nil {
 		return results
 	}
 
-	for _, name := range output {
-		c, err := containerLoadByName(d, name)
+	for _, ct := range output {
+		c, err := containerLoadByProjectAndName(d, ct[0], ct[1])
 		if err != nil {
-			logger.Error("Failed opening container", log.Ctx{"container": name})
+			logger.Error("Failed opening container", log.Ctx{"container": ct[1]})
 			continue
 		}
 		results = append(results, c)
@@ -157,7 +183,13 @@ func getContainersWithProfile(d *Daemon, profile string) []container {
 func profilePut(d *Daemon, r *http.Request) Response {
 	// Get the profile
 	name := mux.Vars(r)["name"]
-	id, profile, err := dbProfileGet(d.db, name)
+
+	project, err := projectProfilesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, profile, err := dbProfileGet(d.db, project, name)
 	if err != nil {
 		return SmartError(fmt.Errorf("Failed to retrieve profile='%s'", name))
 	}
@@ -174,13 +206,19 @@ func profilePut(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
-	return doProfileUpdate(d, name, id, profile, req)
+	return doProfileUpdate(d, project, name, id, profile, req)
 }
 
 func profilePatch(d *Daemon, r *http.Request) Response {
 	// Get the profile
 	name := mux.Vars(r)["name"]
-	id, profile, err := dbProfileGet(d.db, name)
+
+	project, err := projectProfilesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, profile, err := dbProfileGet(d.db, project, name)
 	if err != nil {
 		return SmartError(fmt.Errorf("Failed to retrieve profile='%s'", name))
 	}
@@ -240,7 +278,7 @@ func profilePatch(d *Daemon, r *http.Request) Response {
 		}
 	}
 
-	return doProfileUpdate(d, name, id, profile, req)
+	return doProfileUpdate(d, project, name, id, profile, req)
 }
 
 // The handler for the post operation.
@@ -257,8 +295,13 @@ func profilePost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("No name provided"))
 	}
 
+	project, err := projectProfilesProject(d.db, projectParam(r))
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// Check that the name isn't already in use
-	id, _, _ := dbProfileGet(d.db, req.Name)
+	id, _, _ := dbProfileGet(d.db, project, req.Name)
 	if id > 0 {
 		return Conflict
 	}
@@ -271,33 +314,42 @@ func profilePost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("Invalid profile name '%s'", req.Name))
 	}
 
-	err := dbProfileUpdate(d.db, name, req.Name)
+	err = dbProfileUpdate(d.db, project, name, req.Name)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
+	eventSendLifecycle("profile-renamed", projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, name), project), map[string]interface{}{"new_name": req.Name})
+
+	return SyncResponseLocation(true, nil, projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name), project))
 }
 
 // The handler for the delete operation.
 func profileDelete(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
-	_, err := doProfileGet(d, name)
+	project, err := projectProfilesProject(d.db, projectParam(r))
 	if err != nil {
 		return SmartError(err)
 	}
 
-	clist := getContainersWithProfile(d, name)
+	_, err = doProfileGet(d, r, project, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	clist := getContainersWithProfile(d, project, name)
 	if len(clist) != 0 {
 		return BadRequest(fmt.Errorf("Profile is currently in use"))
 	}
 
-	err = dbProfileDelete(d.db, name)
+	err = dbProfileDelete(d.db, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("profile-deleted", projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, name), project), nil)
+
 	return EmptySyncResponse
 }
 
@@ -29,13 +29,13 @@ func Test_removing_a_profile_deletes_associated_configuration_entries(t *testing
 	}
 
 	// Delete the profile we just created with dbProfileDelete
-	err = dbProfileDelete(db, "theprofile")
+	err = dbProfileDelete(db, "default", "theprofile")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Make sure there are 0 profiles_devices entries left.
-	devices, err := dbDevices(d.db, "theprofile", true)
+	devices, err := dbDevices(d.db, "default", "theprofile", true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -44,7 +44,7 @@ func Test_removing_a_profile_deletes_associated_configuration_entries(t *testing
 	}
 
 	// Make sure there are 0 profiles_config entries left.
-	config, err := dbProfileConfig(d.db, "theprofile")
+	config, err := dbProfileConfig(d.db, "default", "theprofile")
 	if err == nil {
 		t.Fatal("found the profile!")
 	}
@@ -5,9 +5,10 @@ import (
 	"reflect"
 
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
 )
 
-func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req api.ProfilePut) Response {
+func doProfileUpdate(d *Daemon, project string, name string, id int64, profile *api.Profile, req api.ProfilePut) Response {
 	// Sanity checks
 	err := containerValidConfig(d, req.Config, true, false)
 	if err != nil {
@@ -19,7 +20,7 @@ func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req
 		return BadRequest(err)
 	}
 
-	containers := getContainersWithProfile(d, name)
+	containers := getContainersWithProfile(d, project, name)
 
 	// Check if the root device is supposed to be changed or removed.
 	oldProfileRootDiskDeviceKey, oldProfileRootDiskDevice, _ := containerGetRootDiskDevice(profile.Devices)
@@ -41,7 +42,7 @@ func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req
 			// Check what profile the device comes from
 			profiles := container.Profiles()
 			for i := len(profiles) - 1; i >= 0; i-- {
-				_, profile, err := dbProfileGet(d.db, profiles[i])
+				_, profile, err := dbProfileGet(d.db, project, profiles[i])
 				if err != nil {
 					return SmartError(err)
 				}
@@ -83,6 +84,8 @@ func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req
 			return SmartError(err)
 		}
 
+		eventSendLifecycle("profile-updated", projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, name), project), nil)
+
 		return EmptySyncResponse
 	}
 
@@ -109,6 +112,8 @@ func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("profile-updated", projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, name), project), nil)
+
 	// Update all the containers using the profile. Must be done after txCommit due to DB lock.
 	failures := map[string]error{}
 	for _, c := range containers {
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/lxd/types"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+func projectsGet(d *Daemon, r *http.Request) Response {
+	results, err := dbProjects(d.db)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	recursion := d.isRecursionRequest(r)
+
+	resultString := []string{}
+	resultMap := []*api.Project{}
+	for _, name := range results {
+		if !recursion {
+			url := fmt.Sprintf("/%s/projects/%s", version.APIVersion, name)
+			resultString = append(resultString, url)
+		} else {
+			project, err := doProjectGet(d, r, name)
+			if err != nil {
+				logger.Error("Failed to get project", log.Ctx{"project": name})
+				continue
+			}
+			resultMap = append(resultMap, project)
+		}
+	}
+
+	if !recursion {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+func projectsPost(d *Daemon, r *http.Request) Response {
+	req := api.ProjectsPost{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	// Sanity checks
+	err := projectValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	_, project, _ := dbProjectGet(d.db, req.Name)
+	if project != nil {
+		return BadRequest(fmt.Errorf("The project already exists"))
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	// New projects get their own images and profiles unless told otherwise
+	for _, key := range []string{"features.images", "features.profiles"} {
+		if req.Config[key] == "" {
+			req.Config[key] = "true"
+		}
+	}
+
+	err = projectValidConfig(req.Config)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Update DB entry
+	_, err = dbProjectCreate(d.db, req.Name, req.Description, req.Config)
+	if err != nil {
+		return SmartError(
+			fmt.Errorf("Error inserting %s into database: %s", req.Name, err))
+	}
+
+	// Projects with their own profiles start off with an empty default one
+	if shared.IsTrue(req.Config["features.profiles"]) {
+		_, err = dbProfileCreate(d.db, req.Name, "default", fmt.Sprintf("Default LXD profile for project %s", req.Name), map[string]string{}, types.Devices{})
+		if err != nil {
+			dbProjectDelete(d.db, req.Name)
+			return SmartError(err)
+		}
+	}
+
+	url := fmt.Sprintf("/%s/projects/%s", version.APIVersion, req.Name)
+	eventSendLifecycle("project-created", url, nil)
+
+	return SyncResponseLocation(true, nil, url)
+}
+
+var projectsCmd = Command{
+	name: "projects",
+	get:  projectsGet,
+	post: projectsPost}
+
+func doProjectGet(d *Daemon, r *http.Request, name string) (*api.Project, error) {
+	_, project, err := dbProjectGet(d.db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	usedBy, err := projectUsedBy(d, name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	project.UsedBy, err = restrictedFilterUsedBy(d, r, usedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func projectGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	resp, err := doProjectGet(d, r, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	etag := []interface{}{resp.Description, resp.Config}
+	return SyncResponseETag(true, resp, etag)
+}
+
+func projectPut(d *Daemon, r *http.Request) Response {
+	// Get the project
+	name := mux.Vars(r)["name"]
+	id, project, err := dbProjectGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{project.Description, project.Config}
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.ProjectPut{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	return doProjectUpdate(d, name, id, project, req)
+}
+
+func projectPatch(d *Daemon, r *http.Request) Response {
+	// Get the project
+	name := mux.Vars(r)["name"]
+	id, project, err := dbProjectGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{project.Description, project.Config}
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	rdr1 := ioutil.NopCloser(bytes.NewBuffer(body))
+	rdr2 := ioutil.NopCloser(bytes.NewBuffer(body))
+
+	reqRaw := shared.Jmap{}
+	if err := json.NewDecoder(rdr1).Decode(&reqRaw); err != nil {
+		return BadRequest(err)
+	}
+
+	req := api.ProjectPut{}
+	if err := json.NewDecoder(rdr2).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	// Get Description
+	_, err = reqRaw.GetString("description")
+	if err != nil {
+		req.Description = project.Description
+	}
+
+	// Get Config
+	if req.Config == nil {
+		req.Config = project.Config
+	} else {
+		for k, v := range project.Config {
+			_, ok := req.Config[k]
+			if !ok {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	return doProjectUpdate(d, name, id, project, req)
+}
+
+func doProjectUpdate(d *Daemon, name string, id int64, project *api.Project, req api.ProjectPut) Response {
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err := projectValidConfig(req.Config)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Changing the features of a project would change the set of images
+	// and profiles its containers refer to.
+	for _, key := range []string{"features.images", "features.profiles"} {
+		if shared.IsTrue(req.Config[key]) == shared.IsTrue(project.Config[key]) {
+			continue
+		}
+
+		if name == "default" {
+			return BadRequest(fmt.Errorf("The features of the default project can't be changed"))
+		}
+
+		usedBy, err := projectUsedBy(d, name, false)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		if len(usedBy) > 0 {
+			return BadRequest(fmt.Errorf("Features can only be changed on empty projects"))
+		}
+	}
+
+	err = dbProjectUpdate(d.db, id, req.Description, req.Config)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Keep the project's default profile in line with its features
+	if shared.IsTrue(req.Config["features.profiles"]) != shared.IsTrue(project.Config["features.profiles"]) {
+		if shared.IsTrue(req.Config["features.profiles"]) {
+			_, err = dbProfileCreate(d.db, name, "default", fmt.Sprintf("Default LXD profile for project %s", name), map[string]string{}, types.Devices{})
+		} else {
+			err = dbProfileDelete(d.db, name, "default")
+		}
+
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	eventSendLifecycle("project-updated", fmt.Sprintf("/%s/projects/%s", version.APIVersion, name), nil)
+
+	return EmptySyncResponse
+}
+
+// The handler for the post operation.
+func projectPost(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	req := api.ProjectPost{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	// Sanity checks
+	if name == "default" {
+		return BadRequest(fmt.Errorf("The default project can't be renamed"))
+	}
+
+	err := projectValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Check that the name isn't already in use
+	id, _, _ := dbProjectGet(d.db, req.Name)
+	if id > 0 {
+		return Conflict
+	}
+
+	// The names of containers and volumes on disk include the project
+	usedBy, err := projectUsedBy(d, name, false)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if len(usedBy) > 0 {
+		return BadRequest(fmt.Errorf("Only empty projects can be renamed"))
+	}
+
+	err = dbProjectRename(d.db, name, req.Name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	eventSendLifecycle("project-renamed", fmt.Sprintf("/%s/projects/%s", version.APIVersion, name), map[string]interface{}{"new_name": req.Name})
+
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/projects/%s", version.APIVersion, req.Name))
+}
+
+// The handler for the delete operation.
+func projectDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Sanity checks
+	if name == "default" {
+		return BadRequest(fmt.Errorf("The default project can't be deleted"))
+	}
+
+	_, _, err := dbProjectGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	usedBy, err := projectUsedBy(d, name, false)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if len(usedBy) > 0 {
+		return BadRequest(fmt.Errorf("Only empty projects can be deleted"))
+	}
+
+	// This also removes the project's default profile
+	err = dbProjectDelete(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	eventSendLifecycle("project-deleted", fmt.Sprintf("/%s/projects/%s", version.APIVersion, name), nil)
+
+	return EmptySyncResponse
+}
+
+var projectCmd = Command{name: "projects/{name}", get: projectGet, put: projectPut, delete: projectDelete, post: projectPost, patch: projectPatch}
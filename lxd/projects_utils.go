@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// Returns the project a request applies to, as given by its "project"
+// query parameter.
+func projectParam(r *http.Request) string {
+	project := r.URL.Query().Get("project")
+	if project == "" {
+		return "default"
+	}
+
+	return project
+}
+
+// Returns the internal name of an object of the given project. Containers
+// and custom storage volumes of projects other than the default one are
+// stored on disk, in liblxc and in the database as "<project>_<name>".
+func projectPrefix(project string, name string) string {
+	if project == "default" || project == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s_%s", project, name)
+}
+
+// Returns the name of an object as seen from within its project.
+func projectUnprefix(project string, name string) string {
+	if project == "default" || project == "" {
+		return name
+	}
+
+	return strings.TrimPrefix(name, project+"_")
+}
+
+// Returns the given API URL with the project added as query parameter
+// when the object doesn't belong to the default project.
+func projectURL(uri string, project string) string {
+	if project == "default" || project == "" {
+		return uri
+	}
+
+	return fmt.Sprintf("%s?project=%s", uri, url.QueryEscape(project))
+}
+
+// Returns the project whose images are used by the given project.
+func projectImagesProject(db *sql.DB, project string) (string, error) {
+	hasImages, err := dbProjectHasFeature(db, project, "images")
+	if err != nil {
+		return "", err
+	}
+
+	if !hasImages {
+		return "default", nil
+	}
+
+	return project, nil
+}
+
+// Returns the project whose profiles are used by the given project.
+func projectProfilesProject(db *sql.DB, project string) (string, error) {
+	hasProfiles, err := dbProjectHasFeature(db, project, "profiles")
+	if err != nil {
+		return "", err
+	}
+
+	if !hasProfiles {
+		return "default", nil
+	}
+
+	return project, nil
+}
+
+func projectValidName(name string) error {
+	if name == "" {
+		return fmt.Errorf("No name provided")
+	}
+
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("Project names may not contain slashes")
+	}
+
+	if strings.Contains(name, "_") {
+		return fmt.Errorf("Project names may not contain underscores")
+	}
+
+	if strings.ContainsAny(name, " ?&#%") {
+		return fmt.Errorf("Project names may not contain spaces or URL special characters")
+	}
+
+	if shared.StringInSlice(name, []string{".", ".."}) {
+		return fmt.Errorf("Invalid project name '%s'", name)
+	}
+
+	return nil
+}
+
+var projectConfigKeys = map[string]func(value string) error{
+	"features.images":   shared.IsBool,
+	"features.profiles": shared.IsBool,
+}
+
+func projectValidConfig(config map[string]string) error {
+	for k, v := range config {
+		validator, ok := projectConfigKeys[k]
+		if !ok {
+			return fmt.Errorf("Invalid project configuration key: %s", k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return fmt.Errorf("Invalid value for project configuration key '%s': %v", k, err)
+		}
+	}
+
+	return nil
+}
+
+// Returns the API URLs of all the objects belonging to a project. The
+// project's own "default" profile is only listed if includeDefaultProfile
+// is set, as it's automatically created and deleted along with the project.
+func projectUsedBy(d *Daemon, project string, includeDefaultProfile bool) ([]string, error) {
+	usedBy := []string{}
+
+	containers, err := dbContainersProjectList(d.db, project, cTypeRegular)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range containers {
+		usedBy = append(usedBy, containerURL(project, name))
+	}
+
+	hasImages, err := dbProjectHasFeature(d.db, project, "images")
+	if err != nil {
+		return nil, err
+	}
+
+	if hasImages {
+		images, err := dbImagesGet(d.db, project, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fingerprint := range images {
+			usedBy = append(usedBy, projectURL(fmt.Sprintf("/%s/images/%s", version.APIVersion, fingerprint), project))
+		}
+	}
+
+	hasProfiles, err := dbProjectHasFeature(d.db, project, "profiles")
+	if err != nil {
+		return nil, err
+	}
+
+	if hasProfiles {
+		profiles, err := dbProfiles(d.db, project)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range profiles {
+			if name == "default" && !includeDefaultProfile {
+				continue
+			}
+
+			usedBy = append(usedBy, projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, name), project))
+		}
+	}
+
+	volumes, err := dbStoragePoolVolumesGetCustomProject(d.db, project)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, volume := range volumes {
+		usedBy = append(usedBy, projectURL(fmt.Sprintf("/%s/storage-pools/%s/volumes/custom/%s", version.APIVersion, volume[0], projectUnprefix(project, volume[1])), project))
+	}
+
+	return usedBy, nil
+}
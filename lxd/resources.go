@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// The root of the proc and sys filesystems, only changed by the tests
+var resourcesProcPath = "/proc"
+var resourcesSysPath = "/sys"
+
+var resourcesCPUDirRegexp = regexp.MustCompile(`^cpu[0-9]+$`)
+var resourcesNodeDirRegexp = regexp.MustCompile(`^node[0-9]+$`)
+
+// /1.0/resources
+func serverResourcesGet(d *Daemon, r *http.Request) Response {
+	res := api.Resources{}
+
+	cpu, err := resourcesCPU()
+	if err != nil {
+		return SmartError(err)
+	}
+	res.CPU = *cpu
+
+	memory, err := resourcesMemory()
+	if err != nil {
+		return SmartError(err)
+	}
+	res.Memory = *memory
+
+	pools, err := dbStoragePools(d.db)
+	if err != nil && err != NoSuchObjectError {
+		return SmartError(err)
+	}
+
+	res.StoragePools = map[string]api.ResourcesStoragePool{}
+	for _, poolName := range pools {
+		pool, err := storagePoolResources(d, poolName)
+		if err != nil {
+			logger.Warnf("Failed to get resources of storage pool \"%s\": %s", poolName, err)
+			continue
+		}
+
+		res.StoragePools[poolName] = *pool
+	}
+
+	return SyncResponse(true, res)
+}
+
+var serverResourceCmd = Command{name: "resources", get: serverResourcesGet}
+
+// /1.0/storage-pools/{name}/resources
+func storagePoolResourcesGet(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["name"]
+
+	res, err := storagePoolResources(d, poolName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponse(true, res)
+}
+
+var storagePoolResourcesCmd = Command{name: "storage-pools/{name}/resources", get: storagePoolResourcesGet}
+
+func storagePoolResources(d *Daemon, poolName string) (*api.ResourcesStoragePool, error) {
+	s, err := storagePoolInit(d, poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some storage drivers need the pool to be mounted to report usage
+	ourMount, err := s.StoragePoolMount()
+	if err != nil {
+		return nil, err
+	}
+	if ourMount {
+		defer s.StoragePoolUmount()
+	}
+
+	return s.StoragePoolResources()
+}
+
+// storageResourcesStatfs reports the space of the filesystem holding path.
+func storageResourcesStatfs(path string) (*api.ResourcesStoragePool, error) {
+	st := syscall.Statfs_t{}
+	err := syscall.Statfs(path, &st)
+	if err != nil {
+		return nil, err
+	}
+
+	res := api.ResourcesStoragePool{}
+	res.Space.Total = st.Blocks * uint64(st.Bsize)
+	res.Space.Used = (st.Blocks - st.Bfree) * uint64(st.Bsize)
+
+	return &res, nil
+}
+
+func resourcesCPU() (*api.ResourcesCPU, error) {
+	// Model information, by processor number
+	type cpuInfo struct {
+		name      string
+		vendor    string
+		frequency uint64
+	}
+	infos := map[uint64]cpuInfo{}
+
+	f, err := os.Open(filepath.Join(resourcesProcPath, "cpuinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	processor := int64(-1)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(fields[0])
+		value := strings.TrimSpace(fields[1])
+
+		if key == "processor" {
+			processor, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				processor = -1
+			}
+			continue
+		}
+
+		if processor < 0 {
+			continue
+		}
+
+		info := infos[uint64(processor)]
+		switch key {
+		case "model name":
+			info.name = value
+		case "vendor_id":
+			info.vendor = value
+		case "cpu MHz":
+			frequency, err := strconv.ParseFloat(value, 64)
+			if err == nil {
+				info.frequency = uint64(frequency)
+			}
+		}
+		infos[uint64(processor)] = info
+	}
+
+	// Topology, from sysfs
+	type socketInfo struct {
+		cores   map[uint64]bool
+		threads uint64
+		cpu     uint64
+	}
+	sockets := map[uint64]*socketInfo{}
+
+	cpuPath := filepath.Join(resourcesSysPath, "devices", "system", "cpu")
+	ents, err := ioutil.ReadDir(cpuPath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := api.ResourcesCPU{
+		Sockets:   []api.ResourcesCPUSocket{},
+		NUMANodes: []api.ResourcesCPUNUMANode{},
+	}
+
+	for _, ent := range ents {
+		if !resourcesCPUDirRegexp.MatchString(ent.Name()) {
+			continue
+		}
+
+		cpu, err := strconv.ParseUint(strings.TrimPrefix(ent.Name(), "cpu"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// Skip offline threads
+		online, err := resourcesReadUint(filepath.Join(cpuPath, ent.Name(), "online"))
+		if err == nil && online == 0 {
+			continue
+		}
+
+		// Without topology information, consider every thread a core
+		socket, err := resourcesReadUint(filepath.Join(cpuPath, ent.Name(), "topology", "physical_package_id"))
+		if err != nil {
+			socket = 0
+		}
+
+		core, err := resourcesReadUint(filepath.Join(cpuPath, ent.Name(), "topology", "core_id"))
+		if err != nil {
+			core = cpu
+		}
+
+		info, ok := sockets[socket]
+		if !ok {
+			info = &socketInfo{cores: map[uint64]bool{}, cpu: cpu}
+			sockets[socket] = info
+		}
+
+		info.cores[core] = true
+		info.threads++
+		res.Total++
+	}
+
+	socketIDs := []int{}
+	for socket := range sockets {
+		socketIDs = append(socketIDs, int(socket))
+	}
+	sort.Ints(socketIDs)
+
+	for _, id := range socketIDs {
+		socket := uint64(id)
+		info := sockets[socket]
+		entry := api.ResourcesCPUSocket{
+			Socket:    socket,
+			Name:      infos[info.cpu].name,
+			Vendor:    infos[info.cpu].vendor,
+			Cores:     uint64(len(info.cores)),
+			Threads:   info.threads,
+			Frequency: infos[info.cpu].frequency,
+		}
+
+		// Not all architectures report the frequency in cpuinfo
+		if entry.Frequency == 0 {
+			frequency, err := resourcesReadUint(filepath.Join(cpuPath, fmt.Sprintf("cpu%d", info.cpu), "cpufreq", "cpuinfo_max_freq"))
+			if err == nil {
+				entry.Frequency = frequency / 1000
+			}
+		}
+
+		res.Sockets = append(res.Sockets, entry)
+	}
+
+	// NUMA nodes
+	nodePath := filepath.Join(resourcesSysPath, "devices", "system", "node")
+	if shared.PathExists(nodePath) {
+		ents, err := ioutil.ReadDir(nodePath)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeIDs := []int{}
+		for _, ent := range ents {
+			if !resourcesNodeDirRegexp.MatchString(ent.Name()) {
+				continue
+			}
+
+			node, err := strconv.Atoi(strings.TrimPrefix(ent.Name(), "node"))
+			if err != nil {
+				continue
+			}
+
+			nodeIDs = append(nodeIDs, node)
+		}
+		sort.Ints(nodeIDs)
+
+		for _, node := range nodeIDs {
+			content, err := ioutil.ReadFile(filepath.Join(nodePath, fmt.Sprintf("node%d", node), "cpulist"))
+			if err != nil {
+				return nil, err
+			}
+
+			cpus, err := resourcesParseCPUList(strings.TrimSpace(string(content)))
+			if err != nil {
+				return nil, err
+			}
+
+			res.NUMANodes = append(res.NUMANodes, api.ResourcesCPUNUMANode{Node: uint64(node), CPUs: cpus})
+		}
+	}
+
+	return &res, nil
+}
+
+func resourcesMemory() (*api.ResourcesMemory, error) {
+	f, err := os.Open(filepath.Join(resourcesProcPath, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]uint64{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// Values are reported in kB
+		if len(fields) == 3 && fields[2] == "kB" {
+			value *= 1024
+		}
+
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+
+	res := api.ResourcesMemory{Total: values["MemTotal"]}
+
+	available, ok := values["MemAvailable"]
+	if !ok {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+
+	if available < res.Total {
+		res.Used = res.Total - available
+	}
+
+	return &res, nil
+}
+
+func resourcesReadUint(path string) (uint64, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+}
+
+// resourcesParseCPUList parses kernel CPU lists such as "0-3,8,10-11".
+func resourcesParseCPUList(list string) ([]uint64, error) {
+	cpus := []uint64{}
+	if list == "" {
+		return cpus, nil
+	}
+
+	for _, chunk := range strings.Split(list, ",") {
+		bounds := strings.SplitN(chunk, "-", 2)
+
+		first, err := strconv.ParseUint(bounds[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CPU list \"%s\"", list)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.ParseUint(bounds[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid CPU list \"%s\"", list)
+			}
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func resourcesWriteFixture(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		fullPath := filepath.Join(root, path)
+
+		err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(fullPath, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_resourcesCPU(t *testing.T) {
+	root, err := ioutil.TempDir("", "lxd_test_resources_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	oldProc, oldSys := resourcesProcPath, resourcesSysPath
+	defer func() {
+		resourcesProcPath, resourcesSysPath = oldProc, oldSys
+	}()
+	resourcesProcPath = filepath.Join(root, "proc")
+	resourcesSysPath = filepath.Join(root, "sys")
+
+	// Two sockets with two cores and two threads each, the last thread offline
+	files := map[string]string{
+		"proc/cpuinfo": `processor	: 0
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2620
+cpu MHz		: 2100.000
+
+processor	: 4
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2620
+cpu MHz		: 2100.000
+`,
+		"sys/devices/system/node/node0/cpulist": "0-3\n",
+		"sys/devices/system/node/node1/cpulist": "4-6,7\n",
+		"sys/devices/system/cpu/online":         "0-6\n",
+	}
+
+	topology := [][]string{{"0", "0"}, {"0", "0"}, {"0", "1"}, {"0", "1"}, {"1", "0"}, {"1", "0"}, {"1", "1"}, {"1", "1"}}
+	for i, entry := range topology {
+		cpu := filepath.Join("sys/devices/system/cpu", fmt.Sprintf("cpu%d", i))
+		files[filepath.Join(cpu, "topology/physical_package_id")] = entry[0] + "\n"
+		files[filepath.Join(cpu, "topology/core_id")] = entry[1] + "\n"
+	}
+	files["sys/devices/system/cpu/cpu7/online"] = "0\n"
+
+	resourcesWriteFixture(t, root, files)
+
+	cpu, err := resourcesCPU()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cpu.Total != 7 {
+		t.Errorf("Expected 7 threads, got %d", cpu.Total)
+	}
+
+	if len(cpu.Sockets) != 2 {
+		t.Fatalf("Expected 2 sockets, got %d", len(cpu.Sockets))
+	}
+
+	if cpu.Sockets[0].Cores != 2 || cpu.Sockets[0].Threads != 4 {
+		t.Errorf("Bad first socket: %v", cpu.Sockets[0])
+	}
+
+	if cpu.Sockets[1].Cores != 2 || cpu.Sockets[1].Threads != 3 {
+		t.Errorf("Bad second socket: %v", cpu.Sockets[1])
+	}
+
+	if cpu.Sockets[1].Name != "Intel(R) Xeon(R) CPU E5-2620" || cpu.Sockets[1].Vendor != "GenuineIntel" || cpu.Sockets[1].Frequency != 2100 {
+		t.Errorf("Bad second socket model: %v", cpu.Sockets[1])
+	}
+
+	if len(cpu.NUMANodes) != 2 {
+		t.Fatalf("Expected 2 NUMA nodes, got %d", len(cpu.NUMANodes))
+	}
+
+	if !reflect.DeepEqual(cpu.NUMANodes[1].CPUs, []uint64{4, 5, 6, 7}) {
+		t.Errorf("Bad CPUs for second NUMA node: %v", cpu.NUMANodes[1].CPUs)
+	}
+}
+
+func Test_resourcesMemory(t *testing.T) {
+	root, err := ioutil.TempDir("", "lxd_test_resources_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	oldProc := resourcesProcPath
+	defer func() {
+		resourcesProcPath = oldProc
+	}()
+	resourcesProcPath = root
+
+	resourcesWriteFixture(t, root, map[string]string{
+		"meminfo": `MemTotal:        8000000 kB
+MemFree:         1000000 kB
+MemAvailable:    6000000 kB
+Buffers:          500000 kB
+Cached:          3000000 kB
+`,
+	})
+
+	memory, err := resourcesMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if memory.Total != 8000000*1024 {
+		t.Errorf("Bad total memory: %d", memory.Total)
+	}
+
+	if memory.Used != 2000000*1024 {
+		t.Errorf("Bad used memory: %d", memory.Used)
+	}
+}
+
+func Test_resourcesParseCPUList(t *testing.T) {
+	cpus, err := resourcesParseCPUList("0-2,5,8-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(cpus, []uint64{0, 1, 2, 5, 8, 9}) {
+		t.Errorf("Bad CPU list: %v", cpus)
+	}
+
+	_, err = resourcesParseCPUList("0-a")
+	if err == nil {
+		t.Errorf("Expected an error for an invalid CPU list")
+	}
+}
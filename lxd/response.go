@@ -309,6 +309,10 @@ func PreconditionFailed(err error) Response {
 	return &errorResponse{http.StatusPreconditionFailed, err.Error()}
 }
 
+func ForbiddenError(err error) Response {
+	return &errorResponse{http.StatusForbidden, err.Error()}
+}
+
 /*
  * SmartError returns the right error message based on err.
  */
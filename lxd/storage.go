@@ -16,7 +16,6 @@ import (
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/ioprogress"
 	"github.com/lxc/lxd/shared/logger"
-	"github.com/lxc/lxd/shared/version"
 )
 
 // lxdStorageLockMap is a hashmap that allows functions to check whether the
@@ -120,15 +119,18 @@ const (
 	storageTypeZfs
 	storageTypeLvm
 	storageTypeDir
+	storageTypeCeph
 	storageTypeMock
 )
 
-var supportedStoragePoolDrivers = []string{"btrfs", "dir", "lvm", "zfs"}
+var supportedStoragePoolDrivers = []string{"btrfs", "ceph", "dir", "lvm", "zfs"}
 
 func storageTypeToString(sType storageType) (string, error) {
 	switch sType {
 	case storageTypeBtrfs:
 		return "btrfs", nil
+	case storageTypeCeph:
+		return "ceph", nil
 	case storageTypeZfs:
 		return "zfs", nil
 	case storageTypeLvm:
@@ -146,6 +148,8 @@ func storageStringToType(sName string) (storageType, error) {
 	switch sName {
 	case "btrfs":
 		return storageTypeBtrfs, nil
+	case "ceph":
+		return storageTypeCeph, nil
 	case "zfs":
 		return storageTypeZfs, nil
 	case "lvm":
@@ -179,6 +183,10 @@ type storage interface {
 	GetStoragePoolWritable() api.StoragePoolPut
 	SetStoragePoolWritable(writable *api.StoragePoolPut)
 
+	// StoragePoolResources returns the space used and available on the
+	// storage pool.
+	StoragePoolResources() (*api.ResourcesStoragePool, error)
+
 	// Functions dealing with custom storage volumes.
 	StoragePoolVolumeCreate() error
 	StoragePoolVolumeDelete() error
@@ -188,6 +196,17 @@ type storage interface {
 	GetStoragePoolVolumeWritable() api.StorageVolumePut
 	SetStoragePoolVolumeWritable(writable *api.StorageVolumePut)
 
+	// Functions dealing with custom storage volume snapshots. They all
+	// operate on snapshots of the storage volume the driver was
+	// initialized for.
+	StoragePoolVolumeSnapshotCreate(snapshotName string) error
+	StoragePoolVolumeSnapshotDelete(snapshotName string) error
+	StoragePoolVolumeSnapshotRename(snapshotName string, newName string) error
+
+	// StoragePoolVolumeSnapshotRestore replaces the content of the storage
+	// volume with the content of the given snapshot.
+	StoragePoolVolumeSnapshotRestore(snapshotName string) error
+
 	// Functions dealing with container storage volumes.
 	// ContainerCreate creates an empty container (no rootfs/metadata.yaml)
 	ContainerCreate(container container) error
@@ -214,6 +233,16 @@ type storage interface {
 	// For use in migrating snapshots.
 	ContainerSnapshotCreateEmpty(snapshotContainer container) error
 
+	// Functions dealing with container backups.
+	// ContainerBackupCreate dumps the container (and its snapshots unless
+	// the backup is container only) into the given directory.
+	ContainerBackupCreate(path string, backup backup, sourceContainer container) error
+
+	// ContainerBackupLoad restores the content of an unpacked backup
+	// directory into a freshly created container, creating the
+	// snapshots on the way.
+	ContainerBackupLoad(container container, snapshots []containerArgs, path string, optimized bool) error
+
 	// Functions dealing with image storage volumes.
 	ImageCreate(fingerprint string) error
 	ImageDelete(fingerprint string) error
@@ -264,6 +293,13 @@ func storageCoreInit(driver string) (storage, error) {
 			return nil, err
 		}
 		return &btrfs, nil
+	case storageTypeCeph:
+		ceph := storageCeph{}
+		err = ceph.StorageCoreInit()
+		if err != nil {
+			return nil, err
+		}
+		return &ceph, nil
 	case storageTypeDir:
 		dir := storageDir{}
 		err = dir.StorageCoreInit()
@@ -337,6 +373,17 @@ func storageInit(d *Daemon, poolName string, volumeName string, volumeType int)
 			return nil, err
 		}
 		return &btrfs, nil
+	case storageTypeCeph:
+		ceph := storageCeph{}
+		ceph.poolID = poolID
+		ceph.pool = pool
+		ceph.volume = volume
+		ceph.d = d
+		err = ceph.StoragePoolInit()
+		if err != nil {
+			return nil, err
+		}
+		return &ceph, nil
 	case storageTypeDir:
 		dir := storageDir{}
 		dir.poolID = poolID
@@ -441,12 +488,7 @@ func storagePoolVolumeAttachInit(d *Daemon, poolName string, volumeName string,
 		}
 
 		if len(volumeUsedBy) > 1 {
-			for _, ct := range volumeUsedBy {
-				c, err := containerLoadByName(d, ct)
-				if err != nil {
-					continue
-				}
-
+			for _, c := range volumeUsedBy {
 				if c.IsRunning() {
 					return nil, fmt.Errorf("idmaps of container and storage volume are not identical")
 				}
@@ -464,7 +506,7 @@ func storagePoolVolumeAttachInit(d *Daemon, poolName string, volumeName string,
 			// If we're the only one who's attached that container
 			// we can shift the storage volume.
 			// I'm not sure if we want some locking here.
-			if volumeUsedBy[0] != fmt.Sprintf("/%s/containers/%s", version.APIVersion, c.Name()) {
+			if volumeUsedBy[0].Name() != c.Name() {
 				return nil, fmt.Errorf("idmaps of container and storage volume are not identical")
 			}
 		}
@@ -580,6 +622,11 @@ func getStoragePoolVolumeMountPoint(poolName string, volumeName string) string {
 	return shared.VarPath("storage-pools", poolName, "custom", volumeName)
 }
 
+// ${LXD_DIR}/storage-pools/<pool>/custom-snapshots/<storage_volume>/<snapshot_name>
+func getStoragePoolVolumeSnapshotMountPoint(poolName string, volumeName string, snapshotName string) string {
+	return shared.VarPath("storage-pools", poolName, "custom-snapshots", volumeName, snapshotName)
+}
+
 func createContainerMountpoint(mountPoint string, mountPointSymlink string, privileged bool) error {
 	var mode os.FileMode
 	if privileged {
@@ -714,6 +761,41 @@ func deleteSnapshotMountpoint(snapshotMountpoint string, snapshotsSymlinkTarget
 	return nil
 }
 
+func createStoragePoolVolumeSnapshotMountpoint(poolName string, volumeName string, snapshotName string) error {
+	snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(poolName, volumeName, snapshotName)
+	if !shared.PathExists(snapshotMntPoint) {
+		err := os.MkdirAll(snapshotMntPoint, 0711)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteStoragePoolVolumeSnapshotMountpoint(poolName string, volumeName string, snapshotName string) error {
+	snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(poolName, volumeName, snapshotName)
+	if shared.PathExists(snapshotMntPoint) {
+		err := os.Remove(snapshotMntPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the snapshot directory of the storage volume once its last
+	// snapshot is gone.
+	snapshotsPath := shared.VarPath("storage-pools", poolName, "custom-snapshots", volumeName)
+	empty, _ := shared.PathIsEmpty(snapshotsPath)
+	if empty {
+		err := os.Remove(snapshotsPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ShiftIfNecessary sets the volatile.last_state.idmap key to the idmap last
 // used by the container.
 func ShiftIfNecessary(container container, srcIdmap *shared.IdmapSet) error {
@@ -57,6 +57,11 @@ func (s *storageBtrfs) getCustomSubvolumePath(poolName string) string {
 	return shared.VarPath("storage-pools", poolName, "custom")
 }
 
+// ${LXD_DIR}/storage-pools/<pool>/custom-snapshots/<storage_volume>
+func (s *storageBtrfs) getCustomSnapshotSubvolumePath(poolName string, volumeName string) string {
+	return shared.VarPath("storage-pools", poolName, "custom-snapshots", volumeName)
+}
+
 func (s *storageBtrfs) StorageCoreInit() error {
 	s.sType = storageTypeBtrfs
 	typeName, err := storageTypeToString(s.sType)
@@ -496,6 +501,10 @@ func (s *storageBtrfs) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
 
+func (s *storageBtrfs) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	return storageResourcesStatfs(getStoragePoolMountPoint(s.pool.Name))
+}
+
 // Functions dealing with storage volumes.
 func (s *storageBtrfs) StoragePoolVolumeCreate() error {
 	logger.Infof("Creating BTRFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
@@ -628,6 +637,134 @@ func (s *storageBtrfs) SetStoragePoolVolumeWritable(writable *api.StorageVolumeP
 	s.volume.StorageVolumePut = *writable
 }
 
+func (s *storageBtrfs) StoragePoolVolumeSnapshotCreate(snapshotName string) error {
+	logger.Infof("Creating BTRFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	_, err := s.StoragePoolMount()
+	if err != nil {
+		return err
+	}
+
+	// Create the snapshot path of the storage volume on the storage pool.
+	customSnapshotSubvolumePath := s.getCustomSnapshotSubvolumePath(s.pool.Name, s.volume.Name)
+	if !shared.PathExists(customSnapshotSubvolumePath) {
+		err := os.MkdirAll(customSnapshotSubvolumePath, 0711)
+		if err != nil {
+			return err
+		}
+	}
+
+	customSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	snapshotSubvolumeName := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	err = s.btrfsPoolVolumesSnapshot(customSubvolumeName, snapshotSubvolumeName, true)
+	if err != nil {
+		deleteStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+		return err
+	}
+
+	logger.Infof("Created BTRFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageBtrfs) StoragePoolVolumeSnapshotDelete(snapshotName string) error {
+	logger.Infof("Deleting BTRFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	_, err := s.StoragePoolMount()
+	if err != nil {
+		return err
+	}
+
+	snapshotSubvolumeName := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	if shared.PathExists(snapshotSubvolumeName) && isBtrfsSubVolume(snapshotSubvolumeName) {
+		err = btrfsSubVolumesDelete(snapshotSubvolumeName)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = deleteStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Deleted BTRFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageBtrfs) StoragePoolVolumeSnapshotRename(snapshotName string, newName string) error {
+	logger.Infof("Renaming BTRFS storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+
+	_, err := s.StoragePoolMount()
+	if err != nil {
+		return err
+	}
+
+	oldSnapshotSubvolumeName := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	newSnapshotSubvolumeName := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, newName)
+	err = os.Rename(oldSnapshotSubvolumeName, newSnapshotSubvolumeName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Renamed BTRFS storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
+func (s *storageBtrfs) StoragePoolVolumeSnapshotRestore(snapshotName string) error {
+	logger.Infof("Restoring BTRFS storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	_, err := s.StoragePoolMount()
+	if err != nil {
+		return err
+	}
+
+	// Create a backup so we can revert.
+	customSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	backupCustomSubvolumeName := fmt.Sprintf("%s.back", customSubvolumeName)
+	err = os.Rename(customSubvolumeName, backupCustomSubvolumeName)
+	if err != nil {
+		return err
+	}
+	undo := true
+	defer func() {
+		if undo {
+			btrfsSubVolumesDelete(customSubvolumeName)
+			os.Rename(backupCustomSubvolumeName, customSubvolumeName)
+		}
+	}()
+
+	// Replace the storage volume with a writable snapshot of the snapshot.
+	snapshotSubvolumeName := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	err = s.btrfsPoolVolumesSnapshot(snapshotSubvolumeName, customSubvolumeName, false)
+	if err != nil {
+		return err
+	}
+
+	// The quota group of the storage volume went away with it.
+	if s.volume.Config["size"] != "" {
+		size, err := shared.ParseByteSizeString(s.volume.Config["size"])
+		if err != nil {
+			return err
+		}
+
+		err = s.StorageEntitySetQuota(storagePoolVolumeTypeCustom, size, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	undo = false
+
+	// Remove the backup we made.
+	err = btrfsSubVolumesDelete(backupCustomSubvolumeName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Restored BTRFS storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
 // Functions dealing with container storage.
 func (s *storageBtrfs) ContainerStorageReady(name string) bool {
 	containerMntPoint := getContainerMountPoint(s.pool.Name, name)
@@ -1297,6 +1434,189 @@ func (s *storageBtrfs) ContainerSnapshotCreateEmpty(snapshotContainer container)
 	return nil
 }
 
+func (s *storageBtrfs) ContainerBackupCreate(path string, backup backup, sourceContainer container) error {
+	logger.Debugf("Creating backup of BTRFS storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+
+	if !backup.OptimizedStorage() {
+		err := rsyncBackupCreate(path, backup, sourceContainer)
+		if err != nil {
+			return err
+		}
+
+		logger.Debugf("Created backup of BTRFS storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+		return nil
+	}
+
+	if runningInUserns {
+		return fmt.Errorf("Optimized BTRFS backups can't be created from within a user namespace")
+	}
+
+	// Send the snapshots from oldest to newest so that every snapshot
+	// only needs to carry the delta to its predecessor.
+	btrfsParent := ""
+	if !backup.ContainerOnly() {
+		snapshots, err := sourceContainer.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snapshots {
+			_, snapOnlyName, _ := containerGetParentAndSnapshotName(snap.Name())
+			snapshotMntPoint := getSnapshotMountPoint(s.pool.Name, snap.Name())
+			target := filepath.Join(path, "snapshots", fmt.Sprintf("%s.bin", snapOnlyName))
+			err := btrfsSendToFile(snapshotMntPoint, btrfsParent, target)
+			if err != nil {
+				return err
+			}
+
+			btrfsParent = snapshotMntPoint
+		}
+	}
+
+	// Only read-only subvolumes can be sent so take a temporary snapshot
+	// of the container.
+	containersPath := getContainerMountPoint(s.pool.Name, "")
+	tmpContainerMntPoint, err := ioutil.TempDir(containersPath, sourceContainer.Name())
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpContainerMntPoint)
+
+	err = os.Chmod(tmpContainerMntPoint, 0700)
+	if err != nil {
+		return err
+	}
+
+	backupSendSnapshot := fmt.Sprintf("%s/.backup-send", tmpContainerMntPoint)
+	containerMntPoint := getContainerMountPoint(s.pool.Name, sourceContainer.Name())
+	err = s.btrfsPoolVolumesSnapshot(containerMntPoint, backupSendSnapshot, true)
+	if err != nil {
+		return err
+	}
+	defer btrfsSubVolumesDelete(backupSendSnapshot)
+
+	err = btrfsSendToFile(backupSendSnapshot, btrfsParent, filepath.Join(path, "container.bin"))
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Created backup of BTRFS storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+	return nil
+}
+
+func (s *storageBtrfs) ContainerBackupLoad(container container, snapshots []containerArgs, path string, optimized bool) error {
+	logger.Debugf("Loading backup into BTRFS storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+
+	if !optimized {
+		err := rsyncBackupLoad(container, snapshots, path)
+		if err != nil {
+			return err
+		}
+
+		logger.Debugf("Loaded backup into BTRFS storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+		return nil
+	}
+
+	if runningInUserns {
+		return fmt.Errorf("Optimized BTRFS backups can't be restored from within a user namespace")
+	}
+
+	containerName := container.Name()
+	snapshotsPath := getSnapshotMountPoint(s.pool.Name, containerName)
+	for _, args := range snapshots {
+		_, err := containerCreateEmptySnapshot(container.Daemon(), args)
+		if err != nil {
+			return err
+		}
+
+		tmpSnapshotMntPoint, err := ioutil.TempDir(snapshotsPath, containerName)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpSnapshotMntPoint)
+
+		err = os.Chmod(tmpSnapshotMntPoint, 0700)
+		if err != nil {
+			return err
+		}
+
+		_, snapOnlyName, _ := containerGetParentAndSnapshotName(args.Name)
+		source := filepath.Join(path, "snapshots", fmt.Sprintf("%s.bin", snapOnlyName))
+		receivedSnapshot := fmt.Sprintf("%s/%s", tmpSnapshotMntPoint, snapOnlyName)
+		snapshotMntPoint := getSnapshotMountPoint(s.pool.Name, args.Name)
+		err = s.btrfsRecvFromFile(source, tmpSnapshotMntPoint, receivedSnapshot, snapshotMntPoint, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	containersPath := getContainerMountPoint(s.pool.Name, "")
+	tmpContainerMntPoint, err := ioutil.TempDir(containersPath, containerName)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpContainerMntPoint)
+
+	err = os.Chmod(tmpContainerMntPoint, 0700)
+	if err != nil {
+		return err
+	}
+
+	source := filepath.Join(path, "container.bin")
+	receivedSnapshot := fmt.Sprintf("%s/.backup-send", tmpContainerMntPoint)
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+	err = s.btrfsRecvFromFile(source, tmpContainerMntPoint, receivedSnapshot, containerMntPoint, false)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Loaded backup into BTRFS storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+	return nil
+}
+
+// btrfsRecvFromFile receives the send stream stored in file into btrfsPath
+// and uses the received subvolume to replace the pre-created targetPath.
+func (s *storageBtrfs) btrfsRecvFromFile(file string, btrfsPath string, receivedSubvol string, targetPath string, readonly bool) error {
+	output, err := shared.RunCommand("btrfs", "receive", "-e", "-f", file, btrfsPath)
+	if err != nil {
+		logger.Errorf("Problem with btrfs receive: %s.", output)
+		return err
+	}
+
+	// Remove the existing pre-created subvolume
+	err = btrfsSubVolumesDelete(targetPath)
+	if err != nil {
+		logger.Errorf("Failed to delete pre-created BTRFS subvolume: %s.", targetPath)
+		return err
+	}
+
+	err = s.btrfsPoolVolumesSnapshot(receivedSubvol, targetPath, readonly)
+	if err != nil {
+		logger.Errorf("Problem with btrfs snapshot: %s.", err)
+		return err
+	}
+
+	return btrfsSubVolumesDelete(receivedSubvol)
+}
+
+// btrfsSendToFile writes a send stream of subvol into target. If a parent is
+// given only the delta to it is sent.
+func btrfsSendToFile(subvol string, parent string, target string) error {
+	args := []string{"send", "-f", target}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, subvol)
+
+	output, err := shared.RunCommand("btrfs", args...)
+	if err != nil {
+		logger.Errorf("Problem with btrfs send: %s.", output)
+		return err
+	}
+
+	return nil
+}
+
 func (s *storageBtrfs) ImageCreate(fingerprint string) error {
 	logger.Debugf("Creating BTRFS storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
 
@@ -1520,6 +1840,51 @@ func (s *storageBtrfs) btrfsPoolVolumeQGroupUsage(subvol string) (int64, error)
 	return -1, fmt.Errorf("Unable to find current qgroup usage")
 }
 
+// btrfsSubVolumeIdentity returns the UUID btrfs receive looks the parent of
+// incremental streams up with: the UUID a subvolume was received from, or
+// its own UUID if it wasn't received.
+func btrfsSubVolumeIdentity(subvol string) (string, error) {
+	output, err := shared.RunCommand(
+		"btrfs",
+		"subvolume",
+		"show",
+		subvol)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get the UUID of \"%s\": %s", subvol, output)
+	}
+
+	uuid := ""
+	receivedUUID := ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(fields[1])
+		if value == "-" {
+			continue
+		}
+
+		switch strings.TrimSpace(fields[0]) {
+		case "UUID":
+			uuid = value
+		case "Received UUID":
+			receivedUUID = value
+		}
+	}
+
+	if receivedUUID != "" {
+		return receivedUUID, nil
+	}
+
+	if uuid == "" {
+		return "", fmt.Errorf("Unable to find the UUID of \"%s\"", subvol)
+	}
+
+	return uuid, nil
+}
+
 func btrfsSubVolumeDelete(subvol string) error {
 	// Attempt (but don't fail on) to delete any qgroup on the subvolume
 	qgroup, err := btrfsSubVolumeQGroup(subvol)
@@ -1730,6 +2095,7 @@ type btrfsMigrationSourceDriver struct {
 	btrfs              *storageBtrfs
 	runningSnapName    string
 	stoppedSnapName    string
+	refreshParent      string
 }
 
 func (s *btrfsMigrationSourceDriver) Snapshots() []container {
@@ -1817,7 +2183,7 @@ func (s *btrfsMigrationSourceDriver) SendWhileRunning(conn *websocket.Conn, op *
 
 	if !containerOnly {
 		for i, snap := range s.snapshots {
-			prev := ""
+			prev := s.refreshParent
 			if i > 0 {
 				prev = getSnapshotMountPoint(containerPool, s.snapshots[i-1].Name())
 			}
@@ -1849,7 +2215,7 @@ func (s *btrfsMigrationSourceDriver) SendWhileRunning(conn *websocket.Conn, op *
 	}
 	defer btrfsSubVolumesDelete(migrationSendSnapshot)
 
-	btrfsParent := ""
+	btrfsParent := s.refreshParent
 	if len(s.btrfsSnapshotNames) > 0 {
 		btrfsParent = s.btrfsSnapshotNames[len(s.btrfsSnapshotNames)-1]
 	}
@@ -1874,6 +2240,26 @@ func (s *btrfsMigrationSourceDriver) SendAfterCheckpoint(conn *websocket.Conn, b
 	return s.send(conn, s.stoppedSnapName, s.runningSnapName, nil)
 }
 
+func (s *btrfsMigrationSourceDriver) Refresh(targetSnapshots []string) {
+	snapshots := []container{}
+	btrfsSnapshotNames := []string{}
+
+	for i, snap := range s.snapshots {
+		if shared.StringInSlice(shared.ExtractSnapshotName(snap.Name()), targetSnapshots) {
+			// The incremental streams start from the latest
+			// snapshot the target already has.
+			s.refreshParent = s.btrfsSnapshotNames[i]
+			continue
+		}
+
+		snapshots = append(snapshots, snap)
+		btrfsSnapshotNames = append(btrfsSnapshotNames, s.btrfsSnapshotNames[i])
+	}
+
+	s.snapshots = snapshots
+	s.btrfsSnapshotNames = btrfsSnapshotNames
+}
+
 func (s *btrfsMigrationSourceDriver) Cleanup() {
 	if s.stoppedSnapName != "" {
 		btrfsSubVolumesDelete(s.stoppedSnapName)
@@ -1944,13 +2330,6 @@ func (s *storageBtrfs) MigrationSink(live bool, container container, snapshots [
 		args := []string{"receive", "-e", btrfsPath}
 		cmd := exec.Command("btrfs", args...)
 
-		// Remove the existing pre-created subvolume
-		err := btrfsSubVolumesDelete(targetPath)
-		if err != nil {
-			logger.Errorf("Failed to delete pre-created BTRFS subvolume: %s.", btrfsPath)
-			return err
-		}
-
 		stdin, err := cmd.StdinPipe()
 		if err != nil {
 			return err
@@ -1989,6 +2368,15 @@ func (s *storageBtrfs) MigrationSink(live bool, container container, snapshots [
 		if !shared.PathExists(receivedSnapshot) {
 			receivedSnapshot = fmt.Sprintf("%s/.root", btrfsPath)
 		}
+
+		// Remove the existing subvolume, pre-created or being
+		// refreshed, only once its replacement was received.
+		err = btrfsSubVolumesDelete(targetPath)
+		if err != nil {
+			logger.Errorf("Failed to delete pre-created BTRFS subvolume: %s.", btrfsPath)
+			return err
+		}
+
 		if isSnapshot {
 			receivedSnapshot = fmt.Sprintf("%s/%s", btrfsPath, snapName)
 			err = s.btrfsPoolVolumesSnapshot(receivedSnapshot, targetPath, true)
@@ -0,0 +1,1470 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+type storageCeph struct {
+	clusterName string
+	osdPoolName string
+	userName    string
+	pgNum       string
+	storageShared
+}
+
+// Only initialize the minimal information we need about a given storage type.
+func (s *storageCeph) StorageCoreInit() error {
+	s.sType = storageTypeCeph
+	typeName, err := storageTypeToString(s.sType)
+	if err != nil {
+		return err
+	}
+	s.sTypeName = typeName
+
+	output, err := shared.RunCommand("rbd", "--version")
+	if err != nil {
+		return fmt.Errorf("Error getting CEPH version: %v\noutput:'%s'", err, output)
+	}
+
+	// "ceph version 12.2.13 (<commit>) luminous (stable)"
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return fmt.Errorf("Error parsing CEPH version: %s", output)
+	}
+	s.sTypeVersion = fields[2]
+
+	logger.Debugf("Initializing a CEPH driver.")
+	return nil
+}
+
+func (s *storageCeph) StoragePoolInit() error {
+	err := s.StorageCoreInit()
+	if err != nil {
+		return err
+	}
+
+	s.clusterName = "ceph"
+	if s.pool.Config["ceph.cluster_name"] != "" {
+		s.clusterName = s.pool.Config["ceph.cluster_name"]
+	}
+
+	s.userName = "admin"
+	if s.pool.Config["ceph.user.name"] != "" {
+		s.userName = s.pool.Config["ceph.user.name"]
+	}
+
+	s.pgNum = "32"
+	if s.pool.Config["ceph.osd.pg_num"] != "" {
+		s.pgNum = s.pool.Config["ceph.osd.pg_num"]
+	}
+
+	s.osdPoolName = s.pool.Name
+	if s.pool.Config["ceph.osd.pool_name"] != "" {
+		s.osdPoolName = s.pool.Config["ceph.osd.pool_name"]
+	} else if s.pool.Config["source"] != "" {
+		s.osdPoolName = s.pool.Config["source"]
+	}
+
+	return nil
+}
+
+func (s *storageCeph) StoragePoolCheck() error {
+	logger.Debugf("Checking CEPH storage pool \"%s\".", s.pool.Name)
+
+	if !cephOSDPoolExists(s.clusterName, s.osdPoolName, s.userName) {
+		return fmt.Errorf("CEPH OSD storage pool \"%s\" does not exist in cluster \"%s\"", s.osdPoolName, s.clusterName)
+	}
+
+	logger.Debugf("Checked CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolCreate() error {
+	logger.Infof("Creating CEPH storage pool \"%s\".", s.pool.Name)
+
+	tryUndo := true
+	source := s.pool.Config["source"]
+	if source != "" {
+		if s.pool.Config["ceph.osd.pool_name"] != "" && s.pool.Config["ceph.osd.pool_name"] != source {
+			return fmt.Errorf("invalid combination of \"source\" and \"ceph.osd.pool_name\" property")
+		}
+		s.osdPoolName = source
+	}
+
+	// Check that we don't already use this OSD pool.
+	inUse, user, err := lxdUsesPool(s.d.db, s.osdPoolName, s.pool.Driver, "ceph.osd.pool_name")
+	if err != nil {
+		return err
+	}
+
+	if inUse && user != s.pool.Name {
+		msg := fmt.Sprintf("LXD already uses CEPH OSD storage pool \"%s\" for pool \"%s\"", s.osdPoolName, user)
+		logger.Errorf(msg)
+		return fmt.Errorf(msg)
+	}
+
+	if source == "" {
+		// The OSD pool is created and owned by LXD.
+		if cephOSDPoolExists(s.clusterName, s.osdPoolName, s.userName) {
+			return fmt.Errorf("CEPH OSD storage pool \"%s\" already exists in cluster \"%s\", use the \"source\" property to make use of it", s.osdPoolName, s.clusterName)
+		}
+
+		err := cephOSDPoolCreate(s.clusterName, s.osdPoolName, s.pgNum, s.userName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if tryUndo {
+				cephOSDPoolDestroy(s.clusterName, s.osdPoolName, s.userName)
+			}
+		}()
+	} else {
+		// An existing OSD pool must exist and be empty. Otherwise we
+		// will refuse to use it.
+		if !cephOSDPoolExists(s.clusterName, s.osdPoolName, s.userName) {
+			return fmt.Errorf("the requested CEPH OSD storage pool \"%s\" does not exist in cluster \"%s\"", s.osdPoolName, s.clusterName)
+		}
+
+		count, err := cephRBDVolumeCount(s.clusterName, s.osdPoolName, s.userName)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			msg := fmt.Sprintf("CEPH OSD storage pool \"%s\" is not empty", s.osdPoolName)
+			logger.Errorf(msg)
+			return fmt.Errorf(msg)
+		}
+	}
+	s.pool.Config["ceph.osd.pool_name"] = s.osdPoolName
+
+	// Create the mountpoint for the storage pool.
+	poolMntPoint := getStoragePoolMountPoint(s.pool.Name)
+	err = os.MkdirAll(poolMntPoint, 0711)
+	if err != nil {
+		return err
+	}
+
+	// Deregister cleanup.
+	tryUndo = false
+
+	logger.Infof("Created CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolDelete() error {
+	logger.Infof("Deleting CEPH storage pool \"%s\".", s.pool.Name)
+
+	// Only remove OSD pools LXD created itself.
+	if s.pool.Config["source"] == "" && cephOSDPoolExists(s.clusterName, s.osdPoolName, s.userName) {
+		err := cephOSDPoolDestroy(s.clusterName, s.osdPoolName, s.userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete the mountpoint for the storage pool.
+	poolMntPoint := getStoragePoolMountPoint(s.pool.Name)
+	err := os.RemoveAll(poolMntPoint)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Deleted CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolMount() (bool, error) {
+	// Nothing to do: RBD storage volumes are mapped individually.
+	return true, nil
+}
+
+func (s *storageCeph) StoragePoolUmount() (bool, error) {
+	return true, nil
+}
+
+func (s *storageCeph) StoragePoolVolumeCreate() error {
+	logger.Infof("Creating RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	tryUndo := true
+
+	err := s.rbdCreateVolume(s.volume.Name, storagePoolVolumeAPIEndpointCustom)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.StoragePoolVolumeDelete()
+		}
+	}()
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	err = os.MkdirAll(customPoolVolumeMntPoint, 0711)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	logger.Infof("Created RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeDelete() error {
+	logger.Infof("Deleting RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	_, err := s.StoragePoolVolumeUmount()
+	if err != nil {
+		return err
+	}
+
+	if cephRBDVolumeExists(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, s.userName) {
+		err = cephRBDVolumeDelete(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, s.userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	if shared.PathExists(customPoolVolumeMntPoint) {
+		err := os.Remove(customPoolVolumeMntPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = dbStoragePoolVolumeDelete(
+		s.d.db,
+		s.volume.Name,
+		storagePoolVolumeTypeCustom,
+		s.poolID)
+	if err != nil {
+		logger.Errorf(`Failed to delete database entry for RBD `+
+			`storage volume "%s" on storage pool "%s"`,
+			s.volume.Name, s.pool.Name)
+	}
+
+	logger.Infof("Deleted RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeMount() (bool, error) {
+	logger.Debugf("Mounting RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	customMountLockID := getCustomMountLockID(s.pool.Name, s.volume.Name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customMountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			logger.Warnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in mounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[customMountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var customerr error
+	ourMount := false
+	if !shared.IsMountPoint(customPoolVolumeMntPoint) {
+		customerr = s.rbdMount(s.volume.Name, storagePoolVolumeAPIEndpointCustom, "", customPoolVolumeMntPoint, s.getRBDMountOptions())
+		ourMount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customMountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, customMountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if customerr != nil {
+		return false, customerr
+	}
+
+	logger.Debugf("Mounted RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourMount, nil
+}
+
+func (s *storageCeph) StoragePoolVolumeUmount() (bool, error) {
+	logger.Debugf("Unmounting RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	customUmountLockID := getCustomUmountLockID(s.pool.Name, s.volume.Name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customUmountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			logger.Warnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in unmounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[customUmountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var customerr error
+	ourUmount := false
+	if shared.IsMountPoint(customPoolVolumeMntPoint) {
+		customerr = s.rbdUmount(s.volume.Name, storagePoolVolumeAPIEndpointCustom, "", customPoolVolumeMntPoint)
+		ourUmount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customUmountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, customUmountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if customerr != nil {
+		return false, customerr
+	}
+
+	logger.Debugf("Unmounted RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourUmount, nil
+}
+
+func (s *storageCeph) GetStoragePoolWritable() api.StoragePoolPut {
+	return s.pool.Writable()
+}
+
+func (s *storageCeph) GetStoragePoolVolumeWritable() api.StorageVolumePut {
+	return s.volume.Writable()
+}
+
+func (s *storageCeph) SetStoragePoolWritable(writable *api.StoragePoolPut) {
+	s.pool.StoragePoolPut = *writable
+}
+
+func (s *storageCeph) SetStoragePoolVolumeWritable(writable *api.StorageVolumePut) {
+	s.volume.StorageVolumePut = *writable
+}
+
+func (s *storageCeph) GetContainerPoolInfo() (int64, string) {
+	return s.poolID, s.pool.Name
+}
+
+func (s *storageCeph) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	output, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", s.userName),
+		"--cluster", s.clusterName,
+		"df",
+		"-f", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	df := struct {
+		Pools []struct {
+			Name  string `json:"name"`
+			Stats struct {
+				BytesUsed uint64 `json:"bytes_used"`
+				MaxAvail  uint64 `json:"max_avail"`
+			} `json:"stats"`
+		} `json:"pools"`
+	}{}
+
+	err = json.Unmarshal([]byte(output), &df)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range df.Pools {
+		if pool.Name != s.osdPoolName {
+			continue
+		}
+
+		res := api.ResourcesStoragePool{}
+		res.Space.Used = pool.Stats.BytesUsed
+		res.Space.Total = pool.Stats.BytesUsed + pool.Stats.MaxAvail
+
+		return &res, nil
+	}
+
+	return nil, fmt.Errorf("OSD pool \"%s\" wasn't found in the cluster usage", s.osdPoolName)
+}
+
+func (s *storageCeph) StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error {
+	logger.Infof("Updating CEPH storage pool \"%s\".", s.pool.Name)
+
+	for _, key := range changedConfig {
+		if key == "source" || strings.HasPrefix(key, "ceph.") {
+			return fmt.Errorf("the \"%s\" property cannot be changed", key)
+		}
+	}
+
+	// "volume.block.mount_options" requires no on-disk modifications.
+	// "volume.block.filesystem" requires no on-disk modifications.
+	// "volume.size" requires no on-disk modifications.
+	// "rsync.bwlimit" requires no on-disk modifications.
+
+	logger.Infof("Updated CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeUpdate(writable *api.StorageVolumePut, changedConfig []string) error {
+	logger.Infof("Updating RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	if !(shared.StringInSlice("block.mount_options", changedConfig) && len(changedConfig) == 1) &&
+		!(shared.StringInSlice("block.mount_options", changedConfig) && len(changedConfig) == 2 && shared.StringInSlice("size", changedConfig)) &&
+		!(shared.StringInSlice("size", changedConfig) && len(changedConfig) == 1) {
+		return fmt.Errorf("the properties \"%v\" cannot be changed", changedConfig)
+	}
+
+	if shared.StringInSlice("size", changedConfig) {
+		// apply quota
+		if s.volume.Config["size"] != writable.Config["size"] {
+			size, err := shared.ParseByteSizeString(writable.Config["size"])
+			if err != nil {
+				return err
+			}
+
+			err = s.StorageEntitySetQuota(storagePoolVolumeTypeCustom, size, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	logger.Infof("Updated RBD storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeSnapshotCreate(snapshotName string) error {
+	logger.Infof("Creating RBD storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	err := s.rbdSnapshotCreate(s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(snapshotName), customPoolVolumeMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = createStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+	if err != nil {
+		cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(snapshotName), s.userName)
+		return err
+	}
+
+	logger.Infof("Created RBD storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeSnapshotDelete(snapshotName string) error {
+	logger.Infof("Deleting RBD storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	err := s.rbdUmount(s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(snapshotName), snapshotMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(snapshotName), s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = deleteStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Deleted RBD storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeSnapshotRename(snapshotName string, newName string) error {
+	logger.Infof("Renaming RBD storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+
+	oldSnapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	err := s.rbdUmount(s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(snapshotName), oldSnapshotMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotRename(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(snapshotName), getRBDSnapshotName(newName), s.userName)
+	if err != nil {
+		return err
+	}
+
+	newSnapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, newName)
+	err = os.Rename(oldSnapshotMntPoint, newSnapshotMntPoint)
+	if err != nil {
+		cephRBDSnapshotRename(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(newName), getRBDSnapshotName(snapshotName), s.userName)
+		return err
+	}
+
+	logger.Infof("Renamed RBD storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeSnapshotRestore(snapshotName string) error {
+	logger.Infof("Restoring RBD storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	// The storage volume must not be mapped while rolling back.
+	ourUmount, err := s.StoragePoolVolumeUmount()
+	if err != nil {
+		return err
+	}
+	if ourUmount {
+		defer s.StoragePoolVolumeMount()
+	}
+
+	err = cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, "", s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotRollback(s.clusterName, s.osdPoolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, getRBDSnapshotName(snapshotName), s.userName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Restored RBD storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerStorageReady(name string) bool {
+	return cephRBDVolumeExists(s.clusterName, s.osdPoolName, name, storagePoolVolumeAPIEndpointContainers, s.userName)
+}
+
+func (s *storageCeph) ContainerCreate(container container) error {
+	logger.Debugf("Creating empty RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	tryUndo := true
+
+	containerName := container.Name()
+	err := s.rbdCreateVolume(containerName, storagePoolVolumeAPIEndpointContainers)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.ContainerDelete(container)
+		}
+	}()
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+	err = os.MkdirAll(containerMntPoint, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = createContainerMountpoint(containerMntPoint, container.Path(), container.IsPrivileged())
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	logger.Debugf("Created empty RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerCreateFromImage(container container, fingerprint string) error {
+	logger.Debugf("Creating RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	tryUndo := true
+
+	imageStoragePoolLockID := getImageCreateLockID(s.pool.Name, fingerprint)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[imageStoragePoolLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			logger.Warnf("Received value over semaphore. This should not have happened.")
+		}
+	} else {
+		lxdStorageOngoingOperationMap[imageStoragePoolLockID] = make(chan bool)
+		lxdStorageMapLock.Unlock()
+
+		var imgerr error
+		if !cephRBDVolumeExists(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, s.userName) {
+			imgerr = s.ImageCreate(fingerprint)
+		}
+
+		lxdStorageMapLock.Lock()
+		if waitChannel, ok := lxdStorageOngoingOperationMap[imageStoragePoolLockID]; ok {
+			close(waitChannel)
+			delete(lxdStorageOngoingOperationMap, imageStoragePoolLockID)
+		}
+		lxdStorageMapLock.Unlock()
+
+		if imgerr != nil {
+			return imgerr
+		}
+	}
+
+	containerName := container.Name()
+	err := cephRBDCloneCreate(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, cephRBDImageSnapshotName, s.osdPoolName, containerName, storagePoolVolumeAPIEndpointContainers, s.userName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.ContainerDelete(container)
+		}
+	}()
+
+	// The clone and the image share the same UUID which XFS refuses to
+	// mount twice.
+	if s.getRBDFilesystem() == "xfs" {
+		devPath, err := cephRBDVolumeMap(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeAPIEndpointContainers, "", s.userName)
+		if err != nil {
+			return err
+		}
+
+		err = xfsGenerateNewUUID(devPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+	containerPath := container.Path()
+	err = os.MkdirAll(containerMntPoint, 0755)
+	if err != nil {
+		return err
+	}
+	err = createContainerMountpoint(containerMntPoint, containerPath, container.IsPrivileged())
+	if err != nil {
+		return err
+	}
+
+	ourMount, err := s.ContainerMount(container)
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer s.ContainerUmount(containerName, containerPath)
+	}
+
+	if container.IsPrivileged() {
+		err = os.Chmod(containerMntPoint, 0700)
+	} else {
+		err = os.Chmod(containerMntPoint, 0755)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !container.IsPrivileged() {
+		err := s.shiftRootfs(container)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = container.TemplateApply("create")
+	if err != nil {
+		logger.Errorf("Error in create template during ContainerCreateFromImage, continuing to unmount: %s.", err)
+		return err
+	}
+
+	tryUndo = false
+
+	logger.Debugf("Created RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerCanRestore(container container, sourceContainer container) error {
+	return nil
+}
+
+func (s *storageCeph) ContainerDelete(container container) error {
+	if container.IsSnapshot() {
+		return s.ContainerSnapshotDelete(container)
+	}
+
+	logger.Debugf("Deleting RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	containerName := container.Name()
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+
+	// Make sure that the container is really unmounted at this point.
+	// Otherwise we will fail.
+	err := s.rbdUmount(containerName, storagePoolVolumeAPIEndpointContainers, "", containerMntPoint)
+	if err != nil {
+		return fmt.Errorf("Failed to unmount container path '%s': %s", containerMntPoint, err)
+	}
+
+	if cephRBDVolumeExists(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeAPIEndpointContainers, s.userName) {
+		parent, err := cephRBDVolumeGetParent(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeAPIEndpointContainers, s.userName)
+		if err != nil {
+			return err
+		}
+
+		err = cephRBDVolumeDelete(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeAPIEndpointContainers, s.userName)
+		if err != nil {
+			return err
+		}
+
+		// If the image this container was created from has already
+		// been deleted we might have been the last user.
+		err = s.rbdImageDeleteZombie(parent)
+		if err != nil {
+			logger.Warnf("Failed to delete unused image storage volume \"%s\": %s.", parent, err)
+		}
+	}
+
+	err = deleteContainerMountpoint(containerMntPoint, container.Path(), s.GetStorageTypeName())
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Deleted RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerCopy(target container, source container, containerOnly bool) error {
+	logger.Debugf("Copying RBD container storage for container %s -> %s.", source.Name(), target.Name())
+
+	ourStart, err := source.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer source.StorageStop()
+	}
+
+	_, sourcePool := source.Storage().GetContainerPoolInfo()
+	_, targetPool := target.Storage().GetContainerPoolInfo()
+	if sourcePool != targetPool {
+		return fmt.Errorf("copying containers between different storage pools is not implemented")
+	}
+
+	err = s.ContainerCreate(target)
+	if err != nil {
+		return err
+	}
+
+	if !containerOnly {
+		snapshots, err := source.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		// Replay the snapshots onto the new storage volume and take an
+		// RBD snapshot after each of them.
+		for _, snap := range snapshots {
+			_, snapOnlyName, _ := containerGetParentAndSnapshotName(snap.Name())
+			newSnapName := fmt.Sprintf("%s/%s", target.Name(), snapOnlyName)
+
+			logger.Debugf("Copying RBD container storage for snapshot %s -> %s.", snap.Name(), newSnapName)
+
+			sourceSnapshot, err := containerLoadByName(s.d, snap.Name())
+			if err != nil {
+				return err
+			}
+
+			targetSnapshot, err := containerLoadByName(s.d, newSnapName)
+			if err != nil {
+				return err
+			}
+
+			err = s.rsyncContainer(target, sourceSnapshot)
+			if err != nil {
+				return err
+			}
+
+			err = s.ContainerSnapshotCreate(targetSnapshot, target)
+			if err != nil {
+				return err
+			}
+
+			logger.Debugf("Copied RBD container storage for snapshot %s -> %s.", snap.Name(), newSnapName)
+		}
+	}
+
+	err = s.rsyncContainer(target, source)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Copied RBD container storage for container %s -> %s.", source.Name(), target.Name())
+	return nil
+}
+
+func (s *storageCeph) ContainerMount(c container) (bool, error) {
+	if c.IsSnapshot() {
+		return s.ContainerSnapshotStart(c)
+	}
+
+	name := c.Name()
+	logger.Debugf("Mounting RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, name)
+	containerMountLockID := getContainerMountLockID(s.pool.Name, name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerMountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			logger.Warnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in mounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[containerMountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var mounterr error
+	ourMount := false
+	if !shared.IsMountPoint(containerMntPoint) {
+		mounterr = s.rbdMount(name, storagePoolVolumeAPIEndpointContainers, "", containerMntPoint, s.getRBDMountOptions())
+		ourMount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerMountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, containerMountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if mounterr != nil {
+		return false, mounterr
+	}
+
+	logger.Debugf("Mounted RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourMount, nil
+}
+
+func (s *storageCeph) ContainerUmount(name string, path string) (bool, error) {
+	logger.Debugf("Unmounting RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	rbdName := name
+	snapshotName := ""
+	containerMntPoint := getContainerMountPoint(s.pool.Name, name)
+	if shared.IsSnapshot(name) {
+		parentName, snapOnlyName, _ := containerGetParentAndSnapshotName(name)
+		rbdName = parentName
+		snapshotName = getRBDSnapshotName(snapOnlyName)
+		containerMntPoint = getSnapshotMountPoint(s.pool.Name, name)
+	}
+
+	containerUmountLockID := getContainerUmountLockID(s.pool.Name, name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerUmountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			logger.Warnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in unmounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[containerUmountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var imgerr error
+	ourUmount := false
+	if shared.IsMountPoint(containerMntPoint) {
+		imgerr = s.rbdUmount(rbdName, storagePoolVolumeAPIEndpointContainers, snapshotName, containerMntPoint)
+		ourUmount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerUmountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, containerUmountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if imgerr != nil {
+		return false, imgerr
+	}
+
+	logger.Debugf("Unmounted RBD storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourUmount, nil
+}
+
+func (s *storageCeph) ContainerRename(container container, newContainerName string) error {
+	logger.Debugf("Renaming RBD storage volume for container \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+
+	tryUndo := true
+
+	oldName := container.Name()
+	_, err := s.ContainerUmount(oldName, container.Path())
+	if err != nil {
+		return err
+	}
+
+	// The snapshots of the container are RBD snapshots of its storage
+	// volume so they are renamed along with it.
+	err = cephRBDVolumeRename(s.clusterName, s.osdPoolName, oldName, storagePoolVolumeAPIEndpointContainers, newContainerName, storagePoolVolumeAPIEndpointContainers, s.userName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			cephRBDVolumeRename(s.clusterName, s.osdPoolName, newContainerName, storagePoolVolumeAPIEndpointContainers, oldName, storagePoolVolumeAPIEndpointContainers, s.userName)
+		}
+	}()
+
+	oldContainerMntPoint := getContainerMountPoint(s.pool.Name, oldName)
+	oldContainerMntPointSymlink := container.Path()
+	newContainerMntPoint := getContainerMountPoint(s.pool.Name, newContainerName)
+	newContainerMntPointSymlink := shared.VarPath("containers", newContainerName)
+	err = renameContainerMountpoint(oldContainerMntPoint, oldContainerMntPointSymlink, newContainerMntPoint, newContainerMntPointSymlink)
+	if err != nil {
+		return err
+	}
+
+	oldSnapshotPath := getSnapshotMountPoint(s.pool.Name, oldName)
+	newSnapshotPath := getSnapshotMountPoint(s.pool.Name, newContainerName)
+	if shared.PathExists(oldSnapshotPath) {
+		err = os.Rename(oldSnapshotPath, newSnapshotPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	oldSnapshotSymlink := shared.VarPath("snapshots", oldName)
+	newSnapshotSymlink := shared.VarPath("snapshots", newContainerName)
+	if shared.PathExists(oldSnapshotSymlink) {
+		err := os.Remove(oldSnapshotSymlink)
+		if err != nil {
+			return err
+		}
+
+		err = os.Symlink(newSnapshotPath, newSnapshotSymlink)
+		if err != nil {
+			return err
+		}
+	}
+
+	tryUndo = false
+
+	logger.Debugf("Renamed RBD storage volume for container \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+	return nil
+}
+
+func (s *storageCeph) ContainerRestore(target container, source container) error {
+	logger.Debugf("Restoring RBD storage volume for container \"%s\" from %s -> %s.", s.volume.Name, source.Name(), target.Name())
+
+	_, sourcePool := source.Storage().GetContainerPoolInfo()
+	if s.pool.Name != sourcePool {
+		return fmt.Errorf("containers must be on the same pool to be restored")
+	}
+
+	sourceName := source.Name()
+	targetName := target.Name()
+	parentName, snapOnlyName, _ := containerGetParentAndSnapshotName(sourceName)
+	if !source.IsSnapshot() || parentName != targetName {
+		return fmt.Errorf("containers can only be restored from their own snapshots")
+	}
+
+	// The storage volume must not be mapped while rolling back.
+	_, err := s.ContainerUmount(targetName, target.Path())
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, targetName, storagePoolVolumeAPIEndpointContainers, "", s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotRollback(s.clusterName, s.osdPoolName, targetName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), s.userName)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Restored RBD storage volume for container \"%s\" from %s -> %s.", s.volume.Name, sourceName, targetName)
+	return nil
+}
+
+func (s *storageCeph) ContainerGetUsage(container container) (int64, error) {
+	return -1, fmt.Errorf("the CEPH container backend doesn't support usage reporting")
+}
+
+func (s *storageCeph) ContainerSnapshotCreate(snapshotContainer container, sourceContainer container) error {
+	logger.Debugf("Creating RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	sourceName := sourceContainer.Name()
+	_, snapOnlyName, _ := containerGetParentAndSnapshotName(snapshotContainer.Name())
+	sourceMntPoint := getContainerMountPoint(s.pool.Name, sourceName)
+	err := s.rbdSnapshotCreate(sourceName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), sourceMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = s.createSnapshotMountpoint(snapshotContainer)
+	if err != nil {
+		cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, sourceName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), s.userName)
+		return err
+	}
+
+	logger.Debugf("Created RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerSnapshotDelete(snapshotContainer container) error {
+	logger.Debugf("Deleting RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	snapshotName := snapshotContainer.Name()
+	parentName, snapOnlyName, _ := containerGetParentAndSnapshotName(snapshotName)
+	snapshotMntPoint := getSnapshotMountPoint(s.pool.Name, snapshotName)
+	err := s.rbdUmount(parentName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), snapshotMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, parentName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), s.userName)
+	if err != nil {
+		return fmt.Errorf("Error deleting snapshot %s: %s", snapshotName, err)
+	}
+
+	snapshotMntPointSymlinkTarget := shared.VarPath("storage-pools", s.pool.Name, "snapshots", parentName)
+	snapshotMntPointSymlink := shared.VarPath("snapshots", parentName)
+	err = deleteSnapshotMountpoint(snapshotMntPoint, snapshotMntPointSymlinkTarget, snapshotMntPointSymlink)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Deleted RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerSnapshotRename(snapshotContainer container, newContainerName string) error {
+	logger.Debugf("Renaming RBD storage volume for snapshot \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+
+	oldName := snapshotContainer.Name()
+	parentName, oldSnapOnlyName, _ := containerGetParentAndSnapshotName(oldName)
+	_, newSnapOnlyName, _ := containerGetParentAndSnapshotName(newContainerName)
+
+	_, err := s.ContainerUmount(oldName, snapshotContainer.Path())
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotRename(s.clusterName, s.osdPoolName, parentName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(oldSnapOnlyName), getRBDSnapshotName(newSnapOnlyName), s.userName)
+	if err != nil {
+		return err
+	}
+
+	oldSnapshotMntPoint := getSnapshotMountPoint(s.pool.Name, oldName)
+	newSnapshotMntPoint := getSnapshotMountPoint(s.pool.Name, newContainerName)
+	err = os.Rename(oldSnapshotMntPoint, newSnapshotMntPoint)
+	if err != nil {
+		cephRBDSnapshotRename(s.clusterName, s.osdPoolName, parentName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(newSnapOnlyName), getRBDSnapshotName(oldSnapOnlyName), s.userName)
+		return err
+	}
+
+	logger.Debugf("Renamed RBD storage volume for snapshot \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+	return nil
+}
+
+func (s *storageCeph) ContainerSnapshotStart(container container) (bool, error) {
+	logger.Debugf("Initializing RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	containerName := container.Name()
+	parentName, snapOnlyName, _ := containerGetParentAndSnapshotName(containerName)
+	containerMntPoint := getSnapshotMountPoint(s.pool.Name, containerName)
+	if shared.IsMountPoint(containerMntPoint) {
+		return false, nil
+	}
+
+	// RBD snapshots are always mapped read-only.
+	err := s.rbdMount(parentName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), containerMntPoint, s.getRBDSnapshotMountOptions())
+	if err != nil {
+		return false, fmt.Errorf("Error mounting snapshot RBD path='%s': %s", containerMntPoint, err)
+	}
+
+	logger.Debugf("Initialized RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return true, nil
+}
+
+func (s *storageCeph) ContainerSnapshotStop(container container) (bool, error) {
+	logger.Debugf("Stopping RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	containerName := container.Name()
+	parentName, snapOnlyName, _ := containerGetParentAndSnapshotName(containerName)
+	containerMntPoint := getSnapshotMountPoint(s.pool.Name, containerName)
+	if !shared.IsMountPoint(containerMntPoint) {
+		return false, nil
+	}
+
+	err := s.rbdUmount(parentName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), containerMntPoint)
+	if err != nil {
+		return false, err
+	}
+
+	logger.Debugf("Stopped RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return true, nil
+}
+
+func (s *storageCeph) ContainerSnapshotCreateEmpty(snapshotContainer container) error {
+	logger.Debugf("Creating empty RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	// An RBD snapshot can't exist without its parent so snapshot the
+	// parent's storage volume as it is.
+	parentName, snapOnlyName, _ := containerGetParentAndSnapshotName(snapshotContainer.Name())
+	parentMntPoint := getContainerMountPoint(s.pool.Name, parentName)
+	err := s.rbdSnapshotCreate(parentName, storagePoolVolumeAPIEndpointContainers, getRBDSnapshotName(snapOnlyName), parentMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = s.createSnapshotMountpoint(snapshotContainer)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Created empty RBD storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerBackupCreate(path string, backup backup, sourceContainer container) error {
+	logger.Debugf("Creating backup of RBD storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+
+	if backup.OptimizedStorage() {
+		return fmt.Errorf("Optimized backups are not supported by the ceph storage driver")
+	}
+
+	err := rsyncBackupCreate(path, backup, sourceContainer)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Created backup of RBD storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerBackupLoad(container container, snapshots []containerArgs, path string, optimized bool) error {
+	logger.Debugf("Loading backup into RBD storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+
+	if optimized {
+		return fmt.Errorf("Optimized backups can't be restored by the ceph storage driver")
+	}
+
+	err := rsyncBackupLoad(container, snapshots, path)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Loaded backup into RBD storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ImageCreate(fingerprint string) error {
+	logger.Debugf("Creating RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	tryUndo := true
+	zombieType := fmt.Sprintf("%s_%s", cephRBDZombiePrefix, storagePoolVolumeAPIEndpointImages)
+
+	err := s.createImageDbPoolVolume(fingerprint)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if !tryUndo {
+			return
+		}
+		err := s.deleteImageDbPoolVolume(fingerprint)
+		if err != nil {
+			logger.Warnf("Could not delete image \"%s\" from storage volume database. Manual intervention needed.", fingerprint)
+		}
+	}()
+
+	// Create image mountpoint.
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if !shared.PathExists(imageMntPoint) {
+		err := os.MkdirAll(imageMntPoint, 0700)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The image has been deleted before while containers still used it.
+	// Resurrect it instead of unpacking it again.
+	if cephRBDVolumeExists(s.clusterName, s.osdPoolName, fingerprint, zombieType, s.userName) {
+		err := cephRBDVolumeRename(s.clusterName, s.osdPoolName, fingerprint, zombieType, fingerprint, storagePoolVolumeAPIEndpointImages, s.userName)
+		if err != nil {
+			return err
+		}
+
+		tryUndo = false
+
+		logger.Debugf("Created RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+		return nil
+	}
+
+	err = s.rbdCreateVolume(fingerprint, storagePoolVolumeAPIEndpointImages)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.ImageUmount(fingerprint)
+			cephRBDVolumeDelete(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, s.userName)
+		}
+	}()
+
+	_, err = s.ImageMount(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	imagePath := shared.VarPath("images", fingerprint)
+	err = unpackImage(s.d, imagePath, imageMntPoint, storageTypeCeph)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ImageUmount(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	// Containers are created as clones of a protected snapshot of the
+	// image.
+	err = cephRBDSnapshotCreate(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, cephRBDImageSnapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotProtect(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, cephRBDImageSnapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	logger.Debugf("Created RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ImageDelete(fingerprint string) error {
+	logger.Debugf("Deleting RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	_, err := s.ImageUmount(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	if cephRBDVolumeExists(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, s.userName) {
+		clones, err := cephRBDSnapshotListClones(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, cephRBDImageSnapshotName, s.userName)
+		if err != nil {
+			return err
+		}
+
+		if len(clones) > 0 {
+			// Containers still depend on the image. Keep it
+			// around until the last of them is gone.
+			zombieType := fmt.Sprintf("%s_%s", cephRBDZombiePrefix, storagePoolVolumeAPIEndpointImages)
+			err = cephRBDVolumeRename(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, fingerprint, zombieType, s.userName)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = cephRBDSnapshotUnprotect(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, cephRBDImageSnapshotName, s.userName)
+			if err != nil {
+				return err
+			}
+
+			err = cephRBDVolumeDelete(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeAPIEndpointImages, s.userName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = s.deleteImageDbPoolVolume(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if shared.PathExists(imageMntPoint) {
+		err := os.Remove(imageMntPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debugf("Deleted RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ImageMount(fingerprint string) (bool, error) {
+	logger.Debugf("Mounting RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if shared.IsMountPoint(imageMntPoint) {
+		return false, nil
+	}
+
+	err := s.rbdMount(fingerprint, storagePoolVolumeAPIEndpointImages, "", imageMntPoint, s.getRBDMountOptions())
+	if err != nil {
+		logger.Errorf("Error mounting image RBD storage volume for unpacking: %s", err)
+		return false, fmt.Errorf("Error mounting image RBD storage volume: %v", err)
+	}
+
+	logger.Debugf("Mounted RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return true, nil
+}
+
+func (s *storageCeph) ImageUmount(fingerprint string) (bool, error) {
+	logger.Debugf("Unmounting RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if !shared.IsMountPoint(imageMntPoint) {
+		return false, nil
+	}
+
+	err := s.rbdUmount(fingerprint, storagePoolVolumeAPIEndpointImages, "", imageMntPoint)
+	if err != nil {
+		return false, err
+	}
+
+	logger.Debugf("Unmounted RBD storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return true, nil
+}
+
+func (s *storageCeph) MigrationType() MigrationFSType {
+	return MigrationFSType_RSYNC
+}
+
+func (s *storageCeph) PreservesInodes() bool {
+	return false
+}
+
+func (s *storageCeph) MigrationSource(container container, containerOnly bool) (MigrationStorageSourceDriver, error) {
+	return rsyncMigrationSource(container, containerOnly)
+}
+
+func (s *storageCeph) MigrationSink(live bool, container container, snapshots []*Snapshot, conn *websocket.Conn, srcIdmap *shared.IdmapSet, op *operation, containerOnly bool) error {
+	return rsyncMigrationSink(live, container, snapshots, conn, srcIdmap, op, containerOnly)
+}
+
+func (s *storageCeph) StorageEntitySetQuota(volumeType int, size int64, data interface{}) error {
+	logger.Debugf(`Setting RBD quota for "%s"`, s.volume.Name)
+
+	if !shared.IntInSlice(volumeType, supportedVolumeTypes) {
+		return fmt.Errorf("Invalid storage type")
+	}
+
+	var c container
+	fsType := s.getRBDFilesystem()
+	volumeName := ""
+	volumeTypeName := ""
+	mountpoint := ""
+	switch volumeType {
+	case storagePoolVolumeTypeContainer:
+		c = data.(container)
+		ctName := c.Name()
+		if c.IsRunning() {
+			msg := fmt.Sprintf(`Cannot resize RBD storage volume `+
+				`for container \"%s\" when it is running`,
+				ctName)
+			logger.Errorf(msg)
+			return fmt.Errorf(msg)
+		}
+
+		volumeName = ctName
+		volumeTypeName = storagePoolVolumeAPIEndpointContainers
+		mountpoint = getContainerMountPoint(s.pool.Name, ctName)
+	default:
+		volumeName = s.volume.Name
+		volumeTypeName = storagePoolVolumeAPIEndpointCustom
+		mountpoint = getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	}
+
+	oldSize, err := shared.ParseByteSizeString(s.volume.Config["size"])
+	if err != nil {
+		return err
+	}
+
+	// The right disjunct just means that someone unset the size property in
+	// the container's config. We obviously cannot resize to 0.
+	if oldSize == size || size == 0 {
+		return nil
+	}
+
+	if size < oldSize {
+		// Shrinking requires the filesystem to be unmounted.
+		switch volumeType {
+		case storagePoolVolumeTypeContainer:
+			ourUmount, err := c.StorageStop()
+			if err != nil {
+				return err
+			}
+			if ourUmount {
+				defer c.StorageStart()
+			}
+		case storagePoolVolumeTypeCustom:
+			ourUmount, err := s.StoragePoolVolumeUmount()
+			if err != nil {
+				return err
+			}
+			if ourUmount {
+				defer s.StoragePoolVolumeMount()
+			}
+		}
+
+		err = s.rbdShrink(volumeName, volumeTypeName, size, fsType)
+	} else {
+		// Growing requires the filesystem to be mounted.
+		switch volumeType {
+		case storagePoolVolumeTypeContainer:
+			ourMount, err := c.StorageStart()
+			if err != nil {
+				return err
+			}
+			if ourMount {
+				defer c.StorageStop()
+			}
+		case storagePoolVolumeTypeCustom:
+			ourMount, err := s.StoragePoolVolumeMount()
+			if err != nil {
+				return err
+			}
+			if ourMount {
+				defer s.StoragePoolVolumeUmount()
+			}
+		}
+
+		err = s.rbdGrow(volumeName, volumeTypeName, size, fsType, mountpoint)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Update the database
+	s.volume.Config["size"] = shared.GetByteSizeString(size, 0)
+	err = dbStoragePoolVolumeUpdate(
+		s.d.db,
+		s.volume.Name,
+		volumeType,
+		s.poolID,
+		s.volume.Description,
+		s.volume.Config)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf(`Set RBD quota for "%s"`, s.volume.Name)
+	return nil
+}
@@ -0,0 +1,796 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// cephRBDImageSnapshotName is the name of the protected RBD snapshot of an
+// image volume which container volumes are cloned from.
+const cephRBDImageSnapshotName = "readonly"
+
+// cephRBDZombiePrefix is prepended to the RBD name of image volumes that have
+// been deleted while containers still depend on them.
+const cephRBDZombiePrefix = "zombie"
+
+// cephOSDPoolExists checks whether a given OSD pool exists.
+func cephOSDPoolExists(clusterName string, poolName string, userName string) bool {
+	_, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", userName),
+		"--cluster", clusterName,
+		"osd",
+		"pool",
+		"get",
+		poolName,
+		"size")
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+// cephOSDPoolCreate creates an OSD pool and tags it for use by RBD.
+func cephOSDPoolCreate(clusterName string, poolName string, pgNum string, userName string) error {
+	msg, err := shared.TryRunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", userName),
+		"--cluster", clusterName,
+		"osd",
+		"pool",
+		"create",
+		poolName,
+		pgNum)
+	if err != nil {
+		return fmt.Errorf("failed to create CEPH OSD pool \"%s\" in cluster \"%s\": %s", poolName, clusterName, msg)
+	}
+
+	// Older clusters don't know about pool applications so don't treat
+	// this as fatal.
+	msg, err = shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", userName),
+		"--cluster", clusterName,
+		"osd",
+		"pool",
+		"application",
+		"enable",
+		poolName,
+		"rbd")
+	if err != nil {
+		logger.Warnf("Failed to enable RBD application on CEPH OSD pool \"%s\": %s.", poolName, msg)
+	}
+
+	return nil
+}
+
+// cephOSDPoolDestroy destroys an OSD pool including any storage volumes that
+// still exist in it. The command succeeds even if the OSD pool doesn't exist so
+// callers that care need to check for its existence first.
+func cephOSDPoolDestroy(clusterName string, poolName string, userName string) error {
+	msg, err := shared.TryRunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", userName),
+		"--cluster", clusterName,
+		"osd",
+		"pool",
+		"delete",
+		poolName,
+		poolName,
+		"--yes-i-really-really-mean-it")
+	if err != nil {
+		return fmt.Errorf("failed to delete CEPH OSD pool \"%s\" in cluster \"%s\": %s", poolName, clusterName, msg)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeCount returns the number of RBD storage volumes in an OSD pool.
+func cephRBDVolumeCount(clusterName string, poolName string, userName string) (int, error) {
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"--pool", poolName,
+		"ls")
+	if err != nil {
+		return -1, fmt.Errorf("failed to list RBD storage volumes in CEPH OSD pool \"%s\": %s", poolName, msg)
+	}
+
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return 0, nil
+	}
+
+	return len(strings.Split(msg, "\n")), nil
+}
+
+// getRBDVolumeName returns the on-disk name of an RBD storage volume, e.g.
+// "containers_c1" or "images_<fingerprint>".
+func getRBDVolumeName(volumeName string, volumeType string) string {
+	return fmt.Sprintf("%s_%s", volumeType, volumeName)
+}
+
+// getRBDSnapshotName returns the name of the RBD snapshot backing a container
+// or storage volume snapshot.
+func getRBDSnapshotName(snapshotName string) string {
+	return fmt.Sprintf("snapshot_%s", snapshotName)
+}
+
+// getRBDImageSpec returns the "<pool>/<volume>[@<snapshot>]" image
+// specification understood by the rbd tool.
+func getRBDImageSpec(poolName string, volumeName string, volumeType string, snapshotName string) string {
+	spec := fmt.Sprintf("%s/%s", poolName, getRBDVolumeName(volumeName, volumeType))
+	if snapshotName != "" {
+		spec = fmt.Sprintf("%s@%s", spec, snapshotName)
+	}
+
+	return spec
+}
+
+// cephRBDVolumeCreate creates an RBD storage volume. Only the "layering"
+// feature is enabled since it is the only one all kernel clients support.
+func cephRBDVolumeCreate(clusterName string, poolName string, volumeName string, volumeType string, size int64, userName string) error {
+	msg, err := shared.TryRunCommand(
+		"rbd",
+		"--id", userName,
+		"--image-feature", "layering",
+		"--cluster", clusterName,
+		"--pool", poolName,
+		"--size", fmt.Sprintf("%dM", size/1024/1024),
+		"create",
+		getRBDVolumeName(volumeName, volumeType))
+	if err != nil {
+		return fmt.Errorf("failed to create RBD storage volume \"%s\": %s", volumeName, msg)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeExists checks whether a given RBD storage volume exists.
+func cephRBDVolumeExists(clusterName string, poolName string, volumeName string, volumeType string, userName string) bool {
+	_, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"--pool", poolName,
+		"info",
+		getRBDVolumeName(volumeName, volumeType))
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+// cephRBDVolumeDelete deletes an RBD storage volume including all of its
+// unprotected snapshots.
+func cephRBDVolumeDelete(clusterName string, poolName string, volumeName string, volumeType string, userName string) error {
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, "")
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"snap",
+		"purge",
+		imageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshots of RBD storage volume \"%s\": %s", imageSpec, msg)
+	}
+
+	msg, err = shared.TryRunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"rm",
+		imageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to delete RBD storage volume \"%s\": %s", imageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeRename renames an RBD storage volume.
+func cephRBDVolumeRename(clusterName string, poolName string, oldVolumeName string, oldVolumeType string, newVolumeName string, newVolumeType string, userName string) error {
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"--pool", poolName,
+		"mv",
+		getRBDVolumeName(oldVolumeName, oldVolumeType),
+		getRBDVolumeName(newVolumeName, newVolumeType))
+	if err != nil {
+		return fmt.Errorf("failed to rename RBD storage volume \"%s\" to \"%s\": %s", oldVolumeName, newVolumeName, msg)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeResize grows or shrinks an RBD storage volume. The filesystem
+// on top of it has to be dealt with by the caller.
+func cephRBDVolumeResize(clusterName string, poolName string, volumeName string, volumeType string, size int64, userName string) error {
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"--pool", poolName,
+		"--size", fmt.Sprintf("%dM", size/1024/1024),
+		"--allow-shrink",
+		"resize",
+		getRBDVolumeName(volumeName, volumeType))
+	if err != nil {
+		return fmt.Errorf("failed to resize RBD storage volume \"%s\": %s", volumeName, msg)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeGetParent returns the "<pool>/<volume>@<snapshot>" the given
+// RBD storage volume has been cloned from or "" if it isn't a clone.
+func cephRBDVolumeGetParent(clusterName string, poolName string, volumeName string, volumeType string, userName string) (string, error) {
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"--pool", poolName,
+		"info",
+		getRBDVolumeName(volumeName, volumeType))
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve information for RBD storage volume \"%s\": %s", volumeName, msg)
+	}
+
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "parent:") {
+			continue
+		}
+
+		return strings.TrimSpace(strings.TrimPrefix(line, "parent:")), nil
+	}
+
+	return "", nil
+}
+
+// cephRBDVolumeMap maps an RBD storage volume or snapshot and returns the
+// path to the block device. If the storage volume is already mapped the
+// existing block device is returned.
+func cephRBDVolumeMap(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) (string, error) {
+	devPath, err := cephRBDVolumeGetMappedDev(clusterName, poolName, volumeName, volumeType, snapshotName, userName)
+	if err != nil {
+		return "", err
+	}
+
+	if devPath != "" {
+		return devPath, nil
+	}
+
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, snapshotName)
+	msg, err := shared.TryRunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"map",
+		imageSpec)
+	if err != nil {
+		return "", fmt.Errorf("failed to map RBD storage volume \"%s\": %s", imageSpec, msg)
+	}
+
+	devPath = strings.TrimSpace(msg)
+	if !strings.HasPrefix(devPath, "/dev/rbd") {
+		return "", fmt.Errorf("unexpected output when mapping RBD storage volume \"%s\": %s", imageSpec, msg)
+	}
+
+	return devPath, nil
+}
+
+// cephRBDVolumeUnmap unmaps an RBD storage volume or snapshot. It is a noop
+// if the storage volume isn't mapped.
+func cephRBDVolumeUnmap(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	devPath, err := cephRBDVolumeGetMappedDev(clusterName, poolName, volumeName, volumeType, snapshotName, userName)
+	if err != nil {
+		return err
+	}
+
+	if devPath == "" {
+		return nil
+	}
+
+	msg, err := shared.TryRunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"unmap",
+		devPath)
+	if err != nil {
+		return fmt.Errorf("failed to unmap RBD storage volume \"%s\": %s", devPath, msg)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeGetMappedDev returns the block device an RBD storage volume or
+// snapshot is mapped to or "" if it isn't mapped.
+func cephRBDVolumeGetMappedDev(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) (string, error) {
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"showmapped")
+	if err != nil {
+		return "", fmt.Errorf("failed to list mapped RBD storage volumes: %s", msg)
+	}
+
+	rbdName := getRBDVolumeName(volumeName, volumeType)
+	if snapshotName == "" {
+		snapshotName = "-"
+	}
+
+	// The output has the columns "id pool [namespace] image snap device"
+	// where the namespace column is only present (and potentially empty)
+	// on newer versions. So index from the end.
+	for _, line := range strings.Split(msg, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] == "id" {
+			continue
+		}
+
+		n := len(fields)
+		if fields[1] != poolName || fields[n-3] != rbdName || fields[n-2] != snapshotName {
+			continue
+		}
+
+		return fields[n-1], nil
+	}
+
+	return "", nil
+}
+
+// cephRBDSnapshotCreate creates a read-only snapshot of an RBD storage volume.
+func cephRBDSnapshotCreate(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, snapshotName)
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"snap",
+		"create",
+		imageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to create RBD snapshot \"%s\": %s", imageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotDelete deletes an unprotected RBD snapshot.
+func cephRBDSnapshotDelete(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, snapshotName)
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"snap",
+		"rm",
+		imageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to delete RBD snapshot \"%s\": %s", imageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotRename renames an RBD snapshot.
+func cephRBDSnapshotRename(clusterName string, poolName string, volumeName string, volumeType string, oldSnapshotName string, newSnapshotName string, userName string) error {
+	oldImageSpec := getRBDImageSpec(poolName, volumeName, volumeType, oldSnapshotName)
+	newImageSpec := getRBDImageSpec(poolName, volumeName, volumeType, newSnapshotName)
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"snap",
+		"rename",
+		oldImageSpec,
+		newImageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to rename RBD snapshot \"%s\" to \"%s\": %s", oldImageSpec, newImageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotRollback reverts an RBD storage volume to the given snapshot.
+// The storage volume must not be mapped.
+func cephRBDSnapshotRollback(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, snapshotName)
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"snap",
+		"rollback",
+		imageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to rollback RBD storage volume to snapshot \"%s\": %s", imageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotProtect protects an RBD snapshot so that it can be cloned.
+func cephRBDSnapshotProtect(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, snapshotName)
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"snap",
+		"protect",
+		imageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to protect RBD snapshot \"%s\": %s", imageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotUnprotect unprotects an RBD snapshot. This fails if the
+// snapshot still has clones.
+func cephRBDSnapshotUnprotect(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, snapshotName)
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"snap",
+		"unprotect",
+		imageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to unprotect RBD snapshot \"%s\": %s", imageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotListClones returns the "<pool>/<volume>" of all clones of
+// the given RBD snapshot.
+func cephRBDSnapshotListClones(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) ([]string, error) {
+	imageSpec := getRBDImageSpec(poolName, volumeName, volumeType, snapshotName)
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"children",
+		imageSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clones of RBD snapshot \"%s\": %s", imageSpec, msg)
+	}
+
+	clones := []string{}
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		clones = append(clones, line)
+	}
+
+	return clones, nil
+}
+
+// cephRBDCloneCreate creates a clone of a protected RBD snapshot.
+func cephRBDCloneCreate(clusterName string, sourcePoolName string, sourceVolumeName string, sourceVolumeType string, sourceSnapshotName string, targetPoolName string, targetVolumeName string, targetVolumeType string, userName string) error {
+	sourceImageSpec := getRBDImageSpec(sourcePoolName, sourceVolumeName, sourceVolumeType, sourceSnapshotName)
+	targetImageSpec := getRBDImageSpec(targetPoolName, targetVolumeName, targetVolumeType, "")
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", userName,
+		"--cluster", clusterName,
+		"--image-feature", "layering",
+		"clone",
+		sourceImageSpec,
+		targetImageSpec)
+	if err != nil {
+		return fmt.Errorf("failed to clone RBD snapshot \"%s\" to \"%s\": %s", sourceImageSpec, targetImageSpec, msg)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeMakeFS creates a filesystem on a mapped RBD storage volume.
+func cephRBDVolumeMakeFS(devPath string, fsType string) error {
+	var msg string
+	var err error
+
+	switch fsType {
+	case "xfs":
+		msg, err = shared.TryRunCommand("mkfs.xfs", devPath)
+	default:
+		// default = ext4
+		msg, err = shared.TryRunCommand(
+			"mkfs.ext4",
+			"-E", "nodiscard,lazy_itable_init=0,lazy_journal_init=0",
+			devPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s filesystem on \"%s\": %s", fsType, devPath, msg)
+	}
+
+	return nil
+}
+
+func (s *storageCeph) getRBDMountOptions() string {
+	if s.volume.Config["block.mount_options"] != "" {
+		return s.volume.Config["block.mount_options"]
+	}
+
+	if s.pool.Config["volume.block.mount_options"] != "" {
+		return s.pool.Config["volume.block.mount_options"]
+	}
+
+	return "discard"
+}
+
+// getRBDSnapshotMountOptions returns the options needed to mount a read-only
+// mapped RBD snapshot. Journal replay and log recovery have to be skipped
+// since the block device can't be written to.
+func (s *storageCeph) getRBDSnapshotMountOptions() string {
+	mountOptions := fmt.Sprintf("%s,ro", s.getRBDMountOptions())
+	if s.getRBDFilesystem() == "xfs" {
+		return fmt.Sprintf("%s,norecovery,nouuid", mountOptions)
+	}
+
+	return fmt.Sprintf("%s,noload", mountOptions)
+}
+
+func (s *storageCeph) getRBDFilesystem() string {
+	if s.volume.Config["block.filesystem"] != "" {
+		return s.volume.Config["block.filesystem"]
+	}
+
+	if s.pool.Config["volume.block.filesystem"] != "" {
+		return s.pool.Config["volume.block.filesystem"]
+	}
+
+	return "ext4"
+}
+
+func (s *storageCeph) getRBDSize() (int64, error) {
+	sz, err := shared.ParseByteSizeString(s.volume.Config["size"])
+	if err != nil {
+		return -1, err
+	}
+
+	// Safety net: Set to default value.
+	if sz == 0 {
+		sz, _ = shared.ParseByteSizeString("10GB")
+	}
+
+	return sz, nil
+}
+
+// rbdCreateVolume creates, maps and formats an RBD storage volume. The
+// storage volume is left unmapped.
+func (s *storageCeph) rbdCreateVolume(volumeName string, volumeType string) error {
+	size, err := s.getRBDSize()
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDVolumeCreate(s.clusterName, s.osdPoolName, volumeName, volumeType, size, s.userName)
+	if err != nil {
+		return err
+	}
+
+	devPath, err := cephRBDVolumeMap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+	if err != nil {
+		cephRBDVolumeDelete(s.clusterName, s.osdPoolName, volumeName, volumeType, s.userName)
+		return err
+	}
+	defer cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+
+	err = cephRBDVolumeMakeFS(devPath, s.getRBDFilesystem())
+	if err != nil {
+		cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+		cephRBDVolumeDelete(s.clusterName, s.osdPoolName, volumeName, volumeType, s.userName)
+		return err
+	}
+
+	return nil
+}
+
+// rbdImageDeleteZombie removes the zombie image volume the given RBD storage
+// volume was cloned from once its last clone is gone.
+func (s *storageCeph) rbdImageDeleteZombie(parent string) error {
+	prefix := fmt.Sprintf("%s/%s_%s_", s.osdPoolName, cephRBDZombiePrefix, storagePoolVolumeAPIEndpointImages)
+	suffix := fmt.Sprintf("@%s", cephRBDImageSnapshotName)
+	if !strings.HasPrefix(parent, prefix) || !strings.HasSuffix(parent, suffix) {
+		return nil
+	}
+
+	fingerprint := strings.TrimSuffix(strings.TrimPrefix(parent, prefix), suffix)
+	zombieType := fmt.Sprintf("%s_%s", cephRBDZombiePrefix, storagePoolVolumeAPIEndpointImages)
+	clones, err := cephRBDSnapshotListClones(s.clusterName, s.osdPoolName, fingerprint, zombieType, cephRBDImageSnapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	if len(clones) > 0 {
+		return nil
+	}
+
+	err = cephRBDSnapshotUnprotect(s.clusterName, s.osdPoolName, fingerprint, zombieType, cephRBDImageSnapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	return cephRBDVolumeDelete(s.clusterName, s.osdPoolName, fingerprint, zombieType, s.userName)
+}
+
+// rbdGrow extends an RBD storage volume and the filesystem on top of it. The
+// filesystem must be mounted at fsMntPoint if it is xfs.
+func (s *storageCeph) rbdGrow(volumeName string, volumeType string, size int64, fsType string, fsMntPoint string) error {
+	err := cephRBDVolumeResize(s.clusterName, s.osdPoolName, volumeName, volumeType, size, s.userName)
+	if err != nil {
+		return err
+	}
+
+	devPath, err := cephRBDVolumeGetMappedDev(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+	if err != nil {
+		return err
+	}
+
+	var msg string
+	switch fsType {
+	case "xfs":
+		msg, err = shared.TryRunCommand("xfs_growfs", fsMntPoint)
+	default:
+		// default = ext4
+		msg, err = shared.TryRunCommand("resize2fs", devPath)
+	}
+	if err != nil {
+		return fmt.Errorf("could not extend underlying %s filesystem for RBD storage volume \"%s\": %s", fsType, volumeName, msg)
+	}
+
+	logger.Debugf("extended underlying %s filesystem for RBD storage volume \"%s\"", fsType, volumeName)
+	return nil
+}
+
+// rbdShrink reduces the filesystem on top of an unmounted RBD storage volume
+// and then the storage volume itself.
+func (s *storageCeph) rbdShrink(volumeName string, volumeType string, size int64, fsType string) error {
+	if fsType == "xfs" {
+		return fmt.Errorf("xfs filesystems cannot be shrunk: dump, mkfs, and restore are required")
+	}
+
+	devPath, err := cephRBDVolumeMap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+	if err != nil {
+		return err
+	}
+	defer cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+
+	msg, err := shared.TryRunCommand("e2fsck", "-f", "-y", devPath)
+	if err != nil {
+		return fmt.Errorf("could not check filesystem of RBD storage volume \"%s\": %s", volumeName, msg)
+	}
+
+	// don't assume resize2fs semantics are sane (because they aren't)
+	kbSize := strconv.FormatInt(size/1024, 10) + "K"
+	msg, err = shared.TryRunCommand("resize2fs", devPath, kbSize)
+	if err != nil {
+		return fmt.Errorf("could not reduce underlying %s filesystem for RBD storage volume \"%s\": %s", fsType, volumeName, msg)
+	}
+
+	err = cephRBDVolumeResize(s.clusterName, s.osdPoolName, volumeName, volumeType, size, s.userName)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("reduced underlying %s filesystem for RBD storage volume \"%s\"", fsType, volumeName)
+	return nil
+}
+
+// rbdMount maps an RBD storage volume or snapshot and mounts it.
+func (s *storageCeph) rbdMount(volumeName string, volumeType string, snapshotName string, mountPoint string, mountOptions string) error {
+	devPath, err := cephRBDVolumeMap(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	mountFlags, mountOptions := lxdResolveMountoptions(mountOptions)
+	err = tryMount(devPath, mountPoint, s.getRBDFilesystem(), mountFlags, mountOptions)
+	if err != nil {
+		cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+		return err
+	}
+
+	return nil
+}
+
+// rbdUmount unmounts an RBD storage volume or snapshot if it is mounted and
+// unmaps it.
+func (s *storageCeph) rbdUmount(volumeName string, volumeType string, snapshotName string, mountPoint string) error {
+	if shared.IsMountPoint(mountPoint) {
+		err := tryUnmount(mountPoint, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+}
+
+// rbdSnapshotCreate creates an RBD snapshot of a storage volume. If the
+// storage volume is mounted its filesystem is frozen so that the snapshot is
+// consistent.
+func (s *storageCeph) rbdSnapshotCreate(volumeName string, volumeType string, snapshotName string, mountPoint string) error {
+	if shared.IsMountPoint(mountPoint) {
+		msg, err := shared.RunCommand("fsfreeze", "--freeze", mountPoint)
+		if err != nil {
+			return fmt.Errorf("failed to freeze filesystem at \"%s\": %s", mountPoint, msg)
+		}
+		defer shared.RunCommand("fsfreeze", "--unfreeze", mountPoint)
+	}
+
+	return cephRBDSnapshotCreate(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+}
+
+func (s *storageCeph) createSnapshotMountpoint(snapshotContainer container) error {
+	snapshotName := snapshotContainer.Name()
+	sourceName, _, _ := containerGetParentAndSnapshotName(snapshotName)
+	snapshotMntPoint := getSnapshotMountPoint(s.pool.Name, snapshotName)
+	snapshotMntPointSymlinkTarget := shared.VarPath("storage-pools", s.pool.Name, "snapshots", sourceName)
+	snapshotMntPointSymlink := shared.VarPath("snapshots", sourceName)
+	return createSnapshotMountpoint(snapshotMntPoint, snapshotMntPointSymlinkTarget, snapshotMntPointSymlink)
+}
+
+// rsyncContainer syncs the content of a container or snapshot into the
+// storage volume of another container.
+func (s *storageCeph) rsyncContainer(target container, source container) error {
+	targetName := target.Name()
+	targetStart, err := target.StorageStart()
+	if err != nil {
+		return err
+	}
+	if targetStart {
+		defer target.StorageStop()
+	}
+
+	sourceName := source.Name()
+	sourceStart, err := source.StorageStart()
+	if err != nil {
+		return err
+	}
+	if sourceStart {
+		defer source.StorageStop()
+	}
+
+	sourceContainerMntPoint := getContainerMountPoint(s.pool.Name, sourceName)
+	if source.IsSnapshot() {
+		sourceContainerMntPoint = getSnapshotMountPoint(s.pool.Name, sourceName)
+	}
+	targetContainerMntPoint := getContainerMountPoint(s.pool.Name, targetName)
+
+	if source.IsRunning() {
+		err = source.Freeze()
+		if err != nil {
+			return err
+		}
+		defer source.Unfreeze()
+	}
+
+	bwlimit := s.pool.Config["rsync.bwlimit"]
+	output, err := rsyncLocalCopy(sourceContainerMntPoint, targetContainerMntPoint, bwlimit)
+	if err != nil {
+		return fmt.Errorf("failed to rsync container: %s: %s", string(output), err)
+	}
+
+	return nil
+}
@@ -158,6 +158,10 @@ func (s *storageDir) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
 
+func (s *storageDir) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	return storageResourcesStatfs(getStoragePoolMountPoint(s.pool.Name))
+}
+
 func (s *storageDir) StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error {
 	if shared.StringInSlice("rsync.bwlimit", changedConfig) {
 		return nil
@@ -230,6 +234,75 @@ func (s *storageDir) StoragePoolVolumeUpdate(writable *api.StorageVolumePut, cha
 	return fmt.Errorf("dir storage properties cannot be changed")
 }
 
+func (s *storageDir) StoragePoolVolumeSnapshotCreate(snapshotName string) error {
+	logger.Infof("Creating DIR storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	err := createStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	storageVolumePath := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	bwlimit := s.pool.Config["rsync.bwlimit"]
+	output, err := rsyncLocalCopy(storageVolumePath, snapshotMntPoint, bwlimit)
+	if err != nil {
+		os.RemoveAll(snapshotMntPoint)
+		deleteStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+		return fmt.Errorf("failed to rsync storage volume: %s: %s", string(output), err)
+	}
+
+	logger.Infof("Created DIR storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageDir) StoragePoolVolumeSnapshotDelete(snapshotName string) error {
+	logger.Infof("Deleting DIR storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	err := os.RemoveAll(snapshotMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = deleteStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Deleted DIR storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageDir) StoragePoolVolumeSnapshotRename(snapshotName string, newName string) error {
+	logger.Infof("Renaming DIR storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+
+	oldSnapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	newSnapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, newName)
+	err := os.Rename(oldSnapshotMntPoint, newSnapshotMntPoint)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Renamed DIR storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
+func (s *storageDir) StoragePoolVolumeSnapshotRestore(snapshotName string) error {
+	logger.Infof("Restoring DIR storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	storageVolumePath := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	bwlimit := s.pool.Config["rsync.bwlimit"]
+	output, err := rsyncLocalCopy(snapshotMntPoint, storageVolumePath, bwlimit)
+	if err != nil {
+		return fmt.Errorf("failed to rsync storage volume: %s: %s", string(output), err)
+	}
+
+	logger.Infof("Restored DIR storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
 func (s *storageDir) ContainerStorageReady(name string) bool {
 	containerMntPoint := getContainerMountPoint(s.pool.Name, name)
 	ok, _ := shared.PathIsEmpty(containerMntPoint)
@@ -678,6 +751,38 @@ func (s *storageDir) ContainerSnapshotCreateEmpty(snapshotContainer container) e
 	return nil
 }
 
+func (s *storageDir) ContainerBackupCreate(path string, backup backup, sourceContainer container) error {
+	logger.Debugf("Creating backup of DIR storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+
+	if backup.OptimizedStorage() {
+		return fmt.Errorf("Optimized backups are not supported by the dir storage driver")
+	}
+
+	err := rsyncBackupCreate(path, backup, sourceContainer)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Created backup of DIR storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+	return nil
+}
+
+func (s *storageDir) ContainerBackupLoad(container container, snapshots []containerArgs, path string, optimized bool) error {
+	logger.Debugf("Loading backup into DIR storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+
+	if optimized {
+		return fmt.Errorf("Optimized backups can't be restored by the dir storage driver")
+	}
+
+	err := rsyncBackupLoad(container, snapshots, path)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Loaded backup into DIR storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+	return nil
+}
+
 func (s *storageDir) ContainerSnapshotDelete(snapshotContainer container) error {
 	logger.Debugf("Deleting DIR storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/websocket"
@@ -668,6 +669,59 @@ func (s *storageLvm) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
 
+func (s *storageLvm) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	res := api.ResourcesStoragePool{}
+
+	// Thin pools only make part of the volume group available
+	if s.useThinpool {
+		output, err := shared.TryRunCommand("lvs", "--noheadings", "--nosuffix", "--units", "b", "-o", "lv_size,data_percent", fmt.Sprintf("%s/%s", s.getOnDiskPoolName(), s.getLvmThinpoolName()))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the size of the LVM thin pool: %s", output)
+		}
+
+		fields := strings.Fields(output)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Unexpected output from lvs: %s", output)
+		}
+
+		res.Space.Total, err = strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		percent, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		res.Space.Used = uint64(float64(res.Space.Total) * percent / 100)
+
+		return &res, nil
+	}
+
+	output, err := shared.TryRunCommand("vgs", "--noheadings", "--nosuffix", "--units", "b", "-o", "vg_size,vg_free", s.getOnDiskPoolName())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the size of the LVM volume group: %s", output)
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("Unexpected output from vgs: %s", output)
+	}
+
+	res.Space.Total, err = strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	free, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	res.Space.Used = res.Space.Total - free
+
+	return &res, nil
+}
+
 func (s *storageLvm) StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error {
 	logger.Infof("Updating LVM storage pool \"%s\".", s.pool.Name)
 
@@ -810,6 +864,166 @@ func (s *storageLvm) StoragePoolVolumeUpdate(writable *api.StorageVolumePut, cha
 	return nil
 }
 
+func (s *storageLvm) StoragePoolVolumeSnapshotCreate(snapshotName string) error {
+	logger.Infof("Creating LVM storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	poolName := s.getOnDiskPoolName()
+	snapshotLvmName := getCustomSnapshotLVName(s.volume.Name, snapshotName)
+	_, err := s.createSnapshotLV(poolName, s.volume.Name, storagePoolVolumeAPIEndpointCustom, snapshotLvmName, lvmVolumeTypeCustomSnapshots, true, s.useThinpool)
+	if err != nil {
+		return fmt.Errorf("Error creating snapshot LV: %s", err)
+	}
+
+	err = createStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+	if err != nil {
+		s.removeLV(poolName, lvmVolumeTypeCustomSnapshots, snapshotLvmName)
+		return err
+	}
+
+	logger.Infof("Created LVM storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageLvm) StoragePoolVolumeSnapshotDelete(snapshotName string) error {
+	logger.Infof("Deleting LVM storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	if shared.IsMountPoint(snapshotMntPoint) {
+		err := tryUnmount(snapshotMntPoint, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	poolName := s.getOnDiskPoolName()
+	snapshotLvmName := getCustomSnapshotLVName(s.volume.Name, snapshotName)
+	err := s.removeLV(poolName, lvmVolumeTypeCustomSnapshots, snapshotLvmName)
+	if err != nil {
+		return err
+	}
+
+	err = deleteStoragePoolVolumeSnapshotMountpoint(s.pool.Name, s.volume.Name, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Deleted LVM storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageLvm) StoragePoolVolumeSnapshotRename(snapshotName string, newName string) error {
+	logger.Infof("Renaming LVM storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+
+	oldLvmName := getCustomSnapshotLVName(s.volume.Name, snapshotName)
+	newLvmName := getCustomSnapshotLVName(s.volume.Name, newName)
+	err := s.renameLVByPath(oldLvmName, newLvmName, lvmVolumeTypeCustomSnapshots)
+	if err != nil {
+		return fmt.Errorf("Failed to rename a storage volume snapshot LV, oldName='%s', newName='%s', err='%s'", oldLvmName, newLvmName, err)
+	}
+
+	oldSnapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+	newSnapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, newName)
+	err = os.Rename(oldSnapshotMntPoint, newSnapshotMntPoint)
+	if err != nil {
+		s.renameLVByPath(newLvmName, oldLvmName, lvmVolumeTypeCustomSnapshots)
+		return err
+	}
+
+	logger.Infof("Renamed LVM storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
+func (s *storageLvm) StoragePoolVolumeSnapshotRestore(snapshotName string) error {
+	logger.Infof("Restoring LVM storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	poolName := s.getOnDiskPoolName()
+	lvFsType := s.getLvmFilesystem()
+	snapshotLvmName := getCustomSnapshotLVName(s.volume.Name, snapshotName)
+
+	if s.useThinpool {
+		// Replace the storage volume with a writable snapshot of the
+		// snapshot.
+		_, err := s.StoragePoolVolumeUmount()
+		if err != nil {
+			return err
+		}
+
+		err = s.removeLV(poolName, storagePoolVolumeAPIEndpointCustom, s.volume.Name)
+		if err != nil {
+			logger.Errorf("Failed to remove \"%s\": %s.", s.volume.Name, err)
+		}
+
+		lvmVolumePath, err := s.createSnapshotLV(poolName, snapshotLvmName, lvmVolumeTypeCustomSnapshots, s.volume.Name, storagePoolVolumeAPIEndpointCustom, false, true)
+		if err != nil {
+			return fmt.Errorf("Error creating snapshot LV: %v", err)
+		}
+
+		// The snapshot and the new storage volume share the same
+		// UUID which XFS refuses to mount twice.
+		if lvFsType == "xfs" {
+			err := xfsGenerateNewUUID(lvmVolumePath)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = s.StoragePoolVolumeMount()
+		if err != nil {
+			return err
+		}
+	} else {
+		// Mount the snapshot and sync its content back into the
+		// storage volume.
+		snapshotLvmPath := getLvmDevPath(poolName, lvmVolumeTypeCustomSnapshots, snapshotLvmName)
+		wasWritableAtCheck, err := lvmLvIsWritable(snapshotLvmPath)
+		if err != nil {
+			return err
+		}
+
+		if !wasWritableAtCheck {
+			output, err := shared.TryRunCommand("lvchange", "-prw", fmt.Sprintf("%s/%s_%s", poolName, lvmVolumeTypeCustomSnapshots, snapshotLvmName))
+			if err != nil {
+				logger.Errorf("Failed to make LVM snapshot \"%s\" read-write: %s.", snapshotLvmName, output)
+				return err
+			}
+			defer shared.TryRunCommand("lvchange", "-pr", fmt.Sprintf("%s/%s_%s", poolName, lvmVolumeTypeCustomSnapshots, snapshotLvmName))
+		}
+
+		mountOptions := s.getLvmMountOptions()
+		if lvFsType == "xfs" {
+			mountOptions = fmt.Sprintf("%s,nouuid", mountOptions)
+		}
+
+		snapshotMntPoint := getStoragePoolVolumeSnapshotMountPoint(s.pool.Name, s.volume.Name, snapshotName)
+		if !shared.IsMountPoint(snapshotMntPoint) {
+			mountFlags, mountOptions := lxdResolveMountoptions(mountOptions)
+			err = tryMount(snapshotLvmPath, snapshotMntPoint, lvFsType, mountFlags, mountOptions)
+			if err != nil {
+				return fmt.Errorf("Error mounting snapshot LV path='%s': %s", snapshotMntPoint, err)
+			}
+			defer tryUnmount(snapshotMntPoint, 0)
+		}
+
+		ourMount, err := s.StoragePoolVolumeMount()
+		if err != nil {
+			return err
+		}
+		if ourMount {
+			defer s.StoragePoolVolumeUmount()
+		}
+
+		customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+		bwlimit := s.pool.Config["rsync.bwlimit"]
+		output, err := rsyncLocalCopy(snapshotMntPoint, customPoolVolumeMntPoint, bwlimit)
+		if err != nil {
+			return fmt.Errorf("failed to rsync storage volume: %s: %s", string(output), err)
+		}
+	}
+
+	logger.Infof("Restored LVM storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
 func (s *storageLvm) ContainerStorageReady(name string) bool {
 	containerLvmName := containerNameToLVName(name)
 	poolName := s.getOnDiskPoolName()
@@ -1482,6 +1696,38 @@ func (s *storageLvm) ContainerSnapshotCreateEmpty(snapshotContainer container) e
 	return nil
 }
 
+func (s *storageLvm) ContainerBackupCreate(path string, backup backup, sourceContainer container) error {
+	logger.Debugf("Creating backup of LVM storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+
+	if backup.OptimizedStorage() {
+		return fmt.Errorf("Optimized backups are not supported by the lvm storage driver")
+	}
+
+	err := rsyncBackupCreate(path, backup, sourceContainer)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Created backup of LVM storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+	return nil
+}
+
+func (s *storageLvm) ContainerBackupLoad(container container, snapshots []containerArgs, path string, optimized bool) error {
+	logger.Debugf("Loading backup into LVM storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+
+	if optimized {
+		return fmt.Errorf("Optimized backups can't be restored by the lvm storage driver")
+	}
+
+	err := rsyncBackupLoad(container, snapshots, path)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Loaded backup into LVM storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+	return nil
+}
+
 func (s *storageLvm) ImageCreate(fingerprint string) error {
 	logger.Debugf("Creating LVM storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
 
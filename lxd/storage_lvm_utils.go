@@ -689,7 +689,7 @@ func storageLVMGetThinPoolUsers(d *Daemon) ([]string, error) {
 		}
 	}
 
-	imageNames, err := dbImagesGet(d.db, false)
+	imageNames, err := dbImagesGetAllProjects(d.db)
 	if err != nil {
 		return results, err
 	}
@@ -767,6 +767,15 @@ func containerNameToLVName(containerName string) string {
 	return strings.Replace(lvName, shared.SnapshotDelimiter, "-", -1)
 }
 
+// lvmVolumeTypeCustomSnapshots is the LV prefix used for snapshots of custom
+// storage volumes. It cannot clash with the "custom_" prefix of the storage
+// volumes themselves.
+const lvmVolumeTypeCustomSnapshots = "custom-snapshots"
+
+func getCustomSnapshotLVName(volumeName string, snapshotName string) string {
+	return containerNameToLVName(fmt.Sprintf("%s%s%s", volumeName, shared.SnapshotDelimiter, snapshotName))
+}
+
 func getLvmDevPath(lvmPool string, volumeType string, lvmVolume string) string {
 	if volumeType == "" {
 		return fmt.Sprintf("/dev/%s/%s", lvmPool, lvmVolume)
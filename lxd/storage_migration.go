@@ -27,6 +27,12 @@ type MigrationStorageSourceDriver interface {
 	 */
 	SendAfterCheckpoint(conn *websocket.Conn, bwlimit string) error
 
+	/* restrict the transfer to what an existing copy of the container
+	 * is missing: the snapshots it doesn't have yet and the changes to
+	 * the container itself. This is called before anything is sent.
+	 */
+	Refresh(targetSnapshots []string)
+
 	/* Called after either success or failure of a migration, can be used
 	 * to clean up any temporary snapshots, etc.
 	 */
@@ -36,13 +42,14 @@ type MigrationStorageSourceDriver interface {
 type rsyncStorageSourceDriver struct {
 	container container
 	snapshots []container
+	rsyncArgs []string
 }
 
-func (s rsyncStorageSourceDriver) Snapshots() []container {
+func (s *rsyncStorageSourceDriver) Snapshots() []container {
 	return s.snapshots
 }
 
-func (s rsyncStorageSourceDriver) SendWhileRunning(conn *websocket.Conn, op *operation, bwlimit string, containerOnly bool) error {
+func (s *rsyncStorageSourceDriver) SendWhileRunning(conn *websocket.Conn, op *operation, bwlimit string, containerOnly bool) error {
 	ctName, _, _ := containerGetParentAndSnapshotName(s.container.Name())
 
 	if !containerOnly {
@@ -57,7 +64,7 @@ func (s rsyncStorageSourceDriver) SendWhileRunning(conn *websocket.Conn, op *ope
 
 			path := send.Path()
 			wrapper := StorageProgressReader(op, "fs_progress", send.Name())
-			err = RsyncSend(ctName, shared.AddSlash(path), conn, wrapper, bwlimit)
+			err = RsyncSend(ctName, shared.AddSlash(path), conn, wrapper, bwlimit, s.rsyncArgs...)
 			if err != nil {
 				return err
 			}
@@ -65,16 +72,23 @@ func (s rsyncStorageSourceDriver) SendWhileRunning(conn *websocket.Conn, op *ope
 	}
 
 	wrapper := StorageProgressReader(op, "fs_progress", s.container.Name())
-	return RsyncSend(ctName, shared.AddSlash(s.container.Path()), conn, wrapper, bwlimit)
+	return RsyncSend(ctName, shared.AddSlash(s.container.Path()), conn, wrapper, bwlimit, s.rsyncArgs...)
 }
 
-func (s rsyncStorageSourceDriver) SendAfterCheckpoint(conn *websocket.Conn, bwlimit string) error {
+func (s *rsyncStorageSourceDriver) SendAfterCheckpoint(conn *websocket.Conn, bwlimit string) error {
 	ctName, _, _ := containerGetParentAndSnapshotName(s.container.Name())
 	// resync anything that changed between our first send and the checkpoint
-	return RsyncSend(ctName, shared.AddSlash(s.container.Path()), conn, nil, bwlimit)
+	return RsyncSend(ctName, shared.AddSlash(s.container.Path()), conn, nil, bwlimit, s.rsyncArgs...)
+}
+
+func (s *rsyncStorageSourceDriver) Refresh(targetSnapshots []string) {
+	s.snapshots = migrationRefreshContainers(s.snapshots, targetSnapshots)
+
+	// The target holds an older copy, drop whatever was removed since
+	s.rsyncArgs = []string{"--delete"}
 }
 
-func (s rsyncStorageSourceDriver) Cleanup() {
+func (s *rsyncStorageSourceDriver) Cleanup() {
 	// noop
 }
 
@@ -88,7 +102,22 @@ func rsyncMigrationSource(c container, containerOnly bool) (MigrationStorageSour
 		}
 	}
 
-	return rsyncStorageSourceDriver{c, snapshots}, nil
+	return &rsyncStorageSourceDriver{container: c, snapshots: snapshots}, nil
+}
+
+// migrationRefreshContainers returns the snapshots which aren't among the
+// names of the target's snapshots, keeping their order.
+func migrationRefreshContainers(snapshots []container, targetSnapshots []string) []container {
+	missing := []container{}
+	for _, snap := range snapshots {
+		if shared.StringInSlice(shared.ExtractSnapshotName(snap.Name()), targetSnapshots) {
+			continue
+		}
+
+		missing = append(missing, snap)
+	}
+
+	return missing
 }
 
 func snapshotProtobufToContainerArgs(containerName string, snap *Snapshot) containerArgs {
@@ -121,7 +150,7 @@ func snapshotProtobufToContainerArgs(containerName string, snap *Snapshot) conta
 	}
 }
 
-func rsyncMigrationSink(live bool, container container, snapshots []*Snapshot, conn *websocket.Conn, srcIdmap *shared.IdmapSet, op *operation, containerOnly bool) error {
+func rsyncMigrationSink(live bool, container container, snapshots []*Snapshot, conn *websocket.Conn, srcIdmap *shared.IdmapSet, op *operation, containerOnly bool, rsyncArgs ...string) error {
 	ourStart, err := container.StorageStart()
 	if err != nil {
 		return err
@@ -168,7 +197,7 @@ func rsyncMigrationSink(live bool, container container, snapshots []*Snapshot, c
 				}
 
 				wrapper := StorageProgressWriter(op, "fs_progress", s.Name())
-				if err := RsyncRecv(shared.AddSlash(s.Path()), conn, wrapper); err != nil {
+				if err := RsyncRecv(shared.AddSlash(s.Path()), conn, wrapper, rsyncArgs...); err != nil {
 					return err
 				}
 
@@ -180,7 +209,7 @@ func rsyncMigrationSink(live bool, container container, snapshots []*Snapshot, c
 		}
 
 		wrapper := StorageProgressWriter(op, "fs_progress", container.Name())
-		err = RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper)
+		err = RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper, rsyncArgs...)
 		if err != nil {
 			return err
 		}
@@ -202,7 +231,7 @@ func rsyncMigrationSink(live bool, container container, snapshots []*Snapshot, c
 				}
 
 				wrapper := StorageProgressWriter(op, "fs_progress", snap.GetName())
-				err := RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper)
+				err := RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper, rsyncArgs...)
 				if err != nil {
 					return err
 				}
@@ -220,7 +249,7 @@ func rsyncMigrationSink(live bool, container container, snapshots []*Snapshot, c
 		}
 
 		wrapper := StorageProgressWriter(op, "fs_progress", container.Name())
-		err = RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper)
+		err = RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper, rsyncArgs...)
 		if err != nil {
 			return err
 		}
@@ -229,7 +258,7 @@ func rsyncMigrationSink(live bool, container container, snapshots []*Snapshot, c
 	if live {
 		/* now receive the final sync */
 		wrapper := StorageProgressWriter(op, "fs_progress", container.Name())
-		err := RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper)
+		err := RsyncRecv(shared.AddSlash(container.Path()), conn, wrapper, rsyncArgs...)
 		if err != nil {
 			return err
 		}
@@ -80,6 +80,10 @@ func (s *storageMock) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
 
+func (s *storageMock) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	return &api.ResourcesStoragePool{}, nil
+}
+
 func (s *storageMock) StoragePoolVolumeCreate() error {
 	return nil
 }
@@ -100,6 +104,22 @@ func (s *storageMock) StoragePoolVolumeUpdate(writable *api.StorageVolumePut, ch
 	return nil
 }
 
+func (s *storageMock) StoragePoolVolumeSnapshotCreate(snapshotName string) error {
+	return nil
+}
+
+func (s *storageMock) StoragePoolVolumeSnapshotDelete(snapshotName string) error {
+	return nil
+}
+
+func (s *storageMock) StoragePoolVolumeSnapshotRename(snapshotName string, newName string) error {
+	return nil
+}
+
+func (s *storageMock) StoragePoolVolumeSnapshotRestore(snapshotName string) error {
+	return nil
+}
+
 func (s *storageMock) StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error {
 	return nil
 }
@@ -184,6 +204,21 @@ func (s *storageMock) ContainerSnapshotCreateEmpty(snapshotContainer container)
 	return nil
 }
 
+func (s *storageMock) ContainerBackupCreate(path string, backup backup, sourceContainer container) error {
+	return nil
+}
+
+func (s *storageMock) ContainerBackupLoad(container container, snapshots []containerArgs, path string, optimized bool) error {
+	for _, args := range snapshots {
+		_, err := containerCreateEmptySnapshot(container.Daemon(), args)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *storageMock) ImageCreate(fingerprint string) error {
 	return nil
 }
@@ -42,7 +42,11 @@ func storagePoolsGet(d *Daemon, r *http.Request) Response {
 			if err != nil {
 				return SmartError(err)
 			}
-			pl.UsedBy = poolUsedBy
+
+			pl.UsedBy, err = restrictedFilterUsedBy(d, r, poolUsedBy)
+			if err != nil {
+				return SmartError(err)
+			}
 
 			resultMap = append(resultMap, *pl)
 		}
@@ -80,7 +84,10 @@ func storagePoolsPost(d *Daemon, r *http.Request) Response {
 		return InternalError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/storage-pools/%s", version.APIVersion, req.Name))
+	url := fmt.Sprintf("/%s/storage-pools/%s", version.APIVersion, req.Name)
+	eventSendLifecycle("storage-pool-created", url, nil)
+
+	return SyncResponseLocation(true, nil, url)
 }
 
 var storagePoolsCmd = Command{name: "storage-pools", get: storagePoolsGet, post: storagePoolsPost}
@@ -101,7 +108,11 @@ func storagePoolGet(d *Daemon, r *http.Request) Response {
 	if err != nil && err != NoSuchObjectError {
 		return SmartError(err)
 	}
-	pool.UsedBy = poolUsedBy
+
+	pool.UsedBy, err = restrictedFilterUsedBy(d, r, poolUsedBy)
+	if err != nil {
+		return SmartError(err)
+	}
 
 	etag := []interface{}{pool.Name, pool.Driver, pool.Config}
 
@@ -143,6 +154,8 @@ func storagePoolPut(d *Daemon, r *http.Request) Response {
 		return InternalError(err)
 	}
 
+	eventSendLifecycle("storage-pool-updated", fmt.Sprintf("/%s/storage-pools/%s", version.APIVersion, poolName), nil)
+
 	return EmptySyncResponse
 }
 
@@ -193,6 +206,8 @@ func storagePoolPatch(d *Daemon, r *http.Request) Response {
 		return InternalError(fmt.Errorf("failed to update the storage pool configuration"))
 	}
 
+	eventSendLifecycle("storage-pool-updated", fmt.Sprintf("/%s/storage-pools/%s", version.APIVersion, poolName), nil)
+
 	return EmptySyncResponse
 }
 
@@ -219,7 +234,16 @@ func storagePoolDelete(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 	if len(profiles) > 0 {
-		return BadRequest(fmt.Errorf("Storage pool \"%s\" has profiles using it:\n%s", poolName, strings.Join(profiles, "\n")))
+		names := []string{}
+		for _, profile := range profiles {
+			if profile[0] == "default" {
+				names = append(names, profile[1])
+			} else {
+				names = append(names, fmt.Sprintf("%s (project %s)", profile[1], profile[0]))
+			}
+		}
+
+		return BadRequest(fmt.Errorf("Storage pool \"%s\" has profiles using it:\n%s", poolName, strings.Join(names, "\n")))
 	}
 
 	s, err := storagePoolInit(d, poolName)
@@ -237,6 +261,8 @@ func storagePoolDelete(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("storage-pool-deleted", fmt.Sprintf("/%s/storage-pools/%s", version.APIVersion, poolName), nil)
+
 	return EmptySyncResponse
 }
 
@@ -17,6 +17,12 @@ var storagePoolConfigKeys = map[string]func(value string) error{
 	// shared.IsAny() must do.)
 	"btrfs.mount_options": shared.IsAny,
 
+	// valid drivers: ceph
+	"ceph.cluster_name":  shared.IsAny,
+	"ceph.osd.pg_num":    shared.IsInt64,
+	"ceph.osd.pool_name": shared.IsAny,
+	"ceph.user.name":     shared.IsAny,
+
 	// valid drivers: lvm
 	"lvm.thinpool_name": shared.IsAny,
 	"lvm.use_thinpool":  shared.IsBool,
@@ -32,16 +38,16 @@ var storagePoolConfigKeys = map[string]func(value string) error{
 		return err
 	},
 
-	// valid drivers: btrfs, dir, lvm, zfs
+	// valid drivers: btrfs, ceph, dir, lvm, zfs
 	"source": shared.IsAny,
 
-	// valid drivers: lvm
+	// valid drivers: ceph, lvm
 	"volume.block.filesystem": func(value string) error {
 		return shared.IsOneOf(value, []string{"ext4", "xfs"})
 	},
 	"volume.block.mount_options": shared.IsAny,
 
-	// valid drivers: lvm
+	// valid drivers: ceph, lvm
 	"volume.size": func(value string) error {
 		if value == "" {
 			return nil
@@ -93,14 +99,26 @@ func storagePoolValidateConfig(name string, driver string, config map[string]str
 		}
 
 		prfx := strings.HasPrefix
-		if driver == "dir" {
+		if driver == "dir" || driver == "ceph" {
 			if key == "size" {
 				return fmt.Errorf("the key %s cannot be used with %s storage pools", key, strings.ToUpper(driver))
 			}
 		}
 
 		if driver != "lvm" {
-			if prfx(key, "lvm.") || prfx(key, "volume.block.") || key == "volume.size" {
+			if prfx(key, "lvm.") {
+				return fmt.Errorf("the key %s cannot be used with %s storage pools", key, strings.ToUpper(driver))
+			}
+		}
+
+		if driver != "lvm" && driver != "ceph" {
+			if prfx(key, "volume.block.") || key == "volume.size" {
+				return fmt.Errorf("the key %s cannot be used with %s storage pools", key, strings.ToUpper(driver))
+			}
+		}
+
+		if driver != "ceph" {
+			if prfx(key, "ceph.") {
 				return fmt.Errorf("the key %s cannot be used with %s storage pools", key, strings.ToUpper(driver))
 			}
 		}
@@ -127,7 +145,7 @@ func storagePoolValidateConfig(name string, driver string, config map[string]str
 }
 
 func storagePoolFillDefault(name string, driver string, config map[string]string) error {
-	if driver != "dir" {
+	if driver != "dir" && driver != "ceph" {
 		if config["size"] == "" {
 			st := syscall.Statfs_t{}
 			err := syscall.Statfs(shared.VarPath(), &st)
@@ -172,5 +190,26 @@ func storagePoolFillDefault(name string, driver string, config map[string]string
 		}
 	}
 
+	if driver == "ceph" {
+		if config["ceph.cluster_name"] == "" {
+			config["ceph.cluster_name"] = "ceph"
+		}
+
+		if config["ceph.user.name"] == "" {
+			config["ceph.user.name"] = "admin"
+		}
+
+		if config["ceph.osd.pg_num"] == "" {
+			config["ceph.osd.pg_num"] = "32"
+		}
+
+		if config["volume.size"] != "" {
+			_, err := shared.ParseByteSizeString(config["volume.size"])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
@@ -103,11 +103,16 @@ func storagePoolUsedByGet(db *sql.DB, poolID int64, poolName string) ([]string,
 		apiEndpoint, _ := storagePoolVolumeTypeNameToAPIEndpoint(volumes[i].Type)
 		switch apiEndpoint {
 		case storagePoolVolumeAPIEndpointContainers:
+			project, err := dbStoragePoolVolumeProjectGet(db, volumes[i].Name, storagePoolVolumeTypeContainer, poolID)
+			if err != nil {
+				return []string{}, err
+			}
+
 			if strings.Index(volumes[i].Name, shared.SnapshotDelimiter) > 0 {
 				parentName, snapOnlyName, _ := containerGetParentAndSnapshotName(volumes[i].Name)
-				poolUsedBy[i] = fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, parentName, snapOnlyName)
+				poolUsedBy[i] = projectURL(fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, projectUnprefix(project, parentName), snapOnlyName), project)
 			} else {
-				poolUsedBy[i] = fmt.Sprintf("/%s/containers/%s", version.APIVersion, volumes[i].Name)
+				poolUsedBy[i] = containerURL(project, volumes[i].Name)
 			}
 		case storagePoolVolumeAPIEndpointImages:
 			poolUsedBy[i] = fmt.Sprintf("/%s/images/%s", version.APIVersion, volumes[i].Name)
@@ -121,33 +126,42 @@ func storagePoolUsedByGet(db *sql.DB, poolID int64, poolName string) ([]string,
 	}
 
 	for i := 0; i < len(profiles); i++ {
-		poolUsedBy[i+len(volumes)] = fmt.Sprintf("/%s/profiles/%s", version.APIVersion, profiles[i])
+		poolUsedBy[i+len(volumes)] = projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, profiles[i][1]), profiles[i][0])
 	}
 
 	return poolUsedBy, err
 }
 
-func profilesUsingPoolGetNames(db *sql.DB, poolName string) ([]string, error) {
-	usedBy := []string{}
+// Get the project and name of the profiles, of all projects, which use the
+// given storage pool.
+func profilesUsingPoolGetNames(db *sql.DB, poolName string) ([][2]string, error) {
+	usedBy := [][2]string{}
 
-	profiles, err := dbProfiles(db)
+	projects, err := dbProjects(db)
 	if err != nil {
 		return usedBy, err
 	}
 
-	for _, pName := range profiles {
-		_, profile, err := dbProfileGet(db, pName)
+	for _, project := range projects {
+		profiles, err := dbProfiles(db, project)
 		if err != nil {
 			return usedBy, err
 		}
 
-		for _, v := range profile.Devices {
-			if v["type"] != "disk" {
-				continue
+		for _, pName := range profiles {
+			_, profile, err := dbProfileGet(db, project, pName)
+			if err != nil {
+				return usedBy, err
 			}
 
-			if v["pool"] == poolName {
-				usedBy = append(usedBy, pName)
+			for _, v := range profile.Devices {
+				if v["type"] != "disk" {
+					continue
+				}
+
+				if v["pool"] == poolName {
+					usedBy = append(usedBy, [2]string{project, pName})
+				}
 			}
 		}
 	}
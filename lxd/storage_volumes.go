@@ -1,21 +1,29 @@
 package main
 
 import (
+	"crypto/x509"
+	"database/sql"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
+
+	log "gopkg.in/inconshreveable/log15.v2"
 )
 
 // /1.0/storage-pools/{name}/volumes
 // List all storage volumes attached to a given storage pool.
 func storagePoolVolumesGet(d *Daemon, r *http.Request) Response {
 	poolName := mux.Vars(r)["name"]
+	project := projectParam(r)
 
 	recursionStr := r.FormValue("recursion")
 	recursion, err := strconv.Atoi(recursionStr)
@@ -30,11 +38,23 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
-	// Get all volumes currently attached to the storage pool by ID of the
-	// pool.
-	volumes, err := dbStoragePoolVolumesGet(d.db, poolID, supportedVolumeTypes)
-	if err != nil && err != NoSuchObjectError {
-		return SmartError(err)
+	// Get all volumes of the project currently attached to the storage
+	// pool by ID of the pool.
+	volumes := []*api.StorageVolume{}
+	for _, volumeType := range supportedVolumeTypes {
+		volumeNames, err := storagePoolVolumesGetTypeProject(d, project, volumeType, poolID)
+		if err != nil && err != sql.ErrNoRows {
+			return SmartError(err)
+		}
+
+		for _, volumeName := range volumeNames {
+			_, volume, err := dbStoragePoolVolumeGetType(d.db, volumeName, volumeType, poolID)
+			if err != nil {
+				return SmartError(err)
+			}
+
+			volumes = append(volumes, volume)
+		}
 	}
 
 	resultString := []string{}
@@ -44,14 +64,21 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) Response {
 			return InternalError(err)
 		}
 
+		volumeName := volume.Name
+		volume.Name = projectUnprefix(project, volume.Name)
+
 		if recursion == 0 {
 			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, apiEndpoint, volume.Name))
 		} else {
-			volumeUsedBy, err := storagePoolVolumeUsedByGet(d, volume.Name, volume.Type)
+			volumeUsedBy, err := storagePoolVolumeUsedByGet(d, project, volumeName, volume.Type)
+			if err != nil {
+				return InternalError(err)
+			}
+
+			volume.UsedBy, err = restrictedFilterUsedBy(d, r, volumeUsedBy)
 			if err != nil {
 				return InternalError(err)
 			}
-			volume.UsedBy = volumeUsedBy
 		}
 	}
 
@@ -70,6 +97,7 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) Response {
 	// Get the name of the pool the storage volume is supposed to be
 	// attached to.
 	poolName := mux.Vars(r)["name"]
+	project := projectParam(r)
 
 	recursionStr := r.FormValue("recursion")
 	recursion, err := strconv.Atoi(recursionStr)
@@ -99,7 +127,7 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) Response {
 
 	// Get the names of all storage volumes of a given volume type currently
 	// attached to the storage pool.
-	volumes, err := dbStoragePoolVolumesGetType(d.db, volumeType, poolID)
+	volumes, err := storagePoolVolumesGetTypeProject(d, project, volumeType, poolID)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -112,18 +140,23 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) Response {
 			if err != nil {
 				return InternalError(err)
 			}
-			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, apiEndpoint, volume))
+			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, apiEndpoint, projectUnprefix(project, volume)))
 		} else {
 			_, vol, err := dbStoragePoolVolumeGetType(d.db, volume, volumeType, poolID)
 			if err != nil {
 				continue
 			}
 
-			volumeUsedBy, err := storagePoolVolumeUsedByGet(d, vol.Name, vol.Type)
+			volumeUsedBy, err := storagePoolVolumeUsedByGet(d, project, vol.Name, vol.Type)
+			if err != nil {
+				return SmartError(err)
+			}
+
+			vol.UsedBy, err = restrictedFilterUsedBy(d, r, volumeUsedBy)
 			if err != nil {
 				return SmartError(err)
 			}
-			vol.UsedBy = volumeUsedBy
+			vol.Name = projectUnprefix(project, vol.Name)
 
 			resultMap = append(resultMap, vol)
 		}
@@ -161,8 +194,20 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) Response {
 	// Check if the user gave us a valid pool name in which the new storage
 	// volume is supposed to be created.
 	poolName := mux.Vars(r)["name"]
+	project := projectParam(r)
+
+	switch req.Source.Type {
+	case "":
+		// Create an empty storage volume.
+	case "copy":
+		return storagePoolVolumeCreateFromCopy(d, r, project, poolName, &req)
+	case "migration":
+		return storagePoolVolumeCreateFromMigration(d, r, project, poolName, &req)
+	default:
+		return BadRequest(fmt.Errorf("unknown source type %s", req.Source.Type))
+	}
 
-	err = storagePoolVolumeCreateInternal(d, poolName, req.Name, req.Description, req.Type, req.Config)
+	err = storagePoolVolumeCreateInternal(d, project, poolName, projectPrefix(project, req.Name), req.Description, req.Type, req.Config)
 	if err != nil {
 		return InternalError(err)
 	}
@@ -172,9 +217,141 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) Response {
 		return InternalError(err)
 	}
 
+	eventSendLifecycle("storage-volume-created", projectURL(fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, apiEndpoint, req.Name), project), nil)
+
 	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s", version.APIVersion, poolName, apiEndpoint))
 }
 
+func storagePoolVolumeCreateFromCopy(d *Daemon, r *http.Request, project string, poolName string, req *api.StorageVolumesPost) Response {
+	if req.Type != storagePoolVolumeTypeNameCustom {
+		return BadRequest(fmt.Errorf("only storage volumes of type \"%s\" can be copied", storagePoolVolumeTypeNameCustom))
+	}
+
+	if req.Source.Name == "" {
+		return BadRequest(fmt.Errorf("must specify a source storage volume"))
+	}
+
+	// Default to copying within the same storage pool.
+	srcPoolName := req.Source.Pool
+	if srcPoolName == "" {
+		srcPoolName = poolName
+	}
+
+	if srcPoolName == poolName && req.Source.Name == req.Name {
+		return BadRequest(fmt.Errorf("the source and target storage volumes are identical"))
+	}
+
+	// Check that the source storage volume exists.
+	srcPoolID, err := dbStoragePoolGetID(d.db, srcPoolName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, err = dbStoragePoolVolumeGetTypeID(d.db, projectPrefix(project, req.Source.Name), storagePoolVolumeTypeCustom, srcPoolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = storagePoolVolumeCheckProject(d, project, srcPoolID, projectPrefix(project, req.Source.Name), storagePoolVolumeTypeCustom)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	run := func(op *operation) error {
+		return storagePoolVolumeCopy(d, project, srcPoolName, projectPrefix(project, req.Source.Name), poolName, projectPrefix(project, req.Name), req.Description, req.Config)
+	}
+
+	resources := map[string][]string{}
+	resources["storage-pools"] = []string{fmt.Sprintf("%s/volumes/%s/%s", poolName, req.Type, req.Name)}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func storagePoolVolumeCreateFromMigration(d *Daemon, r *http.Request, project string, poolName string, req *api.StorageVolumesPost) Response {
+	if req.Type != storagePoolVolumeTypeNameCustom {
+		return BadRequest(fmt.Errorf("only storage volumes of type \"%s\" can be migrated", storagePoolVolumeTypeNameCustom))
+	}
+
+	// Only pull mode is supported for storage volumes.
+	if req.Source.Mode != "pull" {
+		return NotImplemented
+	}
+
+	var cert *x509.Certificate
+	if req.Source.Certificate != "" {
+		certBlock, _ := pem.Decode([]byte(req.Source.Certificate))
+		if certBlock == nil {
+			return BadRequest(fmt.Errorf("Invalid certificate"))
+		}
+
+		var err error
+		cert, err = x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			return BadRequest(err)
+		}
+	}
+
+	config, err := shared.GetTLSConfig("", "", "", cert)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	// Create the (empty) target storage volume.
+	volumeName := projectPrefix(project, req.Name)
+	err = storagePoolVolumeCreateInternal(d, project, poolName, volumeName, req.Description, req.Type, req.Config)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	migrationArgs := StorageVolumeMigrationSinkArgs{
+		Url: req.Source.Operation,
+		Dialer: websocket.Dialer{
+			TLSClientConfig: config,
+			NetDial:         shared.RFC3493Dialer},
+		Storage:    s,
+		VolumeName: volumeName,
+		Secrets:    req.Source.Websockets,
+	}
+
+	sink, err := NewStorageVolumeMigrationSink(&migrationArgs)
+	if err != nil {
+		s.StoragePoolVolumeDelete()
+		return InternalError(err)
+	}
+
+	run := func(op *operation) error {
+		err := sink.Do(op)
+		if err != nil {
+			logger.Error("Error during storage volume migration sink", log.Ctx{"err": err})
+			s.StoragePoolVolumeDelete()
+			return fmt.Errorf("Error transferring storage volume: %s", err)
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage-pools"] = []string{fmt.Sprintf("%s/volumes/%s/%s", poolName, req.Type, req.Name)}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		s.StoragePoolVolumeDelete()
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
 var storagePoolVolumesTypeCmd = Command{name: "storage-pools/{name}/volumes/{type}", get: storagePoolVolumesTypeGet, post: storagePoolVolumesTypePost}
 
 // /1.0/storage-pools/{pool}/volumes/{type}/{name}
@@ -190,6 +367,8 @@ func storagePoolVolumeTypeGet(d *Daemon, r *http.Request) Response {
 	// Get the name of the volume type.
 	volumeTypeName := mux.Vars(r)["type"]
 
+	project := projectParam(r)
+
 	// Convert the volume type name to our internal integer representation.
 	volumeType, err := storagePoolVolumeTypeNameToType(volumeTypeName)
 	if err != nil {
@@ -208,16 +387,27 @@ func storagePoolVolumeTypeGet(d *Daemon, r *http.Request) Response {
 	}
 
 	// Get the storage volume.
-	_, volume, err := dbStoragePoolVolumeGetType(d.db, volumeName, volumeType, poolID)
+	internalName := storagePoolVolumeInternalName(project, volumeType, volumeName)
+	_, volume, err := dbStoragePoolVolumeGetType(d.db, internalName, volumeType, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = storagePoolVolumeCheckProject(d, project, poolID, internalName, volumeType)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	volumeUsedBy, err := storagePoolVolumeUsedByGet(d, volume.Name, volume.Type)
+	volumeUsedBy, err := storagePoolVolumeUsedByGet(d, project, volume.Name, volume.Type)
 	if err != nil {
 		return SmartError(err)
 	}
-	volume.UsedBy = volumeUsedBy
+
+	volume.UsedBy, err = restrictedFilterUsedBy(d, r, volumeUsedBy)
+	if err != nil {
+		return SmartError(err)
+	}
+	volume.Name = volumeName
 
 	etag := []interface{}{volume.Name, volume.Type, volume.Config}
 
@@ -236,6 +426,8 @@ func storagePoolVolumeTypePut(d *Daemon, r *http.Request) Response {
 	// Get the name of the volume type.
 	volumeTypeName := mux.Vars(r)["type"]
 
+	project := projectParam(r)
+
 	// Convert the volume type name to our internal integer representation.
 	volumeType, err := storagePoolVolumeTypeNameToType(volumeTypeName)
 	if err != nil {
@@ -252,7 +444,14 @@ func storagePoolVolumeTypePut(d *Daemon, r *http.Request) Response {
 	}
 
 	// Get the existing storage volume.
-	_, volume, err := dbStoragePoolVolumeGetType(d.db, volumeName, volumeType, poolID)
+	internalName := storagePoolVolumeInternalName(project, volumeType, volumeName)
+	_, volume, err := dbStoragePoolVolumeGetType(d.db, internalName, volumeType, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+	volume.Name = volumeName
+
+	err = storagePoolVolumeCheckProject(d, project, poolID, internalName, volumeType)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -270,17 +469,32 @@ func storagePoolVolumeTypePut(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
+	if req.Restore != "" {
+		if volumeType != storagePoolVolumeTypeCustom {
+			return BadRequest(fmt.Errorf("storage volumes of type \"%s\" cannot be restored", volumeTypeName))
+		}
+
+		err = storagePoolVolumeSnapshotRestore(d, poolName, poolID, internalName, req.Restore)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		return EmptySyncResponse
+	}
+
 	// Validate the configuration
 	err = storageVolumeValidateConfig(volumeName, req.Config, pool)
 	if err != nil {
 		return BadRequest(err)
 	}
 
-	err = storagePoolVolumeUpdate(d, poolName, volumeName, volumeType, req.Description, req.Config)
+	err = storagePoolVolumeUpdate(d, poolName, internalName, volumeType, req.Description, req.Config)
 	if err != nil {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("storage-volume-updated", projectURL(fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, volumeTypeName, volumeName), project), nil)
+
 	return EmptySyncResponse
 }
 
@@ -296,6 +510,8 @@ func storagePoolVolumeTypePatch(d *Daemon, r *http.Request) Response {
 	// Get the name of the volume type.
 	volumeTypeName := mux.Vars(r)["type"]
 
+	project := projectParam(r)
+
 	// Convert the volume type name to our internal integer representation.
 	volumeType, err := storagePoolVolumeTypeNameToType(volumeTypeName)
 	if err != nil {
@@ -314,7 +530,14 @@ func storagePoolVolumeTypePatch(d *Daemon, r *http.Request) Response {
 	}
 
 	// Get the existing storage volume.
-	_, volume, err := dbStoragePoolVolumeGetType(d.db, volumeName, volumeType, poolID)
+	internalName := storagePoolVolumeInternalName(project, volumeType, volumeName)
+	_, volume, err := dbStoragePoolVolumeGetType(d.db, internalName, volumeType, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+	volume.Name = volumeName
+
+	err = storagePoolVolumeCheckProject(d, project, poolID, internalName, volumeType)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -332,6 +555,19 @@ func storagePoolVolumeTypePatch(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
+	if req.Restore != "" {
+		if volumeType != storagePoolVolumeTypeCustom {
+			return BadRequest(fmt.Errorf("storage volumes of type \"%s\" cannot be restored", volumeTypeName))
+		}
+
+		err = storagePoolVolumeSnapshotRestore(d, poolName, poolID, internalName, req.Restore)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		return EmptySyncResponse
+	}
+
 	if req.Config == nil {
 		req.Config = map[string]string{}
 	}
@@ -349,14 +585,155 @@ func storagePoolVolumeTypePatch(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
-	err = storagePoolVolumeUpdate(d, poolName, volumeName, volumeType, req.Description, req.Config)
+	err = storagePoolVolumeUpdate(d, poolName, internalName, volumeType, req.Description, req.Config)
 	if err != nil {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("storage-volume-updated", projectURL(fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, volumeTypeName, volumeName), project), nil)
+
 	return EmptySyncResponse
 }
 
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}
+// Rename or move a storage volume, or start migrating it to another LXD.
+func storagePoolVolumeTypePost(d *Daemon, r *http.Request) Response {
+	// Get the name of the storage volume.
+	volumeName := mux.Vars(r)["name"]
+
+	// Get the name of the storage pool the volume is supposed to be
+	// attached to.
+	poolName := mux.Vars(r)["pool"]
+
+	// Get the name of the volume type.
+	volumeTypeName := mux.Vars(r)["type"]
+
+	project := projectParam(r)
+
+	// We currently only allow to move or migrate storage volumes of type
+	// storagePoolVolumeTypeCustom.
+	if volumeTypeName != storagePoolVolumeTypeNameCustom {
+		return BadRequest(fmt.Errorf("storage volumes of type \"%s\" cannot be moved with the storage api", volumeTypeName))
+	}
+
+	req := api.StorageVolumePost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Check that the storage volume exists.
+	poolID, err := dbStoragePoolGetID(d.db, poolName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	internalName := projectPrefix(project, volumeName)
+	volumeID, err := dbStoragePoolVolumeGetTypeID(d.db, internalName, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = storagePoolVolumeCheckProject(d, project, poolID, internalName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resources := map[string][]string{}
+	resources["storage-pools"] = []string{fmt.Sprintf("%s/volumes/%s/%s", poolName, volumeTypeName, volumeName)}
+
+	if req.Migration {
+		s, err := storagePoolVolumeInit(d, poolName, internalName, storagePoolVolumeTypeCustom)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		ws, err := NewStorageVolumeMigrationSource(s, internalName)
+		if err != nil {
+			return InternalError(err)
+		}
+
+		op, err := operationCreate(project, r, operationClassWebsocket, resources, ws.Metadata(), ws.Do, nil, ws.Connect)
+		if err != nil {
+			return InternalError(err)
+		}
+
+		return OperationResponse(op)
+	}
+
+	// Default to staying on the same storage pool and keeping the name.
+	if req.Pool == "" {
+		req.Pool = poolName
+	}
+
+	if req.Name == "" {
+		req.Name = volumeName
+	}
+
+	if req.Pool == poolName && req.Name == volumeName {
+		return BadRequest(fmt.Errorf("the storage volume would be left unchanged"))
+	}
+
+	// Check that the target storage volume does not already exist.
+	targetPoolID, err := dbStoragePoolGetID(d.db, req.Pool)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	targetVolumeID, _ := dbStoragePoolVolumeGetTypeID(d.db, projectPrefix(project, req.Name), storagePoolVolumeTypeCustom, targetPoolID)
+	if targetVolumeID > 0 {
+		return Conflict
+	}
+
+	volumeUsedBy, err := storagePoolVolumeUsedByGet(d, project, internalName, volumeTypeName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if len(volumeUsedBy) > 0 {
+		return BadRequest(fmt.Errorf("The storage volume is still in use by containers or profiles"))
+	}
+
+	snapshots, err := dbStoragePoolVolumeSnapshotsGet(d.db, volumeID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if len(snapshots) > 0 {
+		return BadRequest(fmt.Errorf("Storage volumes with snapshots cannot be moved"))
+	}
+
+	run := func(op *operation) error {
+		err := storagePoolVolumeCopy(d, project, poolName, internalName, req.Pool, projectPrefix(project, req.Name), "", nil)
+		if err != nil {
+			return err
+		}
+
+		s, err := storagePoolVolumeInit(d, poolName, internalName, storagePoolVolumeTypeCustom)
+		if err != nil {
+			return err
+		}
+
+		err = s.StoragePoolVolumeDelete()
+		if err != nil {
+			return err
+		}
+
+		eventSendLifecycle("storage-volume-renamed", projectURL(fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, volumeTypeName, volumeName), project), map[string]interface{}{"new_pool": req.Pool, "new_name": req.Name})
+
+		return nil
+	}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
 // /1.0/storage-pools/{pool}/volumes/{type}/{name}
 func storagePoolVolumeTypeDelete(d *Daemon, r *http.Request) Response {
 	// Get the name of the storage volume.
@@ -369,6 +746,8 @@ func storagePoolVolumeTypeDelete(d *Daemon, r *http.Request) Response {
 	// Get the name of the volume type.
 	volumeTypeName := mux.Vars(r)["type"]
 
+	project := projectParam(r)
+
 	// Convert the volume type name to our internal integer representation.
 	volumeType, err := storagePoolVolumeTypeNameToType(volumeTypeName)
 	if err != nil {
@@ -388,7 +767,18 @@ func storagePoolVolumeTypeDelete(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("storage volumes of type \"%s\" cannot be deleted with the storage api", volumeTypeName))
 	}
 
-	volumeUsedBy, err := storagePoolVolumeUsedByGet(d, volumeName, volumeTypeName)
+	poolID, err := dbStoragePoolGetID(d.db, poolName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	internalName := storagePoolVolumeInternalName(project, volumeType, volumeName)
+	err = storagePoolVolumeCheckProject(d, project, poolID, internalName, volumeType)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	volumeUsedBy, err := storagePoolVolumeUsedByGet(d, project, internalName, volumeTypeName)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -405,13 +795,25 @@ func storagePoolVolumeTypeDelete(d *Daemon, r *http.Request) Response {
 		}
 	}
 
-	s, err := storagePoolVolumeInit(d, poolName, volumeName, volumeType)
+	s, err := storagePoolVolumeInit(d, poolName, internalName, volumeType)
 	if err != nil {
 		return NotFound
 	}
 
 	switch volumeType {
 	case storagePoolVolumeTypeCustom:
+		// Remove the snapshots of the storage volume first.
+		poolID, _ := s.GetContainerPoolInfo()
+		volumeID, err := dbStoragePoolVolumeGetTypeID(d.db, internalName, volumeType, poolID)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		err = storagePoolVolumeSnapshotsDelete(d, s, volumeID)
+		if err != nil {
+			return SmartError(err)
+		}
+
 		err = s.StoragePoolVolumeDelete()
 	case storagePoolVolumeTypeImage:
 		err = s.ImageDelete(volumeName)
@@ -424,7 +826,9 @@ func storagePoolVolumeTypeDelete(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
+	eventSendLifecycle("storage-volume-deleted", projectURL(fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, volumeTypeName, volumeName), project), nil)
+
 	return EmptySyncResponse
 }
 
-var storagePoolVolumeTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name:.*}", get: storagePoolVolumeTypeGet, put: storagePoolVolumeTypePut, patch: storagePoolVolumeTypePatch, delete: storagePoolVolumeTypeDelete}
+var storagePoolVolumeTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name:.*}", get: storagePoolVolumeTypeGet, post: storagePoolVolumeTypePost, put: storagePoolVolumeTypePut, patch: storagePoolVolumeTypePatch, delete: storagePoolVolumeTypeDelete}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// Check that the storage volume a snapshot request refers to exists in the
+// given project and supports snapshots. Returns the ID of the storage volume.
+func storagePoolVolumeSnapshotParentGet(d *Daemon, project string, poolName string, volumeTypeName string, volumeName string) (int64, Response) {
+	// Convert the volume type name to our internal integer representation.
+	volumeType, err := storagePoolVolumeTypeNameToType(volumeTypeName)
+	if err != nil {
+		return -1, BadRequest(err)
+	}
+
+	// Only custom storage volumes can be snapshotted through the storage
+	// api.
+	if volumeType != storagePoolVolumeTypeCustom {
+		return -1, BadRequest(fmt.Errorf("invalid storage volume type %s", volumeTypeName))
+	}
+
+	// Retrieve ID of the storage pool (and check if the storage pool
+	// exists).
+	poolID, err := dbStoragePoolGetID(d.db, poolName)
+	if err != nil {
+		return -1, SmartError(err)
+	}
+
+	// Get the ID of the storage volume.
+	internalName := projectPrefix(project, volumeName)
+	volumeID, err := dbStoragePoolVolumeGetTypeID(d.db, internalName, volumeType, poolID)
+	if err != nil {
+		return -1, SmartError(err)
+	}
+
+	err = storagePoolVolumeCheckProject(d, project, poolID, internalName, volumeType)
+	if err != nil {
+		return -1, SmartError(err)
+	}
+
+	return volumeID, nil
+}
+
+func storagePoolVolumeSnapshotValidName(name string) error {
+	if name == "" {
+		return fmt.Errorf("No name provided")
+	}
+
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("Snapshot names may not contain slashes")
+	}
+
+	if name == "." || name == ".." {
+		return fmt.Errorf("Invalid snapshot name \"%s\"", name)
+	}
+
+	return nil
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots
+// List all snapshots of a given storage volume.
+func storagePoolVolumeSnapshotsTypeGet(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeTypeName := mux.Vars(r)["type"]
+	volumeName := mux.Vars(r)["name"]
+	project := projectParam(r)
+
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	volumeID, resp := storagePoolVolumeSnapshotParentGet(d, project, poolName, volumeTypeName, volumeName)
+	if resp != nil {
+		return resp
+	}
+
+	snapshots, err := dbStoragePoolVolumeSnapshotsGet(d.db, volumeID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []*api.StorageVolumeSnapshot{}
+	for _, snapshotName := range snapshots {
+		if recursion == 0 {
+			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s/snapshots/%s", version.APIVersion, poolName, volumeTypeName, volumeName, snapshotName))
+		} else {
+			_, snapshot, err := dbStoragePoolVolumeSnapshotGet(d.db, volumeID, snapshotName)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, snapshot)
+		}
+	}
+
+	if recursion == 0 {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots
+// Create a new snapshot of a given storage volume.
+func storagePoolVolumeSnapshotsTypePost(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeTypeName := mux.Vars(r)["type"]
+	volumeName := mux.Vars(r)["name"]
+	project := projectParam(r)
+
+	volumeID, resp := storagePoolVolumeSnapshotParentGet(d, project, poolName, volumeTypeName, volumeName)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.StorageVolumeSnapshotsPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	if req.Name == "" {
+		// come up with a name
+		snapshots, err := dbStoragePoolVolumeSnapshotsGet(d.db, volumeID)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		for i := 0; ; i++ {
+			req.Name = fmt.Sprintf("snap%d", i)
+			if !shared.StringInSlice(req.Name, snapshots) {
+				break
+			}
+		}
+	}
+
+	err = storagePoolVolumeSnapshotValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Check that the name isn't already in use
+	_, _, err = dbStoragePoolVolumeSnapshotGet(d.db, volumeID, req.Name)
+	if err == nil {
+		return Conflict
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, projectPrefix(project, volumeName), storagePoolVolumeTypeCustom)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	snapshot := func(op *operation) error {
+		snapshotID, err := dbStoragePoolVolumeSnapshotCreate(d.db, volumeID, req.Name, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		err = s.StoragePoolVolumeSnapshotCreate(req.Name)
+		if err != nil {
+			dbStoragePoolVolumeSnapshotDelete(d.db, snapshotID)
+			return err
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage-pools"] = []string{fmt.Sprintf("%s/volumes/%s/%s", poolName, volumeTypeName, volumeName)}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, snapshot, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+var storagePoolVolumeSnapshotsTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name}/snapshots", get: storagePoolVolumeSnapshotsTypeGet, post: storagePoolVolumeSnapshotsTypePost}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}
+// Get a snapshot of a given storage volume.
+func storagePoolVolumeSnapshotTypeGet(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeTypeName := mux.Vars(r)["type"]
+	volumeName := mux.Vars(r)["name"]
+	project := projectParam(r)
+	snapshotName := mux.Vars(r)["snapshotName"]
+
+	volumeID, resp := storagePoolVolumeSnapshotParentGet(d, project, poolName, volumeTypeName, volumeName)
+	if resp != nil {
+		return resp
+	}
+
+	_, snapshot, err := dbStoragePoolVolumeSnapshotGet(d.db, volumeID, snapshotName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponse(true, snapshot)
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}
+// Rename a snapshot of a given storage volume.
+func storagePoolVolumeSnapshotTypePost(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeTypeName := mux.Vars(r)["type"]
+	volumeName := mux.Vars(r)["name"]
+	project := projectParam(r)
+	snapshotName := mux.Vars(r)["snapshotName"]
+
+	volumeID, resp := storagePoolVolumeSnapshotParentGet(d, project, poolName, volumeTypeName, volumeName)
+	if resp != nil {
+		return resp
+	}
+
+	snapshotID, _, err := dbStoragePoolVolumeSnapshotGet(d.db, volumeID, snapshotName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	req := api.StorageVolumeSnapshotPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = storagePoolVolumeSnapshotValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Check that the name isn't already in use
+	_, _, err = dbStoragePoolVolumeSnapshotGet(d.db, volumeID, req.Name)
+	if err == nil {
+		return Conflict
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, projectPrefix(project, volumeName), storagePoolVolumeTypeCustom)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	rename := func(op *operation) error {
+		err := s.StoragePoolVolumeSnapshotRename(snapshotName, req.Name)
+		if err != nil {
+			return err
+		}
+
+		err = dbStoragePoolVolumeSnapshotRename(d.db, snapshotID, req.Name)
+		if err != nil {
+			s.StoragePoolVolumeSnapshotRename(req.Name, snapshotName)
+			return err
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage-pools"] = []string{fmt.Sprintf("%s/volumes/%s/%s", poolName, volumeTypeName, volumeName)}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, rename, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}
+// Delete a snapshot of a given storage volume.
+func storagePoolVolumeSnapshotTypeDelete(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeTypeName := mux.Vars(r)["type"]
+	volumeName := mux.Vars(r)["name"]
+	project := projectParam(r)
+	snapshotName := mux.Vars(r)["snapshotName"]
+
+	volumeID, resp := storagePoolVolumeSnapshotParentGet(d, project, poolName, volumeTypeName, volumeName)
+	if resp != nil {
+		return resp
+	}
+
+	snapshotID, _, err := dbStoragePoolVolumeSnapshotGet(d.db, volumeID, snapshotName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, projectPrefix(project, volumeName), storagePoolVolumeTypeCustom)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	remove := func(op *operation) error {
+		err := s.StoragePoolVolumeSnapshotDelete(snapshotName)
+		if err != nil {
+			return err
+		}
+
+		return dbStoragePoolVolumeSnapshotDelete(d.db, snapshotID)
+	}
+
+	resources := map[string][]string{}
+	resources["storage-pools"] = []string{fmt.Sprintf("%s/volumes/%s/%s", poolName, volumeTypeName, volumeName)}
+
+	op, err := operationCreate(project, r, operationClassTask, resources, nil, remove, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+var storagePoolVolumeSnapshotTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}", get: storagePoolVolumeSnapshotTypeGet, post: storagePoolVolumeSnapshotTypePost, delete: storagePoolVolumeSnapshotTypeDelete}
+
+// Restore a custom storage volume from one of its snapshots.
+func storagePoolVolumeSnapshotRestore(d *Daemon, poolName string, poolID int64, volumeName string, snapshotName string) error {
+	volumeID, err := dbStoragePoolVolumeGetTypeID(d.db, volumeName, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = dbStoragePoolVolumeSnapshotGet(d.db, volumeID, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	// Restoring a storage volume under the feet of running containers
+	// is not supported.
+	ctsUsingVolume, err := storagePoolVolumeUsedByContainersGet(d, volumeName, storagePoolVolumeTypeNameCustom)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range ctsUsingVolume {
+		if c.IsRunning() {
+			return fmt.Errorf("The storage volume is still in use by running containers")
+		}
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	return s.StoragePoolVolumeSnapshotRestore(snapshotName)
+}
+
+// Delete all snapshots of a custom storage volume.
+func storagePoolVolumeSnapshotsDelete(d *Daemon, s storage, volumeID int64) error {
+	snapshots, err := dbStoragePoolVolumeSnapshotsGet(d.db, volumeID)
+	if err != nil {
+		return err
+	}
+
+	for _, snapshotName := range snapshots {
+		snapshotID, _, err := dbStoragePoolVolumeSnapshotGet(d.db, volumeID, snapshotName)
+		if err != nil {
+			return err
+		}
+
+		err = s.StoragePoolVolumeSnapshotDelete(snapshotName)
+		if err != nil {
+			return err
+		}
+
+		err = dbStoragePoolVolumeSnapshotDelete(d.db, snapshotID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -88,6 +88,52 @@ func storagePoolVolumeTypeToAPIEndpoint(volumeType int) (string, error) {
 	return "", fmt.Errorf("invalid storage volume type")
 }
 
+// Returns the internal name of a storage volume of the given project.
+// Container and custom volumes are specific to a project while image volumes
+// are shared by all of them.
+func storagePoolVolumeInternalName(project string, volumeType int, volumeName string) string {
+	if volumeType == storagePoolVolumeTypeImage {
+		return volumeName
+	}
+
+	return projectPrefix(project, volumeName)
+}
+
+// Check that a storage volume, given by its internal name, belongs to the
+// given project. Internal names don't tell projects apart, "foo_vol" is the
+// volume "vol" of project "foo" but could also be a volume of the default
+// project.
+func storagePoolVolumeCheckProject(d *Daemon, project string, poolID int64, volumeName string, volumeType int) error {
+	if volumeType == storagePoolVolumeTypeImage {
+		return nil
+	}
+
+	if project == "" {
+		project = "default"
+	}
+
+	volumeProject, err := dbStoragePoolVolumeProjectGet(d.db, volumeName, volumeType, poolID)
+	if err != nil {
+		return err
+	}
+
+	if volumeProject != project {
+		return NoSuchObjectError
+	}
+
+	return nil
+}
+
+// Get the names of the storage volumes of a given type on a storage pool which
+// are visible from the given project.
+func storagePoolVolumesGetTypeProject(d *Daemon, project string, volumeType int, poolID int64) ([]string, error) {
+	if volumeType == storagePoolVolumeTypeImage {
+		return dbStoragePoolVolumesGetType(d.db, volumeType, poolID)
+	}
+
+	return dbStoragePoolVolumesGetTypeProject(d.db, project, volumeType, poolID)
+}
+
 func storagePoolVolumeUpdate(d *Daemon, poolName string, volumeName string, volumeType int, newDescription string, newConfig map[string]string) error {
 	s, err := storagePoolVolumeInit(d, poolName, volumeName, volumeType)
 	if err != nil {
@@ -179,15 +225,16 @@ func storagePoolVolumeUpdate(d *Daemon, poolName string, volumeName string, volu
 	return nil
 }
 
+// Get the containers, of all projects, which use the storage volume with the
+// given internal name through a disk device.
 func storagePoolVolumeUsedByContainersGet(d *Daemon, volumeName string,
-	volumeTypeName string) ([]string, error) {
+	volumeTypeName string) ([]container, error) {
 	cts, err := dbContainersList(d.db, cTypeRegular)
 	if err != nil {
-		return []string{}, err
+		return []container{}, err
 	}
 
-	ctsUsingVolume := []string{}
-	volumeNameWithType := fmt.Sprintf("%s/%s", volumeTypeName, volumeName)
+	ctsUsingVolume := []container{}
 	for _, ct := range cts {
 		c, err := containerLoadByName(d, ct)
 		if err != nil {
@@ -202,8 +249,12 @@ func storagePoolVolumeUsedByContainersGet(d *Daemon, volumeName string,
 			// Make sure that we don't compare against stuff like
 			// "container////bla" but only against "container/bla".
 			cleanSource := filepath.Clean(d["source"])
-			if cleanSource == volumeName || cleanSource == volumeNameWithType {
-				ctsUsingVolume = append(ctsUsingVolume, ct)
+			cleanSource = strings.TrimPrefix(cleanSource, volumeTypeName+"/")
+
+			// Volume names are relative to the container's project
+			if projectPrefix(c.Project(), cleanSource) == volumeName {
+				ctsUsingVolume = append(ctsUsingVolume, c)
+				break
 			}
 		}
 	}
@@ -212,16 +263,16 @@ func storagePoolVolumeUsedByContainersGet(d *Daemon, volumeName string,
 }
 
 // volumeUsedBy = append(volumeUsedBy, fmt.Sprintf("/%s/containers/%s", version.APIVersion, ct))
-func storagePoolVolumeUsedByGet(d *Daemon, volumeName string, volumeTypeName string) ([]string, error) {
+func storagePoolVolumeUsedByGet(d *Daemon, project string, volumeName string, volumeTypeName string) ([]string, error) {
 	// Handle container volumes
 	if volumeTypeName == "container" {
 		cName, sName, snap := containerGetParentAndSnapshotName(volumeName)
 
 		if snap {
-			return []string{fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, cName, sName)}, nil
+			return []string{projectURL(fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, projectUnprefix(project, cName), sName), project)}, nil
 		}
 
-		return []string{fmt.Sprintf("/%s/containers/%s", version.APIVersion, cName)}, nil
+		return []string{containerURL(project, cName)}, nil
 	}
 
 	// Handle image volumes
@@ -237,12 +288,16 @@ func storagePoolVolumeUsedByGet(d *Daemon, volumeName string, volumeTypeName str
 	}
 
 	volumeUsedBy := []string{}
-	for _, ct := range ctsUsingVolume {
-		volumeUsedBy = append(volumeUsedBy,
-			fmt.Sprintf("/%s/containers/%s", version.APIVersion, ct))
+	for _, c := range ctsUsingVolume {
+		volumeUsedBy = append(volumeUsedBy, containerURL(c.Project(), c.Name()))
+	}
+
+	profilesProject, err := projectProfilesProject(d.db, project)
+	if err != nil {
+		return []string{}, err
 	}
 
-	profiles, err := profilesUsingPoolVolumeGetNames(d.db, volumeName, volumeTypeName)
+	profiles, err := profilesUsingPoolVolumeGetNames(d.db, profilesProject, projectUnprefix(project, volumeName), volumeTypeName)
 	if err != nil {
 		return []string{}, err
 	}
@@ -252,22 +307,22 @@ func storagePoolVolumeUsedByGet(d *Daemon, volumeName string, volumeTypeName str
 	}
 
 	for _, pName := range profiles {
-		volumeUsedBy = append(volumeUsedBy, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, pName))
+		volumeUsedBy = append(volumeUsedBy, projectURL(fmt.Sprintf("/%s/profiles/%s", version.APIVersion, pName), profilesProject))
 	}
 
 	return volumeUsedBy, nil
 }
 
-func profilesUsingPoolVolumeGetNames(db *sql.DB, volumeName string, volumeType string) ([]string, error) {
+func profilesUsingPoolVolumeGetNames(db *sql.DB, project string, volumeName string, volumeType string) ([]string, error) {
 	usedBy := []string{}
 
-	profiles, err := dbProfiles(db)
+	profiles, err := dbProfiles(db, project)
 	if err != nil {
 		return usedBy, err
 	}
 
 	for _, pName := range profiles {
-		_, profile, err := dbProfileGet(db, pName)
+		_, profile, err := dbProfileGet(db, project, pName)
 		if err != nil {
 			return usedBy, err
 		}
@@ -296,7 +351,7 @@ func profilesUsingPoolVolumeGetNames(db *sql.DB, volumeName string, volumeType s
 	return usedBy, nil
 }
 
-func storagePoolVolumeDBCreate(d *Daemon, poolName string, volumeName, volumeDescription string, volumeTypeName string, volumeConfig map[string]string) error {
+func storagePoolVolumeDBCreate(d *Daemon, project string, poolName string, volumeName, volumeDescription string, volumeTypeName string, volumeConfig map[string]string) error {
 	// Check that the name of the new storage volume is valid. (For example.
 	// zfs pools cannot contain "/" in their names.)
 	err := storageValidName(volumeName)
@@ -347,7 +402,7 @@ func storagePoolVolumeDBCreate(d *Daemon, poolName string, volumeName, volumeDes
 	}
 
 	// Create the database entry for the storage volume.
-	_, err = dbStoragePoolVolumeCreate(d.db, volumeName, volumeDescription, volumeType, poolID, volumeConfig)
+	_, err = dbStoragePoolVolumeCreate(d.db, project, volumeName, volumeDescription, volumeType, poolID, volumeConfig)
 	if err != nil {
 		return fmt.Errorf("Error inserting %s of type %s into database: %s", poolName, volumeTypeName, err)
 	}
@@ -355,8 +410,8 @@ func storagePoolVolumeDBCreate(d *Daemon, poolName string, volumeName, volumeDes
 	return nil
 }
 
-func storagePoolVolumeCreateInternal(d *Daemon, poolName string, volumeName, volumeDescription string, volumeTypeName string, volumeConfig map[string]string) error {
-	err := storagePoolVolumeDBCreate(d, poolName, volumeName, volumeDescription, volumeTypeName, volumeConfig)
+func storagePoolVolumeCreateInternal(d *Daemon, project string, poolName string, volumeName, volumeDescription string, volumeTypeName string, volumeConfig map[string]string) error {
+	err := storagePoolVolumeDBCreate(d, project, poolName, volumeName, volumeDescription, volumeTypeName, volumeConfig)
 	if err != nil {
 		return err
 	}
@@ -383,3 +438,82 @@ func storagePoolVolumeCreateInternal(d *Daemon, poolName string, volumeName, vol
 
 	return nil
 }
+
+// storagePoolVolumeCopy creates a new custom storage volume and fills it
+// with the content of an existing one. Both volumes may live on different
+// storage pools as the data is transferred with rsync. The new volume is part
+// of the given project.
+func storagePoolVolumeCopy(d *Daemon, project string, srcPoolName string, srcVolumeName string, dstPoolName string, dstVolumeName string, dstDescription string, dstConfig map[string]string) error {
+	srcPoolID, err := dbStoragePoolGetID(d.db, srcPoolName)
+	if err != nil {
+		return err
+	}
+
+	_, srcVolume, err := dbStoragePoolVolumeGetType(d.db, srcVolumeName, storagePoolVolumeTypeCustom, srcPoolID)
+	if err != nil {
+		return err
+	}
+
+	// Only reuse the configuration of the source volume when staying on
+	// the same storage pool as the keys are driver specific.
+	if len(dstConfig) == 0 && srcPoolName == dstPoolName {
+		dstConfig = map[string]string{}
+		for k, v := range srcVolume.Config {
+			dstConfig[k] = v
+		}
+	}
+
+	if dstDescription == "" {
+		dstDescription = srcVolume.Description
+	}
+
+	err = storagePoolVolumeCreateInternal(d, project, dstPoolName, dstVolumeName, dstDescription, storagePoolVolumeTypeNameCustom, dstConfig)
+	if err != nil {
+		return err
+	}
+
+	dst, err := storagePoolVolumeInit(d, dstPoolName, dstVolumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	// Remove the new storage volume again if anything goes wrong.
+	success := false
+	defer func() {
+		if !success {
+			dst.StoragePoolVolumeDelete()
+		}
+	}()
+
+	src, err := storagePoolVolumeInit(d, srcPoolName, srcVolumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	ourMount, err := src.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer src.StoragePoolVolumeUmount()
+	}
+
+	ourMount, err = dst.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer dst.StoragePoolVolumeUmount()
+	}
+
+	srcPath := getStoragePoolVolumeMountPoint(srcPoolName, srcVolumeName)
+	dstPath := getStoragePoolVolumeMountPoint(dstPoolName, dstVolumeName)
+	bwlimit := dst.GetStoragePoolWritable().Config["rsync.bwlimit"]
+	output, err := rsyncLocalCopy(srcPath, dstPath, bwlimit)
+	if err != nil {
+		return fmt.Errorf("failed to rsync storage volume: %s: %s", string(output), err)
+	}
+
+	success = true
+	return nil
+}
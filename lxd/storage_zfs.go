@@ -371,6 +371,35 @@ func (s *storageZfs) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
 
+func (s *storageZfs) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	res := api.ResourcesStoragePool{}
+
+	poolName := s.getOnDiskPoolName()
+
+	value, err := s.zfsFilesystemEntityPropertyGet(poolName, "used", false)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Space.Used, err = strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = s.zfsFilesystemEntityPropertyGet(poolName, "available", false)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	res.Space.Total = res.Space.Used + available
+
+	return &res, nil
+}
+
 func (s *storageZfs) StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error {
 	logger.Infof("Updating ZFS storage pool \"%s\".", s.pool.Name)
 
@@ -442,6 +471,109 @@ func (s *storageZfs) StoragePoolVolumeUpdate(writable *api.StorageVolumePut, cha
 	return nil
 }
 
+func (s *storageZfs) StoragePoolVolumeSnapshotCreate(snapshotName string) error {
+	logger.Infof("Creating ZFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	err := s.zfsPoolVolumeSnapshotCreate(fs, fmt.Sprintf("snapshot-%s", snapshotName))
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Created ZFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageZfs) StoragePoolVolumeSnapshotDelete(snapshotName string) error {
+	logger.Infof("Deleting ZFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	snapName := fmt.Sprintf("snapshot-%s", snapshotName)
+	if s.zfsFilesystemEntityExists(fmt.Sprintf("%s@%s", fs, snapName), true) {
+		err := s.zfsPoolVolumeSnapshotDestroy(fs, snapName)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("Deleted ZFS storage volume snapshot \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
+func (s *storageZfs) StoragePoolVolumeSnapshotRename(snapshotName string, newName string) error {
+	logger.Infof("Renaming ZFS storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+
+	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	err := s.zfsPoolVolumeSnapshotRename(fs, fmt.Sprintf("snapshot-%s", snapshotName), fmt.Sprintf("snapshot-%s", newName))
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Renamed ZFS storage volume snapshot \"%s/%s\" to \"%s/%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
+func (s *storageZfs) StoragePoolVolumeSnapshotRestore(snapshotName string) error {
+	logger.Infof("Restoring ZFS storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+
+	volumeID, err := dbStoragePoolVolumeGetTypeID(s.d.db, s.volume.Name, storagePoolVolumeTypeCustom, s.poolID)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := dbStoragePoolVolumeSnapshotsGet(s.d.db, volumeID)
+	if err != nil {
+		return err
+	}
+
+	// ZFS can only roll back to the latest snapshot.
+	newer := []string{}
+	for i, snap := range snapshots {
+		if snap == snapshotName {
+			newer = snapshots[i+1:]
+			break
+		}
+	}
+
+	if len(newer) > 0 {
+		if s.pool.Config["volume.zfs.remove_snapshots"] != "" {
+			zfsRemoveSnapshots = s.pool.Config["volume.zfs.remove_snapshots"]
+		}
+		if s.volume.Config["zfs.remove_snapshots"] != "" {
+			zfsRemoveSnapshots = s.volume.Config["zfs.remove_snapshots"]
+		}
+		if !shared.IsTrue(zfsRemoveSnapshots) {
+			return fmt.Errorf("ZFS can only restore from the latest snapshot. Delete newer snapshots or set \"zfs.remove_snapshots\" on the storage volume")
+		}
+
+		for i := len(newer) - 1; i >= 0; i-- {
+			err := s.StoragePoolVolumeSnapshotDelete(newer[i])
+			if err != nil {
+				return err
+			}
+
+			snapshotID, _, err := dbStoragePoolVolumeSnapshotGet(s.d.db, volumeID, newer[i])
+			if err != nil {
+				return err
+			}
+
+			err = dbStoragePoolVolumeSnapshotDelete(s.d.db, snapshotID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	err = s.zfsPoolVolumeSnapshotRestore(fs, fmt.Sprintf("snapshot-%s", snapshotName))
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Restored ZFS storage volume \"%s\" from snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, snapshotName, s.pool.Name)
+	return nil
+}
+
 // Things we don't need to care about
 func (s *storageZfs) ContainerMount(c container) (bool, error) {
 	name := c.Name()
@@ -1568,6 +1700,128 @@ func (s *storageZfs) ContainerSnapshotCreateEmpty(snapshotContainer container) e
 	return nil
 }
 
+func (s *storageZfs) ContainerBackupCreate(path string, backup backup, sourceContainer container) error {
+	logger.Debugf("Creating backup of ZFS storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+
+	if !backup.OptimizedStorage() {
+		err := rsyncBackupCreate(path, backup, sourceContainer)
+		if err != nil {
+			return err
+		}
+
+		logger.Debugf("Created backup of ZFS storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+		return nil
+	}
+
+	// Send the snapshots from oldest to newest so that every snapshot
+	// only needs to carry the delta to its predecessor.
+	zfsName := fmt.Sprintf("containers/%s", sourceContainer.Name())
+	zfsParent := ""
+	if !backup.ContainerOnly() {
+		snapshots, err := sourceContainer.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snapshots {
+			_, snapOnlyName, _ := containerGetParentAndSnapshotName(snap.Name())
+			snapshotName := fmt.Sprintf("snapshot-%s", snapOnlyName)
+			target := filepath.Join(path, "snapshots", fmt.Sprintf("%s.bin", snapOnlyName))
+			err := s.zfsPoolVolumeSnapshotSend(zfsName, snapshotName, zfsParent, target)
+			if err != nil {
+				return err
+			}
+
+			zfsParent = snapshotName
+		}
+	}
+
+	backupSnapshotName := fmt.Sprintf("backup-send-%s", uuid.NewRandom().String())
+	err := s.zfsPoolVolumeSnapshotCreate(zfsName, backupSnapshotName)
+	if err != nil {
+		return err
+	}
+	defer s.zfsPoolVolumeSnapshotDestroy(zfsName, backupSnapshotName)
+
+	err = s.zfsPoolVolumeSnapshotSend(zfsName, backupSnapshotName, zfsParent, filepath.Join(path, "container.bin"))
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("Created backup of ZFS storage volume \"%s\" on storage pool \"%s\".", sourceContainer.Name(), s.pool.Name)
+	return nil
+}
+
+func (s *storageZfs) ContainerBackupLoad(container container, snapshots []containerArgs, path string, optimized bool) error {
+	logger.Debugf("Loading backup into ZFS storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+
+	if !optimized {
+		err := rsyncBackupLoad(container, snapshots, path)
+		if err != nil {
+			return err
+		}
+
+		logger.Debugf("Loaded backup into ZFS storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+		return nil
+	}
+
+	// The freshly created dataset is empty, unmount it so that we can
+	// receive into it.
+	zfsName := fmt.Sprintf("containers/%s", container.Name())
+	containerMntPoint := getContainerMountPoint(s.pool.Name, container.Name())
+	if shared.IsMountPoint(containerMntPoint) {
+		err := s.zfsPoolVolumeUmount(zfsName, containerMntPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, args := range snapshots {
+		_, err := containerCreateEmptySnapshot(container.Daemon(), args)
+		if err != nil {
+			return err
+		}
+
+		_, snapOnlyName, _ := containerGetParentAndSnapshotName(args.Name)
+		source := filepath.Join(path, "snapshots", fmt.Sprintf("%s.bin", snapOnlyName))
+		err = s.zfsPoolVolumeReceive(fmt.Sprintf("%s@snapshot-%s", zfsName, snapOnlyName), source)
+		if err != nil {
+			return err
+		}
+
+		snapshotMntPoint := getSnapshotMountPoint(s.pool.Name, args.Name)
+		snapshotMntPointSymlinkTarget := shared.VarPath("storage-pools", s.pool.Name, "snapshots", container.Name())
+		snapshotMntPointSymlink := shared.VarPath("snapshots", container.Name())
+		err = createSnapshotMountpoint(snapshotMntPoint, snapshotMntPointSymlinkTarget, snapshotMntPointSymlink)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.zfsPoolVolumeReceive(zfsName, filepath.Join(path, "container.bin"))
+	if err != nil {
+		return err
+	}
+
+	// Remove the temporary snapshot the container was sent from.
+	zfsSnapshots, err := s.zfsPoolListSnapshots(zfsName)
+	if err != nil {
+		return err
+	}
+
+	for _, snap := range zfsSnapshots {
+		if strings.HasPrefix(snap, "backup-send") {
+			s.zfsPoolVolumeSnapshotDestroy(zfsName, snap)
+		}
+	}
+
+	// See the comment at the end of MigrationSink about "zfs recv -u".
+	s.zfsPoolVolumeMount(zfsName)
+
+	logger.Debugf("Loaded backup into ZFS storage volume \"%s\" on storage pool \"%s\".", container.Name(), s.pool.Name)
+	return nil
+}
+
 // - create temporary directory ${LXD_DIR}/images/lxd_images_
 // - create new zfs volume images/<fingerprint>
 // - mount the zfs volume on ${LXD_DIR}/images/lxd_images_
@@ -1774,6 +2028,7 @@ type zfsMigrationSourceDriver struct {
 	zfs              *storageZfs
 	runningSnapName  string
 	stoppedSnapName  string
+	refreshParent    string
 }
 
 func (s *zfsMigrationSourceDriver) Snapshots() []container {
@@ -1832,14 +2087,10 @@ func (s *zfsMigrationSourceDriver) SendWhileRunning(conn *websocket.Conn, op *op
 		return s.send(conn, snapshotName, "", wrapper)
 	}
 
-	lastSnap := ""
+	lastSnap := s.refreshParent
 	if !containerOnly {
-		for i, snap := range s.zfsSnapshotNames {
-			prev := ""
-			if i > 0 {
-				prev = s.zfsSnapshotNames[i-1]
-			}
-
+		for _, snap := range s.zfsSnapshotNames {
+			prev := lastSnap
 			lastSnap = snap
 
 			wrapper := StorageProgressReader(op, "fs_progress", snap)
@@ -1875,6 +2126,26 @@ func (s *zfsMigrationSourceDriver) SendAfterCheckpoint(conn *websocket.Conn, bwl
 	return nil
 }
 
+func (s *zfsMigrationSourceDriver) Refresh(targetSnapshots []string) {
+	snapshots := []container{}
+	zfsSnapshotNames := []string{}
+
+	for i, snap := range s.snapshots {
+		if shared.StringInSlice(shared.ExtractSnapshotName(snap.Name()), targetSnapshots) {
+			// The incremental streams start from the latest
+			// snapshot the target already has.
+			s.refreshParent = s.zfsSnapshotNames[i]
+			continue
+		}
+
+		snapshots = append(snapshots, snap)
+		zfsSnapshotNames = append(zfsSnapshotNames, s.zfsSnapshotNames[i])
+	}
+
+	s.snapshots = snapshots
+	s.zfsSnapshotNames = zfsSnapshotNames
+}
+
 func (s *zfsMigrationSourceDriver) Cleanup() {
 	if s.stoppedSnapName != "" {
 		s.zfs.zfsPoolVolumeSnapshotDestroy(fmt.Sprintf("containers/%s", s.container.Name()), s.stoppedSnapName)
@@ -2059,6 +2330,17 @@ func (s *storageZfs) MigrationSink(live bool, container container, snapshots []*
 		}
 	}
 
+	// Snapshots of an existing copy which is being refreshed must be kept
+	existingSnapshots := []string{}
+	ctSnapshots, err := container.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	for _, snap := range ctSnapshots {
+		existingSnapshots = append(existingSnapshots, fmt.Sprintf("snapshot-%s", shared.ExtractSnapshotName(snap.Name())))
+	}
+
 	defer func() {
 		/* clean up our migration-send snapshots that we got from recv. */
 		zfsSnapshots, err := s.zfsPoolListSnapshots(fmt.Sprintf("containers/%s", container.Name()))
@@ -2073,6 +2355,10 @@ func (s *storageZfs) MigrationSink(live bool, container container, snapshots []*
 				continue
 			}
 
+			if shared.StringInSlice(snap, existingSnapshots) {
+				continue
+			}
+
 			s.zfsPoolVolumeSnapshotDestroy(fmt.Sprintf("containers/%s", container.Name()), snap)
 		}
 	}()
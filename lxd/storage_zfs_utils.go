@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -457,6 +459,14 @@ func (s *storageZfs) zfsFilesystemEntityPropertyGet(path string, key string, pre
 	return strings.TrimRight(output, "\n"), nil
 }
 
+// zfsSnapshotGUID returns the GUID of a container snapshot. Snapshots
+// received from incremental or full streams keep the GUID they were sent
+// with.
+func (s *storageZfs) zfsSnapshotGUID(name string) (string, error) {
+	parent, snapshot, _ := containerGetParentAndSnapshotName(name)
+	return s.zfsFilesystemEntityPropertyGet(fmt.Sprintf("containers/%s@snapshot-%s", parent, snapshot), "guid", true)
+}
+
 func (s *storageZfs) zfsPoolVolumeRename(source string, dest string) error {
 	var err error
 	var output string
@@ -533,6 +543,59 @@ func (s *storageZfs) zfsPoolVolumeSnapshotDestroy(path string, name string) erro
 	return nil
 }
 
+// zfsPoolVolumeSnapshotSend writes a send stream of the given snapshot into
+// target. If a parent snapshot is given only the delta to it is sent.
+func (s *storageZfs) zfsPoolVolumeSnapshotSend(path string, name string, parent string, target string) error {
+	poolName := s.getOnDiskPoolName()
+	args := []string{"send", fmt.Sprintf("%s/%s@%s", poolName, path, name)}
+	if parent != "" {
+		args = append(args, "-i", fmt.Sprintf("%s/%s@%s", poolName, path, parent))
+	}
+
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stderr := bytes.Buffer{}
+	cmd := exec.Command("zfs", args...)
+	cmd.Stdout = f
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		logger.Errorf("zfs send failed: %s.", stderr.String())
+		return fmt.Errorf("Failed to send ZFS snapshot: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// zfsPoolVolumeReceive receives the send stream stored in source into the
+// given dataset or snapshot.
+func (s *storageZfs) zfsPoolVolumeReceive(path string, source string) error {
+	poolName := s.getOnDiskPoolName()
+
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	output := bytes.Buffer{}
+	cmd := exec.Command("zfs", "receive", "-F", "-u", fmt.Sprintf("%s/%s", poolName, path))
+	cmd.Stdin = f
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if err != nil {
+		logger.Errorf("zfs receive failed: %s.", output.String())
+		return fmt.Errorf("Failed to receive ZFS stream: %s", output.String())
+	}
+
+	return nil
+}
+
 func (s *storageZfs) zfsPoolVolumeSnapshotRestore(path string, name string) error {
 	poolName := s.getOnDiskPoolName()
 	output, err := shared.TryRunCommand(
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Container config key recording the uid of the unprivileged local user
+// owning a container
+const unixUserOwnerKey = "volatile.owner.uid"
+
+/*
+ * The handlers only get the request, not the connection it came in on, so
+ * the credentials of the unprivileged users making requests are kept in a
+ * global for the duration of the request.
+ */
+var unixUserRequests = map[*http.Request]*ucred{}
+var unixUserRequestsLock sync.Mutex
+
+// Returns the unprivileged local user who made a request, or nil if the
+// request isn't scoped to a user.
+func requestUnixUser(r *http.Request) *ucred {
+	unixUserRequestsLock.Lock()
+	defer unixUserRequestsLock.Unlock()
+
+	return unixUserRequests[r]
+}
+
+func unixUserRequestStart(r *http.Request, user *ucred) {
+	unixUserRequestsLock.Lock()
+	unixUserRequests[r] = user
+	unixUserRequestsLock.Unlock()
+}
+
+func unixUserRequestDone(r *http.Request) {
+	unixUserRequestsLock.Lock()
+	delete(unixUserRequests, r)
+	unixUserRequestsLock.Unlock()
+}
+
+// Returns the group owning the unix socket. Its members, like root, have
+// full access to LXD.
+func (d *Daemon) unixSocketGid() (int, error) {
+	if d.group != "" {
+		return shared.GroupId(d.group)
+	}
+
+	return os.Getgid(), nil
+}
+
+// Lets everyone connect to the unix socket when unprivileged users are
+// allowed, only root and the members of its group otherwise.
+func unixSocketSetMode(path string, unprivileged bool) error {
+	mode := os.FileMode(0660)
+	if unprivileged {
+		mode = 0666
+	}
+
+	return os.Chmod(path, mode)
+}
+
+// Whether a process is allowed full access to LXD, i.e. runs as root or is a
+// member of the group owning the unix socket.
+func (d *Daemon) unixUserIsPrivileged(cred *ucred) (bool, error) {
+	if cred.uid == 0 {
+		return true, nil
+	}
+
+	gid, err := d.unixSocketGid()
+	if err != nil {
+		return false, err
+	}
+
+	if cred.gid == int64(gid) {
+		return true, nil
+	}
+
+	// The supplementary groups aren't part of the socket credentials
+	content, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", cred.pid))
+	if err != nil {
+		return false, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(line, "Groups:") {
+			continue
+		}
+
+		for _, group := range strings.Fields(strings.TrimPrefix(line, "Groups:")) {
+			if group == strconv.Itoa(gid) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Returns the credentials of the local user who made a request if the
+// request must be scoped to that user, nil otherwise. Only the requests of
+// users who are neither root nor members of the group owning the unix
+// socket are scoped, and only when core.unix_unprivileged_users is set.
+func (d *Daemon) unixUserScope(w http.ResponseWriter, r *http.Request) (*ucred, error) {
+	if r.RemoteAddr != "@" || r.TLS != nil {
+		return nil, nil
+	}
+
+	if !daemonConfig["core.unix_unprivileged_users"].GetBool() {
+		return nil, nil
+	}
+
+	cred := extractUnderlyingCred(w)
+	if cred == nil {
+		return nil, fmt.Errorf("Unable to identify the local user")
+	}
+
+	privileged, err := d.unixUserIsPrivileged(cred)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to check the groups of the local user: %s", err)
+	}
+
+	if privileged {
+		return nil, nil
+	}
+
+	return cred, nil
+}
+
+// unixUserCheckPermissions returns an error when a request isn't allowed
+// for an unprivileged local user.
+//
+// Such users can only see and use the containers they created, and only
+// with configurations which don't give them more privileges on the host
+// than they already have. Everything else can only be read, apart from the
+// trust store, the audit log and the internal API which are off limits.
+func unixUserCheckPermissions(d *Daemon, user *ucred, version string, c Command, r *http.Request) error {
+	kind := strings.SplitN(c.name, "/", 2)[0]
+	if version == "internal" || kind == "certificates" || kind == "audit" {
+		return fmt.Errorf("Unprivileged users can't access %s", r.URL.Path)
+	}
+
+	switch kind {
+	case "events":
+		// Filtered down to the user's own operations
+		return nil
+	case "operations":
+		return unixUserCheckOperation(user, r)
+	case "containers":
+		return unixUserCheckContainer(d, user, c, r)
+	}
+
+	if r.Method != "GET" {
+		return fmt.Errorf("Unprivileged users can't modify %s", r.URL.Path)
+	}
+
+	return nil
+}
+
+// Operations can only be accessed by the user who started them.
+func unixUserCheckOperation(user *ucred, r *http.Request) error {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		// Listing is filtered
+		return nil
+	}
+
+	op, err := operationGet(id)
+	if err != nil {
+		// Let the handler report it
+		return nil
+	}
+
+	op.lock.Lock()
+	owner := op.owner
+	op.lock.Unlock()
+
+	if owner != user.uid {
+		return fmt.Errorf("Operation '%s' wasn't started by uid %d", id, user.uid)
+	}
+
+	return nil
+}
+
+func unixUserCheckContainer(d *Daemon, user *ucred, c Command, r *http.Request) error {
+	project := projectParam(r)
+
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		if r.Method != "POST" {
+			// Listing is filtered
+			return nil
+		}
+
+		return unixUserCheckCreate(d, user, project, r)
+	}
+
+	owned, err := unixUserOwnsContainer(d, user, project, name)
+	if err != nil {
+		return err
+	}
+
+	if !owned {
+		return fmt.Errorf("Container '%s' isn't owned by uid %d", name, user.uid)
+	}
+
+	if c.name == "containers/{name}" && (r.Method == "PUT" || r.Method == "PATCH") {
+		return unixUserCheckUpdate(d, project, name, r)
+	}
+
+	return nil
+}
+
+// Whether a container was created by the given unprivileged user.
+func unixUserOwnsContainer(d *Daemon, user *ucred, project string, name string) (bool, error) {
+	args, err := dbContainerGet(d.db, projectPrefix(project, name))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if args.Project != project {
+		return false, nil
+	}
+
+	owner, ok := args.Config[unixUserOwnerKey]
+	if !ok {
+		return false, nil
+	}
+
+	return owner == strconv.FormatInt(user.uid, 10), nil
+}
+
+func unixUserCheckCreate(d *Daemon, user *ucred, project string, r *http.Request) error {
+	req := api.ContainersPost{}
+	err := restrictedRequestBody(r, &req)
+	if err != nil {
+		return err
+	}
+
+	if req.Source.Type == "copy" {
+		parent, _, _ := containerGetParentAndSnapshotName(req.Source.Source)
+		owned, err := unixUserOwnsContainer(d, user, project, parent)
+		if err != nil {
+			return err
+		}
+
+		if !owned {
+			return fmt.Errorf("Container '%s' isn't owned by uid %d", parent, user.uid)
+		}
+
+		// Refreshing overwrites the existing container of the same name
+		if req.Source.Refresh {
+			_, err := dbContainerId(d.db, projectPrefix(project, req.Name))
+			if err == nil {
+				owned, err := unixUserOwnsContainer(d, user, project, req.Name)
+				if err != nil {
+					return err
+				}
+
+				if !owned {
+					return fmt.Errorf("Container '%s' isn't owned by uid %d", req.Name, user.uid)
+				}
+			}
+		}
+	}
+
+	return restrictedCheckCreate(d, project, req)
+}
+
+func unixUserCheckUpdate(d *Daemon, project string, name string, r *http.Request) error {
+	req := api.ContainerPut{}
+	err := restrictedRequestBody(r, &req)
+	if err != nil {
+		return err
+	}
+
+	return restrictedCheckUpdate(d, project, name, req)
+}
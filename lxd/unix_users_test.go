@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type unixUsersTestSuite struct {
+	lxdTestSuite
+}
+
+func (suite *unixUsersTestSuite) createContainer(name string, owner string) {
+	config := map[string]string{}
+	if owner != "" {
+		config[unixUserOwnerKey] = owner
+	}
+
+	_, err := dbContainerCreate(suite.d.db, containerArgs{
+		Name:    name,
+		Ctype:   cTypeRegular,
+		Project: "default",
+		Config:  config,
+	})
+	suite.Req.Nil(err)
+}
+
+// Refreshing a copy overwrites the target, which must be owned by the user
+// as much as the source.
+func (suite *unixUsersTestSuite) TestUnixUserCheckCreate_Refresh() {
+	user := &ucred{uid: 1000}
+
+	suite.createContainer("c1", "1000")
+	suite.createContainer("victim", "1001")
+	suite.createContainer("admin", "")
+
+	for _, target := range []string{"victim", "admin"} {
+		body := `{"name": "` + target + `", "source": {"type": "copy", "source": "c1", "refresh": true}}`
+		r := httptest.NewRequest("POST", "/1.0/containers", bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", "application/json")
+
+		err := unixUserCheckCreate(suite.d, user, "default", r)
+		suite.Req.NotNil(err, target)
+		suite.Req.True(strings.Contains(err.Error(), "'"+target+"'"), err.Error())
+	}
+}
+
+// The users of other objects only include the containers of the user.
+func (suite *unixUsersTestSuite) TestRestrictedFilterUsedBy() {
+	suite.createContainer("c1", "1000")
+	suite.createContainer("c2", "1001")
+
+	r := httptest.NewRequest("GET", "/1.0/profiles/default", nil)
+	unixUserRequestStart(r, &ucred{uid: 1000})
+	defer unixUserRequestDone(r)
+
+	usedBy, err := restrictedFilterUsedBy(suite.d, r, []string{
+		"/1.0/containers/c1",
+		"/1.0/containers/c1/snapshots/snap0",
+		"/1.0/containers/c2",
+		"/1.0/containers/c2/snapshots/snap0",
+		"/1.0/containers/c1?project=ci",
+		"/1.0/profiles/default",
+	})
+	suite.Req.Nil(err)
+	suite.Req.Equal([]string{
+		"/1.0/containers/c1",
+		"/1.0/containers/c1/snapshots/snap0",
+		"/1.0/profiles/default",
+	}, usedBy)
+
+	// Other requests aren't filtered
+	r = httptest.NewRequest("GET", "/1.0/profiles/default", nil)
+	usedBy, err = restrictedFilterUsedBy(suite.d, r, []string{"/1.0/containers/c2"})
+	suite.Req.Nil(err)
+	suite.Req.Equal([]string{"/1.0/containers/c2"}, usedBy)
+}
+
+func TestUnixUsersTestSuite(t *testing.T) {
+	suite.Run(t, new(unixUsersTestSuite))
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"time"
+)
+
+// AuditEntry represents a mutating API request recorded in the audit log
+//
+// API extension: audit
+type AuditEntry struct {
+	ID   int64     `json:"id" yaml:"id"`
+	Date time.Time `json:"date" yaml:"date"`
+
+	// Fingerprint of the client certificate or "unix socket"
+	Requestor string `json:"requestor" yaml:"requestor"`
+
+	// Peer uid of unix socket clients, -1 otherwise
+	UID     int64  `json:"uid" yaml:"uid"`
+	Address string `json:"address" yaml:"address"`
+
+	Method    string `json:"method" yaml:"method"`
+	URL       string `json:"url" yaml:"url"`
+	Body      string `json:"body" yaml:"body"`
+	Operation string `json:"operation" yaml:"operation"`
+	Status    string `json:"status" yaml:"status"`
+}
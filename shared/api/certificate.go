@@ -1,11 +1,21 @@
 package api
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 // CertificatesPost represents the fields of a new LXD certificate
 type CertificatesPost struct {
 	CertificatePut `yaml:",inline"`
 
 	Certificate string `json:"certificate" yaml:"certificate"`
 	Password    string `json:"password" yaml:"password"`
+
+	// API extension: certificate_token
+	Token string `json:"token" yaml:"token"`
 }
 
 // CertificatePut represents the modifiable fields of a LXD certificate
@@ -14,6 +24,12 @@ type CertificatesPost struct {
 type CertificatePut struct {
 	Name string `json:"name" yaml:"name"`
 	Type string `json:"type" yaml:"type"`
+
+	// API extension: certificate_permissions
+	ReadOnly   bool     `json:"read_only" yaml:"read_only"`
+	Containers []string `json:"containers" yaml:"containers"`
+	Profiles   []string `json:"profiles" yaml:"profiles"`
+	Projects   []string `json:"projects" yaml:"projects"`
 }
 
 // Certificate represents a LXD certificate
@@ -28,3 +44,64 @@ type Certificate struct {
 func (cert *Certificate) Writable() CertificatePut {
 	return cert.CertificatePut
 }
+
+// CertificateTokensPost represents the fields of a new certificate add token
+//
+// API extension: certificate_token
+type CertificateTokensPost struct {
+	Name      string    `json:"name" yaml:"name"`
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+}
+
+// CertificateToken represents a pending certificate add token
+//
+// API extension: certificate_token
+type CertificateToken struct {
+	Name      string    `json:"name" yaml:"name"`
+	Secret    string    `json:"secret" yaml:"secret"`
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+
+	// The encoded token to hand out to the client
+	Token string `json:"token" yaml:"token"`
+}
+
+// CertificateTokenData represents the content of an encoded certificate add token
+//
+// API extension: certificate_token
+type CertificateTokenData struct {
+	Name        string    `json:"name"`
+	Fingerprint string    `json:"fingerprint"`
+	Addresses   []string  `json:"addresses"`
+	Secret      string    `json:"secret"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// String encodes the token data into the token handed out to clients
+func (data *CertificateTokenData) String() string {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// CertificateTokenDecode decodes a token handed out by a server
+func CertificateTokenDecode(token string) (*CertificateTokenData, error) {
+	buf, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	data := CertificateTokenData{}
+	err = json.Unmarshal(buf, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Fingerprint == "" || data.Secret == "" || len(data.Addresses) == 0 {
+		return nil, fmt.Errorf("Invalid certificate add token")
+	}
+
+	return &data, nil
+}
@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // ContainerExecControl represents a message on the container exec "control" socket
 type ContainerExecControl struct {
 	Command string            `json:"command" yaml:"command"`
@@ -18,4 +22,24 @@ type ContainerExecPost struct {
 
 	// API extension: container_exec_recording
 	RecordOutput bool `json:"record-output" yaml:"record-output"`
+
+	// API extension: container_exec_user_group_cwd
+	User  uint32 `json:"user" yaml:"user"`
+	Group uint32 `json:"group" yaml:"group"`
+	Cwd   string `json:"cwd" yaml:"cwd"`
+
+	// API extension: container_exec_persistent
+	Persistent        bool `json:"persistent" yaml:"persistent"`
+	PersistentTimeout int  `json:"persistent-timeout" yaml:"persistent-timeout"`
+}
+
+// ContainerExecSession represents a persistent exec session
+//
+// API extension: container_exec_persistent
+type ContainerExecSession struct {
+	ID        string    `json:"id" yaml:"id"`
+	Command   []string  `json:"command" yaml:"command"`
+	Attached  bool      `json:"attached" yaml:"attached"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
 }
@@ -30,4 +30,7 @@ type ContainerSnapshot struct {
 	Name            string                       `json:"name" yaml:"name"`
 	Profiles        []string                     `json:"profiles" yaml:"profiles"`
 	Stateful        bool                         `json:"stateful" yaml:"stateful"`
+
+	// API extension: snapshot_scheduling
+	ExpiryDate time.Time `json:"expires_at" yaml:"expires_at"`
 }
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event represents an event entry (over websocket)
+type Event struct {
+	Type      string          `json:"type" yaml:"type"`
+	Timestamp time.Time       `json:"timestamp" yaml:"timestamp"`
+	Metadata  json.RawMessage `json:"metadata" yaml:"metadata"`
+
+	// API extension: event_filter_replay
+	ID int64 `json:"id" yaml:"id"`
+}
+
+// EventLifecycle represents a lifecycle type event entry
+//
+// API extension: event_lifecycle
+type EventLifecycle struct {
+	Action  string                 `json:"action" yaml:"action"`
+	Source  string                 `json:"source" yaml:"source"`
+	Context map[string]interface{} `json:"context,omitempty" yaml:"context,omitempty"`
+}
@@ -44,3 +44,82 @@ type Network struct {
 func (network *Network) Writable() NetworkPut {
 	return network.NetworkPut
 }
+
+// NetworkLease represents a DHCP lease or static reservation on a LXD network
+//
+// API extension: network_leases
+type NetworkLease struct {
+	Hostname  string `json:"hostname" yaml:"hostname"`
+	Hwaddr    string `json:"hwaddr" yaml:"hwaddr"`
+	Address   string `json:"address" yaml:"address"`
+	Type      string `json:"type" yaml:"type"`
+	Container string `json:"container" yaml:"container"`
+
+	// API extension: projects
+	Project string `json:"project" yaml:"project"`
+}
+
+// NetworkState represents the runtime state of a network interface
+//
+// API extension: network_state
+type NetworkState struct {
+	Addresses []NetworkStateAddress `json:"addresses" yaml:"addresses"`
+	Counters  NetworkStateCounters  `json:"counters" yaml:"counters"`
+	Hwaddr    string                `json:"hwaddr" yaml:"hwaddr"`
+	Mtu       int                   `json:"mtu" yaml:"mtu"`
+	State     string                `json:"state" yaml:"state"`
+	Type      string                `json:"type" yaml:"type"`
+
+	// Only set for bridges
+	Bridge *NetworkStateBridge `json:"bridge" yaml:"bridge"`
+}
+
+// NetworkStateAddress represents a network address as part of the network state
+//
+// API extension: network_state
+type NetworkStateAddress struct {
+	Family  string `json:"family" yaml:"family"`
+	Address string `json:"address" yaml:"address"`
+	Netmask string `json:"netmask" yaml:"netmask"`
+	Scope   string `json:"scope" yaml:"scope"`
+}
+
+// NetworkStateCounters represents packet counters as part of the network state
+//
+// API extension: network_state
+type NetworkStateCounters struct {
+	BytesReceived   int64 `json:"bytes_received" yaml:"bytes_received"`
+	BytesSent       int64 `json:"bytes_sent" yaml:"bytes_sent"`
+	PacketsReceived int64 `json:"packets_received" yaml:"packets_received"`
+	PacketsSent     int64 `json:"packets_sent" yaml:"packets_sent"`
+}
+
+// NetworkStateBridge represents the bridge specific part of the network state
+//
+// API extension: network_state
+type NetworkStateBridge struct {
+	ID            string `json:"id" yaml:"id"`
+	STP           bool   `json:"stp" yaml:"stp"`
+	ForwardDelay  uint64 `json:"forward_delay" yaml:"forward_delay"`
+	VLANDefault   uint64 `json:"vlan_default" yaml:"vlan_default"`
+	VLANFiltering bool   `json:"vlan_filtering" yaml:"vlan_filtering"`
+
+	Ports []NetworkStateBridgePort `json:"ports" yaml:"ports"`
+}
+
+// NetworkStateBridgePort represents an interface attached to a bridge
+//
+// API extension: network_state
+type NetworkStateBridgePort struct {
+	Name  string                   `json:"name" yaml:"name"`
+	VLANs []NetworkStateBridgeVLAN `json:"vlans" yaml:"vlans"`
+}
+
+// NetworkStateBridgeVLAN represents a VLAN configured on a bridge port
+//
+// API extension: network_state
+type NetworkStateBridgeVLAN struct {
+	ID       uint64 `json:"id" yaml:"id"`
+	PVID     bool   `json:"pvid" yaml:"pvid"`
+	Untagged bool   `json:"untagged" yaml:"untagged"`
+}
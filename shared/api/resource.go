@@ -0,0 +1,62 @@
+package api
+
+// Resources represents the system resources available to LXD
+//
+// API extension: resources
+type Resources struct {
+	CPU          ResourcesCPU                    `json:"cpu" yaml:"cpu"`
+	Memory       ResourcesMemory                 `json:"memory" yaml:"memory"`
+	StoragePools map[string]ResourcesStoragePool `json:"storage_pools" yaml:"storage_pools"`
+}
+
+// ResourcesCPU represents the CPU resources of the host
+//
+// API extension: resources
+type ResourcesCPU struct {
+	Sockets   []ResourcesCPUSocket   `json:"sockets" yaml:"sockets"`
+	NUMANodes []ResourcesCPUNUMANode `json:"numa_nodes" yaml:"numa_nodes"`
+	Total     uint64                 `json:"total" yaml:"total"`
+}
+
+// ResourcesCPUSocket represents a CPU socket on the host
+//
+// API extension: resources
+type ResourcesCPUSocket struct {
+	Socket    uint64 `json:"socket" yaml:"socket"`
+	Name      string `json:"name" yaml:"name"`
+	Vendor    string `json:"vendor" yaml:"vendor"`
+	Cores     uint64 `json:"cores" yaml:"cores"`
+	Threads   uint64 `json:"threads" yaml:"threads"`
+	Frequency uint64 `json:"frequency" yaml:"frequency"`
+}
+
+// ResourcesCPUNUMANode represents a NUMA node and the CPU threads it contains
+//
+// API extension: resources
+type ResourcesCPUNUMANode struct {
+	Node uint64   `json:"node" yaml:"node"`
+	CPUs []uint64 `json:"cpus" yaml:"cpus"`
+}
+
+// ResourcesMemory represents the memory resources of the host
+//
+// API extension: resources
+type ResourcesMemory struct {
+	Used  uint64 `json:"used" yaml:"used"`
+	Total uint64 `json:"total" yaml:"total"`
+}
+
+// ResourcesStoragePool represents the resources available to a storage pool
+//
+// API extension: resources
+type ResourcesStoragePool struct {
+	Space ResourcesStoragePoolSpace `json:"space" yaml:"space"`
+}
+
+// ResourcesStoragePoolSpace represents the space available to a storage pool
+//
+// API extension: resources
+type ResourcesStoragePoolSpace struct {
+	Used  uint64 `json:"used" yaml:"used"`
+	Total uint64 `json:"total" yaml:"total"`
+}
@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // StoragePoolsPost represents the fields of a new LXD storage pool
 //
 // API extension: storage
@@ -39,6 +43,36 @@ type StorageVolumesPost struct {
 
 	Name string `json:"name" yaml:"name"`
 	Type string `json:"type" yaml:"type"`
+
+	// API extension: storage_api_local_volume_handling
+	Source StorageVolumeSource `json:"source" yaml:"source"`
+}
+
+// StorageVolumeSource represents the creation source for a new storage volume
+//
+// API extension: storage_api_local_volume_handling
+type StorageVolumeSource struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	Pool string `json:"pool" yaml:"pool"`
+
+	// API extension: storage_api_remote_volume_handling
+	Certificate string            `json:"certificate" yaml:"certificate"`
+	Mode        string            `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Operation   string            `json:"operation,omitempty" yaml:"operation,omitempty"`
+	Websockets  map[string]string `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+}
+
+// StorageVolumePost represents the fields required to rename or move a LXD
+// storage volume
+//
+// API extension: storage_api_local_volume_handling
+type StorageVolumePost struct {
+	Name string `json:"name" yaml:"name"`
+	Pool string `json:"pool,omitempty" yaml:"pool,omitempty"`
+
+	// API extension: storage_api_remote_volume_handling
+	Migration bool `json:"migration" yaml:"migration"`
 }
 
 // StorageVolume represents the fields of a LXD storage volume.
@@ -59,6 +93,31 @@ type StorageVolumePut struct {
 
 	// API extension: entity_description
 	Description string `json:"description" yaml:"description"`
+
+	// API extension: storage_api_volume_snapshots
+	Restore string `json:"restore,omitempty" yaml:"restore,omitempty"`
+}
+
+// StorageVolumeSnapshotsPost represents the fields available for a new LXD storage volume snapshot
+//
+// API extension: storage_api_volume_snapshots
+type StorageVolumeSnapshotsPost struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// StorageVolumeSnapshotPost represents the fields required to rename a LXD storage volume snapshot
+//
+// API extension: storage_api_volume_snapshots
+type StorageVolumeSnapshotPost struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// StorageVolumeSnapshot represents a LXD storage volume snapshot
+//
+// API extension: storage_api_volume_snapshots
+type StorageVolumeSnapshot struct {
+	Name         string    `json:"name" yaml:"name"`
+	CreationDate time.Time `json:"created_at" yaml:"created_at"`
 }
 
 // Writable converts a full StoragePool struct into a StoragePoolPut struct
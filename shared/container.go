@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
+
+	"gopkg.in/robfig/cron.v2"
 )
 
 type ContainerAction string
@@ -87,6 +90,29 @@ func IsAny(value string) error {
 	return nil
 }
 
+// IsCron checks that the value is a standard 5 fields cron expression or
+// one of the @yearly, @monthly, @weekly, @daily or @hourly shorthands.
+func IsCron(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(value, "@every") {
+		return fmt.Errorf("Invalid cron expression: %s (@every isn't supported)", value)
+	}
+
+	if !strings.HasPrefix(value, "@") && len(strings.Fields(value)) != 5 {
+		return fmt.Errorf("Invalid cron expression: %s (expected 5 fields)", value)
+	}
+
+	_, err := cron.Parse(value)
+	if err != nil {
+		return fmt.Errorf("Invalid cron expression: %s: %v", value, err)
+	}
+
+	return nil
+}
+
 // KnownContainerConfigKeys maps all fully defined, well-known config keys
 // to an appropriate checker function, which validates whether or not a
 // given value is syntactically legal.
@@ -167,6 +193,25 @@ var KnownContainerConfigKeys = map[string]func(value string) error{
 
 	"linux.kernel_modules": IsAny,
 
+	"migration.incremental.memory":            IsBool,
+	"migration.incremental.memory.iterations": IsUint32,
+	"migration.incremental.memory.goal": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		valueInt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid value for an integer: %s", value)
+		}
+
+		if valueInt < 0 || valueInt > 100 {
+			return fmt.Errorf("Invalid value for a percentage '%s'. Must be between 0 and 100.", value)
+		}
+
+		return nil
+	},
+
 	"security.nesting":    IsBool,
 	"security.privileged": IsBool,
 
@@ -179,6 +224,14 @@ var KnownContainerConfigKeys = map[string]func(value string) error{
 	"security.syscalls.blacklist":         IsAny,
 	"security.syscalls.whitelist":         IsAny,
 
+	"snapshots.schedule":         IsCron,
+	"snapshots.schedule.stopped": IsBool,
+	"snapshots.pattern":          IsAny,
+	"snapshots.expiry": func(value string) error {
+		_, err := GetSnapshotExpiry(time.Now(), value)
+		return err
+	},
+
 	// Caller is responsible for full validation of any raw.* value
 	"raw.apparmor": IsAny,
 	"raw.lxc":      IsAny,
@@ -192,6 +245,7 @@ var KnownContainerConfigKeys = map[string]func(value string) error{
 	"volatile.idmap.next":       IsAny,
 	"volatile.idmap.base":       IsAny,
 	"volatile.apply_quota":      IsAny,
+	"volatile.owner.uid":        IsInt64,
 }
 
 // ConfigKeyChecker returns a function that will check whether or not
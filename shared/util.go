@@ -832,3 +832,41 @@ func Round(x float64) int64 {
 
 	return int64(math.Floor(x + 0.5))
 }
+
+// GetSnapshotExpiry returns the expiry date of a snapshot created at refDate
+// given an expiry string made of space separated <number><unit> entries,
+// e.g. "1w 2d". Valid units are M (minutes), H (hours), d (days),
+// w (weeks), m (months) and y (years).
+func GetSnapshotExpiry(refDate time.Time, s string) (time.Time, error) {
+	expiry := refDate
+
+	for _, field := range strings.Fields(s) {
+		if len(field) < 2 {
+			return time.Time{}, fmt.Errorf("Invalid expiry: %s", field)
+		}
+
+		value, err := strconv.Atoi(field[:len(field)-1])
+		if err != nil || value < 0 {
+			return time.Time{}, fmt.Errorf("Invalid expiry: %s", field)
+		}
+
+		switch field[len(field)-1] {
+		case 'M':
+			expiry = expiry.Add(time.Duration(value) * time.Minute)
+		case 'H':
+			expiry = expiry.Add(time.Duration(value) * time.Hour)
+		case 'd':
+			expiry = expiry.AddDate(0, 0, value)
+		case 'w':
+			expiry = expiry.AddDate(0, 0, value*7)
+		case 'm':
+			expiry = expiry.AddDate(0, value, 0)
+		case 'y':
+			expiry = expiry.AddDate(value, 0, 0)
+		default:
+			return time.Time{}, fmt.Errorf("Invalid expiry unit: %s", field)
+		}
+	}
+
+	return expiry, nil
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestURLEncode(t *testing.T) {
@@ -105,3 +106,36 @@ func TestReaderToChannel(t *testing.T) {
 		}
 	}
 }
+
+func TestGetSnapshotExpiry(t *testing.T) {
+	refDate := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	expiry, err := GetSnapshotExpiry(refDate, "1w 2d 3H 4M")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := time.Date(2017, 6, 10, 15, 4, 0, 0, time.UTC)
+	if !expiry.Equal(expected) {
+		t.Error(fmt.Errorf("'%s' != '%s'", expiry, expected))
+	}
+
+	expiry, err = GetSnapshotExpiry(refDate, "1m 1y")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected = time.Date(2018, 7, 1, 12, 0, 0, 0, time.UTC)
+	if !expiry.Equal(expected) {
+		t.Error(fmt.Errorf("'%s' != '%s'", expiry, expected))
+	}
+
+	for _, invalid := range []string{"1", "w", "1x", "-1d"} {
+		_, err = GetSnapshotExpiry(refDate, invalid)
+		if err == nil {
+			t.Error(fmt.Errorf("Expected an error for '%s'", invalid))
+		}
+	}
+}
@@ -59,6 +59,11 @@ available_storage_backends() {
       backends="$backends $backend"
     fi
   done
+
+  # ceph needs a running cluster so only use it when told about one.
+  if [ -n "${LXD_CEPH_CLUSTER:-}" ] && which ceph >/dev/null 2>&1; then
+    backends="$backends ceph"
+  fi
   echo "$backends"
 }
 
@@ -393,6 +398,7 @@ kill_lxd() {
     check_empty_table "${daemon_dir}/lxd.db" "storage_pools_config"
     check_empty_table "${daemon_dir}/lxd.db" "storage_volumes"
     check_empty_table "${daemon_dir}/lxd.db" "storage_volumes_config"
+    check_empty_table "${daemon_dir}/lxd.db" "storage_volumes_snapshots"
   fi
 
   # teardown storage
@@ -634,6 +640,21 @@ run_test test_init_interactive "lxd init interactive"
 run_test test_init_preseed "lxd init preseed"
 run_test test_storage_profiles "storage profiles"
 run_test test_container_import "container import"
+run_test test_backup_import "backup import"
 run_test test_storage_volume_attach "attaching storage volumes"
+run_test test_storage_volume_snapshots "storage volume snapshots"
+run_test test_storage_volume_copy "storage volume copy and move"
+run_test test_metrics "metrics"
+run_test test_proxy_device "proxy device"
+run_test test_resources "resources"
+run_test test_lifecycle_events "lifecycle events"
+run_test test_event_filter_replay "event filtering and replay"
+run_test test_webhook "event webhook"
+run_test test_apply "lxc apply and export-manifest"
+run_test test_projects "projects"
+run_test test_certificate_permissions "certificate permissions"
+run_test test_certificate_tokens "certificate add tokens"
+run_test test_audit "audit log"
+run_test test_unix_unprivileged_users "unprivileged users on the unix socket"
 
 TEST_RESULT=success
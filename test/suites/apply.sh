@@ -0,0 +1,81 @@
+test_apply() {
+  ensure_import_testimage
+
+  # shellcheck disable=2039
+  local pool manifest
+  pool="lxdtest-$(basename "${LXD_DIR}")"
+  manifest="${TEST_DIR}/manifest.yaml"
+
+  cat > "${manifest}" <<EOM
+storage_volumes:
+- pool: ${pool}
+  name: applyvol
+profiles:
+- name: applyprofile
+  description: Apply test profile
+  config:
+    limits.cpu: "1"
+containers:
+- name: applyct
+  source:
+    type: image
+    alias: testimage
+  profiles:
+  - default
+  - applyprofile
+  config:
+    user.foo: bar
+EOM
+
+  # A dry run doesn't change anything
+  lxc apply "${manifest}" --dry-run | grep -q '+ create profile "applyprofile"'
+  ! lxc profile show applyprofile
+
+  lxc apply "${manifest}" | grep -q '+ create container "applyct"'
+  lxc storage volume show "${pool}" applyvol
+  lxc profile get applyprofile limits.cpu | grep -q 1
+  lxc config get applyct user.foo | grep -q bar
+  lxc config show applyct | grep -q applyprofile
+
+  # Applying again is a no-op
+  lxc apply "${manifest}" | grep -q "Nothing to do"
+
+  # Changes are reported and reverted
+  lxc profile set applyprofile limits.cpu 2
+  lxc config set applyct user.extra baz
+  lxc apply "${manifest}" --dry-run | grep -q '~ update profile "applyprofile" (config.limits.cpu)'
+  lxc apply "${manifest}" | grep -q '~ update container "applyct" (config.user.extra)'
+  lxc profile get applyprofile limits.cpu | grep -q 1
+  [ -z "$(lxc config get applyct user.extra)" ]
+
+  # The export can be applied back as is
+  lxc export-manifest > "${TEST_DIR}/export.yaml"
+  grep -q "name: applyct" "${TEST_DIR}/export.yaml"
+  ! grep -q "volatile\." "${TEST_DIR}/export.yaml"
+  lxc apply "${TEST_DIR}/export.yaml" | grep -q "Nothing to do"
+
+  # Containers are re-created from the image they were created from
+  grep -q "type: image" "${TEST_DIR}/export.yaml"
+  lxc delete applyct
+  lxc apply "${TEST_DIR}/export.yaml" | grep -q '+ create container "applyct"'
+  lxc config show applyct | grep -q "volatile.base_image"
+
+  # Invalid manifests are rejected
+  ! printf "profiles:\n- name: dup\n- name: dup\n" | lxc apply -
+  ! printf "containers:\n- name: nosource\n" | lxc apply -
+
+  # Pruning removes what's not in the manifest
+  lxc profile create applyextra
+  lxc export-manifest > "${TEST_DIR}/export.yaml"
+  lxc apply "${TEST_DIR}/export.yaml" --prune | grep -q "Nothing to do"
+  sed -i "/^- name: applyextra$/d" "${TEST_DIR}/export.yaml"
+  lxc apply "${TEST_DIR}/export.yaml" --prune | grep -q -- '- delete profile "applyextra"'
+  ! lxc profile show applyextra
+  lxc profile show applyprofile
+  lxc info applyct
+
+  lxc delete applyct
+  lxc storage volume delete "${pool}" applyvol
+  lxc profile delete applyprofile
+  rm -f "${manifest}" "${TEST_DIR}/export.yaml"
+}
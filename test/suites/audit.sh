@@ -0,0 +1,39 @@
+test_audit() {
+  ensure_import_testimage
+
+  # Requests on the unix socket are recorded along with the uid
+  lxc init testimage audit-c1
+  lxc config set audit-c1 user.foo bar
+  lxc audit --url=/1.0/containers/audit-c1 | grep -q "unix socket (uid $(id -u))"
+  lxc audit --method=POST --url=/1.0/containers --format=yaml | grep -q "audit-c1"
+
+  # Operations get their final status recorded
+  lxc delete audit-c1
+  lxc audit --method=DELETE --url=/1.0/containers/audit-c1 --format=yaml | grep -q "status: Success"
+
+  # Secrets are redacted
+  lxc config set core.trust_password sekret
+  lxc audit --url=/1.0 --method=PATCH --format=yaml | grep -q "redacted"
+  ! lxc audit --format=yaml | grep -q sekret
+  lxc config unset core.trust_password
+
+  # Requests over the network are recorded with the certificate fingerprint
+  fingerprint=$(openssl x509 -in "${LXD_CONF}/client.crt" -noout -fingerprint -sha256 | sed 's/.*=//; s/://g' | tr '[:upper:]' '[:lower:]')
+  my_curl -X PATCH "https://${LXD_ADDR}/1.0" -d '{"config":{}}'
+  lxc audit --requestor="${fingerprint}" | grep -q PATCH
+
+  # Failed requests of untrusted clients aren't recorded
+  curl -k -s -X PUT "https://${LXD_ADDR}/1.0" -d '{"config":{}}' | grep 403
+  curl -k -s -X POST "https://${LXD_ADDR}/1.0/certificates" -d '{"type":"client","password":"bad"}' | grep 403
+  ! lxc audit --requestor=untrusted | grep -q /1.0
+  ! lxc audit --method=PUT --url=/1.0 | grep -q /1.0
+
+  # GET requests aren't recorded
+  ! lxc audit --method=GET | grep -q /1.0
+
+  # Only the latest entries are kept
+  lxc config set audit.max_entries 2
+  [ "$(lxc audit --format=yaml | grep -c "^- id:")" -eq 2 ]
+  lxc config unset audit.max_entries
+  [ "$(lxc audit --limit=1 --format=yaml | grep -c "^- id:")" -eq 1 ]
+}
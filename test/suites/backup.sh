@@ -118,3 +118,59 @@ test_container_import() {
   # shellcheck disable=SC2031
   kill_lxd "${LXD_IMPORT_DIR}"
 }
+
+test_backup_import() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  lxd_backend=$(storage_backend "$LXD_DIR")
+
+  lxc launch testimage foo
+  lxc snapshot foo
+
+  # container only
+  lxc export foo "${LXD_DIR}/foo.tar.gz" --container-only
+  tar -xzf "${LXD_DIR}/foo.tar.gz" -C "${LXD_DIR}"
+  [ -f "${LXD_DIR}/backup/index.yaml" ]
+  [ -d "${LXD_DIR}/backup/container" ]
+  [ ! -d "${LXD_DIR}/backup/snapshots/snap0" ]
+  rm -rf "${LXD_DIR}/backup"
+
+  # the temporary backup is gone once exported
+  [ -z "$(ls -A "${LXD_DIR}/backups/foo" 2>/dev/null)" ]
+
+  lxc export foo "${LXD_DIR}/foo.tar.gz"
+  tar -xzf "${LXD_DIR}/foo.tar.gz" -C "${LXD_DIR}"
+  [ -d "${LXD_DIR}/backup/snapshots/snap0" ]
+  rm -rf "${LXD_DIR}/backup"
+
+  # the name is already in use
+  ! lxc import "${LXD_DIR}/foo.tar.gz"
+
+  lxc delete --force foo
+  lxc import "${LXD_DIR}/foo.tar.gz"
+  lxc info foo | grep snap0
+  lxc start foo
+  lxc stop --force foo
+
+  # optimized backups are only supported on btrfs and zfs
+  if [ "$lxd_backend" = "btrfs" ] || [ "$lxd_backend" = "zfs" ]; then
+    lxc export foo "${LXD_DIR}/foo-optimized.tar.gz" --optimized-storage
+    tar -xzf "${LXD_DIR}/foo-optimized.tar.gz" -C "${LXD_DIR}"
+    [ -f "${LXD_DIR}/backup/container.bin" ]
+    [ -f "${LXD_DIR}/backup/snapshots/snap0.bin" ]
+    rm -rf "${LXD_DIR}/backup"
+
+    lxc delete --force foo
+    lxc import "${LXD_DIR}/foo-optimized.tar.gz"
+    lxc info foo | grep snap0
+    lxc start foo
+    lxc stop --force foo
+    rm "${LXD_DIR}/foo-optimized.tar.gz"
+  else
+    ! lxc export foo "${LXD_DIR}/foo-optimized.tar.gz" --optimized-storage
+  fi
+
+  lxc delete --force foo
+  rm "${LXD_DIR}/foo.tar.gz"
+}
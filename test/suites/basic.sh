@@ -285,12 +285,28 @@ test_basic_usage() {
   # check that we can set the environment
   lxc exec foo pwd | grep /root
   lxc exec --env BEST_BAND=meshuggah foo env | grep meshuggah
+  [ "$(lxc exec foo --user 1000 --group 1000 -- id -u)" = "1000" ]
+  [ "$(lxc exec foo --user 1000 --group 1000 -- id -g)" = "1000" ]
+  lxc exec foo --cwd /tmp pwd | grep /tmp
+  ! lxc exec foo --user 1000 -- env | grep -q "^HOME=/root$"
   lxc exec foo ip link show | grep eth0
 
   # check that we can get the return code for a non- wait-for-websocket exec
   op=$(my_curl -X POST "https://${LXD_ADDR}/1.0/containers/foo/exec" -d '{"command": ["sleep", "1"], "environment": {}, "wait-for-websocket": false, "interactive": false}' | jq -r .operation)
   [ "$(my_curl "https://${LXD_ADDR}${op}/wait" | jq -r .metadata.metadata.return)" != "null" ]
 
+  # check that only interactive sessions can be persistent
+  [ "$(my_curl "https://${LXD_ADDR}/1.0/containers/foo/exec" | jq -r '.metadata | length')" = "0" ]
+  [ "$(my_curl -X POST "https://${LXD_ADDR}/1.0/containers/foo/exec" -d '{"command": ["true"], "environment": {}, "wait-for-websocket": true, "interactive": false, "persistent": true}' | jq -r .error_code)" = "400" ]
+  ! lxc exec foo --persistent -T -- true
+  lxc exec foo --sessions
+
+  # check the console log, which needs liblxc 3.0 or higher
+  if [ "$(lxc info | awk '/driver_version:/ {print $2}' | cut -d. -f1)" -ge "3" ]; then
+    lxc console foo --show-log
+    my_curl -X DELETE "https://${LXD_ADDR}/1.0/containers/foo/console" | jq -r .status_code | grep -q 200
+  fi
+
   # test file transfer
   echo abc > "${LXD_DIR}/in"
 
@@ -0,0 +1,79 @@
+restricted_curl() {
+  curl -k -s --cert "${LXD_CONF}/restricted.crt" --key "${LXD_CONF}/restricted.key" "$@"
+}
+
+test_certificate_permissions() {
+  gen_cert restricted
+
+  # A certificate restricted to a project using the default profiles
+  lxc project create ci features.profiles=false
+  lxc config trust add --projects=ci "${LXD_CONF}/restricted.crt"
+  fingerprint=$(openssl x509 -in "${LXD_CONF}/restricted.crt" -noout -fingerprint -sha256 | sed 's/.*=//; s/://g' | tr '[:upper:]' '[:lower:]')
+  my_curl "https://${LXD_ADDR}/1.0/certificates/${fingerprint}" | jq -r '.metadata.projects[0]' | grep -q ci
+
+  # Containers can be managed in the allowed project only
+  restricted_curl -X POST "https://${LXD_ADDR}/1.0/containers?project=ci" \
+        -d "{\"name\":\"c1\",\"source\":{\"type\":\"none\"}}" | jq -r .operation | grep -q operations
+  sleep 1
+  restricted_curl "https://${LXD_ADDR}/1.0/containers?project=ci" | grep -q c1
+  restricted_curl -X POST "https://${LXD_ADDR}/1.0/containers" \
+        -d "{\"name\":\"c1\",\"source\":{\"type\":\"none\"}}" | grep -q '"error_code":403'
+  restricted_curl "https://${LXD_ADDR}/1.0/projects/default" | grep -q '"error_code":403'
+
+  # Host-wide objects can only be read
+  restricted_curl "https://${LXD_ADDR}/1.0/networks" | grep -q '"status_code":200'
+  restricted_curl -X POST "https://${LXD_ADDR}/1.0/networks" \
+        -d "{\"name\":\"lxdt$$\"}" | grep -q '"error_code":403'
+  restricted_curl -X PATCH "https://${LXD_ADDR}/1.0" \
+        -d "{\"config\":{\"core.trust_password\":\"\"}}" | grep -q '"error_code":403'
+
+  # The trust store is off limits
+  restricted_curl "https://${LXD_ADDR}/1.0/certificates" | grep -q '"error_code":403'
+
+  # Restrictions can be changed and take effect immediately
+  my_curl -X PATCH "https://${LXD_ADDR}/1.0/certificates/${fingerprint}" \
+        -d "{\"read_only\":true,\"projects\":[]}"
+  restricted_curl "https://${LXD_ADDR}/1.0/containers/c1?project=ci" | grep -q '"status_code":200'
+  restricted_curl -X DELETE "https://${LXD_ADDR}/1.0/containers/c1?project=ci" | grep -q '"error_code":403'
+
+  # Containers can be restricted by name
+  my_curl -X PATCH "https://${LXD_ADDR}/1.0/certificates/${fingerprint}" \
+        -d "{\"read_only\":false,\"containers\":[\"c2\"]}"
+  restricted_curl -X DELETE "https://${LXD_ADDR}/1.0/containers/c1?project=ci" | grep -q '"error_code":403'
+  ! restricted_curl "https://${LXD_ADDR}/1.0/containers?project=ci" | grep -q c1
+  ! restricted_curl "https://${LXD_ADDR}/1.0/profiles/default?project=ci" | grep -q c1
+  ! restricted_curl "https://${LXD_ADDR}/1.0/metrics" | grep -q 'name="c1"'
+
+  lxc config trust remove "${fingerprint}"
+  my_curl -X DELETE "https://${LXD_ADDR}/1.0/containers/c1?project=ci"
+  sleep 1
+  lxc project delete ci
+}
+
+test_certificate_tokens() {
+  # shellcheck disable=2039
+  local LXD_CONF_CLIENT token
+  LXD_CONF_CLIENT=$(mktemp -d -p "${TEST_DIR}" XXX)
+
+  # Tokens can be listed and revoked
+  lxc config trust add --token revoked
+  lxc config trust list-tokens | grep -q revoked
+  lxc config trust revoke-token revoked
+  ! lxc config trust list-tokens | grep -q revoked
+
+  # A client can add itself with a token, without any prompt
+  token=$(lxc config trust add --token client | tail -n1)
+  LXD_CONF="${LXD_CONF_CLIENT}" lxc remote add server "${token}" < /dev/null
+  LXD_CONF="${LXD_CONF_CLIENT}" lxc info server: | grep -q "auth: trusted"
+  ! lxc config trust list-tokens | grep -q client
+
+  # Tokens can only be used once
+  rm "${LXD_CONF_CLIENT}/client.crt" "${LXD_CONF_CLIENT}/client.key"
+  LXD_CONF="${LXD_CONF_CLIENT}" lxc remote remove server
+  ! LXD_CONF="${LXD_CONF_CLIENT}" lxc remote add server "${token}" < /dev/null
+
+  # Remove the client's certificate from the trust store
+  fingerprint=$(my_curl "https://${LXD_ADDR}/1.0/certificates?recursion=1" | jq -r '.metadata[] | select(.name == "client") | .fingerprint')
+  lxc config trust remove "${fingerprint}"
+  rm -rf "${LXD_CONF_CLIENT}"
+}
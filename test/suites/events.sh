@@ -0,0 +1,137 @@
+test_lifecycle_events() {
+  ensure_import_testimage
+
+  lxc monitor --type=lifecycle > "${TEST_DIR}/lifecycle.log" 2>&1 &
+  monitor_pid=$!
+  sleep 1
+
+  lxc init testimage c1
+  lxc start c1
+  lxc snapshot c1 snap0
+  lxc stop c1 --force
+  lxc move c1 c2
+  lxc delete c2
+
+  lxc profile create lifecycle-profile
+  lxc profile set lifecycle-profile limits.cpu 1
+  lxc profile delete lifecycle-profile
+
+  sleep 1
+  kill -9 "${monitor_pid}" || true
+
+  grep -q "action: container-created" "${TEST_DIR}/lifecycle.log"
+  grep -q "source: /1.0/containers/c1$" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: container-started" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: container-snapshot-created" "${TEST_DIR}/lifecycle.log"
+  grep -q "source: /1.0/containers/c1/snapshots/snap0$" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: container-stopped" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: container-renamed" "${TEST_DIR}/lifecycle.log"
+  grep -q "new_name: c2" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: container-deleted" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: profile-created" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: profile-updated" "${TEST_DIR}/lifecycle.log"
+  grep -q "action: profile-deleted" "${TEST_DIR}/lifecycle.log"
+
+  # Lifecycle events aren't sent to listeners of other types
+  ! grep -q "type: logging" "${TEST_DIR}/lifecycle.log"
+
+  rm -f "${TEST_DIR}/lifecycle.log"
+}
+
+test_event_filter_replay() {
+  ensure_import_testimage
+
+  # Find out the current event ID
+  lxc monitor --type=lifecycle > "${TEST_DIR}/replay.log" 2>&1 &
+  monitor_pid=$!
+  sleep 1
+  lxc profile create replay-profile
+  sleep 1
+  kill -9 "${monitor_pid}" || true
+  since=$(awk '/^id: / {print $2}' "${TEST_DIR}/replay.log" | tail -n 1)
+  [ -n "${since}" ]
+  lxc profile delete replay-profile
+
+  lxc init testimage c1
+  lxc init testimage c2
+
+  # Replay what happened before connecting, restricted to c1
+  lxc monitor --type=lifecycle --resource=/1.0/containers/c1 --since="${since}" > "${TEST_DIR}/replay.log" 2>&1 &
+  monitor_pid=$!
+  sleep 1
+
+  lxc delete c1 c2
+
+  sleep 1
+  kill -9 "${monitor_pid}" || true
+
+  grep -q "action: container-created" "${TEST_DIR}/replay.log"
+  grep -q "action: container-deleted" "${TEST_DIR}/replay.log"
+  ! grep -q "action: profile-" "${TEST_DIR}/replay.log"
+  grep -q "source: /1.0/containers/c1$" "${TEST_DIR}/replay.log"
+  ! grep -q "source: /1.0/containers/c2$" "${TEST_DIR}/replay.log"
+
+  # IDs from the future are rejected
+  ! lxc monitor --since=999999999
+
+  # Invalid levels are rejected
+  ! lxc monitor --level=loud
+
+  rm -f "${TEST_DIR}/replay.log"
+}
+
+test_webhook() {
+  ensure_import_testimage
+
+  # Start a stub webhook which records the signature and body of every event
+  port=$(local_tcp_port)
+  cat > "${TEST_DIR}/webhook.py" << EOF2
+import hashlib
+import hmac
+import http.server
+import sys
+
+class Handler(http.server.BaseHTTPRequestHandler):
+    def do_POST(self):
+        body = self.rfile.read(int(self.headers["Content-Length"]))
+        expected = "sha256=" + hmac.new(b"secret", body, hashlib.sha256).hexdigest()
+        with open(sys.argv[2], "a") as fd:
+            fd.write("signature: %s\n" % (self.headers["X-LXD-Signature"] == expected))
+            fd.write("%s\n" % body.decode())
+        self.send_response(200)
+        self.end_headers()
+
+    def log_message(self, *args):
+        pass
+
+http.server.HTTPServer(("127.0.0.1", int(sys.argv[1])), Handler).serve_forever()
+EOF2
+  python3 "${TEST_DIR}/webhook.py" "${port}" "${TEST_DIR}/webhook.log" &
+  webhook_pid=$!
+  sleep 1
+
+  ! lxc config set core.webhook_url "ftp://127.0.0.1"
+  ! lxc config set core.webhook_types "operation,bogus"
+
+  lxc config set core.webhook_url "http://127.0.0.1:${port}"
+  lxc config set core.webhook_secret secret
+  lxc config set core.webhook_types lifecycle
+  lxc config show | grep -q -v "webhook_secret: secret"
+
+  lxc init testimage c1
+  lxc delete c1
+  sleep 2
+
+  grep -q '"action":"container-created"' "${TEST_DIR}/webhook.log"
+  grep -q '"action":"container-deleted"' "${TEST_DIR}/webhook.log"
+  grep -q "signature: True" "${TEST_DIR}/webhook.log"
+  ! grep -q "signature: False" "${TEST_DIR}/webhook.log"
+  ! grep -q '"type":"operation"' "${TEST_DIR}/webhook.log"
+
+  lxc config unset core.webhook_url
+  lxc config unset core.webhook_secret
+  lxc config unset core.webhook_types
+
+  kill -9 "${webhook_pid}" || true
+  rm -f "${TEST_DIR}/webhook.py" "${TEST_DIR}/webhook.log"
+}
@@ -0,0 +1,36 @@
+test_metrics() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  lxc launch testimage c1
+  lxc init testimage c2
+  lxc project create foo features.images=false features.profiles=false
+  lxc project switch foo
+  lxc init testimage c1
+  lxc project switch default
+
+  # untrusted clients can't scrape metrics
+  curl -k -s -X GET "https://${LXD_ADDR}/1.0/metrics" | grep 403
+
+  my_curl -f -X GET "https://${LXD_ADDR}/1.0/metrics" > "${TEST_DIR}/metrics.txt"
+  tail -n 1 "${TEST_DIR}/metrics.txt" | grep -q "^# EOF$"
+  grep -q '^lxd_container_running{name="c1",project="default"} 1$' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_container_running{name="c2",project="default"} 0$' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_container_cpu_usage_seconds_total{name="c1",project="default"} ' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_container_processes{name="c1",project="default"} ' "${TEST_DIR}/metrics.txt"
+  ! grep -q '^lxd_container_processes{name="c2",project="default"} ' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_container_running{name="c1",project="foo"} 0$' "${TEST_DIR}/metrics.txt"
+  ! grep -q 'foo_c1' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_operations{class="task"} ' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_image_cache_size_bytes ' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_storage_pool_containers{pool="lxdtest-.*"} 3$' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_storage_pool_space_used_bytes{pool="lxdtest-.*"} ' "${TEST_DIR}/metrics.txt"
+  grep -q '^lxd_storage_pool_space_total_bytes{pool="lxdtest-.*"} ' "${TEST_DIR}/metrics.txt"
+
+  rm -f "${TEST_DIR}/metrics.txt"
+  lxc delete -f c1 c2
+  lxc project switch foo
+  lxc delete c1
+  lxc project switch default
+  lxc project delete foo
+}
@@ -157,6 +157,33 @@ migration() {
   [ "$(lxc_remote file pull l2:udssr/blah -)" = "after" ]
   lxc_remote delete l2:udssr
 
+  # Refresh of an existing copy.
+  lxc copy cccp udssr
+  lxc_remote copy l1:cccp l2:udssr
+  lxc snapshot cccp refresh
+  echo "refreshed" | lxc file push - cccp/refreshed
+  lxc file delete cccp/blah
+  ! lxc copy cccp udssr
+  ! lxc copy cccp/refresh udssr --refresh
+  lxc copy cccp udssr --refresh
+  lxc_remote copy l1:cccp l2:udssr --refresh
+  [ "$(lxc info udssr | grep -c snap)" -eq 3 ]
+  [ "$(lxc_remote info l2:udssr | grep -c snap)" -eq 3 ]
+  [ "$(lxc file pull udssr/refreshed -)" = "refreshed" ]
+  [ "$(lxc_remote file pull l2:udssr/refreshed -)" = "refreshed" ]
+  ! lxc file pull udssr/blah -
+  ! lxc_remote file pull l2:udssr/blah -
+  lxc start udssr
+  ! lxc copy cccp udssr --refresh
+  lxc delete --force udssr
+  lxc_remote delete l2:udssr
+
+  # Refresh of a container which doesn't exist yet is a regular copy.
+  lxc_remote copy l1:cccp l2:udssr --refresh --mode=push
+  [ "$(lxc_remote info l2:udssr | grep -c snap)" -eq 3 ]
+  lxc_remote delete l2:udssr
+  lxc delete cccp/refresh
+
   # Remote container only move.
   lxc_remote move l1:cccp l2:udssr --container-only --mode=relay
   ! lxc_remote info l1:cccp
@@ -43,6 +43,28 @@ test_network() {
 
   [ "${SUCCESS}" = "0" ] && (echo "Container static IP wasn't applied" && false)
 
+  # Static reservations show up in the leases
+  lxc network list-leases lxdt$$ | grep "${v4_addr}" | grep -q STATIC
+  lxc network list-leases lxdt$$ | grep "${v4_addr}" | grep -q nettest
+
+  # Containers of other projects are listed under their own name
+  lxc project create foo features.images=false features.profiles=false
+  lxc project switch foo
+  lxc init testimage nettest2
+  lxc config device add nettest2 eth0 nic nictype=bridged parent=lxdt$$ ipv4.address="${v4_addr}1"
+  lxc project switch default
+  my_curl "https://${LXD_ADDR}/1.0/networks/lxdt$$/leases" | grep -q '"container":"nettest2","project":"foo"'
+  ! lxc network list-leases lxdt$$ | grep -q foo_nettest2
+  lxc project switch foo
+  lxc delete nettest2
+  lxc project switch default
+  lxc project delete foo
+
+  # Runtime state of managed and unmanaged interfaces
+  lxc network info lxdt$$ | grep -q "Bridge:"
+  lxc network info lxdt$$ | grep -q "Ports:"
+  lxc network info lo | grep -q "Type: loopback"
+
   lxc delete nettest -f
   lxc network delete lxdt$$
 }
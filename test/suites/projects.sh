@@ -0,0 +1,88 @@
+test_projects() {
+  ensure_import_testimage
+
+  # shellcheck disable=2039
+  local pool
+  pool="lxdtest-$(basename "${LXD_DIR}")"
+
+  # The default project always exists and can't be removed
+  lxc project list | grep -q "default (current)"
+  ! lxc project delete default
+  ! lxc project rename default foo
+
+  # Create a project using the images of the default project
+  lxc project create foo features.images=false
+  lxc project get foo features.images | grep -q false
+  lxc project get foo features.profiles | grep -q true
+  lxc project show foo | grep -q "/1.0/profiles/default?project=foo"
+
+  # Invalid names and configuration are rejected
+  ! lxc project create foo_bar
+  ! lxc project create bar features.invalid=true
+
+  # Containers of different projects can share a name
+  lxc init testimage c1
+  lxc project switch foo
+  lxc project list | grep -q "foo (current)"
+  lxc image list | grep -q testimage
+  lxc profile device add default root disk path="/" pool="${pool}"
+  lxc init testimage c1
+  lxc list | grep -q c1
+  lxc snapshot c1 snap0
+  lxc info c1 | grep -q snap0
+  lxc config show c1 | grep -q "volatile.base_image"
+
+  # Profiles are separate
+  lxc profile create p1
+  lxc profile list | grep -q p1
+  lxc project switch default
+  ! lxc profile show p1
+  ! lxc info c1 | grep -q snap0
+
+  # Custom volumes are separate
+  lxc storage volume create "${pool}" vol1
+  lxc project switch foo
+  ! lxc storage volume show "${pool}" vol1
+  lxc storage volume create "${pool}" vol1
+  lxc storage volume attach "${pool}" vol1 c1 vol1 /mnt
+  lxc storage volume show "${pool}" vol1 | grep -q "/1.0/containers/c1?project=foo"
+  lxc storage volume detach "${pool}" vol1 c1 vol1
+
+  # Internal names don't give access to other projects
+  lxc project switch default
+  ! lxc info foo_c1
+  ! lxc storage volume show "${pool}" foo_vol1
+
+  # Non-empty projects can't be deleted, renamed or have their features changed
+  ! lxc project delete foo
+  ! lxc project rename foo bar
+  ! lxc project set foo features.images true
+  lxc project show foo | grep -q "/1.0/containers/c1?project=foo"
+
+  lxc project switch foo
+  lxc delete c1
+  lxc storage volume delete "${pool}" vol1
+  lxc profile delete p1
+  lxc profile device remove default root
+  lxc project switch default
+
+  # Empty projects can be renamed and deleted
+  lxc project rename foo bar
+  lxc project list | grep -q bar
+  lxc project delete bar
+  ! lxc project list | grep -q bar
+
+  # The default project's objects are untouched
+  lxc list | grep -q c1
+  lxc storage volume show "${pool}" vol1
+  lxc delete c1
+  lxc storage volume delete "${pool}" vol1
+
+  # A project with its own images starts off without any
+  lxc project create images
+  lxc project switch images
+  ! lxc image list | grep -q testimage
+  lxc project switch default
+  lxc image list | grep -q testimage
+  lxc project delete images
+}
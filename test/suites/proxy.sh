@@ -0,0 +1,30 @@
+test_proxy_device() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  lxc launch testimage proxyTester
+
+  # invalid configurations are rejected
+  ! lxc config device add proxyTester proxyDev proxy listen=tcp:127.0.0.1:1234
+  ! lxc config device add proxyTester proxyDev proxy listen=sctp:127.0.0.1:1234 connect=tcp:127.0.0.1:4321
+  ! lxc config device add proxyTester proxyDev proxy listen=udp:127.0.0.1:1234 connect=tcp:127.0.0.1:4321
+  ! lxc config device add proxyTester proxyDev proxy listen=tcp:127.0.0.1:1234 connect=tcp:127.0.0.1:4321 bind=nowhere
+
+  # hotplug
+  lxc config device add proxyTester proxyDev proxy listen=tcp:127.0.0.1:1234 connect=tcp:127.0.0.1:4321 bind=host
+  [ -f "${LXD_DIR}/devices/proxyTester/proxy.proxyDev" ]
+  pid=$(cat "${LXD_DIR}/devices/proxyTester/proxy.proxyDev")
+  kill -0 "${pid}"
+
+  # the proxy is restarted along with the container
+  lxc restart proxyTester --force
+  [ "$(cat "${LXD_DIR}/devices/proxyTester/proxy.proxyDev")" != "${pid}" ]
+  pid=$(cat "${LXD_DIR}/devices/proxyTester/proxy.proxyDev")
+  kill -0 "${pid}"
+
+  # unplug
+  lxc config device remove proxyTester proxyDev
+  [ ! -f "${LXD_DIR}/devices/proxyTester/proxy.proxyDev" ]
+
+  lxc delete -f proxyTester
+}
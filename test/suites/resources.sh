@@ -0,0 +1,16 @@
+test_resources() {
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  # shellcheck disable=2039
+  local pool
+  pool="lxdtest-$(basename "${LXD_DIR}")"
+
+  RES=$(lxc info --resources)
+  echo "${RES}" | grep -q "^cpu:"
+  echo "${RES}" | grep -q "^memory:"
+  echo "${RES}" | grep -q "${pool}:"
+
+  [ "$(my_curl "https://${LXD_ADDR}/1.0/resources" | jq -r .metadata.cpu.total)" -gt "0" ]
+  [ "$(my_curl "https://${LXD_ADDR}/1.0/resources" | jq -r .metadata.memory.total)" -gt "0" ]
+  my_curl -f "https://${LXD_ADDR}/1.0/storage-pools/${pool}/resources" | jq -r .metadata.space.total
+}
@@ -12,6 +12,14 @@ test_server_config() {
   lxc config unset core.trust_password
   lxc config show | grep -q -v "trust_password"
 
+  # test log rotation settings
+  lxc config set logs.rotate_size 10MB
+  lxc config set logs.rotate_count 2
+  ! lxc config set logs.rotate_size invalid
+  ! lxc config set logs.rotate_count invalid
+  lxc config unset logs.rotate_size
+  lxc config unset logs.rotate_count
+
   # test untrusted server GET
   my_curl -X GET "https://$(cat "${LXD_SERVERCONFIG_DIR}/lxd.addr")/1.0" | grep -v -q environment
 }
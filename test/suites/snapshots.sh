@@ -82,6 +82,14 @@ snapshots() {
     [ -d "${LXD_DIR}/snapshots/foople/namechange" ]
   fi
 
+  # Scheduled snapshots
+  lxc config set foople snapshots.schedule "0 */6 * * *"
+  lxc config set foople snapshots.expiry "1d 12H"
+  lxc config set foople snapshots.pattern "auto-%d"
+  ! lxc config set foople snapshots.schedule "@every 1m"
+  ! lxc config set foople snapshots.schedule "0 0 */6 * * *"
+  ! lxc config set foople snapshots.expiry "1x"
+
   lxc delete foople
   lxc delete foosnap1
   [ ! -d "${LXD_DIR}/containers/foople" ]
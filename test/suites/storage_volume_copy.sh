@@ -0,0 +1,59 @@
+test_storage_volume_copy() {
+  # setup a second LXD
+  # shellcheck disable=2039
+  local LXD2_DIR LXD2_ADDR pool pool2
+  LXD2_DIR=$(mktemp -d -p "${TEST_DIR}" XXX)
+  chmod +x "${LXD2_DIR}"
+  spawn_lxd "${LXD2_DIR}" true
+  LXD2_ADDR=$(cat "${LXD2_DIR}/lxd.addr")
+
+  ensure_import_testimage
+
+  pool="lxdtest-$(basename "${LXD_DIR}")"
+  pool2="lxdtest-$(basename "${LXD2_DIR}")"
+
+  lxc storage volume create "${pool}" vol1
+  lxc launch testimage c1
+  lxc storage volume attach "${pool}" vol1 c1 vol1 /mnt
+  lxc exec c1 -- sh -c "echo foo > /mnt/testfile"
+  lxc storage volume detach "${pool}" vol1 c1 vol1
+
+  # local copy within the same pool
+  lxc storage volume copy "${pool}/vol1" "${pool}/vol2"
+  lxc storage volume show "${pool}" vol2
+  ! lxc storage volume copy "${pool}/vol1" "${pool}/vol2"
+  lxc storage volume attach "${pool}" vol2 c1 vol2 /mnt
+  lxc exec c1 -- cat /mnt/testfile | grep -q foo
+
+  # moving a volume which is in use must fail
+  ! lxc storage volume move "${pool}/vol2" "${pool}/vol3"
+  lxc storage volume detach "${pool}" vol2 c1 vol2
+
+  # rename through move
+  lxc storage volume move "${pool}/vol2" "${pool}/vol3"
+  ! lxc storage volume show "${pool}" vol2
+  lxc storage volume show "${pool}" vol3
+
+  # copy to a remote LXD
+  if ! lxc_remote remote list | grep -q l1; then
+    # shellcheck disable=2153
+    lxc_remote remote add l1 "${LXD_ADDR}" --accept-certificate --password foo
+  fi
+  if ! lxc_remote remote list | grep -q l2; then
+    lxc_remote remote add l2 "${LXD2_ADDR}" --accept-certificate --password foo
+  fi
+
+  lxc_remote storage volume copy "l1:${pool}/vol3" "l2:${pool2}"
+  LXD_DIR="${LXD2_DIR}" lxc storage volume show "${pool2}" vol3
+  lxc_remote storage volume move "l1:${pool}/vol3" "l2:${pool2}/vol4"
+  ! lxc storage volume show "${pool}" vol3
+  LXD_DIR="${LXD2_DIR}" lxc storage volume show "${pool2}" vol4
+
+  LXD_DIR="${LXD2_DIR}" lxc storage volume delete "${pool2}" vol3
+  LXD_DIR="${LXD2_DIR}" lxc storage volume delete "${pool2}" vol4
+  lxc_remote remote remove l2
+  kill_lxd "$LXD2_DIR"
+
+  lxc delete -f c1
+  lxc storage volume delete "${pool}" vol1
+}
@@ -0,0 +1,46 @@
+test_storage_volume_snapshots() {
+  ensure_import_testimage
+
+  lxd_backend=$(storage_backend "$LXD_DIR")
+  pool="lxdtest-$(basename "${LXD_DIR}")"
+
+  lxc storage volume create "${pool}" vol1
+  lxc launch testimage c1
+  lxc storage volume attach "${pool}" vol1 c1 vol1 /mnt
+
+  lxc exec c1 -- sh -c "echo foo > /mnt/testfile"
+
+  # create, list and rename snapshots
+  lxc storage volume snapshot "${pool}" vol1
+  lxc storage volume snapshot "${pool}" vol1 snap-named
+  lxc storage volume snapshot-list "${pool}" vol1 | grep snap0
+  lxc storage volume snapshot-list "${pool}" vol1 | grep snap-named
+  ! lxc storage volume snapshot "${pool}" vol1 snap0
+  lxc storage volume snapshot-rename "${pool}" vol1 snap-named snap1
+  ! lxc storage volume snapshot-list "${pool}" vol1 | grep snap-named
+  lxc storage volume snapshot-list "${pool}" vol1 | grep snap1
+
+  lxc exec c1 -- sh -c "echo bar > /mnt/testfile"
+
+  # restoring requires the volume not to be in use by running containers
+  ! lxc storage volume restore "${pool}" vol1 snap1
+  lxc stop --force c1
+
+  # zfs can only restore the latest snapshot
+  if [ "$lxd_backend" = "zfs" ]; then
+    ! lxc storage volume restore "${pool}" vol1 snap0
+  fi
+
+  lxc storage volume restore "${pool}" vol1 snap1
+  lxc start c1
+  lxc exec c1 -- cat /mnt/testfile | grep -q foo
+  lxc stop --force c1
+
+  lxc storage volume snapshot-delete "${pool}" vol1 snap1
+  ! lxc storage volume snapshot-list "${pool}" vol1 | grep snap1
+
+  # deleting the volume removes its remaining snapshots
+  lxc delete c1
+  lxc storage volume delete "${pool}" vol1
+  [ ! -d "${LXD_DIR}/storage-pools/${pool}/custom-snapshots/vol1" ]
+}
@@ -0,0 +1,55 @@
+unprivileged_curl() {
+  setpriv --reuid=65534 --regid=65534 --clear-groups -- curl -s --unix-socket "${LXD_DIR}/unix.socket" -H "Content-Type: application/json" "$@"
+}
+
+unprivileged_wait() {
+  op=$(unprivileged_curl "$@" | sed -n 's/.*"operation":"\([^"]*\)".*/\1/p')
+  [ -n "${op}" ]
+  unprivileged_curl "lxd${op}/wait" | grep -q '"err":""'
+}
+
+test_unix_unprivileged_users() {
+  ensure_import_testimage
+
+  # The socket is only opened up when asked to
+  [ "$(stat -c %a "${LXD_DIR}/unix.socket")" = "660" ]
+  lxc config set core.unix_unprivileged_users true
+  [ "$(stat -c %a "${LXD_DIR}/unix.socket")" = "666" ]
+
+  lxc init testimage unix-root
+
+  # Unprivileged users only see their own containers
+  unprivileged_wait -X POST lxd/1.0/containers -d '{"name":"unix-user","source":{"type":"image","alias":"testimage"}}'
+  [ "$(lxc config get unix-user volatile.owner.uid)" = "65534" ]
+  unprivileged_curl lxd/1.0/containers | grep -q unix-user
+  ! unprivileged_curl lxd/1.0/containers | grep -q unix-root
+  unprivileged_curl lxd/1.0/containers/unix-root | grep -q '"error_code":403'
+  unprivileged_curl -X PATCH lxd/1.0/containers/unix-user -d '{"config":{"user.foo":"bar"}}' | grep -q '"status_code":200'
+
+  # Nothing giving access to the host can be set
+  unprivileged_curl -X PATCH lxd/1.0/containers/unix-user -d '{"config":{"security.privileged":"true"}}' | grep -q '"error_code":403'
+  unprivileged_curl -X PATCH lxd/1.0/containers/unix-user -d '{"config":{"raw.lxc":"lxc.aa_profile=unconfined"}}' | grep -q '"error_code":403'
+  unprivileged_curl -X PATCH lxd/1.0/containers/unix-user -d '{"devices":{"host":{"type":"disk","source":"/","path":"/mnt"}}}' | grep -q '"error_code":403'
+  unprivileged_curl -X POST lxd/1.0/containers -d '{"name":"unix-priv","config":{"security.privileged":"true"},"source":{"type":"image","alias":"testimage"}}' | grep -q '"error_code":403'
+  unprivileged_curl -X POST lxd/1.0/containers -d '{"name":"unix-copy","source":{"type":"copy","source":"unix-root"}}' | grep -q '"error_code":403'
+  unprivileged_curl -X POST lxd/1.0/containers -d '{"name":"unix-root","source":{"type":"copy","source":"unix-user","refresh":true}}' | grep -q '"error_code":403'
+
+  # Nor the containers of others through other objects
+  ! unprivileged_curl lxd/1.0/profiles/default | grep -q unix-root
+  unprivileged_curl lxd/1.0/profiles/default | grep -q unix-user
+  ! unprivileged_curl lxd/1.0/metrics | grep -q unix-root
+  unprivileged_curl lxd/1.0/metrics | grep -q 'name="unix-user"'
+
+  # Everything else is read-only
+  unprivileged_curl lxd/1.0/profiles | grep -q default
+  unprivileged_curl -X PUT lxd/1.0/profiles/default -d '{"config":{}}' | grep -q '"error_code":403'
+  unprivileged_curl -X PATCH lxd/1.0 -d '{"config":{"core.unix_unprivileged_users":"false"}}' | grep -q '"error_code":403'
+  unprivileged_curl lxd/1.0/certificates | grep -q '"error_code":403'
+
+  unprivileged_wait -X DELETE lxd/1.0/containers/unix-user
+  ! lxc info unix-user
+
+  lxc delete unix-root
+  lxc config unset core.unix_unprivileged_users
+  [ "$(stat -c %a "${LXD_DIR}/unix.socket")" = "660" ]
+}